@@ -0,0 +1,102 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	cfgit "github.com/codefresh-io/cli-v2/pkg/git"
+	"github.com/codefresh-io/cli-v2/pkg/store"
+	ingressutil "github.com/codefresh-io/cli-v2/pkg/util/ingress"
+
+	"github.com/spf13/cobra"
+)
+
+type capabilities struct {
+	Version            string   `json:"version"`
+	MaxDefVersion      string   `json:"maxDefVersion"`
+	GitProviders       []string `json:"gitProviders"`
+	IngressControllers []string `json:"ingressControllers"`
+	Features           []string `json:"features"`
+}
+
+// cliFeatures are the optional runtime install features, that wrapping tools may need to check for before using them
+var cliFeatures = []string{
+	"cluster-server",
+	"component-resources",
+	"confirm-rollback",
+	"demo-resources-repo",
+	"dry-run",
+	"events-output",
+	"export-helm-values",
+	"fail-fast-on-degraded",
+	"impersonation",
+	"install-lock",
+	"internal-ingress-insecure",
+	"kustomize-build-options",
+	"local-runtime-definition",
+	"min-token-ttl",
+	"name-suffix",
+	"namespace-finalizers",
+	"notify-webhook",
+	"openshift-detection",
+	"otel-traces",
+	"proxy",
+	"repo-template",
+	"server-side-apply",
+	"skip-components",
+	"ssh-repo",
+	"support-bundle",
+}
+
+func NewCapabilitiesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "capabilities",
+		Short: "Print the git providers, ingress controllers and features supported by this cli, as json",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return printCapabilities(os.Stdout)
+		},
+	}
+
+	return cmd
+}
+
+func getCapabilities() capabilities {
+	s := store.Get()
+	c := capabilities{
+		Version:       s.Version.Version,
+		MaxDefVersion: s.MaxDefVersion.String(),
+		Features:      cliFeatures,
+	}
+
+	for _, providerType := range cfgit.ProviderTypes() {
+		c.GitProviders = append(c.GitProviders, string(providerType))
+	}
+
+	for _, controller := range ingressutil.SupportedControllers {
+		c.IngressControllers = append(c.IngressControllers, string(controller))
+	}
+
+	return c
+}
+
+func printCapabilities(out io.Writer) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(getCapabilities())
+}
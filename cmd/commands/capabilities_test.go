@@ -0,0 +1,43 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	cfgit "github.com/codefresh-io/cli-v2/pkg/git"
+	"github.com/codefresh-io/cli-v2/pkg/store"
+	ingressutil "github.com/codefresh-io/cli-v2/pkg/util/ingress"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_printCapabilities(t *testing.T) {
+	out := &bytes.Buffer{}
+	assert.NoError(t, printCapabilities(out))
+
+	c := capabilities{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &c))
+	assert.Equal(t, store.Get().MaxDefVersion.String(), c.MaxDefVersion)
+	assert.Contains(t, c.GitProviders, string(cfgit.GITHUB_CLOUD))
+	assert.Contains(t, c.GitProviders, string(cfgit.GITLAB))
+	assert.Contains(t, c.GitProviders, string(cfgit.BITBUCKET_SERVER))
+	assert.Contains(t, c.IngressControllers, string(ingressutil.IngressControllerNginxCommunity))
+	assert.Contains(t, c.IngressControllers, string(ingressutil.IngressControllerALB))
+	assert.Len(t, c.IngressControllers, len(ingressutil.SupportedControllers))
+	assert.Contains(t, c.Features, "server-side-apply")
+}
@@ -361,7 +361,7 @@ func createAddClusterManifests(ingressUrl, contextName, server, csdpToken, versi
 	k.FixKustomizationPostUnmarshalling()
 	util.Die(k.FixKustomizationPreMarshalling())
 
-	manifests, err := kustutil.BuildKustomization(k)
+	manifests, err := kustutil.BuildKustomization(k, nil)
 	if err != nil {
 		// go to fallback add-cluster manifests
 		// remove this once all manifests has been moved official-csdp repo.
@@ -370,7 +370,7 @@ func createAddClusterManifests(ingressUrl, contextName, server, csdpToken, versi
 		k.Resources[0] = fallbackResourceUrl
 		log.G().Warnf("Failed to get \"add-cluster\" manifests from %s, using fallback of %s", resourceUrl, fallbackResourceUrl)
 
-		manifests, err = kustutil.BuildKustomization(k)
+		manifests, err = kustutil.BuildKustomization(k, nil)
 		if err != nil {
 			return nil, "", fmt.Errorf("failed to build kustomization: %w", err)
 		}
@@ -19,13 +19,17 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	_ "embed"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/codefresh-io/cli-v2/pkg/config"
 	cfgit "github.com/codefresh-io/cli-v2/pkg/git"
@@ -46,6 +50,12 @@ var (
 	die  = util.Die
 	exit = os.Exit
 
+	// stdinIsTerminal is false when stdin is piped or redirected, and prompts would fail on EOF
+	stdinIsTerminal = func() bool {
+		fi, err := os.Stdin.Stat()
+		return err == nil && fi.Mode()&os.ModeCharDevice != 0
+	}
+
 	//go:embed assets/workflows-ingress-patch.json
 	workflowsIngressPatch []byte
 
@@ -53,6 +63,7 @@ var (
 
 	GREEN           = "\033[32m"
 	RED             = "\033[31m"
+	YELLOW          = "\033[33m"
 	CYAN            = "\033[36m"
 	BOLD            = "\033[1m"
 	UNDERLINE       = "\033[4m"
@@ -93,6 +104,10 @@ func askUserIfToInstallDemoResources(cmd *cobra.Command, sampleInstall *bool) er
 			Selected: "{{ . | yellow }} ",
 		}
 
+		if err := ensureCanPrompt("demo resources installation", "--demo-resources"); err != nil {
+			return err
+		}
+
 		labelStr := fmt.Sprintf("%vInstall Codefresh demo resources?%v", CYAN, COLOR_RESET)
 
 		prompt := promptui.Select{
@@ -148,6 +163,10 @@ func ensureRepo(cmd *cobra.Command, runtimeName string, cloneOpts *apgit.CloneOp
 }
 
 func getRepoFromUserInput(cmd *cobra.Command) error {
+	if err := ensureCanPrompt("repository URL", "--repo"); err != nil {
+		return err
+	}
+
 	repoPrompt := promptui.Prompt{
 		Label: "Repository URL",
 		Validate: func(value string) error {
@@ -214,6 +233,10 @@ func getRuntimeNameFromUserSelect(ctx context.Context, allowManaged bool) (strin
 		runtimeNames = append(runtimeNames, rtDisplay)
 	}
 
+	if err := ensureCanPrompt("runtime name", "the runtime name argument"); err != nil {
+		return "", err
+	}
+
 	templates := &promptui.SelectTemplates{
 		Selected: "{{ . | yellow }} ",
 	}
@@ -232,7 +255,7 @@ func getRuntimeNameFromUserSelect(ctx context.Context, allowManaged bool) (strin
 }
 
 func getRuntimeNameFromUserInput() (string, error) {
-	runtimeName, err := getValueFromUserInput("Runtime name", "codefresh", validateRuntimeName)
+	runtimeName, err := getValueFromUserInput("Runtime name", "codefresh", "the runtime name argument", validateRuntimeName)
 	return runtimeName, err
 }
 
@@ -247,7 +270,11 @@ func validateRuntimeName(runtime string) error {
 	return nil
 }
 
-func getValueFromUserInput(label, defaultValue string, validate promptui.ValidateFunc) (string, error) {
+func getValueFromUserInput(label, defaultValue, flag string, validate promptui.ValidateFunc) (string, error) {
+	if err := ensureCanPrompt(strings.ToLower(label), flag); err != nil {
+		return "", err
+	}
+
 	prompt := promptui.Prompt{
 		Label:    label,
 		Default:  defaultValue,
@@ -259,6 +286,10 @@ func getValueFromUserInput(label, defaultValue string, validate promptui.Validat
 }
 
 func getIngressClassFromUserSelect(ingressClassNames []string) (string, error) {
+	if err := ensureCanPrompt("ingress class", "--ingress-class"); err != nil {
+		return "", err
+	}
+
 	templates := &promptui.SelectTemplates{
 		Selected: "{{ . | yellow }} ",
 	}
@@ -280,7 +311,7 @@ func getIngressClassFromUserSelect(ingressClassNames []string) (string, error) {
 }
 
 // ensureGitToken gets the runtime token from the user (if !silent), and verifys it witht he provider (if available)
-func ensureGitToken(cmd *cobra.Command, gitProvider cfgit.Provider, cloneOpts *apgit.CloneOptions) error {
+func ensureGitToken(cmd *cobra.Command, gitProvider cfgit.Provider, cloneOpts *apgit.CloneOptions, minTTL time.Duration) error {
 	ctx := cmd.Context()
 	errMessage := "Value stored in environment variable GIT_TOKEN is invalid; enter a valid runtime token: %w"
 	if cloneOpts.Auth.Password == "" && !store.Get().Silent {
@@ -298,6 +329,8 @@ func ensureGitToken(cmd *cobra.Command, gitProvider cfgit.Provider, cloneOpts *a
 			cloneOpts.Auth.Password = ""
 			return fmt.Errorf(errMessage, err)
 		}
+
+		warnOnGitTokenExpiration(ctx, gitProvider, cloneOpts.Auth.Password, minTTL)
 	} else if cloneOpts.Auth.Password == "" {
 		return fmt.Errorf("must provide a git token using --git-token")
 	}
@@ -305,8 +338,126 @@ func ensureGitToken(cmd *cobra.Command, gitProvider cfgit.Provider, cloneOpts *a
 	return nil
 }
 
+// warnOnGitTokenExpiration warns when the git token expires within minTTL, as it would fail the installation
+// when it expires midway. Providers that do not expose the token expiration are skipped
+func warnOnGitTokenExpiration(ctx context.Context, gitProvider cfgit.Provider, token string, minTTL time.Duration) {
+	getter, ok := gitProvider.(cfgit.TokenExpirationGetter)
+	if !ok || minTTL <= 0 {
+		return
+	}
+
+	expiration, err := getter.GetTokenExpiration(ctx, token)
+	if err != nil {
+		log.G(ctx).Debugf("Could not get the git token expiration: %s", err.Error())
+		return
+	}
+
+	if expiration.IsZero() {
+		return
+	}
+
+	if ttl := time.Until(expiration); ttl < minTTL {
+		log.G(ctx).Warnf("The git token expires in %s (at %s), which is less than the --min-token-ttl of %s. The installation will fail if the token expires before it is done", ttl.Round(time.Second), expiration.Format(time.RFC3339), minTTL)
+	}
+}
+
+// setGitBasicAuth configures username/password basic auth on the clone options, for git servers that do not support tokens
+func setGitBasicAuth(cloneOpts *apgit.CloneOptions, username, password string) error {
+	if username == "" || password == "" {
+		return fmt.Errorf("must provide both --git-username and --git-password when using basic auth")
+	}
+
+	cloneOpts.Auth.Username = username
+	cloneOpts.Auth.Password = password
+	return nil
+}
+
+// ensureTokenFromFile sets the token from the file, unless it was already set by its flag or environment variable
+func ensureTokenFromFile(token *string, path, flagName string) error {
+	if path == "" || *token != "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", flagName, err)
+	}
+
+	value := strings.TrimRightFunc(string(data), unicode.IsSpace)
+	if value == "" {
+		return fmt.Errorf("%s \"%s\" is empty", flagName, path)
+	}
+
+	*token = value
+	return nil
+}
+
+// lenientDuration is a duration flag that also accepts a bare number of minutes, which is deprecated
+type lenientDuration struct {
+	value *time.Duration
+	name  string
+}
+
+func newLenientDuration(value *time.Duration, name string) *lenientDuration {
+	return &lenientDuration{value: value, name: name}
+}
+
+func (d *lenientDuration) Set(s string) error {
+	value, bareNumber, err := parseLenientDuration(s)
+	if err != nil {
+		return err
+	}
+
+	if bareNumber {
+		log.G().Warnf("--%s %s is read as %s, a number without a unit is deprecated, use a duration like \"%s\" instead", d.name, s, value, value)
+	}
+
+	*d.value = value
+	return nil
+}
+
+func (d *lenientDuration) String() string {
+	if d.value == nil {
+		return ""
+	}
+
+	return d.value.String()
+}
+
+func (d *lenientDuration) Type() string {
+	return "duration"
+}
+
+// parseLenientDuration parses a go duration, or a bare number of minutes
+func parseLenientDuration(s string) (time.Duration, bool, error) {
+	if minutes, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Duration(minutes) * time.Minute, true, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid duration \"%s\", use a number of minutes or a duration like \"10m\" or \"600s\"", s)
+	}
+
+	return d, false, nil
+}
+
 // ensureGitPAT verifys the user's Personal Access Token (if it is different from the Runtime Token)
 func ensureGitPAT(ctx context.Context, opts *RuntimeInstallOptions) error {
+	if opts.GitUsername != "" || opts.GitPassword != "" {
+		// the basic auth password is not a token, and cannot be used as the personal git token
+		if opts.GitIntegrationRegistrationOpts.Token == "" {
+			log.G(ctx).Info("Personal git token was not provided. You may add your personal git token at any time from the UI in the user settings")
+			return nil
+		}
+
+		if opts.gitProvider != nil {
+			return opts.gitProvider.VerifyToken(ctx, cfgit.PersonalToken, opts.GitIntegrationRegistrationOpts.Token)
+		}
+
+		return nil
+	}
+
 	if opts.GitIntegrationRegistrationOpts.Token == "" {
 		opts.GitIntegrationRegistrationOpts.Token = opts.InsCloneOpts.Auth.Password
 		currentUser, err := cfConfig.NewClient().Users().GetCurrent(ctx)
@@ -325,7 +476,81 @@ func ensureGitPAT(ctx context.Context, opts *RuntimeInstallOptions) error {
 	return nil
 }
 
+// verifyGitSourceWriteAccess fails early when the git token cannot push to the git-source repo, which may be
+// a different repo than the installation repo. Providers that cannot check the repo permissions are skipped with a warning
+func verifyGitSourceWriteAccess(ctx context.Context, gsCloneOpts *apgit.CloneOptions) error {
+	gitProvider, err := cfgit.GetProvider(cfgit.ProviderType(gsCloneOpts.Provider), gsCloneOpts.Repo)
+	if err != nil {
+		log.G(ctx).Warnf("Skipping the git-source repo write access check: %s", err.Error())
+		return nil
+	}
+
+	verifier, ok := gitProvider.(cfgit.RepoWriteAccessVerifier)
+	if !ok {
+		log.G(ctx).Warnf("Skipping the git-source repo write access check, it is not supported for %s", gitProvider.Type())
+		return nil
+	}
+
+	err = verifier.VerifyRepoWriteAccess(ctx, gsCloneOpts.Repo, gsCloneOpts.Auth.Password)
+	if errors.Is(err, cfgit.ErrRepoNotFound) && gsCloneOpts.CreateIfNotExist {
+		// a missing repo is created, but a repo the token cannot see can neither be pushed to nor created
+		log.G(ctx).Warnf("The git-source repo \"%s\" was not found, or the git token has no access to it. It will be created, which fails if it exists", gsCloneOpts.Repo)
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to verify write access to the git-source repo: %w", err)
+	}
+
+	return nil
+}
+
+// ensureRepoDefaultBranch sets the repo's default branch as the ref of the clone options, when none was specified
+func ensureRepoDefaultBranch(ctx context.Context, gitProvider cfgit.Provider, cloneOpts *apgit.CloneOptions) error {
+	// an scp-like ssh url cannot be parsed as a url, its https form has the same query
+	u, err := url.Parse(cfgit.HTTPSRepoURL(cloneOpts.Repo))
+	if err != nil {
+		return fmt.Errorf("failed to parse repo url: %w", err)
+	}
+
+	if u.Query().Get("ref") != "" || gitProvider == nil {
+		return nil
+	}
+
+	branch, err := gitProvider.GetDefaultBranch(ctx, cloneOpts.Repo, cloneOpts.Auth.Password)
+	if err != nil {
+		// the repo might not exist yet, in which case it will be created with the provider's default branch
+		log.G(ctx).Debugf("Could not get the default branch of \"%s\": %s", cloneOpts.Repo, err.Error())
+		return nil
+	}
+
+	if branch == "" {
+		return nil
+	}
+
+	log.G(ctx).Infof("Using the repository default branch \"%s\"", branch)
+	if cfgit.IsSSHRepoURL(cloneOpts.Repo) {
+		sep := "?"
+		if strings.Contains(cloneOpts.Repo, "?") {
+			sep = "&"
+		}
+
+		cloneOpts.Repo += sep + "ref=" + url.QueryEscape(branch)
+		return nil
+	}
+
+	q := u.Query()
+	q.Set("ref", branch)
+	u.RawQuery = q.Encode()
+	cloneOpts.Repo = u.String()
+	return nil
+}
+
 func getGitTokenFromUserInput(cmd *cobra.Command) error {
+	if err := ensureCanPrompt("git token", "--git-token"); err != nil {
+		return err
+	}
+
 	gitTokenPrompt := promptui.Prompt{
 		Label: "Runtime git api token",
 		Mask:  '*',
@@ -358,6 +583,10 @@ func getApprovalFromUser(ctx context.Context, finalParameters map[string]string,
 }
 
 func promptSummaryToUser(ctx context.Context, finalParameters map[string]string, description string) (bool, error) {
+	if err := ensureCanPrompt("approval", ""); err != nil {
+		return false, err
+	}
+
 	templates := &promptui.SelectTemplates{
 		Selected: "{{ . | yellow }} ",
 	}
@@ -420,6 +649,11 @@ func getKubeContextName(context, kubeconfig *pflag.Flag) (string, error) {
 
 	if contextName == "" {
 		contextName = util.KubeCurrentContextName(kubeconfigPath)
+		// fail fast instead of failing later on a context that cannot be resolved
+		if contextName == "" || !util.CheckExistingContext(contextName, kubeconfigPath) {
+			return "", noCurrentKubeContextError(kubeconfigPath)
+		}
+
 		log.G().Infof("Using current kube context '%s'", contextName)
 	}
 
@@ -432,7 +666,15 @@ type SelectItem struct {
 }
 
 func getKubeContextNameFromUserSelect(kubeconfig string) (string, error) {
+	if err := ensureCanPrompt("kube context", "--context"); err != nil {
+		return "", err
+	}
+
 	contexts := util.KubeContexts(kubeconfig)
+	if len(contexts) == 0 {
+		return "", fmt.Errorf("no contexts were found in the kubeconfig")
+	}
+
 	templates := &promptui.SelectTemplates{
 		Active:   "▸ {{ .Name }} {{if .Current }}(current){{end}}",
 		Inactive: "  {{ .Name }} {{if .Current }}(current){{end}}",
@@ -455,6 +697,33 @@ func getKubeContextNameFromUserSelect(kubeconfig string) (string, error) {
 	return contexts[index].Name, nil
 }
 
+func noCurrentKubeContextError(kubeconfig string) error {
+	contexts := util.KubeContexts(kubeconfig)
+	if len(contexts) == 0 {
+		return fmt.Errorf("no contexts were found in the kubeconfig")
+	}
+
+	names := make([]string, 0, len(contexts))
+	for _, context := range contexts {
+		names = append(names, context.Name)
+	}
+
+	return fmt.Errorf("the kubeconfig has no current context, use --context to select one of: %s", strings.Join(names, ", "))
+}
+
+// ensureCanPrompt returns an actionable error instead of letting the prompt fail on EOF, when stdin is piped
+func ensureCanPrompt(value, flag string) error {
+	if stdinIsTerminal() {
+		return nil
+	}
+
+	if flag == "" {
+		return fmt.Errorf("cannot prompt for %s, stdin is not a terminal. use --silent to skip the prompt", value)
+	}
+
+	return fmt.Errorf("cannot prompt for %s, stdin is not a terminal. use --silent and provide %s", value, flag)
+}
+
 func validateIngressHost(ingressHost string) error {
 	isValid, err := isValidIngressHost(ingressHost)
 	if err != nil {
@@ -500,11 +769,15 @@ func setIngressHost(ctx context.Context, opts *RuntimeInstallOptions) error {
 	if store.Get().Silent {
 		opts.IngressHost = foundIngressHost
 	} else {
+		if foundHostName != "" {
+			foundIngressHost = suggestIngressHostFromCertificate(ctx, foundHostName, foundIngressHost)
+		}
+
 		opts.IngressHost, err = getIngressHostFromUserInput(foundIngressHost)
 		if err != nil {
 			return err
 		}
-		response, err := http.Get(opts.IngressHost)
+		response, err := http.Get(ingressHealthURL(opts.IngressHost))
 		if err != nil {
 			opts.IngressHost = ""
 			return err
@@ -519,8 +792,89 @@ func setIngressHost(ctx context.Context, opts *RuntimeInstallOptions) error {
 	return nil
 }
 
+// suggestIngressHostFromCertificate offers the host names of the certificate presented by the ingress controller
+// load balancer, for when it is only known by its ip or load balancer host name
+func suggestIngressHostFromCertificate(ctx context.Context, address, foundIngressHost string) string {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "443")
+	}
+
+	hostNames, err := getCertificateHostNames(address)
+	if err != nil {
+		log.G(ctx).Debugf("Could not get the certificate of \"%s\": %s", address, err.Error())
+		return foundIngressHost
+	}
+
+	if len(hostNames) == 0 {
+		return foundIngressHost
+	}
+
+	candidates := make([]string, 0, len(hostNames)+1)
+	for _, hostName := range hostNames {
+		candidates = append(candidates, "https://"+hostName)
+	}
+
+	if foundIngressHost != "" {
+		candidates = append(candidates, foundIngressHost)
+	}
+
+	selected, err := selectIngressHostCandidate(candidates)
+	if err != nil {
+		log.G(ctx).Debugf("Failed to select an ingress host: %s", err.Error())
+		return foundIngressHost
+	}
+
+	return selected
+}
+
+// getCertificateHostNames returns the DNS SANs of the certificate presented at address, without the wildcard ones.
+// The certificate is not verified, since the ip it is fetched from is not one of its host names
+func getCertificateHostNames(address string) ([]string, error) {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", address, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate presented by \"%s\"", address)
+	}
+
+	var hostNames []string
+	for _, name := range certs[0].DNSNames {
+		if !strings.HasPrefix(name, "*") {
+			hostNames = append(hostNames, name)
+		}
+	}
+
+	return hostNames, nil
+}
+
+var selectIngressHostCandidate = func(candidates []string) (string, error) {
+	if err := ensureCanPrompt("ingress host", "--ingress-host"); err != nil {
+		return "", err
+	}
+
+	templates := &promptui.SelectTemplates{
+		Selected: "{{ . | yellow }} ",
+	}
+
+	labelStr := fmt.Sprintf("%vSelect ingress host (from the ingress certificate)%v", CYAN, COLOR_RESET)
+
+	prompt := promptui.Select{
+		Label:     labelStr,
+		Items:     candidates,
+		Templates: templates,
+	}
+
+	_, result, err := prompt.Run()
+	return result, err
+}
+
 func getIngressHostFromUserInput(foundIngressHost string) (string, error) {
-	ingressHostInput, err := getValueFromUserInput("Ingress host", foundIngressHost, validateIngressHost)
+	ingressHostInput, err := getValueFromUserInput("Ingress host", foundIngressHost, "--ingress-host", validateIngressHost)
 	if err != nil {
 		return "", err
 	}
@@ -528,6 +882,68 @@ func getIngressHostFromUserInput(foundIngressHost string) (string, error) {
 	return ingressHostInput, nil
 }
 
+// ingressHealthURL is the url used to probe the ingress host, for environments that do not serve the root of the host
+func ingressHealthURL(ingressHost string) string {
+	healthPath := store.Get().IngressHealthPath
+	if healthPath == "" {
+		return ingressHost
+	}
+
+	return strings.TrimSuffix(ingressHost, "/") + "/" + strings.TrimPrefix(healthPath, "/")
+}
+
+var lookupIngressHost = net.DefaultResolver.LookupHost
+
+// checkIngressHostReachable resolves the ingress host and opens a tcp connection to its port, with the default http
+// transport, which --proxy configures. An unreachable host otherwise only fails the reporters, after the installation
+func checkIngressHostReachable(ctx context.Context, ingressHost string) error {
+	parsed, err := url.Parse(ingressHost)
+	if err != nil {
+		return err
+	}
+
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("failed to check the ingress host: unexpected default http transport")
+	}
+
+	if transport.Proxy != nil {
+		proxyURL, err := transport.Proxy(&http.Request{URL: parsed})
+		if err == nil && proxyURL != nil {
+			log.G(ctx).Debugf("Skipping the reachability check of \"%s\", it is reached through proxy \"%s\"", ingressHost, proxyURL.Host)
+			return nil
+		}
+	}
+
+	host, port := parsed.Hostname(), parsed.Port()
+	if port == "" {
+		port = "443"
+		if parsed.Scheme == "http" {
+			port = "80"
+		}
+	}
+
+	if !util.IsIP(host) {
+		if _, err = lookupIngressHost(ctx, host); err != nil {
+			return fmt.Errorf("ingress host \"%s\" could not be resolved: %w", host, err)
+		}
+	}
+
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	conn, err := dial(dialCtx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return fmt.Errorf("ingress host \"%s\" is not reachable on port %s: %w", host, port, err)
+	}
+
+	return conn.Close()
+}
+
 func checkIngressHostCertificate(ingress string) (bool, error) {
 	match, err := regexp.MatchString("http:", ingress)
 	if err != nil {
@@ -537,7 +953,7 @@ func checkIngressHostCertificate(ingress string) (bool, error) {
 		return true, nil
 	}
 
-	res, err := http.Get(ingress)
+	res, err := http.Get(ingressHealthURL(ingress))
 
 	if err == nil {
 		res.Body.Close()
@@ -560,7 +976,7 @@ func checkIngressHostCertificate(ingress string) (bool, error) {
 		return false, fmt.Errorf("failed with non-certificate error: %w", err)
 	}
 
-	insecureOk := checkIngressHostWithInsecure(ingress)
+	insecureOk := checkIngressHostWithInsecure(ingressHealthURL(ingress))
 	if !insecureOk {
 		return false, fmt.Errorf("insecure call failed: %w", err)
 	}
@@ -595,6 +1011,10 @@ func askUserIfToProceedWithInsecure(ctx context.Context) error {
 		return fmt.Errorf("cancelled installation due to invalid ingress host certificate. you can try again with --insecure-ingress-host")
 	}
 
+	if err := ensureCanPrompt("insecure ingress host approval", "--insecure-ingress-host"); err != nil {
+		return err
+	}
+
 	templates := &promptui.SelectTemplates{
 		Selected: "{{ . | yellow }} ",
 	}
@@ -651,6 +1071,67 @@ func getIscRepo(ctx context.Context) (string, error) {
 	return *currentUser.ActiveAccount.SharedConfigRepo, nil
 }
 
+// ensureIscRepo sets the suggested repo as the account's shared config repo. Since the shared config repo is account-wide,
+// replacing a different existing one requires override, or an approval from the user
+func ensureIscRepo(ctx context.Context, suggestedSharedConfigRepo string, override bool) (string, error) {
+	currentIscRepo, err := getAccountIscRepo(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if currentIscRepo != "" && !isSameRepo(currentIscRepo, suggestedSharedConfigRepo) && !override {
+		if store.Get().Silent {
+			return "", fmt.Errorf("account already uses \"%s\" as its shared config repo, use --override-shared-config-repo to replace it with \"%s\"", currentIscRepo, suggestedSharedConfigRepo)
+		}
+
+		approved, err := confirmIscRepoOverride(currentIscRepo, suggestedSharedConfigRepo)
+		if err != nil {
+			return "", err
+		}
+
+		if !approved {
+			return "", fmt.Errorf("replacing the shared config repo \"%s\" was cancelled by user", currentIscRepo)
+		}
+	}
+
+	return suggestIscRepo(ctx, suggestedSharedConfigRepo)
+}
+
+func isSameRepo(a, b string) bool {
+	normalize := func(repo string) string {
+		return strings.TrimSuffix(strings.TrimSuffix(strings.ToLower(repo), "/"), ".git")
+	}
+
+	return normalize(a) == normalize(b)
+}
+
+var getAccountIscRepo = getIscRepo
+
+var confirmIscRepoOverride = func(currentIscRepo, suggestedSharedConfigRepo string) (bool, error) {
+	if err := ensureCanPrompt("shared config repo override", "--override-shared-config-repo"); err != nil {
+		return false, err
+	}
+
+	templates := &promptui.SelectTemplates{
+		Selected: "{{ . | yellow }} ",
+	}
+
+	labelStr := fmt.Sprintf("%vThe account already uses \"%s\" as its shared config repo, replace it with \"%s\" for the whole account?%v", CYAN, currentIscRepo, suggestedSharedConfigRepo, COLOR_RESET)
+
+	prompt := promptui.Select{
+		Label:     labelStr,
+		Items:     []string{"No", "Yes"},
+		Templates: templates,
+	}
+
+	_, result, err := prompt.Run()
+	if err != nil {
+		return false, err
+	}
+
+	return result == "Yes", nil
+}
+
 func suggestIscRepo(ctx context.Context, suggestedSharedConfigRepo string) (string, error) {
 	setIscRepoResponse, err := cfConfig.NewClient().V2().Runtime().SetSharedConfigRepo(ctx, suggestedSharedConfigRepo)
 	if err != nil {
@@ -0,0 +1,679 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/cli-v2/pkg/config"
+	cfgit "github.com/codefresh-io/cli-v2/pkg/git"
+	"github.com/codefresh-io/cli-v2/pkg/log"
+	"github.com/codefresh-io/cli-v2/pkg/store"
+
+	apgit "github.com/argoproj-labs/argocd-autopilot/pkg/git"
+	apmodel "github.com/codefresh-io/go-sdk/pkg/codefresh/model/app-proxy"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+type fakeProvider struct {
+	defaultBranch string
+	err           error
+}
+
+func (p *fakeProvider) Type() cfgit.ProviderType { return cfgit.GITHUB_CLOUD }
+
+func (p *fakeProvider) ApiUrl() string { return cfgit.GITHUB_CLOUD_URL }
+
+func (p *fakeProvider) VerifyToken(_ context.Context, _ cfgit.TokenType, _ string) error { return nil }
+
+func (p *fakeProvider) SupportsMarketplace() bool { return true }
+
+func (p *fakeProvider) GetDefaultBranch(_ context.Context, _, _ string) (string, error) {
+	return p.defaultBranch, p.err
+}
+
+type fakeTemplatingProvider struct {
+	fakeProvider
+	template string
+	private  bool
+}
+
+func (p *fakeTemplatingProvider) CreateRepoFromTemplate(_ context.Context, _, template, _ string, private bool) (bool, error) {
+	p.template = template
+	p.private = private
+	return true, nil
+}
+
+type fakeExpiringTokenProvider struct {
+	fakeProvider
+	expiration time.Time
+}
+
+func (p *fakeExpiringTokenProvider) GetTokenExpiration(_ context.Context, _ string) (time.Time, error) {
+	return p.expiration, nil
+}
+
+// withFakeCfConfig sets a cli config with a single "test" context of the default platform url, for the length of the test
+func withFakeCfConfig(t *testing.T) {
+	t.Helper()
+	origConfig := cfConfig
+	cfConfig = &config.Config{
+		CurrentContext: "test",
+		Contexts:       map[string]*config.AuthContext{"test": {URL: "https://g.codefresh.io"}},
+	}
+	t.Cleanup(func() { cfConfig = origConfig })
+}
+
+func Test_warnOnGitTokenExpiration(t *testing.T) {
+	tests := map[string]struct {
+		provider cfgit.Provider
+		minTTL   time.Duration
+		wantWarn bool
+	}{
+		"should warn on a token that expires within the min ttl": {
+			provider: &fakeExpiringTokenProvider{expiration: time.Now().Add(5 * time.Minute)},
+			minTTL:   30 * time.Minute,
+			wantWarn: true,
+		},
+		"should not warn on a token that expires after the min ttl": {
+			provider: &fakeExpiringTokenProvider{expiration: time.Now().Add(time.Hour)},
+			minTTL:   30 * time.Minute,
+		},
+		"should not warn on a token that does not expire": {
+			provider: &fakeExpiringTokenProvider{},
+			minTTL:   30 * time.Minute,
+		},
+		"should not warn when the check is disabled": {
+			provider: &fakeExpiringTokenProvider{expiration: time.Now().Add(5 * time.Minute)},
+		},
+		"should skip a provider without token expiration": {
+			provider: &fakeProvider{},
+			minTTL:   30 * time.Minute,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger, hook := logrustest.NewNullLogger()
+			ctx := log.WithLogger(context.Background(), log.FromLogrus(logrus.NewEntry(logger), nil))
+
+			warnOnGitTokenExpiration(ctx, tt.provider, "token", tt.minTTL)
+
+			warned := false
+			for _, entry := range hook.AllEntries() {
+				if entry.Level == logrus.WarnLevel && strings.Contains(entry.Message, "--min-token-ttl") {
+					warned = true
+				}
+			}
+
+			if warned != tt.wantWarn {
+				t.Errorf("warnOnGitTokenExpiration() warned = %v, want %v", warned, tt.wantWarn)
+			}
+		})
+	}
+}
+
+func Test_ensureRepoDefaultBranch(t *testing.T) {
+	tests := []struct {
+		name         string
+		repo         string
+		provider     *fakeProvider
+		wantRepo     string
+		wantRevision string
+	}{
+		{
+			name:         "should target the repo default branch",
+			repo:         "https://github.com/owner/repo",
+			provider:     &fakeProvider{defaultBranch: "develop"},
+			wantRepo:     "https://github.com/owner/repo?ref=develop",
+			wantRevision: "develop",
+		},
+		{
+			name:         "should keep an explicit ref",
+			repo:         "https://github.com/owner/repo?ref=feature",
+			provider:     &fakeProvider{defaultBranch: "develop"},
+			wantRepo:     "https://github.com/owner/repo?ref=feature",
+			wantRevision: "feature",
+		},
+		{
+			name:         "should target the default branch of an ssh repo",
+			repo:         "git@github.com:owner/repo.git",
+			provider:     &fakeProvider{defaultBranch: "develop"},
+			wantRepo:     "git@github.com:owner/repo.git?ref=develop",
+			wantRevision: "develop",
+		},
+		{
+			name:     "should keep the repo as is when the lookup fails",
+			repo:     "https://github.com/owner/repo",
+			provider: &fakeProvider{err: fmt.Errorf("not found")},
+			wantRepo: "https://github.com/owner/repo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloneOpts := &apgit.CloneOptions{Repo: tt.repo}
+			if err := ensureRepoDefaultBranch(context.Background(), tt.provider, cloneOpts); err != nil {
+				t.Fatalf("ensureRepoDefaultBranch() error = %v", err)
+			}
+
+			if cloneOpts.Repo != tt.wantRepo {
+				t.Errorf("ensureRepoDefaultBranch() repo = %v, want %v", cloneOpts.Repo, tt.wantRepo)
+			}
+
+			cloneOpts.Parse()
+			if got := cloneOpts.Revision(); got != tt.wantRevision {
+				t.Errorf("Revision() = %v, want %v", got, tt.wantRevision)
+			}
+		})
+	}
+}
+
+func Test_setGitBasicAuth(t *testing.T) {
+	tests := []struct {
+		name         string
+		username     string
+		password     string
+		wantUsername string
+		wantPassword string
+		wantErr      bool
+	}{
+		{
+			name:         "should set basic auth credentials on the clone options",
+			username:     "user",
+			password:     "pass",
+			wantUsername: "user",
+			wantPassword: "pass",
+		},
+		{
+			name:     "should fail without a password",
+			username: "user",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloneOpts := &apgit.CloneOptions{Repo: "https://git.internal/owner/repo"}
+			if err := setGitBasicAuth(cloneOpts, tt.username, tt.password); (err != nil) != tt.wantErr {
+				t.Fatalf("setGitBasicAuth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if cloneOpts.Auth.Username != tt.wantUsername || cloneOpts.Auth.Password != tt.wantPassword {
+				t.Errorf("setGitBasicAuth() auth = %v:%v, want %v:%v", cloneOpts.Auth.Username, cloneOpts.Auth.Password, tt.wantUsername, tt.wantPassword)
+			}
+		})
+	}
+}
+
+func Test_ensureGitPAT_basicAuth(t *testing.T) {
+	opts := &RuntimeInstallOptions{
+		InsCloneOpts:                   &apgit.CloneOptions{Repo: "https://git.internal/owner/repo"},
+		GitIntegrationRegistrationOpts: &apmodel.RegisterToGitIntegrationArgs{},
+		GitUsername:                    "user",
+		GitPassword:                    "pass",
+	}
+	if err := setGitBasicAuth(opts.InsCloneOpts, opts.GitUsername, opts.GitPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ensureGitPAT(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if opts.GitIntegrationRegistrationOpts.Token != "" {
+		t.Errorf("ensureGitPAT() should not use the basic auth password as the personal git token, got \"%s\"", opts.GitIntegrationRegistrationOpts.Token)
+	}
+}
+
+func Test_ensureTokenFromFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		token     string
+		content   string
+		noFile    bool
+		wantToken string
+		wantErr   bool
+	}{
+		{
+			name:      "should read the token and trim the trailing newline",
+			content:   "file-token \n\n",
+			wantToken: "file-token",
+		},
+		{
+			name:      "should keep the token that was already set",
+			token:     "flag-token",
+			content:   "file-token\n",
+			wantToken: "flag-token",
+		},
+		{
+			name:    "should fail on an empty file",
+			content: "\n",
+			wantErr: true,
+		},
+		{
+			name:    "should fail on a missing file",
+			noFile:  true,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "token")
+			if !tt.noFile {
+				if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			token := tt.token
+			if err := ensureTokenFromFile(&token, path, "--git-token-file"); (err != nil) != tt.wantErr {
+				t.Fatalf("ensureTokenFromFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if token != tt.wantToken {
+				t.Errorf("ensureTokenFromFile() token = %v, want %v", token, tt.wantToken)
+			}
+		})
+	}
+}
+
+func Test_parseLenientDuration(t *testing.T) {
+	tests := []struct {
+		name           string
+		value          string
+		want           time.Duration
+		wantBareNumber bool
+		wantErr        bool
+	}{
+		{
+			name:  "should parse minutes",
+			value: "10m",
+			want:  10 * time.Minute,
+		},
+		{
+			name:  "should parse seconds",
+			value: "600s",
+			want:  10 * time.Minute,
+		},
+		{
+			name:           "should read a bare number as minutes",
+			value:          "10",
+			want:           10 * time.Minute,
+			wantBareNumber: true,
+		},
+		{
+			name:    "should reject an invalid duration",
+			value:   "ten",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, bareNumber, err := parseLenientDuration(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLenientDuration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want || bareNumber != tt.wantBareNumber {
+				t.Errorf("parseLenientDuration() = %v, %v, want %v, %v", got, bareNumber, tt.want, tt.wantBareNumber)
+			}
+		})
+	}
+}
+
+func Test_lenientDuration_flag(t *testing.T) {
+	var timeout time.Duration
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.Var(newLenientDuration(&timeout, "wait-timeout"), "wait-timeout", "")
+
+	for value, want := range map[string]time.Duration{"10m": 10 * time.Minute, "600s": 10 * time.Minute, "10": 10 * time.Minute} {
+		if err := flags.Parse([]string{"--wait-timeout", value}); err != nil {
+			t.Fatalf("Parse(%s) error = %v", value, err)
+		}
+
+		if timeout != want {
+			t.Errorf("--wait-timeout %s = %v, want %v", value, timeout, want)
+		}
+	}
+
+	if err := flags.Parse([]string{"--wait-timeout", "ten"}); err == nil {
+		t.Error("Parse(ten) should fail")
+	}
+}
+
+func Test_ensureCanPrompt_pipedStdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	_, _ = w.WriteString("runtime\n")
+	w.Close()
+
+	origStdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = origStdin }()
+
+	_, err = getRuntimeNameFromUserInput()
+	if err == nil {
+		t.Fatal("getRuntimeNameFromUserInput() should fail when stdin is piped")
+	}
+
+	for _, want := range []string{"stdin is not a terminal", "--silent", "runtime name argument"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("getRuntimeNameFromUserInput() error = %v, want it to contain %q", err, want)
+		}
+	}
+}
+
+func Test_checkIngressHostCertificate_healthPath(t *testing.T) {
+	var paths []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	defer server.Close()
+
+	origHealthPath := store.Get().IngressHealthPath
+	store.Get().IngressHealthPath = "/healthz"
+	defer func() { store.Get().IngressHealthPath = origHealthPath }()
+
+	// the self signed certificate fails the first probe, and the insecure probe reaches the server
+	certValid, err := checkIngressHostCertificate(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if certValid {
+		t.Error("checkIngressHostCertificate() should not accept a self signed certificate")
+	}
+
+	if len(paths) != 1 || paths[0] != "/healthz" {
+		t.Errorf("checkIngressHostCertificate() probed paths %v, want [/healthz]", paths)
+	}
+}
+
+func Test_suggestIngressHostFromCertificate(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{newTestCertificate(t, "runtime.example.com", "*.example.com", "alt.example.com")}}
+	server.StartTLS()
+	defer server.Close()
+
+	var offered []string
+	origSelect := selectIngressHostCandidate
+	selectIngressHostCandidate = func(candidates []string) (string, error) {
+		offered = candidates
+		return candidates[0], nil
+	}
+	defer func() { selectIngressHostCandidate = origSelect }()
+
+	address := server.Listener.Addr().String()
+	got := suggestIngressHostFromCertificate(context.Background(), address, "https://"+address)
+	if got != "https://runtime.example.com" {
+		t.Errorf("suggestIngressHostFromCertificate() = %v, want https://runtime.example.com", got)
+	}
+
+	want := []string{"https://runtime.example.com", "https://alt.example.com", "https://" + address}
+	if !reflect.DeepEqual(offered, want) {
+		t.Errorf("suggestIngressHostFromCertificate() offered %v, want %v", offered, want)
+	}
+}
+
+func newTestCertificate(t *testing.T, dnsNames ...string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func Test_ensureIscRepo_conflicting(t *testing.T) {
+	origGet := getAccountIscRepo
+	getAccountIscRepo = func(context.Context) (string, error) {
+		return "https://github.com/org/isc.git", nil
+	}
+	defer func() { getAccountIscRepo = origGet }()
+
+	prompted := 0
+	origConfirm := confirmIscRepoOverride
+	confirmIscRepoOverride = func(current, suggested string) (bool, error) {
+		prompted++
+		return false, nil
+	}
+	defer func() { confirmIscRepoOverride = origConfirm }()
+
+	_, err := ensureIscRepo(context.Background(), "https://github.com/org/other-isc", false)
+	if err == nil || !strings.Contains(err.Error(), "cancelled by user") {
+		t.Errorf("ensureIscRepo() error = %v, want cancelled by user", err)
+	}
+
+	if prompted != 1 {
+		t.Errorf("ensureIscRepo() prompted %d times, want 1", prompted)
+	}
+
+	origSilent := store.Get().Silent
+	store.Get().Silent = true
+	defer func() { store.Get().Silent = origSilent }()
+
+	_, err = ensureIscRepo(context.Background(), "https://github.com/org/other-isc", false)
+	if err == nil || !strings.Contains(err.Error(), "--override-shared-config-repo") {
+		t.Errorf("ensureIscRepo() error = %v, want to require --override-shared-config-repo", err)
+	}
+
+	if prompted != 1 {
+		t.Errorf("ensureIscRepo() prompted in silent mode")
+	}
+}
+
+func Test_getKubeContextName_silent(t *testing.T) {
+	origSilent := store.Get().Silent
+	store.Get().Silent = true
+	defer func() { store.Get().Silent = origSilent }()
+	t.Setenv("KUBECONFIG", "")
+
+	tests := map[string]struct {
+		currentContext string
+		context        string
+		want           string
+		wantErr        string
+	}{
+		"should use the current context": {
+			currentContext: "prod",
+			want:           "prod",
+		},
+		"should use the --context": {
+			currentContext: "prod",
+			context:        "staging",
+			want:           "staging",
+		},
+		"should fail without a current context": {
+			wantErr: "the kubeconfig has no current context, use --context to select one of: prod, staging",
+		},
+		"should fail on a current context that does not exist": {
+			currentContext: "deleted",
+			wantErr:        "the kubeconfig has no current context, use --context to select one of: prod, staging",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			conf := clientcmdapi.NewConfig()
+			conf.Clusters["cluster"] = &clientcmdapi.Cluster{Server: "https://cluster"}
+			conf.Contexts["prod"] = &clientcmdapi.Context{Cluster: "cluster"}
+			conf.Contexts["staging"] = &clientcmdapi.Context{Cluster: "cluster"}
+			conf.CurrentContext = tt.currentContext
+			kubeconfig := filepath.Join(t.TempDir(), "config")
+			if err := clientcmd.WriteToFile(*conf, kubeconfig); err != nil {
+				t.Fatal(err)
+			}
+
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			flags.String("context", tt.context, "")
+			flags.String("kubeconfig", kubeconfig, "")
+
+			got, err := getKubeContextName(flags.Lookup("context"), flags.Lookup("kubeconfig"))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("getKubeContextName() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("getKubeContextName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_checkIngressHostReachable(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closedURL := closed.URL
+	closed.Close()
+
+	origLookup := lookupIngressHost
+	defer func() { lookupIngressHost = origLookup }()
+	lookupIngressHost = func(_ context.Context, _ string) ([]string, error) {
+		return nil, fmt.Errorf("no such host")
+	}
+
+	t.Setenv("HTTPS_PROXY", "")
+	t.Setenv("HTTP_PROXY", "")
+
+	tests := map[string]struct {
+		ingressHost string
+		wantErr     string
+	}{
+		"Should pass when the host accepts connections": {
+			ingressHost: server.URL,
+		},
+		"Should fail when the host does not resolve": {
+			ingressHost: "https://missing.example.com",
+			wantErr:     "ingress host \"missing.example.com\" could not be resolved: no such host",
+		},
+		"Should fail when the port does not accept connections": {
+			ingressHost: closedURL,
+			wantErr:     "ingress host \"127.0.0.1\" is not reachable on port " + closedURL[strings.LastIndex(closedURL, ":")+1:],
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := checkIngressHostReachable(context.Background(), tt.ingressHost)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkIngressHostReachable() error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("checkIngressHostReachable() error = %v, want prefix %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_checkIngressHostReachable_proxy(t *testing.T) {
+	transport := http.DefaultTransport.(*http.Transport)
+	origProxy := transport.Proxy
+	defer func() { transport.Proxy = origProxy }()
+	// what util.SetProxy configures for --proxy
+	transport.Proxy = func(_ *http.Request) (*url.URL, error) {
+		return url.Parse("http://proxy.corp:3128")
+	}
+
+	origLookup := lookupIngressHost
+	defer func() { lookupIngressHost = origLookup }()
+	lookupIngressHost = func(_ context.Context, _ string) ([]string, error) {
+		return nil, fmt.Errorf("no such host")
+	}
+
+	if err := checkIngressHostReachable(context.Background(), "https://missing.example.com"); err != nil {
+		t.Errorf("checkIngressHostReachable() error = %v, want the check skipped for a host behind the proxy", err)
+	}
+}
+
+func Test_verifyGitSourceWriteAccess_notFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	tests := map[string]struct {
+		createIfNotExist bool
+		wantErr          bool
+	}{
+		"should warn about a repo that will be created": {
+			createIfNotExist: true,
+		},
+		"should fail on a repo that is not created": {
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			gsCloneOpts := &apgit.CloneOptions{
+				Repo:             server.URL + "/owner/repo_git-source.git/resources_runtime",
+				Provider:         string(cfgit.GITHUB_ENT),
+				CreateIfNotExist: tt.createIfNotExist,
+			}
+			err := verifyGitSourceWriteAccess(context.Background(), gsCloneOpts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyGitSourceWriteAccess() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -55,6 +55,7 @@ commands, respectively:
 	cmd.AddCommand(NewConfigDeleteContextCommand())
 	cmd.AddCommand(NewConfigSetRuntimeCommand())
 	cmd.AddCommand(NewConfigGetRuntimeCommand())
+	cmd.AddCommand(NewConfigSetTelemetryCommand())
 
 	return cmd
 }
@@ -272,3 +273,52 @@ func RunConfigDeleteContext(ctx context.Context, context string) error {
 	log.G(ctx).Infof("Deleted context: %s", context)
 	return nil
 }
+
+func NewConfigSetTelemetryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set-telemetry on|off",
+		Short: "Enables or disables the analytics reporting of every command",
+		Args:  cobra.MaximumNArgs(1),
+		Example: util.Doc(`
+# Disables the analytics reporting, a single command can still override it with --disable-telemetry=false:
+
+		<BIN> config set-telemetry off`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return fmt.Errorf("must provide the telemetry setting, on or off")
+			}
+
+			return RunConfigSetTelemetry(cmd.Context(), args[0])
+		},
+	}
+
+	return cmd
+}
+
+func RunConfigSetTelemetry(ctx context.Context, value string) error {
+	disable, err := parseTelemetrySetting(value)
+	if err != nil {
+		return err
+	}
+
+	cfConfig.DisableTelemetry = disable
+	if err := cfConfig.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	log.G(ctx).Infof("telemetry set to: %s", value)
+
+	return nil
+}
+
+// parseTelemetrySetting returns whether the telemetry setting disables the telemetry
+func parseTelemetrySetting(value string) (bool, error) {
+	switch value {
+	case "on":
+		return false, nil
+	case "off":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid telemetry setting \"%s\", must be on or off", value)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/codefresh-io/cli-v2/pkg/config"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseTelemetrySetting(t *testing.T) {
+	disable, err := parseTelemetrySetting("off")
+	assert.NoError(t, err)
+	assert.True(t, disable)
+
+	disable, err = parseTelemetrySetting("on")
+	assert.NoError(t, err)
+	assert.False(t, disable)
+
+	_, err = parseTelemetrySetting("disabled")
+	assert.EqualError(t, err, "invalid telemetry setting \"disabled\", must be on or off")
+}
+
+func Test_isTelemetryDisabled(t *testing.T) {
+	tests := map[string]struct {
+		configDisabled bool
+		args           []string
+		want           bool
+	}{
+		"Should use the config setting when the flag is not set": {
+			configDisabled: true,
+			want:           true,
+		},
+		"Should disable with the flag when the config enables": {
+			args: []string{"--disable-telemetry"},
+			want: true,
+		},
+		"Should enable with the flag when the config disables": {
+			configDisabled: true,
+			args:           []string{"--disable-telemetry=false"},
+			want:           false,
+		},
+	}
+	origConfig := cfConfig
+	defer func() { cfConfig = origConfig }()
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfConfig = &config.Config{DisableTelemetry: tt.configDisabled}
+			var disableTelemetry bool
+			cmd := &cobra.Command{}
+			cmd.Flags().BoolVar(&disableTelemetry, "disable-telemetry", false, "")
+			assert.NoError(t, cmd.Flags().Parse(tt.args))
+			assert.Equal(t, tt.want, isTelemetryDisabled(cmd, disableTelemetry))
+		})
+	}
+}
@@ -45,6 +45,7 @@ import (
 	wf "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow"
 	wfv1alpha1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 	appProxyModel "github.com/codefresh-io/go-sdk/pkg/codefresh/model/app-proxy"
+	"github.com/go-git/go-billy/v5"
 	billyUtils "github.com/go-git/go-billy/v5/util"
 	"github.com/juju/ansiterm"
 	"github.com/spf13/cobra"
@@ -68,6 +69,9 @@ type (
 		IngressClass        string
 		IngressController   ingressutil.IngressController
 		Flow                string
+		// DemoResourcesCloneOpts replaces the built-in demo pipelines with the files of another repo
+		DemoResourcesCloneOpts *git.CloneOptions
+		DemoResourcesPath      string
 	}
 
 	GitSourceDeleteOptions struct {
@@ -189,7 +193,7 @@ func NewGitSourceCreateCommand() *cobra.Command {
 			insCloneOpts.Parse()
 			gsCloneOpts.Parse()
 
-			return nil
+			return verifyGitSourceWriteAccess(ctx, gsCloneOpts)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
@@ -270,6 +274,10 @@ func createDemoResources(ctx context.Context, opts *GitSourceCreateOptions, gsRe
 	if err != nil {
 		return fmt.Errorf("failed to read files in git-source repo. Err: %w", err)
 	}
+	if len(fi) == 0 && opts.DemoResourcesCloneOpts != nil {
+		return copyDemoResources(ctx, opts, gsRepo, gsFs)
+	}
+
 	if len(fi) == 0 {
 		err = createCronExamplePipeline(&gitSourceCronExampleOptions{
 			runtimeName: opts.RuntimeName,
@@ -305,6 +313,80 @@ func createDemoResources(ctx context.Context, opts *GitSourceCreateOptions, gsRe
 	return nil
 }
 
+// copyDemoResources seeds the git-source with the files of the custom demo resources repo (or of a directory in it),
+// so an organization can install its own starter resources instead of the public demo pipelines
+func copyDemoResources(ctx context.Context, opts *GitSourceCreateOptions, gsRepo git.Repository, gsFs fs.FS) error {
+	_, demoFs, err := opts.DemoResourcesCloneOpts.GetRepo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to clone the demo resources repo: %w", err)
+	}
+
+	var srcFs billy.Filesystem = demoFs
+	if opts.DemoResourcesPath != "" {
+		srcFs, err = demoFs.Chroot(opts.DemoResourcesPath)
+		if err != nil {
+			return fmt.Errorf("failed to read the demo resources path \"%s\": %w", opts.DemoResourcesPath, err)
+		}
+	}
+
+	copied, err := copyDir(srcFs, gsFs, "")
+	if err != nil {
+		return fmt.Errorf("failed to copy the demo resources: %w", err)
+	}
+
+	if copied == 0 {
+		return fmt.Errorf("no demo resources were found in \"%s\"", opts.DemoResourcesCloneOpts.Repo)
+	}
+
+	commitMsg := fmt.Sprintf("Created demo resources in %s Directory", opts.GsCloneOpts.Path())
+
+	log.G(ctx).Info("Pushing demo resources to the new git-source repo")
+	if err := apu.PushWithMessage(ctx, gsRepo, commitMsg); err != nil {
+		return fmt.Errorf("failed to push demo resources to git-source repo: %w", err)
+	}
+
+	return nil
+}
+
+// copyDir copies the files of dir from src to the same path in dst, and returns how many files were copied
+func copyDir(src, dst billy.Filesystem, dir string) (int, error) {
+	files, err := src.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	copied := 0
+	for _, f := range files {
+		path := src.Join(dir, f.Name())
+		if f.IsDir() {
+			if f.Name() == ".git" {
+				continue
+			}
+
+			n, err := copyDir(src, dst, path)
+			if err != nil {
+				return copied, err
+			}
+
+			copied += n
+			continue
+		}
+
+		data, err := billyUtils.ReadFile(src, path)
+		if err != nil {
+			return copied, err
+		}
+
+		if err = billyUtils.WriteFile(dst, path, data, 0666); err != nil {
+			return copied, err
+		}
+
+		copied++
+	}
+
+	return copied, nil
+}
+
 func createPlaceholderIfNeeded(ctx context.Context, opts *GitSourceCreateOptions, gsRepo git.Repository, gsFs fs.FS) error {
 	fi, err := gsFs.ReadDir(".")
 	if err != nil {
@@ -811,7 +893,7 @@ func createDemoWorkflowTemplate(gsFs fs.FS) error {
 func createGithubExamplePipeline(opts *gitSourceGithubExampleOptions) error {
 	if !store.Get().SkipIngress {
 		// Create an ingress that will manage external access to the github eventsource service
-		ingress := createGithubExampleIngress(opts.ingressClass, opts.hostName, opts.ingressController, opts.runtimeName)
+		ingress := createGithubExampleIngress(opts.ingressClass, opts.hostName, opts.ingressController, opts.runtimeName, util.IsSecureHost(opts.ingressHost))
 		ingressFilePath := opts.gsFs.Join(opts.gsCloneOpts.Path(), store.Get().GithubExampleIngressFileName)
 
 		ingressRedundanded, err := cleanUpFieldsIngressGithub(&ingress)
@@ -864,7 +946,7 @@ func createGithubExamplePipeline(opts *gitSourceGithubExampleOptions) error {
 	return nil
 }
 
-func createGithubExampleIngress(ingressClass string, hostName string, ingressController ingressutil.IngressController, runtimeName string) *netv1.Ingress {
+func createGithubExampleIngress(ingressClass string, hostName string, ingressController ingressutil.IngressController, runtimeName string, tls bool) *netv1.Ingress {
 	ingressOptions := ingressutil.CreateIngressOptions{
 		Name:             store.Get().CodefreshDeliveryPipelines,
 		IngressClassName: ingressClass,
@@ -879,7 +961,7 @@ func createGithubExampleIngress(ingressClass string, hostName string, ingressCon
 		}}
 
 	ingress := ingressutil.CreateIngress(&ingressOptions)
-	ingressController.Decorate(ingress)
+	ingressController.Decorate(ingress, tls)
 
 	return ingress
 }
@@ -1239,7 +1321,7 @@ func getRuntimeVersion(ctx context.Context, runtimeName string) (*semver.Version
 
 func legacyGitSourceCreate(ctx context.Context, opts *GitSourceCreateOptions) error {
 	// upsert git-source repo
-	gsRepo, gsFs, err := opts.GsCloneOpts.GetRepo(ctx)
+	gsRepo, gsFs, err := apu.GetRepo(ctx, opts.GsCloneOpts)
 	if err != nil {
 		return fmt.Errorf("failed to clone git-source repo: %w", err)
 	}
@@ -1271,7 +1353,7 @@ func legacyGitSourceCreate(ctx context.Context, opts *GitSourceCreateOptions) er
 }
 
 func legacyGitSourceEdit(ctx context.Context, opts *GitSourceEditOptions) error {
-	repo, fs, err := opts.InsCloneOpts.GetRepo(ctx)
+	repo, fs, err := apu.GetRepo(ctx, opts.InsCloneOpts)
 	if err != nil {
 		return fmt.Errorf("failed to clone the installation repo, attempting to edit git-source %s. Err: %w", opts.GsName, err)
 	}
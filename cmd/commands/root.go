@@ -49,6 +49,7 @@ variables in advanced to simplify the use of those commands.
 	cfConfig = config.AddFlags(cmd.PersistentFlags())
 
 	cmd.AddCommand(NewVersionCommand())
+	cmd.AddCommand(NewCapabilitiesCommand())
 	cmd.AddCommand(NewConfigCommand())
 	cmd.AddCommand(NewRuntimeCommand())
 	cmd.AddCommand(NewGitSourceCommand())
@@ -59,7 +60,10 @@ variables in advanced to simplify the use of those commands.
 	cmd.AddCommand(NewIntegrationCommand())
 	cmd.AddCommand(NewCompletionCommand())
 
-	cobra.OnInitialize(func() { postInitCommands(cmd.Commands()) })
+	cobra.OnInitialize(func() {
+		die(util.SetProxy(s.Proxy, s.NoProxy))
+		postInitCommands(cmd.Commands())
+	})
 
 	return cmd
 }
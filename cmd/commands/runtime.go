@@ -16,6 +16,7 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -44,6 +45,8 @@ import (
 	argocdv1alpha1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
 	argocdv1alpha1cs "github.com/argoproj/argo-cd/v2/pkg/client/clientset/versioned"
 	"github.com/codefresh-io/go-sdk/pkg/codefresh/model"
+	"github.com/fatih/color"
+	"github.com/ghodss/yaml"
 	"github.com/juju/ansiterm"
 	"github.com/manifoldco/promptui"
 	"github.com/rkrmr33/checklist"
@@ -62,9 +65,39 @@ type (
 		FastExit         bool
 		DisableTelemetry bool
 		Managed          bool
+		All              bool
+		Yes              bool
+		ContinueOnError  bool
+		DryRun           bool
+		ProgressInterval time.Duration
 
 		kubeContext            string
 		skipAutopilotUninstall bool
+		bulk                   bool
+	}
+
+	RuntimeListOptions struct {
+		Watch         bool
+		WatchInterval time.Duration
+		NoColor       bool
+		Output        string
+	}
+
+	// runtimeListItem is the stable json/yaml representation of a runtime in the runtime list.
+	// missing values are null, instead of the "N/A" of the table
+	runtimeListItem struct {
+		Name                string                   `json:"name"`
+		Hosted              bool                     `json:"hosted"`
+		Namespace           *string                  `json:"namespace"`
+		Cluster             *string                  `json:"cluster"`
+		Version             *string                  `json:"version"`
+		SyncStatus          model.SyncStatus         `json:"syncStatus"`
+		HealthStatus        model.HealthStatus       `json:"healthStatus"`
+		HealthMessage       *string                  `json:"healthMessage"`
+		InstallationStatus  model.InstallationStatus `json:"installationStatus"`
+		IngressHost         *string                  `json:"ingressHost"`
+		InternalIngressHost *string                  `json:"internalIngressHost"`
+		IngressClass        *string                  `json:"ingressClass"`
 	}
 
 	RuntimeUpgradeOptions struct {
@@ -73,7 +106,9 @@ type (
 		CloneOpts                 *apgit.CloneOptions
 		CommonConfig              *runtime.CommonConfig
 		SuggestedSharedConfigRepo string
+		OverrideSharedConfigRepo  bool
 		DisableTelemetry          bool
+		DryRun                    bool
 	}
 
 	gvr struct {
@@ -82,12 +117,26 @@ type (
 		version      string
 	}
 
+	// argoEventsRef is what the reporters event sources and sensors reference, to be picked up by an argo-events controller
+	argoEventsRef struct {
+		eventBusName string
+		labels       map[string]string
+	}
+
+	// triggerRetry is the retry policy of the reporters sensor triggers, for when the platform is briefly unavailable
+	triggerRetry struct {
+		steps    int32
+		interval time.Duration
+	}
+
 	reporterCreateOptions struct {
-		reporterName string
-		gvr          []gvr
-		saName       string
-		IsInternal   bool
-		clusterScope bool
+		reporterName       string
+		gvr                []gvr
+		saName             string
+		createsWorkflowsSA bool
+		IsInternal         bool
+		clusterScope       bool
+		triggerDataKey     string
 	}
 
 	summaryLogLevels string
@@ -100,10 +149,56 @@ type (
 const (
 	Success summaryLogLevels = "Success"
 	Failed  summaryLogLevels = "Failed"
+	Warning summaryLogLevels = "Warning"
 	Info    summaryLogLevels = "Info"
 )
 
-var summaryArr []summaryLog
+// the --summary-level values, from the least to the most verbose
+const (
+	summaryLevelErrors   = "errors"
+	summaryLevelWarnings = "warnings"
+	summaryLevelAll      = "all"
+)
+
+var summaryLevels = []string{summaryLevelErrors, summaryLevelWarnings, summaryLevelAll}
+
+// clearScreen moves the cursor to the top left corner and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+// defaultProgressInterval is how often the uninstall progress of the runtime components is refreshed
+const defaultProgressInterval = time.Second
+
+var (
+	summaryArr  []summaryLog
+	summaryLock sync.Mutex
+)
+
+// componentColumns are the checklist columns of the runtime components, in the order of the fields
+// returned by getComponentChecklistState
+var componentColumns = []struct {
+	name   string
+	header string
+}{
+	{"component", "COMPONENT"},
+	{"health", "HEALTH STATUS"},
+	{"sync", "SYNC STATUS"},
+	{"version", "VERSION"},
+	{"errors", "ERRORS"},
+	{"namespace", "NAMESPACE"},
+}
+
+// the indexes of the fields returned by getComponentChecklistState
+const (
+	componentInfoName = iota
+	componentInfoHealth
+	componentInfoSync
+	componentInfoVersion
+	componentInfoErrors
+	componentInfoNamespace
+)
+
+// defaultComponentColumns are printed when no columns are configured
+var defaultComponentColumns = []string{"component", "health", "sync", "version", "errors"}
 
 func NewRuntimeCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -122,6 +217,9 @@ func NewRuntimeCommand() *cobra.Command {
 	cmd.AddCommand(NewRuntimeUninstallCommand())
 	cmd.AddCommand(NewRuntimeUpgradeCommand())
 	cmd.AddCommand(NewRuntimeLogsCommand())
+	cmd.AddCommand(NewRuntimeIngressCommand())
+	cmd.AddCommand(NewRuntimeExportCommand())
+	cmd.AddCommand(NewRuntimeImportCommand())
 
 	cmd.PersistentFlags().BoolVar(&store.Get().Silent, "silent", false, "Disables the command wizard")
 
@@ -179,7 +277,7 @@ func runtimeUninstallCommandPreRunHandler(cmd *cobra.Command, args []string, opt
 	}
 
 	if !opts.Managed {
-		err = ensureGitToken(cmd, nil, opts.CloneOpts)
+		err = ensureGitToken(cmd, nil, opts.CloneOpts, 0)
 	}
 	handleCliStep(reporter.UninstallStepPreCheckEnsureGitToken, "Getting git token", err, true, false)
 	if err != nil {
@@ -217,14 +315,17 @@ func runtimeUpgradeCommandPreRunHandler(cmd *cobra.Command, args []string, opts
 		return err
 	}
 
-	err = ensureGitToken(cmd, nil, opts.CloneOpts)
+	err = ensureGitToken(cmd, nil, opts.CloneOpts, 0)
 	handleCliStep(reporter.UpgradeStepPreCheckEnsureGitToken, "Getting git token", err, true, false)
 	if err != nil {
 		return err
 	}
 
-	if opts.SuggestedSharedConfigRepo != "" {
-		sharedConfigRepo, err := suggestIscRepo(ctx, opts.SuggestedSharedConfigRepo)
+	// the shared config repo is account-wide, a dry run must not change it
+	if opts.SuggestedSharedConfigRepo != "" && opts.DryRun {
+		log.G(ctx).Infof("The shared config repo of this account would be set to \"%s\"", opts.SuggestedSharedConfigRepo)
+	} else if opts.SuggestedSharedConfigRepo != "" {
+		sharedConfigRepo, err := ensureIscRepo(ctx, opts.SuggestedSharedConfigRepo, opts.OverrideSharedConfigRepo)
 		if err != nil {
 			return fmt.Errorf("failed to ensure shared config repo for account: %w", err)
 		}
@@ -262,8 +363,13 @@ func getComponentChecklistState(c model.Component) (checklist.ListItemState, che
 	version := "N/A"
 	syncStatus := "N/A"
 	healthStatus := "N/A"
+	namespace := "N/A"
 	errs := ""
 
+	if c.Metadata.Namespace != nil && *c.Metadata.Namespace != "" {
+		namespace = *c.Metadata.Namespace
+	}
+
 	if c.Version != "" {
 		version = c.Version
 	}
@@ -291,42 +397,221 @@ func getComponentChecklistState(c model.Component) (checklist.ListItemState, che
 		state = checklist.Ready
 	}
 
-	return state, []string{name, healthStatus, syncStatus, version, errs}
+	return state, []string{name, healthStatus, syncStatus, version, errs, namespace}
 }
 
+// parseComponentColumns returns the indexes of the columns in the fields returned by getComponentChecklistState
+func parseComponentColumns(columns []string) ([]int, error) {
+	if len(columns) == 0 {
+		columns = defaultComponentColumns
+	}
+
+	indexes := make([]int, 0, len(columns))
+	for _, column := range columns {
+		index := -1
+		for i, c := range componentColumns {
+			if c.name == strings.ToLower(strings.TrimSpace(column)) {
+				index = i
+				break
+			}
+		}
+
+		if index == -1 {
+			names := make([]string, len(componentColumns))
+			for i, c := range componentColumns {
+				names[i] = c.name
+			}
+
+			return nil, fmt.Errorf("unknown component column \"%s\", must be one of: %s", column, strings.Join(names, ", "))
+		}
+
+		indexes = append(indexes, index)
+	}
+
+	return indexes, nil
+}
+
+func componentColumnsHeader(indexes []int) checklist.ListItemInfo {
+	header := make(checklist.ListItemInfo, len(indexes))
+	for i, index := range indexes {
+		header[i] = componentColumns[index].header
+	}
+
+	return header
+}
+
+func selectComponentColumns(info checklist.ListItemInfo, indexes []int) checklist.ListItemInfo {
+	selected := make(checklist.ListItemInfo, len(indexes))
+	for i, index := range indexes {
+		selected[i] = info[index]
+	}
+
+	return selected
+}
+
+var runtimeListFormats = []string{"table", "json", "yaml", "yml"}
+
 func NewRuntimeListCommand() *cobra.Command {
+	var opts RuntimeListOptions
+
 	cmd := &cobra.Command{
 		Use:     "list",
 		Aliases: []string{"ls"},
 		Args:    cobra.NoArgs,
 		Short:   "List all Codefresh runtimes",
-		Example: util.Doc("<BIN> runtime list"),
+		Example: util.Doc(`
+# List all runtimes
+
+	<BIN> runtime list
+
+# Refresh the list every 10 seconds, until Ctrl-C
+
+	<BIN> runtime list --watch --watch-interval 10s
+
+# Print the runtimes as json
+
+	<BIN> runtime list -o json
+`),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := verifyOutputFormat(opts.Output, runtimeListFormats...); err != nil {
+				return err
+			}
+
+			if opts.Watch && opts.Output != "table" {
+				return fmt.Errorf("--watch only supports the table output")
+			}
+
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			ctx := cmd.Context()
 
-			return runRuntimeList(ctx)
+			return RunRuntimeList(ctx, &opts)
 		},
 	}
 
+	cmd.Flags().BoolVarP(&opts.Watch, "watch", "w", false, "If true, will keep refreshing the list until interrupted")
+	cmd.Flags().DurationVar(&opts.WatchInterval, "watch-interval", 5*time.Second, "How often to refresh the list in watch mode")
+	cmd.Flags().BoolVar(&opts.NoColor, "no-color", false, "If true, will append every refresh of the list instead of redrawing it in place (implied when the output is not a terminal)")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "table", "Output format, one of: "+strings.Join(runtimeListFormats, "|"))
+
 	return cmd
 }
 
-func runRuntimeList(ctx context.Context) error {
-	runtimes, err := cfConfig.NewClient().V2().Runtime().List(ctx)
+func RunRuntimeList(ctx context.Context, opts *RuntimeListOptions) error {
+	listRuntimes := cfConfig.NewClient().V2().Runtime().List
+	if opts.Watch {
+		// redrawing relies on terminal escape codes, so it falls back to appending when colors are disabled or stdout is not a terminal
+		inPlace := !opts.NoColor && !color.NoColor
+		return watchRuntimeList(ctx, os.Stdout, opts.WatchInterval, inPlace, listRuntimes)
+	}
+
+	runtimes, err := listRuntimes(ctx)
 	if err != nil {
 		return err
 	}
 
+	if opts.Output != "" && opts.Output != "table" {
+		return printRuntimes(os.Stdout, runtimes, opts.Output)
+	}
+
 	if len(runtimes) == 0 {
 		log.G(ctx).Info("No runtimes were found")
 		return nil
 	}
 
-	tb := ansiterm.NewTabWriter(os.Stdout, 0, 0, 4, ' ', 0)
-	_, err = fmt.Fprintln(tb, "NAME\tNAMESPACE\tCLUSTER\tVERSION\tSYNC_STATUS\tHEALTH_STATUS\tHEALTH_MESSAGE\tINSTALLATION_STATUS\tINGRESS_HOST\tINGRESS_CLASS")
+	return printRuntimesTable(os.Stdout, runtimes)
+}
+
+// printRuntimes prints the runtimes as json or yaml
+func printRuntimes(w io.Writer, runtimes []model.Runtime, format string) error {
+	items := make([]runtimeListItem, 0, len(runtimes))
+	for _, rt := range runtimes {
+		items = append(items, runtimeListItem{
+			Name:                rt.Metadata.Name,
+			Hosted:              rt.Managed,
+			Namespace:           rt.Metadata.Namespace,
+			Cluster:             rt.Cluster,
+			Version:             rt.RuntimeVersion,
+			SyncStatus:          rt.SyncStatus,
+			HealthStatus:        rt.HealthStatus,
+			HealthMessage:       rt.HealthMessage,
+			InstallationStatus:  rt.InstallationStatus,
+			IngressHost:         rt.IngressHost,
+			InternalIngressHost: rt.InternalIngressHost,
+			IngressClass:        rt.IngressClass,
+		})
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	switch format {
+	case "json":
+		data, err = json.MarshalIndent(items, "", "  ")
+	case "yaml", "yml":
+		data, err = yaml.Marshal(items)
+	default:
+		return fmt.Errorf("invalid output format: %s", format)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal runtimes: %w", err)
+	}
+
+	_, err = fmt.Fprintln(w, strings.TrimSuffix(string(data), "\n"))
+	return err
+}
+
+// watchRuntimeList re-queries the runtimes on every tick and prints them, until ctx is canceled
+func watchRuntimeList(ctx context.Context, w io.Writer, interval time.Duration, inPlace bool, listRuntimes func(context.Context) ([]model.Runtime, error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runtimes, err := listRuntimes(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		if inPlace {
+			_, err = fmt.Fprint(w, clearScreen)
+		} else {
+			_, err = fmt.Fprintf(w, "\n%s\n", time.Now().Format(time.RFC3339))
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(runtimes) == 0 {
+			_, err = fmt.Fprintln(w, "No runtimes were found")
+		} else {
+			err = printRuntimesTable(w, runtimes)
+		}
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			// both might be ready, and select picks randomly
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+	}
+}
+
+func printRuntimesTable(w io.Writer, runtimes []model.Runtime) error {
+	tb := ansiterm.NewTabWriter(w, 0, 0, 4, ' ', 0)
+	_, err := fmt.Fprintln(tb, "NAME\tNAMESPACE\tCLUSTER\tVERSION\tSYNC_STATUS\tHEALTH_STATUS\tHEALTH_MESSAGE\tINSTALLATION_STATUS\tINGRESS_HOST\tINGRESS_CLASS")
 	if err != nil {
 		return err
 	}
@@ -400,13 +685,14 @@ func runRuntimeList(ctx context.Context) error {
 func NewRuntimeUninstallCommand() *cobra.Command {
 	var (
 		opts            RuntimeUninstallOptions
+		runtimesOpts    []*RuntimeUninstallOptions
 		finalParameters map[string]string
 	)
 
 	cmd := &cobra.Command{
-		Use:   "uninstall [RUNTIME_NAME]",
-		Short: "Uninstall a Codefresh runtime",
-		Args:  cobra.MaximumNArgs(1),
+		Use:   "uninstall [RUNTIME_NAME...]",
+		Short: "Uninstall Codefresh runtimes",
+		Args:  cobra.ArbitraryArgs,
 		Example: util.Doc(`
 # To run this command you need to create a personal access token for your git provider
 # and provide it using:
@@ -420,11 +706,55 @@ func NewRuntimeUninstallCommand() *cobra.Command {
 # Deletes a runtime
 
 	<BIN> runtime uninstall runtime-name --repo gitops_repo
+
+# Deletes several runtimes, one after the other, using the repo and the kube context of each runtime
+
+	<BIN> runtime uninstall runtime-a runtime-b runtime-c --continue-on-error
+
+# Deletes all of the runtimes of the account
+
+	<BIN> runtime uninstall --all --yes
+
+# Lists what would be removed, without removing anything
+
+	<BIN> runtime uninstall runtime-name --repo gitops_repo --dry-run
 `),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 
-			createAnalyticsReporter(ctx, reporter.UninstallFlow, opts.DisableTelemetry)
+			createAnalyticsReporter(ctx, reporter.UninstallFlow, isTelemetryDisabled(cmd, opts.DisableTelemetry))
+
+			if opts.ProgressInterval <= 0 {
+				return fmt.Errorf("--progress-interval must be positive")
+			}
+
+			if opts.All || len(args) > 1 {
+				var err error
+				runtimesOpts, err = runtimeBulkUninstallPreRunHandler(cmd, args, &opts)
+				if err != nil {
+					return fmt.Errorf("pre run error: %w", err)
+				}
+
+				var names []string
+				for _, runtimeOpts := range runtimesOpts {
+					runtimeOpts.Timeout = store.Get().WaitTimeout
+					name := runtimeOpts.RuntimeName
+					if !runtimeOpts.Managed {
+						name = fmt.Sprintf("%s (kube context: %s)", name, runtimeOpts.kubeContext)
+					}
+
+					names = append(names, name)
+				}
+
+				if opts.DryRun {
+					return nil
+				}
+
+				return getApprovalFromUser(ctx, map[string]string{
+					"Codefresh context": cfConfig.CurrentContext,
+					"Runtime names":     strings.Join(names, ", "),
+				}, "runtime uninstall")
+			}
 
 			err := runtimeUninstallCommandPreRunHandler(cmd, args, &opts)
 			handleCliStep(reporter.UninstallPhasePreCheckFinish, "Finished pre run checks", err, true, false)
@@ -447,9 +777,11 @@ func NewRuntimeUninstallCommand() *cobra.Command {
 				opts.CloneOpts.Parse()
 			}
 
-			err = getApprovalFromUser(ctx, finalParameters, "runtime uninstall")
-			if err != nil {
-				return err
+			if !opts.DryRun {
+				err = getApprovalFromUser(ctx, finalParameters, "runtime uninstall")
+				if err != nil {
+					return err
+				}
 			}
 
 			opts.Timeout = store.Get().WaitTimeout
@@ -457,6 +789,22 @@ func NewRuntimeUninstallCommand() *cobra.Command {
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, _ []string) error {
+			if opts.DryRun {
+				if runtimesOpts == nil {
+					runtimesOpts = []*RuntimeUninstallOptions{&opts}
+				}
+
+				for _, runtimeOpts := range runtimesOpts {
+					runRuntimeUninstallDryRun(cmd.Context(), runtimeOpts)
+				}
+
+				return nil
+			}
+
+			if runtimesOpts != nil {
+				return runRuntimeBulkUninstall(cmd.Context(), runtimesOpts, opts.ContinueOnError)
+			}
+
 			err := runRuntimeUninstall(cmd.Context(), &opts)
 			handleCliStep(reporter.UninstallPhaseFinish, "Uninstall phase finished", err, false, true)
 			return err
@@ -464,10 +812,15 @@ func NewRuntimeUninstallCommand() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&opts.SkipChecks, "skip-checks", false, "If true, will not verify that runtime exists before uninstalling")
-	cmd.Flags().DurationVar(&store.Get().WaitTimeout, "wait-timeout", store.Get().WaitTimeout, "How long to wait for the runtime components to be deleted")
+	cmd.Flags().Var(newLenientDuration(&store.Get().WaitTimeout, "wait-timeout"), "wait-timeout", "How long to wait for the runtime components to be deleted (a number without a unit is deprecated, and read as minutes)")
 	cmd.Flags().BoolVar(&opts.Force, "force", false, "If true, will guarantee the runtime is removed from the platform, even in case of errors while cleaning the repo and the cluster")
 	cmd.Flags().BoolVar(&opts.FastExit, "fast-exit", false, "If true, will not wait for deletion of cluster resources. This means that full resource deletion will not be verified")
 	cmd.Flags().BoolVar(&opts.DisableTelemetry, "disable-telemetry", false, "If true, will disable the analytics reporting for the uninstall process")
+	cmd.Flags().BoolVar(&opts.All, "all", false, "If true, will uninstall all of the runtimes of the account. Requires --yes")
+	cmd.Flags().BoolVar(&opts.Yes, "yes", false, "Confirms the uninstall of all of the runtimes of the account with --all")
+	cmd.Flags().BoolVar(&opts.ContinueOnError, "continue-on-error", false, "If true, will keep uninstalling the rest of the runtimes when one of them fails, when uninstalling multiple runtimes")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "If true, will print the git integrations, ISC entry, applications, repo and platform record the uninstall would remove, without removing anything")
+	cmd.Flags().DurationVar(&opts.ProgressInterval, "progress-interval", defaultProgressInterval, "How often the uninstall progress of the runtime components is refreshed")
 
 	opts.CloneOpts = apu.AddCloneFlags(cmd, &apu.CloneFlagsOptions{
 		CloneForWrite: true,
@@ -479,7 +832,10 @@ func NewRuntimeUninstallCommand() *cobra.Command {
 }
 
 func runRuntimeUninstall(ctx context.Context, opts *RuntimeUninstallOptions) error {
-	defer printSummaryToUser()
+	// the summary of a bulk uninstall is printed once all of the runtimes are done
+	if !opts.bulk {
+		defer printSummaryToUser()
+	}
 
 	handleCliStep(reporter.UninstallPhaseStart, "Uninstall phase started", nil, false, false)
 
@@ -490,7 +846,7 @@ func runRuntimeUninstall(ctx context.Context, opts *RuntimeUninstallOptions) err
 	}
 	handleCliStep(reporter.UninstallStepCheckRuntimeExists, "Checking if runtime exists", err, false, true)
 	if err != nil {
-		summaryArr = append(summaryArr, summaryLog{"you can attempt to uninstall again with the \"--skip-checks\" flag", Info})
+		appendToSummary(summaryLog{"you can attempt to uninstall again with the \"--skip-checks\" flag", Info})
 		return err
 	}
 
@@ -502,7 +858,7 @@ func runRuntimeUninstall(ctx context.Context, opts *RuntimeUninstallOptions) err
 	}
 	handleCliStep(reporter.UninstallStepRemoveGitIntegrations, "Removing git integrations", err, false, true)
 	if err != nil {
-		summaryArr = append(summaryArr, summaryLog{"you can attempt to uninstall again with the \"--force\" flag", Info})
+		appendToSummary(summaryLog{"you can attempt to uninstall again with the \"--force\" flag", Info})
 		return err
 	}
 
@@ -517,8 +873,10 @@ func runRuntimeUninstall(ctx context.Context, opts *RuntimeUninstallOptions) err
 
 	if !opts.skipAutopilotUninstall {
 		subCtx, cancel := context.WithCancel(ctx)
+		progressDone := make(chan struct{})
 		go func() {
-			if err := printApplicationsState(subCtx, opts.RuntimeName, opts.KubeFactory, opts.Managed); err != nil {
+			defer close(progressDone)
+			if err := printApplicationsState(subCtx, opts.RuntimeName, opts.KubeFactory, opts.Managed, uninstallProgressInterval(opts)); err != nil {
 				log.G(ctx).WithError(err).Debug("failed to print uninstallation progress")
 			}
 		}()
@@ -535,13 +893,14 @@ func runRuntimeUninstall(ctx context.Context, opts *RuntimeUninstallOptions) err
 			})
 		}
 		cancel() // to tell the progress to stop displaying even if it's not finished
+		<-progressDone
 		if opts.Force {
 			err = nil
 		}
 	}
 	handleCliStep(reporter.UninstallStepUninstallRepo, "Uninstalling repo", err, false, !opts.Managed && !opts.skipAutopilotUninstall)
 	if err != nil {
-		summaryArr = append(summaryArr, summaryLog{"you can attempt to uninstall again with the \"--force\" flag", Info})
+		appendToSummary(summaryLog{"you can attempt to uninstall again with the \"--force\" flag", Info})
 		return err
 	}
 
@@ -566,7 +925,7 @@ func runRuntimeUninstall(ctx context.Context, opts *RuntimeUninstallOptions) err
 	return nil
 }
 
-func printApplicationsState(ctx context.Context, runtime string, f kube.Factory, managed bool) error {
+func printApplicationsState(ctx context.Context, runtime string, f kube.Factory, managed bool, interval time.Duration) error {
 	if managed {
 		return nil
 	}
@@ -601,20 +960,21 @@ func printApplicationsState(ctx context.Context, runtime string, f kube.Factory,
 		apps[a.Name] = &curApps.Items[i]
 	}
 
-	// refresh components state
-	go func() {
-		t := time.NewTicker(time.Second)
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-t.C:
-			}
+	// refresh components state, until the checklist is done
+	refreshCtx, cancel := context.WithCancel(ctx)
+	refreshDone := make(chan struct{})
+	defer func() {
+		cancel()
+		<-refreshDone
+	}()
 
+	go func() {
+		defer close(refreshDone)
+		pollApplications(refreshCtx, interval, func(ctx context.Context) {
 			curApps, err := appIf.List(ctx, metav1.ListOptions{LabelSelector: componentsLabelSelector})
 			if err != nil {
 				log.G(ctx).WithError(err).Debug("failed to refresh components state")
-				continue
+				return
 			}
 
 			newApps := make(map[string]*argocdv1alpha1.Application, len(curApps.Items))
@@ -635,7 +995,7 @@ func printApplicationsState(ctx context.Context, runtime string, f kube.Factory,
 				}
 			}
 			lock.Unlock()
-		}
+		})
 	}()
 
 	checkers := make([]checklist.Checker, len(curApps.Items))
@@ -664,6 +1024,31 @@ func printApplicationsState(ctx context.Context, runtime string, f kube.Factory,
 	return nil
 }
 
+// uninstallProgressInterval falls back to the default interval for uninstalls that were not started by the uninstall command
+func uninstallProgressInterval(opts *RuntimeUninstallOptions) time.Duration {
+	if opts.ProgressInterval <= 0 {
+		return defaultProgressInterval
+	}
+
+	return opts.ProgressInterval
+}
+
+// pollApplications calls refresh every interval, until ctx is done
+func pollApplications(ctx context.Context, interval time.Duration, refresh func(context.Context)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		refresh(ctx)
+	}
+}
+
 func getApplicationChecklistState(name string, a *argocdv1alpha1.Application, runtime string) (checklist.ListItemState, checklist.ListItemInfo) {
 	state := checklist.Waiting
 	name = strings.TrimPrefix(name, fmt.Sprintf("%s-", runtime))
@@ -748,11 +1133,15 @@ func NewRuntimeUpgradeCommand() *cobra.Command {
 # Upgrade a runtime to version v0.0.30
 
 	<BIN> runtime upgrade runtime-name --version 0.0.30 --repo gitops_repo
+
+# Review the components an upgrade would add, remove and change, without applying it
+
+	<BIN> runtime upgrade runtime-name --version 0.0.30 --repo gitops_repo --dry-run
 `),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 
-			createAnalyticsReporter(ctx, reporter.UpgradeFlow, opts.DisableTelemetry)
+			createAnalyticsReporter(ctx, reporter.UpgradeFlow, isTelemetryDisabled(cmd, opts.DisableTelemetry))
 
 			err := runtimeUpgradeCommandPreRunHandler(cmd, args, &opts)
 			handleCliStep(reporter.UpgradePhasePreCheckFinish, "Finished pre run checks", err, true, false)
@@ -804,8 +1193,10 @@ func NewRuntimeUpgradeCommand() *cobra.Command {
 
 	cmd.Flags().StringVar(&versionStr, "version", "", "The runtime version to upgrade to, defaults to latest")
 	cmd.Flags().StringVar(&opts.SuggestedSharedConfigRepo, "shared-config-repo", "", "URL to the shared configurations repo. (default: <installation-repo> or the existing one for this account)")
+	cmd.Flags().BoolVar(&opts.OverrideSharedConfigRepo, "override-shared-config-repo", false, "If true, will replace the account's existing shared config repo with --shared-config-repo, without asking for approval")
 	cmd.Flags().BoolVar(&opts.DisableTelemetry, "disable-telemetry", false, "If true, will disable analytics reporting for the upgrade process")
 	cmd.Flags().BoolVar(&store.Get().SetDefaultResources, "set-default-resources", false, "If true, will set default requests and limits on all of the runtime components")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "If true, will print the components the upgrade would add, remove and change, without pushing or installing anything")
 	opts.CloneOpts = apu.AddCloneFlags(cmd, &apu.CloneFlagsOptions{CloneForWrite: true})
 
 	return cmd
@@ -830,7 +1221,7 @@ func runRuntimeUpgrade(ctx context.Context, opts *RuntimeUpgradeOptions) error {
 	}
 
 	log.G(ctx).Info("Cloning installation repository")
-	r, fs, err := opts.CloneOpts.GetRepo(ctx)
+	r, fs, err := apu.GetRepo(ctx, opts.CloneOpts)
 	handleCliStep(reporter.UpgradeStepGetRepo, "Getting repository", err, true, false)
 	if err != nil {
 		return err
@@ -851,6 +1242,11 @@ func runRuntimeUpgrade(ctx context.Context, opts *RuntimeUpgradeOptions) error {
 		return err
 	}
 
+	if opts.DryRun {
+		printUpgradeDiff(os.Stdout, opts.RuntimeName, curRt.UpgradeDiff(newRt))
+		return nil
+	}
+
 	log.G(ctx).Infof("Upgrading runtime \"%s\" to version: v%s", opts.RuntimeName, newRt.Spec.Version)
 	newComponents, err := curRt.Upgrade(fs, newRt, opts.CommonConfig)
 	handleCliStep(reporter.UpgradeStepUpgradeRuntime, "Upgrading runtime", err, false, false)
@@ -882,6 +1278,36 @@ func runRuntimeUpgrade(ctx context.Context, opts *RuntimeUpgradeOptions) error {
 	return nil
 }
 
+// printUpgradeDiff prints the version delta and the components the upgrade would add, remove and change
+func printUpgradeDiff(w io.Writer, runtimeName string, diff *runtime.UpgradeDiff) {
+	fmt.Fprintf(w, "Runtime \"%s\" would be upgraded from v%s to v%s\n", runtimeName, diff.FromVersion, diff.ToVersion)
+	if len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Changed) == 0 {
+		fmt.Fprintln(w, "No component changes")
+		return
+	}
+
+	if len(diff.Added) > 0 {
+		fmt.Fprintln(w, "\nComponents to add:")
+		for _, component := range diff.Added {
+			fmt.Fprintf(w, "  + %s (%s)\n", component.Name, component.URL)
+		}
+	}
+
+	if len(diff.Removed) > 0 {
+		fmt.Fprintln(w, "\nComponents to remove:")
+		for _, component := range diff.Removed {
+			fmt.Fprintf(w, "  - %s (%s)\n", component.Name, component.URL)
+		}
+	}
+
+	if len(diff.Changed) > 0 {
+		fmt.Fprintln(w, "\nComponents to change:")
+		for _, change := range diff.Changed {
+			fmt.Fprintf(w, "  ~ %s: %s -> %s\n", change.Name, change.FromURL, change.ToURL)
+		}
+	}
+}
+
 func NewRuntimeLogsCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "logs [--ingress-host <url>] [--download]",
@@ -988,29 +1414,73 @@ func handleCliStep(step reporter.CliStep, message string, err error, preStep boo
 
 func appendLogToSummary(message string, err error) {
 	if err != nil {
-		summaryArr = append(summaryArr, summaryLog{message, Failed})
+		appendToSummary(summaryLog{message, Failed})
 	} else {
-		summaryArr = append(summaryArr, summaryLog{message, Success})
+		appendToSummary(summaryLog{message, Success})
 	}
 }
 
+// appendToSummary adds the logs to the summary under the summary lock
+func appendToSummary(logs ...summaryLog) {
+	summaryLock.Lock()
+	defer summaryLock.Unlock()
+	summaryArr = append(summaryArr, logs...)
+}
+
 func printSummaryToUser() {
+	printFilteredSummaryToUser(summaryLevelAll)
+}
+
+// printFilteredSummaryToUser prints only the summary entries shown at the summary level
+func printFilteredSummaryToUser(summaryLevel string) {
+	summaryLock.Lock()
+	defer summaryLock.Unlock()
 	for i := 0; i < len(summaryArr); i++ {
-		if summaryArr[i].level == Success {
-			fmt.Printf("%s -> %v%s%v\n", summaryArr[i].message, GREEN, summaryArr[i].level, COLOR_RESET)
-		} else if summaryArr[i].level == Failed {
-			fmt.Printf("%s -> %v%s%v\n", summaryArr[i].message, RED, summaryArr[i].level, COLOR_RESET)
-		} else {
-			fmt.Printf("%s\n", summaryArr[i].message)
+		if !isShownAtSummaryLevel(summaryArr[i].level, summaryLevel) {
+			continue
 		}
+
+		printSummaryLog(summaryArr[i])
 	}
 	//clear array to avoid double printing
 	summaryArr = []summaryLog{}
 }
 
+func printSummaryLog(l summaryLog) {
+	if l.level == Success {
+		fmt.Printf("%s -> %v%s%v\n", l.message, GREEN, l.level, COLOR_RESET)
+	} else if l.level == Failed {
+		fmt.Printf("%s -> %v%s%v\n", l.message, RED, l.level, COLOR_RESET)
+	} else if l.level == Warning {
+		fmt.Printf("%v%s%v\n", YELLOW, l.message, COLOR_RESET)
+	} else {
+		fmt.Printf("%s\n", l.message)
+	}
+}
+
+func isShownAtSummaryLevel(level summaryLogLevels, summaryLevel string) bool {
+	switch summaryLevel {
+	case summaryLevelErrors:
+		return level == Failed
+	case summaryLevelWarnings:
+		return level == Failed || level == Warning
+	default:
+		return true
+	}
+}
+
+// isTelemetryDisabled returns the --disable-telemetry value when it is set, and the config telemetry setting otherwise
+func isTelemetryDisabled(cmd *cobra.Command, disableTelemetry bool) bool {
+	if f := cmd.Flags().Lookup("disable-telemetry"); f != nil && f.Changed {
+		return disableTelemetry
+	}
+
+	return cfConfig.DisableTelemetry
+}
+
 func createAnalyticsReporter(ctx context.Context, flow reporter.FlowType, disableTelemetry bool) {
 	if disableTelemetry {
-		log.G().Debug("Analytics Reporter disabled by the --disable-telemetry flag.")
+		log.G().Debug("Analytics Reporter disabled by the --disable-telemetry flag or the config telemetry setting.")
 		return
 	}
 
@@ -0,0 +1,319 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/codefresh-io/cli-v2/pkg/log"
+	"github.com/codefresh-io/cli-v2/pkg/runtime"
+	"github.com/codefresh-io/cli-v2/pkg/store"
+	"github.com/codefresh-io/cli-v2/pkg/util"
+	apu "github.com/codefresh-io/cli-v2/pkg/util/aputil"
+
+	"github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+	apgit "github.com/argoproj-labs/argocd-autopilot/pkg/git"
+	apmodel "github.com/codefresh-io/go-sdk/pkg/codefresh/model/app-proxy"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+)
+
+const defaultGitIntegrationName = "default"
+
+type (
+	RuntimeExportOptions struct {
+		RuntimeName string
+		OutputFile  string
+		CloneOpts   *apgit.CloneOptions
+	}
+
+	// runtimeExport is the portable definition of a runtime, that "runtime import" installs
+	runtimeExport struct {
+		Runtime         *runtime.Runtime         `json:"runtime"`
+		GitIntegrations []exportedGitIntegration `json:"gitIntegrations,omitempty"`
+	}
+
+	exportedGitIntegration struct {
+		Name          string                `json:"name"`
+		Provider      apmodel.GitProviders  `json:"provider"`
+		APIURL        string                `json:"apiUrl"`
+		SharingPolicy apmodel.SharingPolicy `json:"sharingPolicy"`
+	}
+)
+
+func NewRuntimeExportCommand() *cobra.Command {
+	var opts RuntimeExportOptions
+
+	cmd := &cobra.Command{
+		Use:   "export [RUNTIME_NAME]",
+		Short: "Export the definition of a runtime to a file, that can be installed with \"runtime import\"",
+		Args:  cobra.MaximumNArgs(1),
+		Example: util.Doc(`
+# Export a runtime to a file
+
+	<BIN> runtime export runtime-name --repo gitops_repo -o runtime.yaml
+`),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			ctx := cmd.Context()
+
+			opts.RuntimeName, err = ensureRuntimeName(ctx, args, false)
+			if err != nil {
+				return err
+			}
+
+			if err = ensureRepo(cmd, opts.RuntimeName, opts.CloneOpts, true); err != nil {
+				return err
+			}
+
+			if err = ensureGitToken(cmd, nil, opts.CloneOpts, 0); err != nil {
+				return err
+			}
+
+			opts.CloneOpts.Parse()
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return RunRuntimeExport(cmd.Context(), &opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.OutputFile, "output", "o", "", "The file the runtime is exported to (default: stdout)")
+
+	opts.CloneOpts = apu.AddCloneFlags(cmd, &apu.CloneFlagsOptions{})
+
+	return cmd
+}
+
+func RunRuntimeExport(ctx context.Context, opts *RuntimeExportOptions) error {
+	_, repofs, err := opts.CloneOpts.GetRepo(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the cluster of a runtime that is being recovered might be gone, so the export does not depend on the app-proxy
+	var integrations []apmodel.GitIntegration
+	appProxyClient, err := cfConfig.NewClient().AppProxy(ctx, opts.RuntimeName, store.Get().InsecureIngressHost)
+	if err == nil {
+		integrations, err = appProxyClient.GitIntegrations().List(ctx)
+	}
+
+	if err != nil {
+		log.G(ctx).WithError(err).Warn("Failed to get the git integrations of the runtime, exporting without them")
+	}
+
+	export, err := getRuntimeExport(ctx, repofs, opts.RuntimeName, integrations)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(export)
+	if err != nil {
+		return fmt.Errorf("failed to marshal runtime export: %w", err)
+	}
+
+	if opts.OutputFile == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	if err = ioutil.WriteFile(opts.OutputFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write runtime export to \"%s\": %w", opts.OutputFile, err)
+	}
+
+	log.G(ctx).Infof("Exported runtime \"%s\" to \"%s\"", opts.RuntimeName, opts.OutputFile)
+
+	return nil
+}
+
+func getRuntimeExport(ctx context.Context, repofs fs.FS, runtimeName string, integrations []apmodel.GitIntegration) (*runtimeExport, error) {
+	rt, err := getRuntimeDataFromCodefreshCM(ctx, repofs, runtimeName, &v1.ConfigMap{})
+	if err != nil {
+		return nil, err
+	}
+
+	export := &runtimeExport{Runtime: rt}
+	for _, intg := range integrations {
+		export.GitIntegrations = append(export.GitIntegrations, exportedGitIntegration{
+			Name:          intg.Name,
+			Provider:      intg.Provider,
+			APIURL:        intg.APIURL,
+			SharingPolicy: intg.SharingPolicy,
+		})
+	}
+
+	return export, nil
+}
+
+func readRuntimeExport(fileName string) (*runtimeExport, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read runtime export \"%s\": %w", fileName, err)
+	}
+
+	export := &runtimeExport{}
+	if err = yaml.Unmarshal(data, export); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal runtime export \"%s\": %w", fileName, err)
+	}
+
+	if export.Runtime == nil || export.Runtime.Name == "" {
+		return nil, fmt.Errorf("runtime export \"%s\" has no runtime definition", fileName)
+	}
+
+	return export, nil
+}
+
+func NewRuntimeImportCommand() *cobra.Command {
+	var (
+		fileName string
+		export   *runtimeExport
+	)
+
+	// the import is an installation, with the install flags it did not get set from the export
+	cmd := NewRuntimeInstallCommand()
+	installPreRunE, installRunE := cmd.PreRunE, cmd.RunE
+	cmd.Use = "import [runtime_name]"
+	cmd.Short = "Install a runtime from a file written by \"runtime export\""
+	cmd.Example = util.Doc(`
+# Install an exported runtime on the current kube context
+
+	<BIN> runtime import -f runtime.yaml
+
+# Install an exported runtime to another repo
+
+	<BIN> runtime import -f runtime.yaml --repo other_gitops_repo
+`)
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		var err error
+		export, err = readRuntimeExport(fileName)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			args = []string{export.Runtime.Name}
+		}
+
+		if err = applyRuntimeExport(cmd, export); err != nil {
+			return err
+		}
+
+		return installPreRunE(cmd, args)
+	}
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := installRunE(cmd, args); err != nil {
+			return err
+		}
+
+		if cmd.Flag("dry-run").Value.String() == "true" {
+			return nil
+		}
+
+		// the suffixed runtime name is only known to the installation
+		if cmd.Flag("name-suffix").Value.String() != "" {
+			log.G(cmd.Context()).Warnf("The runtime name was suffixed, add the git integrations of the export with \"%s integration git add\"", store.Get().BinaryName)
+			return nil
+		}
+
+		runtimeName := export.Runtime.Name
+		if len(args) > 0 {
+			runtimeName = args[0]
+		}
+
+		addExportedGitIntegrations(cmd.Context(), runtimeName, export)
+		return nil
+	}
+
+	cmd.Flags().StringVarP(&fileName, "file", "f", "", "The runtime export file")
+	util.Die(cobra.MarkFlagRequired(cmd.Flags(), "file"))
+
+	return cmd
+}
+
+// getRuntimeImportFlags returns the install flags that reconstruct the exported runtime
+func getRuntimeImportFlags(export *runtimeExport) map[string]string {
+	spec := export.Runtime.Spec
+	flags := map[string]string{
+		"repo":                  spec.Repo,
+		"ingress-host":          spec.IngressHost,
+		"ingress-class":         spec.IngressClass,
+		"internal-ingress-host": spec.InternalIngressHost,
+	}
+
+	if spec.Version != nil {
+		flags["version"] = spec.Version.String()
+	}
+
+	for _, intg := range export.GitIntegrations {
+		if intg.Name == defaultGitIntegrationName {
+			flags["provider-api-url"] = intg.APIURL
+		}
+	}
+
+	return flags
+}
+
+// applyRuntimeExport sets the install flags from the export, flags that were set explicitly take precedence
+func applyRuntimeExport(cmd *cobra.Command, export *runtimeExport) error {
+	for name, value := range getRuntimeImportFlags(export) {
+		if value == "" || cmd.Flags().Changed(name) {
+			continue
+		}
+
+		if err := cmd.Flags().Set(name, value); err != nil {
+			return fmt.Errorf("failed to set --%s from the runtime export: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// addExportedGitIntegrations adds the exported git integrations other than the default one, which the installation creates.
+// The runtime is already installed, so failures are only reported
+func addExportedGitIntegrations(ctx context.Context, runtimeName string, export *runtimeExport) {
+	var additional []exportedGitIntegration
+	for _, intg := range export.GitIntegrations {
+		if intg.Name != defaultGitIntegrationName {
+			additional = append(additional, intg)
+		}
+	}
+
+	if len(additional) == 0 {
+		return
+	}
+
+	appProxyClient, err := cfConfig.NewClient().AppProxy(ctx, runtimeName, store.Get().InsecureIngressHost)
+	if err != nil {
+		log.G(ctx).WithError(err).Warn("Failed to add the git integrations of the export")
+		return
+	}
+
+	for i := range additional {
+		intg := additional[i]
+		err = RunGitIntegrationAddCommand(ctx, appProxyClient, &apmodel.AddGitIntegrationArgs{
+			Name:          &intg.Name,
+			Provider:      intg.Provider,
+			APIURL:        &intg.APIURL,
+			SharingPolicy: intg.SharingPolicy,
+		})
+		if err != nil {
+			log.G(ctx).WithError(err).Warnf("Failed to add git integration \"%s\"", intg.Name)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/codefresh-io/cli-v2/pkg/runtime"
+
+	"github.com/Masterminds/semver/v3"
+	apfs "github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+	apstore "github.com/argoproj-labs/argocd-autopilot/pkg/store"
+	apmodel "github.com/codefresh-io/go-sdk/pkg/codefresh/model/app-proxy"
+	"github.com/ghodss/yaml"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_runtimeExportImport(t *testing.T) {
+	repofs := apfs.Create(memfs.New())
+	rt := &runtime.Runtime{
+		ObjectMeta: metav1.ObjectMeta{Name: "runtime", Namespace: "runtime"},
+		Spec: runtime.RuntimeSpec{
+			Version:             semver.MustParse("0.0.500"),
+			Repo:                "https://github.com/owner/repo",
+			IngressHost:         "https://ingress.host",
+			IngressClass:        "nginx",
+			InternalIngressHost: "https://internal.host",
+			IngressController:   "k8s.io/ingress-nginx",
+		},
+	}
+	assert.NoError(t, rt.Save(repofs, repofs.Join(apstore.Default.BootsrtrapDir, "runtime.yaml"), &runtime.CommonConfig{}))
+
+	export, err := getRuntimeExport(context.Background(), repofs, "runtime", []apmodel.GitIntegration{
+		{Name: "default", Provider: apmodel.GitProvidersGithub, APIURL: "https://api.github.com", SharingPolicy: apmodel.SharingPolicyAllUsersInAccount},
+		{Name: "admins", Provider: apmodel.GitProvidersGitlab, APIURL: "https://gitlab.com/api/v4", SharingPolicy: apmodel.SharingPolicyAccountAdmins},
+	})
+	assert.NoError(t, err)
+
+	data, err := yaml.Marshal(export)
+	assert.NoError(t, err)
+	fileName := filepath.Join(t.TempDir(), "runtime.yaml")
+	assert.NoError(t, ioutil.WriteFile(fileName, data, 0644))
+
+	imported, err := readRuntimeExport(fileName)
+	assert.NoError(t, err)
+	assert.Equal(t, export.GitIntegrations, imported.GitIntegrations)
+
+	cmd := NewRuntimeImportCommand()
+	assert.NoError(t, cmd.Flags().Parse([]string{"--ingress-class", "traefik"}))
+	assert.NoError(t, applyRuntimeExport(cmd, imported))
+
+	for flag, want := range map[string]string{
+		"repo":                  "https://github.com/owner/repo",
+		"version":               "0.0.500",
+		"ingress-host":          "https://ingress.host",
+		"ingress-class":         "traefik",
+		"internal-ingress-host": "https://internal.host",
+		"provider-api-url":      "https://api.github.com",
+	} {
+		assert.Equal(t, want, cmd.Flag(flag).Value.String(), "--%s", flag)
+	}
+}
+
+func Test_readRuntimeExport_noRuntime(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "runtime.yaml")
+	assert.NoError(t, ioutil.WriteFile(fileName, []byte("gitIntegrations: []\n"), 0644))
+
+	_, err := readRuntimeExport(fileName)
+	assert.EqualError(t, err, "runtime export \""+fileName+"\" has no runtime definition")
+}
@@ -0,0 +1,338 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/codefresh-io/cli-v2/pkg/log"
+	"github.com/codefresh-io/cli-v2/pkg/runtime"
+	"github.com/codefresh-io/cli-v2/pkg/store"
+	"github.com/codefresh-io/cli-v2/pkg/util"
+	apu "github.com/codefresh-io/cli-v2/pkg/util/aputil"
+	ingressutil "github.com/codefresh-io/cli-v2/pkg/util/ingress"
+
+	"github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+	apgit "github.com/argoproj-labs/argocd-autopilot/pkg/git"
+	"github.com/argoproj-labs/argocd-autopilot/pkg/kube"
+	apstore "github.com/argoproj-labs/argocd-autopilot/pkg/store"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type (
+	RuntimeIngressSyncOptions struct {
+		RuntimeName string
+		CloneOpts   *apgit.CloneOptions
+		KubeFactory kube.Factory
+		Reconcile   bool
+	}
+
+	// ingressDrift is a place where the ingress host differs from the one in the platform record
+	ingressDrift struct {
+		Source   string
+		Expected string
+		Actual   string
+	}
+)
+
+func NewRuntimeIngressCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ingress",
+		Short: "Manage the ingress configuration of a runtime",
+		Args:  cobra.NoArgs, // Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+			exit(1)
+		},
+	}
+
+	cmd.AddCommand(NewRuntimeIngressSyncCommand())
+
+	return cmd
+}
+
+func NewRuntimeIngressSyncCommand() *cobra.Command {
+	var opts RuntimeIngressSyncOptions
+
+	cmd := &cobra.Command{
+		Use:   "sync [RUNTIME_NAME]",
+		Short: "Report the drift between the ingress host in the platform, the runtime repo and the cluster ingresses",
+		Args:  cobra.MaximumNArgs(1),
+		Example: util.Doc(`
+# Report the ingress drift of a runtime
+
+	<BIN> runtime ingress sync runtime-name --repo gitops_repo
+
+# Update the repo to the ingress host of the platform
+
+	<BIN> runtime ingress sync runtime-name --repo gitops_repo --reconcile
+`),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			ctx := cmd.Context()
+
+			opts.RuntimeName, err = ensureRuntimeName(ctx, args, false)
+			if err != nil {
+				return err
+			}
+
+			if err = ensureRepo(cmd, opts.RuntimeName, opts.CloneOpts, true); err != nil {
+				return err
+			}
+
+			if err = ensureGitToken(cmd, nil, opts.CloneOpts, 0); err != nil {
+				return err
+			}
+
+			opts.CloneOpts.Parse()
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return RunRuntimeIngressSync(cmd.Context(), &opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Reconcile, "reconcile", false, "If true, will update the runtime spec and the ingresses in the repo to the ingress host of the platform")
+
+	opts.CloneOpts = apu.AddCloneFlags(cmd, &apu.CloneFlagsOptions{CloneForWrite: true})
+	opts.KubeFactory = kube.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// RunRuntimeIngressSync compares the ingress host of the platform record with the runtime spec in the repo and with the
+// live ingresses. The platform record is the source of truth, so reconciling updates the repo, and argo-cd the ingresses
+func RunRuntimeIngressSync(ctx context.Context, opts *RuntimeIngressSyncOptions) error {
+	platformRt, err := cfConfig.NewClient().V2().Runtime().Get(ctx, opts.RuntimeName)
+	if err != nil {
+		return fmt.Errorf("failed to get runtime from platform: %w", err)
+	}
+
+	if platformRt.IngressHost == nil || *platformRt.IngressHost == "" {
+		return fmt.Errorf("runtime \"%s\" has no ingress host in the platform", opts.RuntimeName)
+	}
+
+	_, repofs, err := opts.CloneOpts.GetRepo(ctx)
+	if err != nil {
+		return err
+	}
+
+	rt, err := getRuntimeDataFromCodefreshCM(ctx, repofs, opts.RuntimeName, &v1.ConfigMap{})
+	if err != nil {
+		return err
+	}
+
+	cs, err := opts.KubeFactory.KubernetesClientSet()
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	liveIngresses, err := getRuntimeIngresses(ctx, cs, rt)
+	if err != nil {
+		return err
+	}
+
+	repoIngresses := map[string]*netv1.Ingress{}
+	if ingress := readRepoIngress(repofs, store.Get().WorkflowsIngressPath, rt.Name); ingress != nil {
+		repoIngresses[rt.Name+store.Get().WorkflowsIngressName] = ingress
+	}
+
+	if ingress := readRepoIngress(repofs, "app-proxy", rt.Name); ingress != nil {
+		repoIngresses[rt.Name+store.Get().AppProxyIngressName] = ingress
+	}
+
+	drifts, err := detectIngressDrift(*platformRt.IngressHost, rt, repoIngresses, liveIngresses)
+	if err != nil {
+		return err
+	}
+
+	if len(drifts) == 0 {
+		log.G(ctx).Infof("The ingress host of runtime \"%s\" is in sync: %s", opts.RuntimeName, *platformRt.IngressHost)
+		return nil
+	}
+
+	for _, drift := range drifts {
+		log.G(ctx).Warnf("Ingress drift in %s: expected \"%s\", found \"%s\"", drift.Source, drift.Expected, drift.Actual)
+	}
+
+	if !opts.Reconcile {
+		log.G(ctx).Info("Run with --reconcile to update the repo to the ingress host of the platform")
+		return nil
+	}
+
+	return reconcileIngress(ctx, opts, repofs, rt, *platformRt.IngressHost)
+}
+
+// getRuntimeIngresses returns the live app-proxy and workflows ingresses of the runtime, by name. Missing ones are skipped
+func getRuntimeIngresses(ctx context.Context, cs kubernetes.Interface, rt *runtime.Runtime) (map[string]*netv1.Ingress, error) {
+	ingresses := map[string]*netv1.Ingress{}
+	for _, name := range []string{rt.Name + store.Get().AppProxyIngressName, rt.Name + store.Get().WorkflowsIngressName} {
+		ingress, err := cs.NetworkingV1().Ingresses(rt.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+
+			return nil, fmt.Errorf("failed to get ingress \"%s\": %w", name, err)
+		}
+
+		ingresses[name] = ingress
+	}
+
+	return ingresses, nil
+}
+
+// detectIngressDrift compares the runtime spec with the platform ingress host, and every host of the live ingresses with
+// the platform ingress host followed by the additional hosts of the ingresses in the repo
+func detectIngressDrift(platformIngressHost string, rt *runtime.Runtime, repoIngresses, liveIngresses map[string]*netv1.Ingress) ([]ingressDrift, error) {
+	var drifts []ingressDrift
+	if rt.Spec.IngressHost != platformIngressHost {
+		drifts = append(drifts, ingressDrift{
+			Source:   fmt.Sprintf("the runtime spec in %s/%s.yaml", apstore.Default.BootsrtrapDir, rt.Name),
+			Expected: platformIngressHost,
+			Actual:   rt.Spec.IngressHost,
+		})
+	}
+
+	var hostName string
+	if err := parseHostName(platformIngressHost, &hostName); err != nil {
+		return nil, fmt.Errorf("failed to parse the platform ingress host: %w", err)
+	}
+
+	// the app-proxy ingress uses the internal host, when there is one
+	appProxyHostName := hostName
+	if rt.Spec.InternalIngressHost != "" {
+		if err := parseHostName(rt.Spec.InternalIngressHost, &appProxyHostName); err != nil {
+			return nil, fmt.Errorf("failed to parse the internal ingress host: %w", err)
+		}
+	}
+
+	expectedHosts := map[string]string{
+		rt.Name + store.Get().AppProxyIngressName:  appProxyHostName,
+		rt.Name + store.Get().WorkflowsIngressName: hostName,
+	}
+	for _, name := range []string{rt.Name + store.Get().AppProxyIngressName, rt.Name + store.Get().WorkflowsIngressName} {
+		ingress, ok := liveIngresses[name]
+		if !ok || len(ingress.Spec.Rules) == 0 {
+			continue
+		}
+
+		expected := []string{expectedHosts[name]}
+		if repoIngress, ok := repoIngresses[name]; ok {
+			expected = append(expected, ingressHosts(repoIngress)[1:]...)
+		}
+
+		if actual := ingressHosts(ingress); strings.Join(actual, ",") != strings.Join(expected, ",") {
+			drifts = append(drifts, ingressDrift{
+				Source:   fmt.Sprintf("the cluster ingress \"%s\"", name),
+				Expected: strings.Join(expected, ", "),
+				Actual:   strings.Join(actual, ", "),
+			})
+		}
+	}
+
+	return drifts, nil
+}
+
+// ingressHosts returns the hosts of the ingress rules, in order
+func ingressHosts(ingress *netv1.Ingress) []string {
+	hosts := make([]string, 0, len(ingress.Spec.Rules))
+	for _, rule := range ingress.Spec.Rules {
+		hosts = append(hosts, rule.Host)
+	}
+
+	return hosts
+}
+
+// reconcileIngress rewrites the runtime spec and the ingresses in the repo with the platform ingress host,
+// keeping the annotations and the additional hosts of the ingresses in the repo
+func reconcileIngress(ctx context.Context, opts *RuntimeIngressSyncOptions, repofs fs.FS, rt *runtime.Runtime, ingressHost string) error {
+	installOpts := &RuntimeInstallOptions{
+		RuntimeName:         opts.RuntimeName,
+		InsCloneOpts:        opts.CloneOpts,
+		IngressHost:         ingressHost,
+		IngressClass:        rt.Spec.IngressClass,
+		IngressController:   ingressutil.GetController(rt.Spec.IngressController),
+		InternalIngressHost: rt.Spec.InternalIngressHost,
+	}
+
+	if err := parseHostName(ingressHost, &installOpts.HostName); err != nil {
+		return err
+	}
+
+	if installOpts.InternalIngressHost != "" {
+		if err := parseHostName(installOpts.InternalIngressHost, &installOpts.InternalHostName); err != nil {
+			return err
+		}
+	}
+
+	workflowsIngress := readRepoIngress(repofs, store.Get().WorkflowsIngressPath, rt.Name)
+	if workflowsIngress != nil {
+		installOpts.ExternalIngressAnnotation = workflowsIngress.Annotations
+		// keeps the tls of an ingress that was installed with --cert-manager-issuer
+		if issuer := workflowsIngress.Annotations[ingressutil.CertManagerClusterIssuerAnnotation]; issuer != "" {
+			installOpts.CertManagerIssuer = issuer
+		}
+		for _, rule := range workflowsIngress.Spec.Rules[1:] {
+			installOpts.AdditionalHostNames = append(installOpts.AdditionalHostNames, rule.Host)
+		}
+
+		if err := createWorkflowsIngress(ctx, installOpts, rt); err != nil {
+			return fmt.Errorf("failed to update Argo-Workflows ingress: %w", err)
+		}
+	}
+
+	// a runtime installed with --skip-ingress has no app-proxy ingress to update
+	if appProxyIngress := readRepoIngress(repofs, "app-proxy", rt.Name); appProxyIngress != nil {
+		installOpts.InternalIngressAnnotation = appProxyIngress.Annotations
+		if issuer := appProxyIngress.Annotations[ingressutil.CertManagerClusterIssuerAnnotation]; issuer != "" {
+			installOpts.CertManagerIssuer = issuer
+		}
+		if installOpts.InternalHostName != "" && len(appProxyIngress.Spec.Rules) > 1 {
+			for _, rule := range appProxyIngress.Spec.Rules[1:] {
+				installOpts.AdditionalInternalHostNames = append(installOpts.AdditionalInternalHostNames, rule.Host)
+			}
+		}
+
+		if err := configureAppProxy(ctx, installOpts, rt); err != nil {
+			return fmt.Errorf("failed to update App-Proxy ingress: %w", err)
+		}
+	}
+
+	if err := updateCodefreshCM(ctx, installOpts, rt, rt.Spec.Cluster); err != nil {
+		return err
+	}
+
+	log.G(ctx).Infof("Updated the ingress host of runtime \"%s\" in the repo to %s", opts.RuntimeName, ingressHost)
+	return nil
+}
+
+// readRepoIngress returns the ingress in the overlay of the app in the repo, or nil if there is none
+func readRepoIngress(repofs fs.FS, appName, runtimeName string) *netv1.Ingress {
+	ingress := &netv1.Ingress{}
+	path := repofs.Join(apstore.Default.AppsDir, appName, apstore.Default.OverlaysDir, runtimeName, "ingress.yaml")
+	if err := repofs.ReadYamls(path, ingress); err != nil || len(ingress.Spec.Rules) == 0 {
+		return nil
+	}
+
+	return ingress
+}
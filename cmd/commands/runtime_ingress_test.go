@@ -0,0 +1,130 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/codefresh-io/cli-v2/pkg/runtime"
+
+	"github.com/stretchr/testify/assert"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kusttypes "sigs.k8s.io/kustomize/api/types"
+)
+
+func Test_detectIngressDrift(t *testing.T) {
+	newIngress := func(hosts ...string) *netv1.Ingress {
+		ingress := &netv1.Ingress{}
+		for _, host := range hosts {
+			ingress.Spec.Rules = append(ingress.Spec.Rules, netv1.IngressRule{Host: host})
+		}
+
+		return ingress
+	}
+
+	tests := map[string]struct {
+		specHost      string
+		repoIngresses map[string]*netv1.Ingress
+		liveIngresses map[string]*netv1.Ingress
+		wantSources   []string
+	}{
+		"should report nothing when the repo and the ingresses match the platform": {
+			specHost: "https://runtime.example.com",
+			liveIngresses: map[string]*netv1.Ingress{
+				"runtime-cap-app-proxy":     newIngress("runtime.example.com"),
+				"runtime-workflows-ingress": newIngress("runtime.example.com"),
+			},
+		},
+		"should report a host mismatch in the repo and in the ingresses": {
+			specHost: "https://old.example.com",
+			liveIngresses: map[string]*netv1.Ingress{
+				"runtime-cap-app-proxy":     newIngress("old.example.com"),
+				"runtime-workflows-ingress": newIngress("runtime.example.com"),
+			},
+			wantSources: []string{
+				"the runtime spec in bootstrap/runtime.yaml",
+				"the cluster ingress \"runtime-cap-app-proxy\"",
+			},
+		},
+		"should report nothing when the additional hosts match the repo": {
+			specHost: "https://runtime.example.com",
+			repoIngresses: map[string]*netv1.Ingress{
+				"runtime-workflows-ingress": newIngress("old.example.com", "extra.example.com"),
+			},
+			liveIngresses: map[string]*netv1.Ingress{
+				"runtime-cap-app-proxy":     newIngress("runtime.example.com"),
+				"runtime-workflows-ingress": newIngress("runtime.example.com", "extra.example.com"),
+			},
+		},
+		"should report a mismatch in the additional hosts": {
+			specHost: "https://runtime.example.com",
+			repoIngresses: map[string]*netv1.Ingress{
+				"runtime-workflows-ingress": newIngress("runtime.example.com", "extra.example.com"),
+			},
+			liveIngresses: map[string]*netv1.Ingress{
+				"runtime-cap-app-proxy":     newIngress("runtime.example.com", "stale.example.com"),
+				"runtime-workflows-ingress": newIngress("runtime.example.com"),
+			},
+			wantSources: []string{
+				"the cluster ingress \"runtime-cap-app-proxy\"",
+				"the cluster ingress \"runtime-workflows-ingress\"",
+			},
+		},
+		"should skip missing ingresses": {
+			specHost:      "https://runtime.example.com",
+			liveIngresses: map[string]*netv1.Ingress{},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			rt := &runtime.Runtime{
+				ObjectMeta: metav1.ObjectMeta{Name: "runtime", Namespace: "runtime"},
+				Spec:       runtime.RuntimeSpec{IngressHost: tt.specHost},
+			}
+
+			drifts, err := detectIngressDrift("https://runtime.example.com", rt, tt.repoIngresses, tt.liveIngresses)
+			assert.NoError(t, err)
+
+			var sources []string
+			for _, drift := range drifts {
+				sources = append(sources, drift.Source)
+				assert.Contains(t, drift.Expected, "runtime.example.com")
+			}
+
+			assert.Equal(t, tt.wantSources, sources)
+		})
+	}
+}
+
+func Test_setConfigMapGenerator(t *testing.T) {
+	newGenerator := func(name string, literals ...string) kusttypes.ConfigMapArgs {
+		return kusttypes.ConfigMapArgs{
+			GeneratorArgs: kusttypes.GeneratorArgs{
+				Name:          name,
+				KvPairSources: kusttypes.KvPairSources{LiteralSources: literals},
+			},
+		}
+	}
+
+	generators := []kusttypes.ConfigMapArgs{newGenerator("other-cm", "a=b")}
+	generators = setConfigMapGenerator(generators, newGenerator("app-proxy-cm", "cfHost=https://g.codefresh.io"))
+	generators = setConfigMapGenerator(generators, newGenerator("app-proxy-cm", "cfHost=https://other.codefresh.io"))
+
+	assert.Equal(t, []kusttypes.ConfigMapArgs{
+		newGenerator("other-cm", "a=b"),
+		newGenerator("app-proxy-cm", "cfHost=https://other.codefresh.io"),
+	}, generators)
+}
@@ -24,6 +24,10 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -51,6 +55,7 @@ import (
 	"github.com/argoproj-labs/argocd-autopilot/pkg/kube"
 	apstore "github.com/argoproj-labs/argocd-autopilot/pkg/store"
 	aputil "github.com/argoproj-labs/argocd-autopilot/pkg/util"
+	argoglob "github.com/argoproj/argo-cd/v2/util/glob"
 	aev1alpha1 "github.com/argoproj/argo-events/pkg/apis/eventsource/v1alpha1"
 	"github.com/codefresh-io/go-sdk/pkg/codefresh"
 	"github.com/codefresh-io/go-sdk/pkg/codefresh/model"
@@ -58,18 +63,20 @@ import (
 	"github.com/ghodss/yaml"
 	"github.com/go-git/go-billy/v5/memfs"
 	billyUtils "github.com/go-git/go-billy/v5/util"
-	"github.com/juju/ansiterm"
 	"github.com/manifoldco/promptui"
 	"github.com/rkrmr33/checklist"
 	"github.com/spf13/cobra"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 	appsv1 "k8s.io/api/apps/v1"
+	authv1 "k8s.io/api/authorization/v1"
 	v1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
 	kusttypes "sigs.k8s.io/kustomize/api/types"
 	kustid "sigs.k8s.io/kustomize/kyaml/resid"
 )
@@ -81,6 +88,8 @@ type (
 		RuntimeStoreIV                 string
 		HostName                       string
 		InternalHostName               string
+		AdditionalIngressHosts         []string
+		AdditionalHostNames            []string
 		IngressHost                    string
 		IngressClass                   string
 		InternalIngressHost            string
@@ -102,12 +111,96 @@ type (
 		SuggestedSharedConfigRepo      string
 		InternalIngressAnnotation      map[string]string
 		ExternalIngressAnnotation      map[string]string
+		MasterIngressAnnotation        map[string]string
 		EnableGitProviders             bool
-
-		versionStr  string
-		kubeContext string
-		kubeconfig  string
-		gitProvider cfgit.Provider
+		Resume                         bool
+		EventsOutput                   string
+		GitUsername                    string
+		GitPassword                    string
+		TimeoutReport                  bool
+		ResourceLabels                 map[string]string
+		ResourceAnnotations            map[string]string
+		ForceUnlock                    bool
+		ExportHelmValues               string
+		Impersonate                    string
+		ImpersonateGroups              []string
+		SupportBundle                  string
+		GitSigningKey                  string
+		GitSigningKeyPassphrase        string
+		ComponentVersions              map[string]string
+		GitProviderRateLimit           float64
+		GitProviderMaxRetries          int
+		ArgoEventsClusterWide          bool
+		ArgoEventsEventBus             string
+		ArgoEventsControllerLabels     map[string]string
+		RepoTemplate                   string
+		RepoVisibility                 string
+		Openshift                      string
+		NotifyWebhook                  string
+		ChecklistColumns               []string
+		ServerSideApply                bool
+		OverrideSharedConfigRepo       bool
+		NamespaceFinalizers            []string
+		RuntimeDef                     string
+		DryRun                         bool
+		DryRunOutput                   string
+		OtelEndpoint                   string
+		FailFastOnDegraded             bool
+		GitIntegrationTimeout          time.Duration
+		DemoResourcesRepo              string
+		DemoResourcesPath              string
+		SkipComponents                 []string
+		InternalIngressInsecure        bool
+		KustomizeBuildOptions          kustutil.BuildOptions
+		ComponentResources             []string
+		AppProxyServiceName            string
+		AppProxyServicePort            int32
+		ConfirmRollback                bool
+		ClusterServer                  string
+		SSHKey                         string
+		SSHKeyPassphrase               string
+		MinTokenTTL                    time.Duration
+		NameSuffix                     string
+		AdditionalInternalIngressHosts []string
+		AdditionalInternalHostNames    []string
+		ImageRegistry                  string
+		SensorRetryCount               int32
+		SensorRetryInterval            time.Duration
+		GitTokenFile                   string
+		PersonalGitTokenFile           string
+		PrintResourceGraph             string
+		UseExistingNamespace           bool
+		NodeSelector                   map[string]string
+		Tolerations                    []string
+		Affinity                       string
+		BootstrapSpecifier             string
+		SmokeTest                      string
+		SummaryFile                    string
+		CommitMessagePrefix            string
+		CommitAuthor                   string
+		UseExistingProject             bool
+		SSHKnownHosts                  string
+		SSHInsecureIgnoreHostKey       bool
+		CertManagerIssuer              string
+		SummaryLevel                   string
+
+		versionStr              string
+		kubeContext             string
+		kubeconfig              string
+		impersonatingKubeconfig string
+		gitProvider             cfgit.Provider
+		runtimeDef              *runtime.Runtime
+		componentVersions       map[string]*semver.Version
+		componentResources      map[string]v1.ResourceRequirements
+		podPlacement            eventsutil.PodPlacement
+		reporters               []reporterCreateOptions
+		postInstallationHandled bool
+		installStart            time.Time
+		checklistColumns        []int
+		dryRunFS                fs.FS
+		sshPrivateKey           string
+		commitAuthor            *apu.CommitAuthor
+		installOutcome          *summaryLog
 	}
 )
 
@@ -127,6 +220,8 @@ func NewRuntimeInstallCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "install [runtime_name]",
 		Short: "Install a new Codefresh runtime",
+		// a flag value without "=" (e.g. "--print-resource-graph json") would be taken as another positional arg
+		Args: cobra.MaximumNArgs(1),
 		Example: util.Doc(`
 # To run this command you need to create a personal access token for your git provider
 # and provide it using:
@@ -146,7 +241,30 @@ func NewRuntimeInstallCommand() *cobra.Command {
 				installationOpts.RuntimeName = args[0]
 			}
 
-			createAnalyticsReporter(cmd.Context(), reporter.InstallFlow, installationOpts.DisableTelemetry)
+			if installationOpts.SupportBundle != "" {
+				captureSupportBundleLogs()
+			}
+
+			createAnalyticsReporter(cmd.Context(), reporter.InstallFlow, isTelemetryDisabled(cmd, installationOpts.DisableTelemetry))
+			if installationOpts.EventsOutput != "" {
+				if err := reporter.AddEventsOutput(installationOpts.EventsOutput); err != nil {
+					return err
+				}
+			}
+
+			if installationOpts.OtelEndpoint != "" {
+				if err := reporter.AddTracesOutput(installationOpts.OtelEndpoint, reporter.InstallFlow); err != nil {
+					return err
+				}
+			}
+
+			preRunFinished := false
+			defer func() {
+				// RunE will not be called to remove the impersonating kubeconfig
+				if !preRunFinished {
+					removeImpersonatingKubeconfig(installationOpts)
+				}
+			}()
 
 			err := runtimeInstallCommandPreRunHandler(cmd, installationOpts)
 			handleCliStep(reporter.InstallPhasePreCheckFinish, "Finished pre installation checks", err, true, false)
@@ -172,31 +290,52 @@ func NewRuntimeInstallCommand() *cobra.Command {
 				finalParameters["Internal ingress host"] = installationOpts.InternalIngressHost
 			}
 
+			if len(installationOpts.AdditionalIngressHosts) > 0 {
+				finalParameters["Additional ingress hosts"] = strings.Join(installationOpts.AdditionalIngressHosts, ", ")
+			}
+
+			if len(installationOpts.AdditionalInternalIngressHosts) > 0 {
+				finalParameters["Additional internal ingress hosts"] = strings.Join(installationOpts.AdditionalInternalIngressHosts, ", ")
+			}
+
 			if err := getApprovalFromUser(cmd.Context(), finalParameters, "runtime install"); err != nil {
 				return err
 			}
 
+			if installationOpts.ExportHelmValues != "" {
+				if err := exportHelmValues(installationOpts.ExportHelmValues, installationOpts); err != nil {
+					return err
+				}
+
+				log.G(cmd.Context()).Infof("Exported helm values to \"%s\"", installationOpts.ExportHelmValues)
+			}
+
+			preRunFinished = true
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, _ []string) error {
+			defer removeImpersonatingKubeconfig(installationOpts)
+
 			err := runRuntimeInstall(cmd.Context(), installationOpts)
 			handleCliStep(reporter.InstallPhaseFinish, "Runtime installation phase finished", err, false, false)
+			writeEarlySupportBundle(cmd.Context(), installationOpts, err)
 			return err
 		},
 	}
 
-	cmd.Flags().StringVar(&installationOpts.IngressHost, "ingress-host", "", "The ingress host")
-	cmd.Flags().StringVar(&installationOpts.IngressClass, "ingress-class", "", "The ingress class name")
-	cmd.Flags().StringVar(&installationOpts.InternalIngressHost, "internal-ingress-host", "", "The internal ingress host (by default the external ingress will be used for both internal and external traffic)")
+	cmd.Flags().StringVar(&installationOpts.IngressHost, "ingress-host", "", "The ingress host. A comma separated list adds ingress rules for every host, the first one is the primary host")
+	cmd.Flags().StringVar(&installationOpts.IngressClass, "ingress-class", "", "The ingress class name. Can reference environment variables (e.g. \"nginx-${ENV}\") or be a pattern that matches a single cluster ingress class (e.g. \"nginx-*\")")
+	cmd.Flags().StringVar(&installationOpts.InternalIngressHost, "internal-ingress-host", "", "The internal ingress host. A comma separated list adds app-proxy ingress rules for every host, the first one is the primary host (by default the external ingress will be used for both internal and external traffic)")
 	cmd.Flags().StringVar(&installationOpts.GitIntegrationRegistrationOpts.Token, "personal-git-token", "", "The Personal git token for your user")
 	cmd.Flags().StringVar(&installationOpts.versionStr, "version", "", "The runtime version to install (default: latest)")
 	cmd.Flags().StringVar(&installationOpts.SuggestedSharedConfigRepo, "shared-config-repo", "", "URL to the shared configurations repo. (default: <installation-repo> or the existing one for this account)")
 	cmd.Flags().BoolVar(&installationOpts.InstallDemoResources, "demo-resources", true, "Installs demo resources (default: true)")
 	cmd.Flags().BoolVar(&installationOpts.SkipClusterChecks, "skip-cluster-checks", false, "Skips the cluster's checks")
 	cmd.Flags().BoolVar(&installationOpts.DisableRollback, "disable-rollback", false, "If true, will not perform installation rollback after a failed installation")
-	cmd.Flags().DurationVar(&store.Get().WaitTimeout, "wait-timeout", store.Get().WaitTimeout, "How long to wait for the runtime components to be ready")
+	cmd.Flags().Var(newLenientDuration(&store.Get().WaitTimeout, "wait-timeout"), "wait-timeout", "How long to wait for the runtime components to be ready (a number without a unit is deprecated, and read as minutes)")
 	cmd.Flags().StringVar(&gitIntegrationApiURL, "provider-api-url", "", "Git provider API url")
 	cmd.Flags().BoolVar(&store.Get().SkipIngress, "skip-ingress", false, "Skips the creation of ingress resources")
+	cmd.Flags().StringVar(&store.Get().IngressHealthPath, "ingress-health-path", "", "A path on the ingress host that is used to check the host reachability and certificate (default: the root of the host)")
 	cmd.Flags().BoolVar(&store.Get().BypassIngressClassCheck, "bypass-ingress-class-check", false, "Disables the ingress class check during pre-installation")
 	cmd.Flags().BoolVar(&installationOpts.DisableTelemetry, "disable-telemetry", false, "If true, will disable the analytics reporting for the installation process")
 	cmd.Flags().BoolVar(&store.Get().SetDefaultResources, "set-default-resources", false, "If true, will set default requests and limits on all of the runtime components")
@@ -205,6 +344,80 @@ func NewRuntimeInstallCommand() *cobra.Command {
 	cmd.Flags().StringToStringVar(&installationOpts.InternalIngressAnnotation, "internal-ingress-annotation", nil, "Add annotations to the internal ingress")
 	cmd.Flags().StringToStringVar(&installationOpts.ExternalIngressAnnotation, "external-ingress-annotation", nil, "Add annotations to the external ingress")
 	cmd.Flags().BoolVar(&installationOpts.EnableGitProviders, "enable-git-providers", false, "Enable git providers (bitbucket-server|gitlab)")
+	cmd.Flags().BoolVar(&installationOpts.Resume, "resume", false, "If true, will resume a previously interrupted installation of the same runtime from its last persisted phase")
+	cmd.Flags().StringVar(&installationOpts.EventsOutput, "events-output", "", "Stream the installation step events as NDJSON to stderr, a file path, a file descriptor (fd://3) or a unix socket (unix:///path/to/socket)")
+	cmd.Flags().StringVar(&installationOpts.GitUsername, "git-username", "", "The username for git servers that use basic auth instead of tokens (requires --git-password)")
+	cmd.Flags().StringVar(&installationOpts.GitPassword, "git-password", "", "The password for git servers that use basic auth instead of tokens (requires --git-username)")
+	cmd.Flags().BoolVar(&installationOpts.TimeoutReport, "timeout-report", true, "If true, will list the runtime components that are not ready in the summary, when waiting for the runtime sync times out")
+	cmd.Flags().StringToStringVar(&installationOpts.ResourceLabels, "resource-labels", nil, "Optional labels that will be set on the resources the cli generates: the codefresh-cm, the runtime token secrets, the ingresses and the reporters RBAC. Existing codefresh labels are kept (e.g. \"key1=value1,key2=value2\")")
+	cmd.Flags().StringToStringVar(&installationOpts.ResourceAnnotations, "resource-annotations", nil, "Optional annotations that will be set on the resources the cli generates: the codefresh-cm, the runtime token secrets, the ingresses and the reporters RBAC. Existing codefresh annotations are kept")
+	cmd.Flags().BoolVar(&installationOpts.ForceUnlock, "force-unlock", false, "If true, will override the installation repo lock left by another installation")
+	cmd.Flags().StringVar(&installationOpts.ExportHelmValues, "export-helm-values", "", "Write the resolved ingress, repo and git provider settings to this file, as helm values")
+	cmd.Flags().StringVar(&installationOpts.Impersonate, "as", "", "Username to impersonate for all of the cluster operations")
+	cmd.Flags().StringArrayVar(&installationOpts.ImpersonateGroups, "as-group", nil, "Group to impersonate for all of the cluster operations, can be repeated to specify multiple groups (requires --as)")
+	cmd.Flags().StringVar(&installationOpts.SupportBundle, "support-bundle", "", "If the installation fails, write a tar.gz with the cli logs, the generated manifests, the cluster state and the runtime definition to this path (secrets are redacted)")
+	cmd.Flags().StringVar(&installationOpts.GitSigningKey, "git-signing-key", "", "Path to an armored gpg private key or an ssh private key, that will be used to sign all of the commits made by the installation")
+	cmd.Flags().StringVar(&installationOpts.GitSigningKeyPassphrase, "git-signing-key-passphrase", "", "The passphrase of the --git-signing-key, if it is encrypted")
+	cmd.Flags().StringToStringVar(&installationOpts.ComponentVersions, "component-version", nil, "Pin a runtime component to a version other than the runtime version, can be repeated (e.g. \"events=0.0.101\")")
+	cmd.Flags().Float64Var(&installationOpts.GitProviderRateLimit, "git-provider-rate-limit", 0, "Maximum number of git provider api calls per second (default: no limit)")
+	cmd.Flags().IntVar(&installationOpts.GitProviderMaxRetries, "git-provider-max-retries", 3, "How many times to retry a git provider api call that was rate limited, before failing")
+	cmd.Flags().StringVar(&store.Get().MasterIngressName, "master-ingress-name-suffix", store.Get().MasterIngressName, "The suffix of the master ingress name, that is added to the runtime name")
+	cmd.Flags().StringToStringVar(&installationOpts.MasterIngressAnnotation, "master-ingress-annotation", nil, "Add annotations to the master ingress, on top of the external ingress annotations")
+	cmd.Flags().BoolVar(&installationOpts.ArgoEventsClusterWide, "argo-events-cluster-wide", false, "If true, the reporters event sources and sensors will reference an existing cluster-wide argo-events controller, instead of being namespaced to the runtime")
+	cmd.Flags().StringVar(&installationOpts.ArgoEventsEventBus, "argo-events-eventbus", "", "The event bus of the cluster-wide argo-events controller (requires --argo-events-cluster-wide) (default: "+store.Get().EventBusName+")")
+	cmd.Flags().StringToStringVar(&installationOpts.ArgoEventsControllerLabels, "argo-events-controller-labels", nil, "Labels the cluster-wide argo-events controller selects its event sources and sensors by, e.g. its instance id (requires --argo-events-cluster-wide)")
+	cmd.Flags().StringVar(&installationOpts.RepoTemplate, "repo-template", "", "When the installation repo does not exist, create it from this template repo (\"owner/repo\"), for git providers that support templates (github)")
+	cmd.Flags().StringVar(&installationOpts.RepoVisibility, "repo-visibility", "private", "The visibility of the installation repo when it is created from --repo-template: private or public")
+	cmd.Flags().StringVar(&installationOpts.Openshift, "openshift", string(oc.ModeAuto), "Whether to prepare the cluster for openshift: auto (detect the openshift routes api), true or false")
+	cmd.Flags().StringVar(&installationOpts.NotifyWebhook, "notify-webhook", "", "POST a json notification (runtime, status, duration, failed components) to this url, when the runtime sync completes or times out")
+	cmd.Flags().StringSliceVar(&installationOpts.ChecklistColumns, "checklist-columns", defaultComponentColumns, "The columns of the components checklist printed while waiting for the runtime sync (component|health|sync|version|errors|namespace)")
+	cmd.Flags().BoolVar(&installationOpts.ServerSideApply, "server-side-apply", false, "If true, the resources applied directly to the cluster (e.g. the runtime secrets) will use server-side apply, instead of overwriting fields managed by other controllers")
+	cmd.Flags().BoolVar(&installationOpts.OverrideSharedConfigRepo, "override-shared-config-repo", false, "If true, will replace the account's existing shared config repo with --shared-config-repo, without asking for approval")
+	cmd.Flags().StringSliceVar(&installationOpts.NamespaceFinalizers, "namespace-finalizers", nil, "Optional finalizers that will be set on the runtime namespace, they are removed on uninstall (e.g. \"example.com/cleanup\")")
+	cmd.Flags().StringVar(&installationOpts.RuntimeDef, "runtime-def", "", "Install from a local runtime definition file, instead of downloading it (for air-gapped environments)")
+	cmd.Flags().BoolVar(&installationOpts.DryRun, "dry-run", false, "If true, will only render the runtime manifests, without creating the runtime on the platform, pushing to the repo or applying to the cluster")
+	cmd.Flags().StringVar(&installationOpts.DryRunOutput, "dry-run-output", "", "The directory the --dry-run manifests are written to (default: stdout)")
+	cmd.Flags().StringVar(&installationOpts.OtelEndpoint, "otel-endpoint", "", "Export the installation as an OpenTelemetry trace, with a span for every step, to this OTLP/HTTP collector url (e.g. \"http://localhost:4318\")")
+	cmd.Flags().BoolVar(&store.Get().ReporterWildcardRBAC, "reporter-wildcard-rbac", false, "If true, the reporters roles will allow all verbs on all resources, instead of only reading the resources they watch")
+	cmd.Flags().BoolVar(&installationOpts.FailFastOnDegraded, "fail-fast-on-degraded", false, "If true, will stop waiting for the runtime sync and fail the installation as soon as a component is degraded, instead of waiting for the timeout")
+	cmd.Flags().DurationVar(&installationOpts.GitIntegrationTimeout, "git-integration-timeout", time.Minute, "How long to wait for the app-proxy to create the default git integration")
+	cmd.Flags().StringVar(&installationOpts.DemoResourcesRepo, "demo-resources-repo", "", "A repo whose files are used as the demo resources of the git-source, instead of the built-in demo pipelines")
+	cmd.Flags().StringVar(&installationOpts.DemoResourcesPath, "demo-resources-path", "", "The directory in --demo-resources-repo to take the demo resources from (default: the repo root)")
+	cmd.Flags().StringSliceVar(&installationOpts.SkipComponents, "skip-components", nil, "Components and reporters of the runtime definition that will not be installed, for debugging and minimal installations (e.g. \"rollouts,rollout-reporter\")")
+	cmd.Flags().BoolVar(&installationOpts.InternalIngressInsecure, "internal-ingress-insecure", false, "If true, the internal ingress host is served over plain http, and its certificate is not validated")
+	cmd.Flags().BoolVar(&installationOpts.KustomizeBuildOptions.EnableHelm, "kustomize-enable-helm", false, "If true, argo-cd will build the runtime overlays with \"--enable-helm\", for patches that inflate helm charts")
+	cmd.Flags().StringVar(&installationOpts.KustomizeBuildOptions.LoadRestrictor, "kustomize-load-restrictor", "", "The kustomize load restrictor argo-cd will build the runtime overlays with (LoadRestrictionsRootOnly|LoadRestrictionsNone)")
+	cmd.Flags().StringArrayVar(&installationOpts.ComponentResources, "component-resources", nil, "Resource requests and limits of a single component, overriding the default resources. Can be repeated (e.g. \"app-proxy:cpu=200m,memory=256Mi,limits.memory=512Mi\")")
+	cmd.Flags().StringVar(&installationOpts.AppProxyServiceName, "app-proxy-service-name", "", fmt.Sprintf("The name of the app-proxy service the ingress routes to, for an app-proxy deployed with a custom service (default: %s)", store.Get().AppProxyServiceName))
+	cmd.Flags().Int32Var(&installationOpts.AppProxyServicePort, "app-proxy-service-port", 0, fmt.Sprintf("The port of the app-proxy service the ingress routes to (default: %d)", store.Get().AppProxyServicePort))
+	cmd.Flags().StringVar(&installationOpts.ClusterServer, "cluster-server", "", "The cluster server address the platform records for the runtime, when it differs from the address of the kube context (e.g. a private endpoint behind a proxy)")
+	cmd.Flags().BoolVar(&installationOpts.ConfirmRollback, "confirm-rollback", false, "If true, will ask before rolling back a failed installation, so it can be kept for inspection. With --silent the rollback is performed without asking")
+	cmd.Flags().StringVar(&installationOpts.SSHKey, "ssh-key", "", "Path to an ssh private key, that will be used to clone and push to an ssh --repo (e.g. git@github.com:owner/repo.git). The git token is still used for the git provider api")
+	cmd.Flags().StringVar(&installationOpts.SSHKeyPassphrase, "ssh-key-passphrase", "", "The passphrase of the --ssh-key, if it is encrypted")
+	cmd.Flags().DurationVar(&installationOpts.MinTokenTTL, "min-token-ttl", 30*time.Minute, "Warn when the git token expires within this duration, as the installation fails if the token expires before it is done. Only checked with git providers that expose the token expiration, 0 to skip")
+	cmd.Flags().StringVar(&installationOpts.NameSuffix, "name-suffix", "", fmt.Sprintf("Append a unique suffix to the runtime name, which is regenerated until no runtime has that name. One of: %s", strings.Join(nameSuffixTypes, ", ")))
+	cmd.Flags().StringVar(&installationOpts.ImageRegistry, "image-registry", "", "Pull the images of all components from this registry instead of their upstream registries, for air-gapped clusters with mirrored images (e.g. \"my-registry.internal\" pulls \"gcr.io/codefresh/app-proxy\" as \"my-registry.internal/gcr.io/codefresh/app-proxy\"). The images the components pass in *_IMAGE env vars and --*-image args are mirrored too. The component bases are built on the machine running the installation to find their images, and the argo-cd bootstrap images are not mirrored, use --bootstrap-specifier for them")
+	cmd.Flags().Int32Var(&installationOpts.SensorRetryCount, "sensor-retry-count", eventsutil.DefaultTriggerRetrySteps, "How many times the reporters sensors retry sending an event to the platform, before the event is dropped")
+	cmd.Flags().DurationVar(&installationOpts.SensorRetryInterval, "sensor-retry-interval", eventsutil.DefaultTriggerRetryInterval, "How long the reporters sensors wait before retrying to send an event to the platform")
+	cmd.Flags().StringVar(&installationOpts.GitTokenFile, "git-token-file", "", "Path to a file with the git runtime token, so it is not passed on the command line. Used only when --git-token and GIT_TOKEN are not set")
+	cmd.Flags().StringVar(&installationOpts.PersonalGitTokenFile, "personal-git-token-file", "", "Path to a file with the personal git token, so it is not passed on the command line. Used only when --personal-git-token is not set")
+	cmd.Flags().StringVar(&installationOpts.PrintResourceGraph, "print-resource-graph", "", fmt.Sprintf("Print the graph of the resources the installation would create (project, application set, applications and their resources), without installing. The component bases are built to find their resources. One of: %s. A format must be given with \"=\" (e.g. --print-resource-graph=json)", strings.Join(resourceGraphFormats, ", ")))
+	cmd.Flags().Lookup("print-resource-graph").NoOptDefVal = resourceGraphFormatDOT
+	cmd.Flags().BoolVar(&installationOpts.UseExistingNamespace, "use-existing-namespace", false, "If true, will install into the runtime namespace that already exists (e.g. with quotas set by the cluster admins) instead of creating it. The --namespace-labels are merged onto its labels, which are kept, and the namespace is not deleted when the runtime is uninstalled")
+	cmd.Flags().StringToStringVar(&installationOpts.NodeSelector, "node-selector", nil, "Node labels the pods of argo-cd, the components and the reporters must be scheduled on (e.g. \"pool=runtime\")")
+	cmd.Flags().StringArrayVar(&installationOpts.Tolerations, "tolerations", nil, "A taint the pods of argo-cd, the components and the reporters tolerate, as <key>[=<value>][:<effect>]. Can be repeated (e.g. \"dedicated=runtime:NoSchedule\")")
+	cmd.Flags().StringVar(&installationOpts.Affinity, "affinity", "", "The affinity of the pods of argo-cd, the components and the reporters, as a json or yaml pod affinity (e.g. '{\"nodeAffinity\": {...}}')")
+	cmd.Flags().StringVar(&installationOpts.BootstrapSpecifier, "bootstrap-specifier", "", "Take the argo-cd bootstrap manifests from this kustomization instead of the runtime definition, e.g. from an internal mirror (e.g. \"https://git.internal/mirror/cli-v2/manifests/argo-cd?ref=v0.1.0\")")
+	cmd.Flags().StringVar(&installationOpts.SmokeTest, "smoke-test", "", fmt.Sprintf("After the installation, send an authenticated request through the ingress to the app-proxy, to verify it is routed end-to-end. When the app-proxy does not respond correctly, \"%s\" fails the installation and \"%s\" only warns. A mode must be given with \"=\" (e.g. --smoke-test=%s)", smokeTestModeFail, smokeTestModeWarn, smokeTestModeWarn))
+	cmd.Flags().Lookup("smoke-test").NoOptDefVal = smokeTestModeFail
+	cmd.Flags().StringVar(&installationOpts.SummaryFile, "summary-file", "", "Write the installation summary (runtime, repo url, ingress host, status and the outcome of every step) as json to this path. It is written also when the installation fails or completes with some issues")
+	cmd.Flags().StringVar(&installationOpts.CommitMessagePrefix, "commit-message-prefix", "", "A prefix of the message of every commit the installation pushes to the git repos, e.g. a ticket reference (e.g. \"OPS-123\")")
+	cmd.Flags().StringVar(&installationOpts.CommitAuthor, "commit-author", "", "The author of every commit the installation pushes to the git repos, instead of the git config user (e.g. \"Runtime Installer <installer@example.com>\")")
+	cmd.Flags().BoolVar(&installationOpts.UseExistingProject, "use-existing-project", false, "If true, will reuse the project and application set of the runtime when they already exist in the installation repo (e.g. a project shared by several installations), only applying the labels the runtime requires, instead of failing to create them")
+	cmd.Flags().StringVar(&installationOpts.SSHKnownHosts, "ssh-known-hosts", "", "Path to a known_hosts file the host key of an ssh --repo is strictly checked against (default: $SSH_KNOWN_HOSTS, ~/.ssh/known_hosts or /etc/ssh/ssh_known_hosts)")
+	cmd.Flags().BoolVar(&installationOpts.SSHInsecureIgnoreHostKey, "ssh-insecure-ignore-host-key", false, "If true, will accept any host key of an ssh --repo, without checking it against the known hosts. Only for development, this allows man-in-the-middle attacks")
+	cmd.Flags().StringVar(&installationOpts.CertManagerIssuer, "cert-manager-issuer", "", "The cert-manager cluster issuer that issues the certificates of the runtime ingresses hosts. The ingresses get a tls section, so the certificates are issued on install")
+	cmd.Flags().StringVar(&installationOpts.SummaryLevel, "summary-level", "", fmt.Sprintf("Which entries of the installation summary are printed, one of: %s (default: %s when the installation fails, %s when it succeeds)", strings.Join(summaryLevels, ", "), summaryLevelAll, summaryLevelWarnings))
 
 	installationOpts.InsCloneOpts = apu.AddCloneFlags(cmd, &apu.CloneFlagsOptions{
 		CreateIfNotExist: true,
@@ -225,6 +438,42 @@ func NewRuntimeInstallCommand() *cobra.Command {
 	return cmd
 }
 
+// setKubeImpersonation points the kube factory to a copy of the kubeconfig in which the user of the selected kube
+// context impersonates the requested identity, so every cluster operation (checks, apply, bootstrap) runs with its permissions
+func setKubeImpersonation(cmd *cobra.Command, opts *RuntimeInstallOptions) error {
+	if opts.Impersonate == "" {
+		if len(opts.ImpersonateGroups) > 0 {
+			return fmt.Errorf("--as-group requires --as")
+		}
+
+		return nil
+	}
+
+	kubeconfig, err := util.WriteImpersonatingKubeconfig(cmd.Flag("kubeconfig").Value.String(), opts.kubeContext, opts.Impersonate, opts.ImpersonateGroups)
+	if err != nil {
+		return err
+	}
+
+	// set before anything else can fail, so the caller removes it
+	opts.impersonatingKubeconfig = kubeconfig
+	opts.kubeconfig = kubeconfig
+	log.G(cmd.Context()).Infof("Impersonating \"%s\" for all cluster operations", opts.Impersonate)
+
+	return cmd.Flags().Set("kubeconfig", kubeconfig)
+}
+
+func removeImpersonatingKubeconfig(opts *RuntimeInstallOptions) {
+	if opts.impersonatingKubeconfig == "" {
+		return
+	}
+
+	if err := os.Remove(opts.impersonatingKubeconfig); err != nil {
+		log.G().WithError(err).Debug("Failed to remove the impersonating kubeconfig")
+	}
+
+	opts.impersonatingKubeconfig = ""
+}
+
 func runtimeInstallCommandPreRunHandler(cmd *cobra.Command, opts *RuntimeInstallOptions) error {
 	var err error
 	ctx := cmd.Context()
@@ -232,11 +481,152 @@ func runtimeInstallCommandPreRunHandler(cmd *cobra.Command, opts *RuntimeInstall
 	handleCliStep(reporter.InstallPhasePreCheckStart, "Starting pre checks", nil, true, false)
 
 	opts.Version, err = getVersionIfExists(opts.versionStr)
+	if err == nil && opts.Version != nil && opts.RuntimeDef != "" {
+		err = fmt.Errorf("--version cannot be used with --runtime-def, the version is set by the definition file")
+	}
 	handleCliStep(reporter.InstallStepPreCheckValidateRuntimeVersion, "Validating runtime version", err, true, false)
 	if err != nil {
 		return err
 	}
 
+	opts.componentVersions, err = parseComponentVersions(opts.ComponentVersions)
+	if err != nil {
+		return err
+	}
+
+	opts.componentResources, err = parseComponentResources(opts.ComponentResources)
+	if err != nil {
+		return err
+	}
+
+	opts.podPlacement, err = parsePodPlacement(opts.NodeSelector, opts.Tolerations, opts.Affinity)
+	if err != nil {
+		return err
+	}
+
+	if err = validateArgoEventsRef(opts); err != nil {
+		return err
+	}
+
+	if err = oc.ValidateMode(opts.Openshift); err != nil {
+		return err
+	}
+
+	if err = validateNotifyWebhook(opts.NotifyWebhook); err != nil {
+		return err
+	}
+
+	if err = validateNameSuffix(opts.NameSuffix, opts.FromRepo); err != nil {
+		return err
+	}
+
+	opts.ImageRegistry, err = parseImageRegistry(opts.ImageRegistry)
+	if err != nil {
+		return err
+	}
+
+	opts.checklistColumns, err = parseComponentColumns(opts.ChecklistColumns)
+	if err != nil {
+		return err
+	}
+
+	if opts.GitIntegrationTimeout <= 0 {
+		return fmt.Errorf("--git-integration-timeout must be positive")
+	}
+
+	if opts.SensorRetryCount < 1 {
+		return fmt.Errorf("--sensor-retry-count must be at least 1")
+	}
+
+	if opts.SensorRetryInterval <= 0 {
+		return fmt.Errorf("--sensor-retry-interval must be positive")
+	}
+
+	if errs := validation.IsDNS1123Subdomain(opts.CertManagerIssuer); opts.CertManagerIssuer != "" && len(errs) > 0 {
+		return fmt.Errorf("invalid --cert-manager-issuer \"%s\": %s", opts.CertManagerIssuer, strings.Join(errs, ", "))
+	}
+
+	if opts.RepoVisibility != "private" && opts.RepoVisibility != "public" {
+		return fmt.Errorf("invalid --repo-visibility \"%s\", must be one of: private, public", opts.RepoVisibility)
+	}
+
+	if opts.SummaryLevel != "" && util.StringIndexOf(summaryLevels, opts.SummaryLevel) == -1 {
+		return fmt.Errorf("invalid --summary-level \"%s\", must be one of: %s", opts.SummaryLevel, strings.Join(summaryLevels, ", "))
+	}
+
+	if opts.CommitAuthor != "" {
+		if opts.commitAuthor, err = apu.ParseCommitAuthor(opts.CommitAuthor); err != nil {
+			return fmt.Errorf("invalid --commit-author: %w", err)
+		}
+	}
+
+	if err = ensureTokenFromFile(&opts.InsCloneOpts.Auth.Password, opts.GitTokenFile, "--git-token-file"); err != nil {
+		return err
+	}
+
+	if err = ensureTokenFromFile(&opts.GitIntegrationRegistrationOpts.Token, opts.PersonalGitTokenFile, "--personal-git-token-file"); err != nil {
+		return err
+	}
+
+	if opts.DemoResourcesPath != "" && opts.DemoResourcesRepo == "" {
+		return fmt.Errorf("--demo-resources-path requires --demo-resources-repo")
+	}
+
+	if opts.InternalIngressInsecure && opts.InternalIngressHost == "" {
+		return fmt.Errorf("--internal-ingress-insecure requires --internal-ingress-host")
+	}
+
+	if err = opts.KustomizeBuildOptions.Validate(); err != nil {
+		return err
+	}
+
+	if errs := validation.IsDNS1035Label(opts.AppProxyServiceName); opts.AppProxyServiceName != "" && len(errs) > 0 {
+		return fmt.Errorf("invalid --app-proxy-service-name \"%s\": %s", opts.AppProxyServiceName, strings.Join(errs, ", "))
+	}
+
+	if opts.AppProxyServicePort < 0 || opts.AppProxyServicePort > 65535 {
+		return fmt.Errorf("invalid --app-proxy-service-port %d, must be between 1 and 65535", opts.AppProxyServicePort)
+	}
+
+	if opts.ClusterServer != "" {
+		if u, err := url.Parse(opts.ClusterServer); err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+			return fmt.Errorf("invalid --cluster-server \"%s\", expected a url such as \"https://cluster.example.com:6443\"", opts.ClusterServer)
+		}
+	}
+
+	if opts.DryRunOutput != "" && !opts.DryRun {
+		return fmt.Errorf("--dry-run-output requires --dry-run")
+	}
+
+	if opts.PrintResourceGraph != "" {
+		if err = validateResourceGraphFormat(opts.PrintResourceGraph); err != nil {
+			return err
+		}
+
+		if opts.DryRunOutput != "" {
+			return fmt.Errorf("--print-resource-graph cannot be used with --dry-run-output")
+		}
+
+		// the graph is rendered like the manifests of a dry run, so nothing is created
+		opts.DryRun = true
+	}
+
+	if opts.SmokeTest != "" {
+		if err = validateSmokeTestMode(opts.SmokeTest); err != nil {
+			return err
+		}
+
+		if store.Get().SkipIngress {
+			return fmt.Errorf("--smoke-test cannot be used with --skip-ingress, the app-proxy is not routed until the ingress is configured")
+		}
+	}
+
+	for _, finalizer := range opts.NamespaceFinalizers {
+		if errs := validation.IsQualifiedName(finalizer); len(errs) > 0 {
+			return fmt.Errorf("invalid namespace finalizer \"%s\": %s", finalizer, strings.Join(errs, ", "))
+		}
+	}
+
 	if opts.RuntimeName == "" {
 		if !store.Get().Silent {
 			opts.RuntimeName, err = getRuntimeNameFromUserInput()
@@ -250,17 +640,36 @@ func runtimeInstallCommandPreRunHandler(cmd *cobra.Command, opts *RuntimeInstall
 	}
 
 	err = validateRuntimeName(opts.RuntimeName)
+	if err == nil {
+		err = applyNameSuffix(ctx, opts)
+	}
 	handleCliStep(reporter.InstallStepPreCheckRuntimeNameValidation, "Validating runtime name", err, true, false)
 	if err != nil {
 		return err
 	}
 
+	if err = validateIngressAnnotations(opts); err != nil {
+		return err
+	}
+
 	opts.kubeContext, err = getKubeContextName(cmd.Flag("context"), cmd.Flag("kubeconfig"))
 	handleCliStep(reporter.InstallStepPreCheckGetKubeContext, "Getting kube context name", err, true, false)
 	if err != nil {
 		return err
 	}
 
+	// impersonates the user of the selected context, which may have been picked interactively
+	if err = setKubeImpersonation(cmd, opts); err != nil {
+		return err
+	}
+
+	if opts.Resume {
+		resumed, err := preRunResumeInstall(cmd, opts)
+		if err != nil || resumed {
+			return err
+		}
+	}
+
 	err = ensureIngressClass(ctx, opts)
 	handleCliStep(reporter.InstallStepPreCheckEnsureIngressClass, "Getting ingress class", err, true, false)
 	if err != nil {
@@ -277,6 +686,30 @@ func runtimeInstallCommandPreRunHandler(cmd *cobra.Command, opts *RuntimeInstall
 		return err
 	}
 
+	if opts.SSHKey != "" || cfgit.IsSSHRepoURL(opts.InsCloneOpts.Repo) {
+		err = ensureSSHAuth(opts)
+		handleCliStep(reporter.InstallStepPreCheckEnsureSSHKey, "Verifying ssh key", err, true, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	// nothing is committed in a dry run
+	if opts.GitSigningKey != "" && !opts.DryRun {
+		err = apu.ConfigureCommitSigning(opts.InsCloneOpts, opts.GitSigningKey, opts.GitSigningKeyPassphrase)
+		handleCliStep(reporter.InstallStepPreCheckEnsureGitSigningKey, "Verifying git signing key", err, true, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !opts.DryRun {
+		apu.ConfigureCommitMessagePrefix(opts.CommitMessagePrefix)
+		if opts.commitAuthor != nil {
+			apu.ConfigureCommitAuthor(opts.InsCloneOpts, opts.commitAuthor)
+		}
+	}
+
 	err = askUserIfToInstallDemoResources(cmd, &opts.InstallDemoResources)
 	handleCliStep(reporter.InstallStepPreCheckShouldInstallDemoResources, "Asking user is demo resources should be installed", err, true, false)
 	if err != nil {
@@ -288,6 +721,15 @@ func runtimeInstallCommandPreRunHandler(cmd *cobra.Command, opts *RuntimeInstall
 	opts.InsCloneOpts.Parse()
 	opts.GsCloneOpts.Parse()
 
+	// a recovered runtime already has its git sources
+	if !opts.FromRepo {
+		err = verifyGitSourceWriteAccess(ctx, opts.GsCloneOpts)
+		handleCliStep(reporter.InstallStepPreCheckEnsureGitSourceWriteAccess, "Verifying git-source repo write access", err, true, false)
+		if err != nil {
+			return err
+		}
+	}
+
 	if err := ensureGitIntegrationOpts(opts); err != nil {
 		return err
 	}
@@ -298,8 +740,8 @@ func runtimeInstallCommandPreRunHandler(cmd *cobra.Command, opts *RuntimeInstall
 		}
 	}
 
-	if opts.SuggestedSharedConfigRepo != "" {
-		sharedConfigRepo, err := suggestIscRepo(ctx, opts.SuggestedSharedConfigRepo)
+	if opts.SuggestedSharedConfigRepo != "" && !opts.DryRun {
+		sharedConfigRepo, err := ensureIscRepo(ctx, opts.SuggestedSharedConfigRepo, opts.OverrideSharedConfigRepo)
 		if err != nil {
 			return fmt.Errorf("failed to ensure shared config repo: %w", err)
 		}
@@ -308,7 +750,11 @@ func runtimeInstallCommandPreRunHandler(cmd *cobra.Command, opts *RuntimeInstall
 	}
 
 	opts.Insecure = true // installs argo-cd in insecure mode, we need this so that the eventsource can talk to the argocd-server with http
-	opts.CommonConfig = &runtime.CommonConfig{CodefreshBaseURL: cfConfig.GetCurrentContext().URL}
+	opts.CommonConfig = &runtime.CommonConfig{
+		CodefreshBaseURL:    cfConfig.GetCurrentContext().URL,
+		ResourceLabels:      opts.ResourceLabels,
+		ResourceAnnotations: opts.ResourceAnnotations,
+	}
 
 	return nil
 }
@@ -317,6 +763,13 @@ func ensureGitData(cmd *cobra.Command, opts *RuntimeInstallOptions) error {
 	var err error
 	ctx := cmd.Context()
 
+	cfgit.SetRateLimit(cfgit.RateLimitOptions{
+		RequestsPerSecond: opts.GitProviderRateLimit,
+		MaxRetries:        opts.GitProviderMaxRetries,
+		InitialBackoff:    time.Second,
+		MaxBackoff:        time.Minute,
+	})
+
 	err = ensureRepo(cmd, opts.RuntimeName, opts.InsCloneOpts, false)
 	handleCliStep(reporter.InstallStepPreCheckEnsureRuntimeRepo, "Getting runtime repo", err, true, false)
 	if err != nil {
@@ -333,8 +786,14 @@ func ensureGitData(cmd *cobra.Command, opts *RuntimeInstallOptions) error {
 	}
 
 	opts.InsCloneOpts.Provider = string(opts.gitProvider.Type())
-	err = getGitToken(cmd, opts)
-	handleCliStep(reporter.InstallStepPreCheckEnsureGitToken, "Getting git token", err, true, false)
+	if opts.GitUsername != "" || opts.GitPassword != "" {
+		// basic auth credentials are not tokens, so there are no scopes to verify
+		err = setGitBasicAuth(opts.InsCloneOpts, opts.GitUsername, opts.GitPassword)
+		handleCliStep(reporter.InstallStepPreCheckEnsureGitToken, "Getting git basic auth credentials", err, true, false)
+	} else {
+		err = getGitToken(cmd, opts)
+		handleCliStep(reporter.InstallStepPreCheckEnsureGitToken, "Getting git token", err, true, false)
+	}
 	if err != nil {
 		return err
 	}
@@ -345,6 +804,93 @@ func ensureGitData(cmd *cobra.Command, opts *RuntimeInstallOptions) error {
 		return err
 	}
 
+	if opts.GitUsername == "" && opts.GitPassword == "" {
+		err = verifyGitTokenPermissions(ctx, opts.gitProvider, opts.InsCloneOpts.Auth.Password, getRequiredTokenPermissions(opts))
+		handleCliStep(reporter.InstallStepPreCheckEnsureGitTokenPermissions, "Verifying git token permissions", err, true, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	// a dry run must not create the repo
+	if !opts.DryRun {
+		if err = ensureRepoFromTemplate(ctx, opts.gitProvider, opts.InsCloneOpts, opts.RepoTemplate, opts.RepoVisibility != "public"); err != nil {
+			return err
+		}
+	}
+
+	return ensureRepoDefaultBranch(ctx, opts.gitProvider, opts.InsCloneOpts)
+}
+
+// ensureSSHAuth makes the clones and pushes of an ssh installation repo authenticate with the --ssh-key
+func ensureSSHAuth(opts *RuntimeInstallOptions) error {
+	if !cfgit.IsSSHRepoURL(opts.InsCloneOpts.Repo) {
+		return fmt.Errorf("--ssh-key requires an ssh --repo, such as \"git@github.com:owner/repo.git\"")
+	}
+
+	if opts.SSHKey == "" {
+		return fmt.Errorf("must provide --ssh-key when using an ssh --repo")
+	}
+
+	if opts.SSHKnownHosts != "" && opts.SSHInsecureIgnoreHostKey {
+		return fmt.Errorf("--ssh-known-hosts cannot be used with --ssh-insecure-ignore-host-key")
+	}
+
+	key, err := os.ReadFile(opts.SSHKey)
+	if err != nil {
+		return fmt.Errorf("failed to read ssh key: %w", err)
+	}
+
+	hostKeyCallback, err := apu.GetSSHHostKeyCallback(apu.SSHHostKeyOptions{
+		KnownHostsFile:        opts.SSHKnownHosts,
+		InsecureIgnoreHostKey: opts.SSHInsecureIgnoreHostKey,
+	})
+	if err != nil {
+		return fmt.Errorf("%w. Provide --ssh-known-hosts, or --ssh-insecure-ignore-host-key for development only", err)
+	}
+
+	if opts.SSHInsecureIgnoreHostKey {
+		log.G().Warn("The ssh host key of the installation repo will not be checked, this is insecure and only meant for development")
+	}
+
+	if err = apu.ConfigureSSHAuth(sshRepoBase(opts.InsCloneOpts.Repo), opts.SSHKey, opts.SSHKeyPassphrase, hostKeyCallback); err != nil {
+		return err
+	}
+
+	opts.sshPrivateKey = string(key)
+	return nil
+}
+
+// ensureRepoFromTemplate creates the installation repo from the template, before it is created empty by the bootstrap.
+// Providers that do not support templates fall back to the plain create, and nothing is created when the repo
+// must already exist
+func ensureRepoFromTemplate(ctx context.Context, gitProvider cfgit.Provider, cloneOpts *apgit.CloneOptions, template string, private bool) error {
+	if template == "" {
+		return nil
+	}
+
+	if !cloneOpts.CreateIfNotExist {
+		log.G(ctx).Warn("The installation repo is not created when it does not exist, ignoring --repo-template")
+		return nil
+	}
+
+	templater, ok := gitProvider.(cfgit.RepoTemplater)
+	if !ok {
+		log.G(ctx).Warnf("The %s git provider does not support repo templates, ignoring --repo-template", gitProvider.Type())
+		return nil
+	}
+
+	created, err := templater.CreateRepoFromTemplate(ctx, cloneOpts.Repo, template, cloneOpts.Auth.Password, private)
+	if err != nil {
+		return fmt.Errorf("failed to create the installation repo from template \"%s\": %w", template, err)
+	}
+
+	if created {
+		log.G(ctx).Infof("Created the installation repo from template \"%s\"", template)
+	} else {
+		log.G(ctx).Info("The installation repo already exists, ignoring --repo-template")
+	}
+
 	return nil
 }
 
@@ -357,7 +903,7 @@ func getIngressHost(ctx context.Context, opts *RuntimeInstallOptions) error {
 		handleValidationFailsWithRepeat(func() error {
 			err = ensureIngressHost(ctx, opts)
 			if isValidationError(err) {
-				fmt.Println("Could not resolve the URL for ingress host; enter a valid URL")
+				fmt.Printf("Could not resolve the URL for ingress host: %s; enter a valid URL\n", err)
 				return err
 			}
 			return nil
@@ -370,10 +916,10 @@ func getGitToken(cmd *cobra.Command, opts *RuntimeInstallOptions) error {
 	var err error
 
 	if store.Get().Silent {
-		err = ensureGitToken(cmd, opts.gitProvider, opts.InsCloneOpts)
+		err = ensureGitToken(cmd, opts.gitProvider, opts.InsCloneOpts, opts.MinTokenTTL)
 	} else {
 		handleValidationFailsWithRepeat(func() error {
-			err = ensureGitToken(cmd, opts.gitProvider, opts.InsCloneOpts)
+			err = ensureGitToken(cmd, opts.gitProvider, opts.InsCloneOpts, opts.MinTokenTTL)
 			if isValidationError(err) {
 				fmt.Println(err)
 				return err
@@ -391,42 +937,191 @@ func ensureIngressHost(ctx context.Context, opts *RuntimeInstallOptions) error {
 		}
 	}
 
+	// the first host is the primary one, the rest are only added to the ingress rules
+	hosts := strings.Split(opts.IngressHost, ",")
+	opts.IngressHost = strings.TrimSpace(hosts[0])
+	opts.AdditionalIngressHosts = nil
+	opts.AdditionalHostNames = nil
+
+	ingressHost, err := validateIngressHostPath(opts.IngressHost)
+	if err != nil {
+		// clear it so the user will be asked for a different one
+		opts.IngressHost = ""
+		return err
+	}
+	opts.IngressHost = ingressHost
+
 	if err := parseHostName(opts.IngressHost, &opts.HostName); err != nil {
 		return err
 	}
 
+	for _, host := range hosts[1:] {
+		additionalHost, err := validateIngressHostPath(strings.TrimSpace(host))
+		if err != nil {
+			opts.IngressHost = ""
+			return err
+		}
+
+		var hostName string
+		if err := parseHostName(additionalHost, &hostName); err != nil {
+			return err
+		}
+
+		opts.AdditionalIngressHosts = append(opts.AdditionalIngressHosts, additionalHost)
+		opts.AdditionalHostNames = append(opts.AdditionalHostNames, hostName)
+	}
+
+	opts.AdditionalInternalIngressHosts = nil
+	opts.AdditionalInternalHostNames = nil
 	if opts.InternalIngressHost != "" {
+		// like the ingress host, the first internal host is the primary one
+		internalHosts := strings.Split(opts.InternalIngressHost, ",")
+		opts.InternalIngressHost, err = validateIngressHostPath(strings.TrimSpace(internalHosts[0]))
+		if err != nil {
+			return err
+		}
+
+		if opts.InternalIngressInsecure {
+			// the app-proxy is reached through the internal host, so it must be configured with the http scheme
+			internalURL, err := url.Parse(opts.InternalIngressHost)
+			if err != nil {
+				return err
+			}
+
+			internalURL.Scheme = "http"
+			opts.InternalIngressHost = internalURL.String()
+		}
+
 		if err := parseHostName(opts.InternalIngressHost, &opts.InternalHostName); err != nil {
 			return err
 		}
+
+		for _, host := range internalHosts[1:] {
+			additionalHost, err := validateIngressHostPath(strings.TrimSpace(host))
+			if err != nil {
+				return err
+			}
+
+			var hostName string
+			if err := parseHostName(additionalHost, &hostName); err != nil {
+				return err
+			}
+
+			opts.AdditionalInternalIngressHosts = append(opts.AdditionalInternalIngressHosts, additionalHost)
+			opts.AdditionalInternalHostNames = append(opts.AdditionalInternalHostNames, hostName)
+		}
 	}
 
 	log.G(ctx).Infof("Using ingress host: %s", opts.IngressHost)
 
-	if !opts.SkipClusterChecks {
+	if opts.SkipClusterChecks {
+		return nil
+	}
+
+	if err := validateIngressHostsReachable(ctx, append([]string{opts.IngressHost}, opts.AdditionalIngressHosts...)); err != nil {
+		// clear it so the user will be asked for a different one
+		opts.IngressHost = ""
+		return err
+	}
+
+	if opts.CertManagerIssuer != "" {
+		// the certificates are only issued once the ingresses are created
+		log.G(ctx).Infof("The ingress hosts certificates will be issued by cert-manager issuer \"%s\", skipping their validation", opts.CertManagerIssuer)
 		return nil
 	}
 
 	log.G(ctx).Info("Validating ingress host")
 
-	if opts.InternalIngressHost != "" {
-		if err := validateIngressHostCertificate(ctx, opts.InternalIngressHost); err != nil {
-			return err
-		}
-		log.G(ctx).Infof("Using internal ingress host: %s", opts.InternalIngressHost)
+	if err := validateInternalIngressHostCertificate(ctx, opts); err != nil {
+		return err
 	}
 
-	return validateIngressHostCertificate(ctx, opts.IngressHost)
+	return validateIngressHostsCertificates(ctx, append([]string{opts.IngressHost}, opts.AdditionalIngressHosts...))
 }
 
-func parseHostName(ingressHost string, hostName *string) error {
-	parsed, err := url.Parse(ingressHost)
-	if err != nil {
-		return err
+func validateInternalIngressHostCertificate(ctx context.Context, opts *RuntimeInstallOptions) error {
+	if opts.InternalIngressHost == "" {
+		return nil
 	}
 
-	isIP := util.IsIP(parsed.Host)
-	if !isIP {
+	if opts.InternalIngressInsecure {
+		log.G(ctx).Infof("Using insecure internal ingress host: %s", opts.InternalIngressHost)
+		return nil
+	}
+
+	if err := validateIngressHostsCertificates(ctx, append([]string{opts.InternalIngressHost}, opts.AdditionalInternalIngressHosts...)); err != nil {
+		return err
+	}
+
+	log.G(ctx).Infof("Using internal ingress host: %s", opts.InternalIngressHost)
+	return nil
+}
+
+// validateIngressHostsReachable checks the external ingress hosts only, the internal one is usually not reachable
+// from outside of the cluster
+func validateIngressHostsReachable(ctx context.Context, ingressHosts []string) error {
+	log.G(ctx).Info("Checking the ingress host reachability")
+
+	for _, ingressHost := range ingressHosts {
+		if err := checkIngressHostReachable(ctx, ingressHost); err != nil {
+			return fmt.Errorf("ingress host validation failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func validateIngressHostsCertificates(ctx context.Context, ingressHosts []string) error {
+	for _, ingressHost := range ingressHosts {
+		if err := validateIngressHostCertificate(ctx, ingressHost); err != nil {
+			return fmt.Errorf("failed to validate ingress host \"%s\": %w", ingressHost, err)
+		}
+	}
+
+	return nil
+}
+
+// validateIngressHostPath removes a trailing slash from the ingress host, and fails if it contains a path,
+// since all of the runtime ingresses (app-proxy, webhooks, workflows) are served from the root of the host
+func validateIngressHostPath(ingressHost string) (string, error) {
+	// without a scheme the host would be parsed as a path
+	if err := validateIngressHost(ingressHost); err != nil {
+		return "", err
+	}
+
+	parsed, err := url.Parse(ingressHost)
+	if err != nil {
+		return "", err
+	}
+
+	if path := strings.Trim(parsed.Path, "/"); path != "" {
+		return "", fmt.Errorf("ingress host \"%s\" must not contain a path (\"/%s\"), the runtime is always served from the root of the host", ingressHost, path)
+	}
+
+	parsed.Path = ""
+	return parsed.String(), nil
+}
+
+// parseHostName sets hostName to the host of the ingress url, without the port. An ip address is not a valid
+// ingress rule host, so hostName is left empty for ip hosts
+func parseHostName(ingressHost string, hostName *string) error {
+	parsed, err := url.Parse(ingressHost)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(parsed.Host, "[") {
+		// a bracketed ipv6 literal, e.g. "[2001:db8::1]:8443", which SplitHostPort does not handle without a port
+		end := strings.Index(parsed.Host, "]")
+		if end == -1 || net.ParseIP(parsed.Host[1:end]) == nil {
+			return fmt.Errorf("invalid ipv6 address in ingress host \"%s\"", ingressHost)
+		}
+
+		return nil
+	}
+
+	isIP := util.IsIP(parsed.Host)
+	if !isIP {
 		*hostName, _, err = net.SplitHostPort(parsed.Host)
 		if err != nil {
 			if err.Error() == fmt.Sprintf("address %s: missing port in address", parsed.Host) {
@@ -457,6 +1152,8 @@ func validateIngressHostCertificate(ctx context.Context, ingressHost string) err
 
 func ensureIngressClass(ctx context.Context, opts *RuntimeInstallOptions) error {
 	if store.Get().BypassIngressClassCheck || store.Get().SkipIngress {
+		// without the cluster classes only the environment variables can be resolved
+		opts.IngressClass = os.ExpandEnv(opts.IngressClass)
 		opts.IngressController = ingressutil.GetController("")
 		return nil
 	}
@@ -464,33 +1161,26 @@ func ensureIngressClass(ctx context.Context, opts *RuntimeInstallOptions) error
 	log.G(ctx).Info("Retrieving ingress class info from your cluster...\n")
 
 	cs := opts.KubeFactory.KubernetesClientSetOrDie()
-	ingressClassList, err := cs.NetworkingV1().IngressClasses().List(ctx, metav1.ListOptions{})
+	ingressClassNames, ingressClassNameToController, err := getIngressClasses(ctx, cs, opts.IngressClass)
 	if err != nil {
-		return fmt.Errorf("failed to get ingress class list from your cluster: %w", err)
+		return err
 	}
 
-	var ingressClassNames []string
-	ingressClassNameToController := make(map[string]ingressutil.IngressController)
-	var isValidClass bool
-
-	for _, ic := range ingressClassList.Items {
-		for _, controller := range ingressutil.SupportedControllers {
-			if ic.Spec.Controller == string(controller) {
-				ingressClassNames = append(ingressClassNames, ic.Name)
-				ingressClassNameToController[ic.Name] = ingressutil.GetController(string(controller))
+	if opts.IngressClass != "" { //if ingress class provided via flag
+		ingressClass, err := resolveIngressClass(opts.IngressClass, ingressClassNames)
+		if err != nil {
+			return err
+		}
 
-				if opts.IngressClass == ic.Name { //if ingress class provided via flag
-					isValidClass = true
-				}
-				break
-			}
+		if ingressClass != opts.IngressClass {
+			log.G(ctx).Infof("Resolved ingress class \"%s\" to: %s", opts.IngressClass, ingressClass)
 		}
-	}
 
-	if opts.IngressClass != "" { //if ingress class provided via flag
-		if !isValidClass {
-			return fmt.Errorf("ingress class '%s' is not supported", opts.IngressClass)
+		if _, ok := ingressClassNameToController[ingressClass]; !ok {
+			return fmt.Errorf("ingress class '%s' is not supported", ingressClass)
 		}
+
+		opts.IngressClass = ingressClass
 	} else if len(ingressClassNames) == 0 {
 		return fmt.Errorf("no ingress classes of the supported types were found")
 	} else if len(ingressClassNames) == 1 {
@@ -516,17 +1206,118 @@ func ensureIngressClass(ctx context.Context, opts *RuntimeInstallOptions) error
 	return nil
 }
 
+// getIngressClasses returns the supported ingress classes of the cluster. When the list is forbidden and
+// the class was set with --ingress-class, it returns only that class, so restricted users can still install
+func getIngressClasses(ctx context.Context, cs kubernetes.Interface, ingressClass string) ([]string, map[string]ingressutil.IngressController, error) {
+	ingressClassNames, ingressClassNameToController, err := getSupportedIngressClasses(ctx, cs)
+	if err != nil && kerrors.IsForbidden(err) && ingressClass != "" {
+		log.G(ctx).Debugf("Not allowed to list the ingress classes, getting ingress class \"%s\" instead", ingressClass)
+		return getNamedIngressClass(ctx, cs, ingressClass)
+	}
+
+	return ingressClassNames, ingressClassNameToController, err
+}
+
+func getSupportedIngressClasses(ctx context.Context, cs kubernetes.Interface) ([]string, map[string]ingressutil.IngressController, error) {
+	ingressClassList, err := cs.NetworkingV1().IngressClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get ingress class list from your cluster: %w", err)
+	}
+
+	ingressClassNames, ingressClassNameToController := filterSupportedIngressClasses(ingressClassList.Items)
+	return ingressClassNames, ingressClassNameToController, nil
+}
+
+// getNamedIngressClass gets a single ingress class, for users that are not allowed to list them.
+// The name cannot be a pattern, as there is nothing to match it against
+func getNamedIngressClass(ctx context.Context, cs kubernetes.Interface, ingressClass string) ([]string, map[string]ingressutil.IngressController, error) {
+	name := os.ExpandEnv(ingressClass)
+	if strings.ContainsAny(name, "*?[") {
+		return nil, nil, fmt.Errorf("ingress class pattern '%s' requires permission to list the ingress classes of the cluster", name)
+	}
+
+	ic, err := cs.NetworkingV1().IngressClasses().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get ingress class '%s' from your cluster: %w", name, err)
+	}
+
+	ingressClassNames, ingressClassNameToController := filterSupportedIngressClasses([]netv1.IngressClass{*ic})
+	return ingressClassNames, ingressClassNameToController, nil
+}
+
+func filterSupportedIngressClasses(ingressClasses []netv1.IngressClass) ([]string, map[string]ingressutil.IngressController) {
+	var ingressClassNames []string
+	ingressClassNameToController := make(map[string]ingressutil.IngressController)
+	for _, ic := range ingressClasses {
+		for _, controller := range ingressutil.SupportedControllers {
+			if ic.Spec.Controller == string(controller) {
+				ingressClassNames = append(ingressClassNames, ic.Name)
+				ingressClassNameToController[ic.Name] = ingressutil.GetController(string(controller))
+				break
+			}
+		}
+	}
+
+	return ingressClassNames, ingressClassNameToController
+}
+
+// resolveIngressClass expands the environment variables in the --ingress-class value (e.g. "nginx-${ENV}"),
+// and a glob (e.g. "nginx-*") to the single cluster ingress class it matches, so one value works on different clusters
+func resolveIngressClass(ingressClass string, ingressClassNames []string) (string, error) {
+	expanded := os.ExpandEnv(ingressClass)
+	if expanded == "" {
+		return "", fmt.Errorf("ingress class '%s' resolved to an empty value", ingressClass)
+	}
+
+	if !strings.ContainsAny(expanded, "*?[") {
+		return expanded, nil
+	}
+
+	var matches []string
+	for _, name := range ingressClassNames {
+		matched, err := path.Match(expanded, name)
+		if err != nil {
+			return "", fmt.Errorf("invalid ingress class pattern '%s': %w", expanded, err)
+		}
+
+		if matched {
+			matches = append(matches, name)
+		}
+	}
+
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no supported ingress class matches '%s', found: %s", expanded, strings.Join(ingressClassNames, ", "))
+	}
+
+	if len(matches) > 1 {
+		sort.Strings(matches)
+		return "", fmt.Errorf("ingress class pattern '%s' matches multiple ingress classes: %s", expanded, strings.Join(matches, ", "))
+	}
+
+	return matches[0], nil
+}
+
+// getComponents returns the components the installation waits for. It only includes what the installation creates,
+// as a component that is never created would keep the installation waiting until it times out.
+// The git sources are not components, so the skipped marketplace git source is never waited for
 func getComponents(rt *runtime.Runtime, opts *RuntimeInstallOptions) []string {
 	var componentNames []string
 	for _, component := range rt.Spec.Components {
+		if isComponentSkipped(opts, component.Name) {
+			continue
+		}
+
 		componentFullName := fmt.Sprintf("%s-%s", opts.RuntimeName, component.Name)
 		componentNames = append(componentNames, componentFullName)
 	}
 
-	//  should find a more dynamic way to get these additional components
-	additionalComponents := []string{"events-reporter", "workflow-reporter", "rollout-reporter"}
-	for _, additionalComponentName := range additionalComponents {
-		componentFullName := fmt.Sprintf("%s-%s", opts.RuntimeName, additionalComponentName)
+	// the reporters are created by the cli, so they are not part of the definition components
+	for _, reporterName := range rt.Spec.GetReporters() {
+		if !createsReporter(rt, opts, reporterName) {
+			continue
+		}
+
+		componentFullName := fmt.Sprintf("%s-%s", opts.RuntimeName, reporterName)
 		componentNames = append(componentNames, componentFullName)
 	}
 	argoCDFullName := store.Get().ArgoCD
@@ -535,6 +1326,39 @@ func getComponents(rt *runtime.Runtime, opts *RuntimeInstallOptions) []string {
 	return componentNames
 }
 
+func isComponentSkipped(opts *RuntimeInstallOptions, name string) bool {
+	return util.StringIndexOf(opts.SkipComponents, name) != -1
+}
+
+// createsReporter is true for the reporters that are created by installComponents, which only knows
+// how to create the internal reporters
+func createsReporter(rt *runtime.Runtime, opts *RuntimeInstallOptions, name string) bool {
+	return rt.Spec.HasReporter(name) && !isComponentSkipped(opts, name) && util.StringIndexOf(store.Get().CFInternalReporters, name) != -1
+}
+
+// createsWorkflowsIngress is false when the workflows component is skipped, since its ingress is patched into the
+// overlay of the component, which does not exist then
+func createsWorkflowsIngress(rt *runtime.Runtime, opts *RuntimeInstallOptions) bool {
+	return !store.Get().SkipIngress && rt.Spec.IngressController != string(ingressutil.IngressControllerALB) && !isComponentSkipped(opts, store.Get().ArgoWorkflows)
+}
+
+// validateSkipComponents fails on a --skip-components name that is neither a component nor a reporter of the runtime
+func validateSkipComponents(rt *runtime.Runtime, skipComponents []string) error {
+	var known []string
+	for _, component := range rt.Spec.Components {
+		known = append(known, component.Name)
+	}
+
+	known = append(known, rt.Spec.GetReporters()...)
+	for _, name := range skipComponents {
+		if util.StringIndexOf(known, name) == -1 {
+			return fmt.Errorf("unknown component \"%s\" in --skip-components, the runtime components are: %s", name, strings.Join(known, ", "))
+		}
+	}
+
+	return nil
+}
+
 func createRuntimeOnPlatform(ctx context.Context, opts *model.RuntimeInstallationArgs) (string, string, error) {
 	runtimeCreationResponse, err := cfConfig.NewClient().V2().Runtime().Create(ctx, opts)
 	if err != nil {
@@ -551,8 +1375,47 @@ func createRuntimeOnPlatform(ctx context.Context, opts *model.RuntimeInstallatio
 	return runtimeCreationResponse.NewAccessToken, hex.EncodeToString(iv), nil
 }
 
-func runRuntimeInstall(ctx context.Context, opts *RuntimeInstallOptions) error {
-	err := preInstallationChecks(ctx, opts)
+func getRuntimeInstallationArgs(opts *RuntimeInstallOptions, server, runtimeVersion string, componentNames []string) *model.RuntimeInstallationArgs {
+	ingressControllerName := opts.IngressController.Name()
+	return &model.RuntimeInstallationArgs{
+		RuntimeName:         opts.RuntimeName,
+		Cluster:             server,
+		RuntimeVersion:      runtimeVersion,
+		IngressHost:         &opts.IngressHost,
+		InternalIngressHost: &opts.InternalIngressHost,
+		IngressClass:        &opts.IngressClass,
+		IngressController:   &ingressControllerName,
+		ComponentNames:      componentNames,
+		Repo:                &opts.InsCloneOpts.Repo,
+		Recover:             &opts.FromRepo,
+	}
+}
+
+func runRuntimeInstall(ctx context.Context, opts *RuntimeInstallOptions) (err error) {
+	opts.installStart = time.Now()
+	if opts.DryRun {
+		return runRuntimeInstallDryRun(ctx, opts)
+	}
+
+	// deferred first, so the summary is printed last, after the rollback and the release of the lock
+	defer func() {
+		finishInstallSummary(ctx, opts, err)
+	}()
+
+	if opts.Resume {
+		state, err := loadInstallState(opts.RuntimeName)
+		if err != nil {
+			return err
+		}
+
+		if canResumeInstall(state, opts) {
+			return resumeRuntimeInstall(ctx, opts, state)
+		}
+
+		log.G(ctx).Infof("No resumable installation state was found for runtime \"%s\", starting a new installation", opts.RuntimeName)
+	}
+
+	err = preInstallationChecks(ctx, opts)
 	handleCliStep(reporter.InstallPhaseRunPreCheckFinish, "Pre run installation checks", err, true, true)
 	if err != nil {
 		return fmt.Errorf("pre installation checks failed: %w", err)
@@ -565,6 +1428,7 @@ func runRuntimeInstall(ctx context.Context, opts *RuntimeInstallOptions) error {
 		return err
 	}
 
+	opts.runtimeDef = rt
 	runtimeVersion := rt.Spec.Version.String()
 
 	componentNames := getComponents(rt, opts)
@@ -574,25 +1438,25 @@ func runRuntimeInstall(ctx context.Context, opts *RuntimeInstallOptions) error {
 		opts.DisableRollback = true
 	}
 
+	// must be acquired before the rollback is deferred, so a refused installation will not uninstall the running one
+	err = acquireInstallLock(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	// released after the rollback, whatever the outcome of the installation
+	defer func() {
+		if err := releaseInstallLock(ctx, opts); err != nil {
+			log.G(ctx).WithError(err).Warn("Failed to release the installation repo lock")
+		}
+	}()
+
 	defer func() {
 		// will rollback if err is not nil and it is safe to do so
 		postInstallationHandler(ctx, opts, err, &opts.DisableRollback)
 	}()
 
-	ingressControllerName := opts.IngressController.Name()
-
-	token, iv, err := createRuntimeOnPlatform(ctx, &model.RuntimeInstallationArgs{
-		RuntimeName:         opts.RuntimeName,
-		Cluster:             server,
-		RuntimeVersion:      runtimeVersion,
-		IngressHost:         &opts.IngressHost,
-		InternalIngressHost: &opts.InternalIngressHost,
-		IngressClass:        &opts.IngressClass,
-		IngressController:   &ingressControllerName,
-		ComponentNames:      componentNames,
-		Repo:                &opts.InsCloneOpts.Repo,
-		Recover:             &opts.FromRepo,
-	})
+	token, iv, err := createRuntimeOnPlatform(ctx, getRuntimeInstallationArgs(opts, server, runtimeVersion, componentNames))
 	handleCliStep(reporter.InstallStepCreateRuntimeOnPlatform, "Creating runtime on platform", err, false, true)
 	if err != nil {
 		return util.DecorateErrorWithDocsLink(fmt.Errorf("failed to create a new runtime: %w", err))
@@ -607,11 +1471,13 @@ func runRuntimeInstall(ctx context.Context, opts *RuntimeInstallOptions) error {
 	rt.Spec.IngressController = string(opts.IngressController.Name())
 	rt.Spec.Repo = opts.InsCloneOpts.Repo
 
-	appSpecifier := rt.Spec.FullSpecifier()
+	appSpecifier := getBootstrapAppSpecifier(rt, opts)
 
-	if opts.FromRepo {
-		// installing argocd with manifests from the provided repo
-		appSpecifier = opts.InsCloneOpts.Repo + "/bootstrap/argo-cd"
+	// the bootstrap applies the namespace manifest, which leaves an existing namespace as is,
+	// so its labels are merged afterwards instead
+	namespaceLabels := opts.NamespaceLabels
+	if opts.UseExistingNamespace {
+		namespaceLabels = nil
 	}
 
 	log.G(ctx).WithField("version", rt.Spec.Version).Infof("Installing runtime \"%s\"", opts.RuntimeName)
@@ -631,34 +1497,51 @@ func runRuntimeInstall(ctx context.Context, opts *RuntimeInstallOptions) error {
 		BootstrapAppsLabels: map[string]string{
 			store.Get().LabelKeyCFInternal: "true",
 		},
-		NamespaceLabels: opts.NamespaceLabels,
+		NamespaceLabels: namespaceLabels,
 	})
 	handleCliStep(reporter.InstallStepBootstrapRepo, "Bootstrapping repository", err, false, true)
 	if err != nil {
 		return util.DecorateErrorWithDocsLink(fmt.Errorf("failed to bootstrap repository: %w", err))
 	}
 
+	if opts.UseExistingNamespace {
+		if err = mergeNamespaceLabels(ctx, opts); err != nil {
+			return err
+		}
+
+		if !opts.FromRepo {
+			if err = retainExistingNamespace(ctx, opts); err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.sshPrivateKey != "" {
+		if err = createRepoSSHCredsSecret(ctx, opts); err != nil {
+			return err
+		}
+
+		if err = configureArgoCDSSHKnownHosts(ctx, opts); err != nil {
+			return err
+		}
+	}
+
+	if err = addNamespaceFinalizers(ctx, opts); err != nil {
+		return err
+	}
+
 	err = oc.PrepareOpenshiftCluster(ctx, &oc.OpenshiftOptions{
 		KubeFactory:  opts.KubeFactory,
 		RuntimeName:  opts.RuntimeName,
 		InsCloneOpts: opts.InsCloneOpts,
+		Mode:         oc.Mode(opts.Openshift),
 	})
 	if err != nil {
 		return fmt.Errorf("failed setting up environment for openshift %w", err)
 	}
 
 	if !opts.FromRepo {
-		err = apcmd.RunProjectCreate(ctx, &apcmd.ProjectCreateOptions{
-			CloneOpts:   opts.InsCloneOpts,
-			ProjectName: opts.RuntimeName,
-			Labels: map[string]string{
-				store.Get().LabelKeyCFType:     fmt.Sprintf("{{ labels.%s }}", util.EscapeAppsetFieldName(store.Get().LabelKeyCFType)),
-				store.Get().LabelKeyCFInternal: fmt.Sprintf("{{ labels.%s }}", util.EscapeAppsetFieldName(store.Get().LabelKeyCFInternal)),
-			},
-			Annotations: map[string]string{
-				store.Get().AnnotationKeySyncWave: fmt.Sprintf("{{ annotations.%s }}", util.EscapeAppsetFieldName(store.Get().AnnotationKeySyncWave)),
-			},
-		})
+		err = ensureRuntimeProject(ctx, opts, rt)
 	}
 	handleCliStep(reporter.InstallStepCreateProject, "Creating Project", err, false, true)
 	if err != nil {
@@ -694,16 +1577,80 @@ func runRuntimeInstall(ctx context.Context, opts *RuntimeInstallOptions) error {
 		return err
 	}
 
-	timeoutErr := intervalCheckIsRuntimePersisted(ctx, opts.RuntimeName)
+	err = saveInstallState(&installState{
+		RuntimeName:    opts.RuntimeName,
+		Phase:          installPhaseComponentsPushed,
+		Repo:           opts.InsCloneOpts.Repo,
+		IngressHost:    opts.IngressHost,
+		GitProvider:    string(opts.gitProvider.Type()),
+		GitProviderAPI: opts.gitProvider.ApiUrl(),
+	})
+	if err != nil {
+		log.G(ctx).WithError(err).Warn("Failed to persist the installation state, the installation will not be resumable")
+		err = nil
+	}
+
+	// a degraded component fails the wait, and the deferred handler rolls the installation back
+	return waitForInstallation(ctx, opts)
+}
+
+// resumeRuntimeInstall skips straight to the sync wait phase of an installation that was
+// interrupted after all of the manifests were pushed to the installation repo
+func resumeRuntimeInstall(ctx context.Context, opts *RuntimeInstallOptions, state *installState) error {
+	log.G(ctx).Infof("Resuming installation of runtime \"%s\" from phase \"%s\"", opts.RuntimeName, state.Phase)
+
+	// everything up to the push already succeeded, there is nothing to roll back
+	opts.DisableRollback = true
+
+	// the interrupted installation still holds the lock
+	defer func() {
+		if err := releaseInstallLock(ctx, opts); err != nil {
+			log.G(ctx).WithError(err).Warn("Failed to release the installation repo lock")
+		}
+	}()
+
+	return waitForInstallation(ctx, opts)
+}
+
+func waitForRuntimeInstallation(ctx context.Context, opts *RuntimeInstallOptions) error {
+	cs, err := opts.KubeFactory.KubernetesClientSet()
+	if err != nil {
+		log.G(ctx).WithError(err).Debug("Failed to create kubernetes client, the pods of progressing components will not be checked")
+		cs = nil
+	}
+
+	timeoutErr := intervalCheckIsRuntimePersisted(ctx, opts.RuntimeName, opts.checklistColumns, cs, opts.FailFastOnDegraded)
 	handleCliStep(reporter.InstallStepCompleteRuntimeInstallation, "Wait for runtime sync", timeoutErr, false, true)
+	if timeoutErr != nil && ctx.Err() == nil && opts.TimeoutReport {
+		reportUnreadyComponents(ctx, opts.RuntimeName)
+	}
+
+	var degradedErr *componentDegradedError
+	if errors.As(timeoutErr, &degradedErr) {
+		if opts.NotifyWebhook != "" && ctx.Err() == nil {
+			sendInstallNotification(ctx, opts, timeoutErr)
+		}
+
+		// unlike a timeout, the installation fails, so it is rolled back (unless rollback is disabled)
+		return fmt.Errorf("failed to wait for runtime sync: %w", timeoutErr)
+	}
+
+	if timeoutErr == nil {
+		// the reporters rbac is only applied once the runtime is synced
+		verifyReportersAccess(ctx, opts)
+	}
+
+	if opts.NotifyWebhook != "" && ctx.Err() == nil {
+		sendInstallNotification(ctx, opts, timeoutErr)
+	}
 
 	// if we got to this point the runtime was installed successfully
 	// thus we shall not perform a rollback after this point.
 	opts.DisableRollback = true
 
 	if store.Get().SkipIngress {
-		handleCliStep(reporter.InstallStepCreateDefaultGitIntegration, "-skipped-", err, false, true)
-		handleCliStep(reporter.InstallStepRegisterToDefaultGitIntegration, "-skipped-", err, false, true)
+		handleCliStep(reporter.InstallStepCreateDefaultGitIntegration, "-skipped-", nil, false, true)
+		handleCliStep(reporter.InstallStepRegisterToDefaultGitIntegration, "-skipped-", nil, false, true)
 
 		var apiURL string
 		if opts.GitIntegrationCreationOpts.APIURL != nil {
@@ -725,31 +1672,58 @@ To complete the installation:
 			apiURL,
 			opts.GitIntegrationCreationOpts.Provider,
 			opts.RuntimeName))
-		summaryArr = append(summaryArr, summaryLog{skipIngressInfoMsg, Info})
+		summaryArr = append(summaryArr, summaryLog{skipIngressInfoMsg, Warning})
 	} else {
 		gitIntegrationErr := intervalCheckIsGitIntegrationCreated(ctx, opts)
 		if gitIntegrationErr != nil {
 			return gitIntegrationErr
 		}
+
+		if opts.SmokeTest != "" && ctx.Err() == nil {
+			if err := smokeTestAppProxy(ctx, opts); err != nil {
+				return err
+			}
+		}
 	}
 
-	installationSuccessMsg := fmt.Sprintf("Runtime \"%s\" installed successfully", opts.RuntimeName)
+	installationSuccessMsg := summaryLog{fmt.Sprintf("Runtime \"%s\" installed successfully", opts.RuntimeName), Info}
 	if timeoutErr != nil {
-		installationSuccessMsg = fmt.Sprintf("Runtime \"%s\" installed with some issues", opts.RuntimeName)
+		installationSuccessMsg = summaryLog{fmt.Sprintf("Runtime \"%s\" installed with some issues", opts.RuntimeName), Warning}
+	}
+
+	if err := clearInstallState(opts.RuntimeName); err != nil {
+		log.G(ctx).WithError(err).Debug("Failed to clear the installation state")
 	}
 
-	summaryArr = append(summaryArr, summaryLog{installationSuccessMsg, Info})
+	opts.installOutcome = &installationSuccessMsg
+	summaryArr = append(summaryArr, installationSuccessMsg)
 	return nil
 }
 
 func runtimeInstallPreparations(opts *RuntimeInstallOptions) (*runtime.Runtime, string, error) {
-	rt, err := runtime.Download(opts.Version, opts.RuntimeName)
+	rt, err := getRuntimeDefinition(opts)
 	handleCliStep(reporter.InstallStepDownloadRuntimeDefinition, "Downloading runtime definition", err, false, true)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to download runtime definition: %w", err)
 	}
 
-	server, err := util.KubeServerByContextName(opts.kubeContext, opts.kubeconfig)
+	for name, version := range opts.componentVersions {
+		if err = rt.Spec.SetComponentVersion(name, version); err != nil {
+			return nil, "", fmt.Errorf("failed to pin component version: %w", err)
+		}
+
+		log.G().Infof("Pinning component \"%s\" to version %s", name, version)
+	}
+
+	if err = validateSkipComponents(rt, opts.SkipComponents); err != nil {
+		return nil, "", err
+	}
+
+	if err = validateComponentResources(rt, opts.componentResources); err != nil {
+		return nil, "", err
+	}
+
+	server, err := getClusterServer(opts)
 	handleCliStep(reporter.InstallStepGetServerAddress, "Getting kube server address", err, false, true)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to get current server address: %w", err)
@@ -758,11 +1732,35 @@ func runtimeInstallPreparations(opts *RuntimeInstallOptions) (*runtime.Runtime,
 	return rt, server, nil
 }
 
+// getClusterServer returns the --cluster-server override, or the server of the kube context
+func getClusterServer(opts *RuntimeInstallOptions) (string, error) {
+	if opts.ClusterServer != "" {
+		log.G().Infof("Using cluster server \"%s\"", opts.ClusterServer)
+		return opts.ClusterServer, nil
+	}
+
+	return util.KubeServerByContextName(opts.kubeContext, opts.kubeconfig)
+}
+
+// getRuntimeDefinition loads the --runtime-def file when it is set, and downloads the definition otherwise
+func getRuntimeDefinition(opts *RuntimeInstallOptions) (*runtime.Runtime, error) {
+	if opts.RuntimeDef != "" {
+		return runtime.LoadDefinition(opts.RuntimeDef, opts.RuntimeName)
+	}
+
+	return runtime.Download(opts.Version, opts.RuntimeName)
+}
+
 func createRuntimeComponents(ctx context.Context, opts *RuntimeInstallOptions, rt *runtime.Runtime) error {
 	var err error
 
 	if !opts.FromRepo {
 		for _, component := range rt.Spec.Components {
+			if isComponentSkipped(opts, component.Name) {
+				log.G(ctx).Infof("Skipping component \"%s\"", component.Name)
+				continue
+			}
+
 			infoStr := fmt.Sprintf("Creating component \"%s\"", component.Name)
 			log.G(ctx).Infof(infoStr)
 			component.IsInternal = true
@@ -772,7 +1770,19 @@ func createRuntimeComponents(ctx context.Context, opts *RuntimeInstallOptions, r
 				break
 			}
 		}
-	}
+
+		if err == nil {
+			err = setComponentsResources(ctx, opts)
+		}
+
+		if err == nil {
+			err = setComponentsImageRegistry(ctx, opts, rt)
+		}
+
+		if err == nil {
+			err = setComponentsPlacement(ctx, opts, rt)
+		}
+	}
 
 	handleCliStep(reporter.InstallStepCreateComponents, "Creating components", err, false, true)
 	if err != nil {
@@ -802,54 +1812,95 @@ func createMasterIngressResource(ctx context.Context, opts *RuntimeInstallOption
 		return nil
 	}
 
-	r, fs, err := opts.InsCloneOpts.GetRepo(ctx)
+	r, fs, err := apu.GetRepo(ctx, opts.InsCloneOpts)
+	if err != nil {
+		return err
+	}
+
+	ingress, err := getMasterIngress(opts)
 	if err != nil {
 		return err
 	}
 
+	if err = fs.WriteYamls(fs.Join(store.Get().InClusterPath, "master-ingress.yaml"), ingress); err != nil {
+		return err
+	}
+
+	log.G(ctx).Info("Pushing Master Ingress Manifest")
+
+	return apu.PushWithMessage(ctx, r, "Created master ingress resource")
+}
+
+func getMasterIngress(opts *RuntimeInstallOptions) (*netv1.Ingress, error) {
 	ingressOptions := ingressutil.CreateIngressOptions{
 		Name:             opts.RuntimeName + store.Get().MasterIngressName,
 		Namespace:        opts.RuntimeName,
 		IngressClassName: opts.IngressClass,
 		Host:             opts.HostName,
+		AdditionalHosts:  opts.AdditionalHostNames,
 		Annotations: map[string]string{
 			"nginx.org/mergeable-ingress-type": "master",
 		},
 	}
 
 	if opts.ExternalIngressAnnotation != nil {
-		mergeAnnotations(ingressOptions.Annotations, opts.ExternalIngressAnnotation)
+		if err := mergeAnnotations(ingressOptions.Annotations, opts.ExternalIngressAnnotation); err != nil {
+			return nil, fmt.Errorf("invalid --external-ingress-annotation: %w", err)
+		}
 	}
 
-	ingress := ingressutil.CreateIngress(&ingressOptions)
-
-	if err = fs.WriteYamls(fs.Join(store.Get().InClusterPath, "master-ingress.yaml"), ingress); err != nil {
-		return err
+	if opts.MasterIngressAnnotation != nil {
+		if err := mergeAnnotations(ingressOptions.Annotations, opts.MasterIngressAnnotation); err != nil {
+			return nil, fmt.Errorf("invalid --master-ingress-annotation: %w", err)
+		}
 	}
 
-	log.G(ctx).Info("Pushing Master Ingress Manifest")
+	ingress := ingressutil.CreateIngress(&ingressOptions)
+	kubeutil.AddLabelsAndAnnotations(&ingress.ObjectMeta, opts.ResourceLabels, opts.ResourceAnnotations)
 
-	return apu.PushWithMessage(ctx, r, "Created master ingress resource")
+	return ingress, nil
 }
 
+var (
+	runGitSourceCreate         = RunGitSourceCreate
+	verifyMarketplaceGitSource = verifyMarketplaceGitSourceContent
+)
+
+const (
+	marketplaceGitSourceInclude = "workflows/**/*.yaml"
+	marketplaceGitSourceExclude = "**/images/**/*"
+)
+
 func createGitSources(ctx context.Context, opts *RuntimeInstallOptions) error {
 	var err error
 	var gitSrcMessage string
 	var createGitSrcMessgae string
 
 	if !opts.FromRepo {
+		var demoResourcesCloneOpts *apgit.CloneOptions
+		if opts.InstallDemoResources && opts.DemoResourcesRepo != "" {
+			demoResourcesCloneOpts = &apgit.CloneOptions{
+				Repo: opts.DemoResourcesRepo,
+				FS:   fs.Create(memfs.New()),
+				Auth: opts.GsCloneOpts.Auth,
+			}
+			demoResourcesCloneOpts.Parse()
+		}
+
 		gitSrcMessage = fmt.Sprintf("Creating git source \"%s\"", store.Get().GitSourceName)
-		err = RunGitSourceCreate(ctx, &GitSourceCreateOptions{
-			InsCloneOpts:        opts.InsCloneOpts,
-			GsCloneOpts:         opts.GsCloneOpts,
-			GsName:              store.Get().GitSourceName,
-			RuntimeName:         opts.RuntimeName,
-			CreateDemoResources: opts.InstallDemoResources,
-			HostName:            opts.HostName,
-			IngressHost:         opts.IngressHost,
-			IngressClass:        opts.IngressClass,
-			IngressController:   opts.IngressController,
-			Flow:                store.Get().InstallationFlow,
+		err = runGitSourceCreate(ctx, &GitSourceCreateOptions{
+			InsCloneOpts:           opts.InsCloneOpts,
+			GsCloneOpts:            opts.GsCloneOpts,
+			GsName:                 store.Get().GitSourceName,
+			RuntimeName:            opts.RuntimeName,
+			CreateDemoResources:    opts.InstallDemoResources,
+			HostName:               opts.HostName,
+			IngressHost:            opts.IngressHost,
+			IngressClass:           opts.IngressClass,
+			IngressController:      opts.IngressController,
+			Flow:                   store.Get().InstallationFlow,
+			DemoResourcesCloneOpts: demoResourcesCloneOpts,
+			DemoResourcesPath:      opts.DemoResourcesPath,
 		})
 	}
 	handleCliStep(reporter.InstallStepCreateGitsource, gitSrcMessage, err, false, true)
@@ -867,16 +1918,19 @@ func createGitSources(ctx context.Context, opts *RuntimeInstallOptions) error {
 
 			createGitSrcMessgae = fmt.Sprintf("Creating %s", store.Get().MarketplaceGitSourceName)
 
-			err = RunGitSourceCreate(ctx, &GitSourceCreateOptions{
+			err = runGitSourceCreate(ctx, &GitSourceCreateOptions{
 				InsCloneOpts:        opts.InsCloneOpts,
 				GsCloneOpts:         mpCloneOpts,
 				GsName:              store.Get().MarketplaceGitSourceName,
 				RuntimeName:         opts.RuntimeName,
 				CreateDemoResources: false,
-				Exclude:             "**/images/**/*",
-				Include:             "workflows/**/*.yaml",
+				Exclude:             marketplaceGitSourceExclude,
+				Include:             marketplaceGitSourceInclude,
 				Flow:                store.Get().InstallationFlow,
 			})
+			if err == nil {
+				verifyMarketplaceGitSource(ctx)
+			}
 		} else {
 			createGitSrcMessgae = fmt.Sprintf("Skipping %s with git provider %s", store.Get().MarketplaceGitSourceName, opts.gitProvider.Type())
 		}
@@ -889,6 +1943,63 @@ func createGitSources(ctx context.Context, opts *RuntimeInstallOptions) error {
 	return nil
 }
 
+// verifyMarketplaceGitSourceContent warns when the marketplace git source filtering matches no resources, so a
+// marketplace layout change does not silently yield an empty source
+func verifyMarketplaceGitSourceContent(ctx context.Context) {
+	cloneOpts := &apgit.CloneOptions{
+		Repo: store.Get().MarketplaceRepo,
+		FS:   fs.Create(memfs.New()),
+	}
+	cloneOpts.Parse()
+
+	_, repofs, err := cloneOpts.GetRepo(ctx)
+	if err != nil {
+		log.G(ctx).Warnf("Failed to verify the content of \"%s\": %v", store.Get().MarketplaceGitSourceName, err)
+		return
+	}
+
+	warnOnEmptyGitSource(ctx, repofs, store.Get().MarketplaceGitSourceName, marketplaceGitSourceInclude, marketplaceGitSourceExclude)
+}
+
+// warnOnEmptyGitSource warns when no file of the git source repo matches its include and exclude patterns,
+// which are matched the same way argo-cd matches them
+func warnOnEmptyGitSource(ctx context.Context, repofs fs.FS, gsName, include, exclude string) {
+	matched := 0
+	err := billyUtils.Walk(repofs, "/", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath := strings.TrimPrefix(path, "/")
+		if info.IsDir() {
+			if relPath == ".git" {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if exclude != "" && argoglob.Match(exclude, relPath) {
+			return nil
+		}
+
+		if include != "" && !argoglob.Match(include, relPath) {
+			return nil
+		}
+
+		matched++
+		return nil
+	})
+	if err != nil {
+		log.G(ctx).Warnf("Failed to verify the content of \"%s\": %v", gsName, err)
+		return
+	}
+
+	if matched == 0 {
+		log.G(ctx).Warnf("\"%s\" did not match any resource with include \"%s\" and exclude \"%s\", it will be empty", gsName, include, exclude)
+	}
+}
+
 func createGitIntegration(ctx context.Context, opts *RuntimeInstallOptions) error {
 	appProxyClient, err := cfConfig.NewClient().AppProxy(ctx, opts.RuntimeName, store.Get().InsecureIngressHost)
 	if err != nil {
@@ -901,6 +2012,12 @@ func createGitIntegration(ctx context.Context, opts *RuntimeInstallOptions) erro
 		return util.DecorateErrorWithDocsLink(fmt.Errorf("failed to create default git integration: %w", err))
 	}
 
+	if opts.GitIntegrationRegistrationOpts.Token == "" {
+		// a runtime with basic auth credentials has no token to register the user with
+		log.G(ctx).Info("Skipping the registration to the default git integration, no personal git token was provided")
+		return nil
+	}
+
 	err = registerUserToGitIntegration(ctx, appProxyClient, opts.RuntimeName, opts.GitIntegrationRegistrationOpts)
 	handleCliStep(reporter.InstallStepRegisterToDefaultGitIntegration, "Registering user to the default git integration", err, false, true)
 	if err != nil {
@@ -910,33 +2027,90 @@ func createGitIntegration(ctx context.Context, opts *RuntimeInstallOptions) erro
 	return nil
 }
 
-func intervalCheckIsGitIntegrationCreated(ctx context.Context, opts *RuntimeInstallOptions) error {
-	maxRetries := 6 // up to a minute
-	ticker := time.NewTicker(time.Second * 10)
-	defer ticker.Stop()
-	_, cancel := context.WithCancel(ctx)
-	defer cancel()
+var (
+	gitIntegrationCheckInterval    = 10 * time.Second
+	gitIntegrationMaxCheckInterval = 2 * time.Minute
 
-	for triesLeft := maxRetries; triesLeft > 0; triesLeft-- {
+	createGitIntegrationFunc = createGitIntegration
+
+	rateLimitMessageRegex = regexp.MustCompile(`(?i)rate.?limit|too many requests|\b429\b`)
+)
+
+func intervalCheckIsGitIntegrationCreated(ctx context.Context, opts *RuntimeInstallOptions) error {
+	interval := gitIntegrationCheckInterval
+	attempts, rateLimited := 0, 0
+	var lastErr error
+	for triesLeft := retriesForTimeout(opts.GitIntegrationTimeout, gitIntegrationCheckInterval); triesLeft > 0; {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-ticker.C:
+		case <-time.After(interval):
 		}
 
-		err := createGitIntegration(ctx, opts)
-		if err != nil {
-			if err == ctx.Err() {
-				return ctx.Err()
-			}
+		err := createGitIntegrationFunc(ctx, opts)
+		if err == nil {
+			return nil
+		}
 
-			log.G(ctx).Debugf("Retrying to create the default git integration. Error: %s", err.Error())
+		if err == ctx.Err() {
+			return ctx.Err()
+		}
+
+		attempts++
+		lastErr = err
+		if isRateLimitError(err) {
+			rateLimited++
+			log.G(ctx).Warnf("The git provider rate limited the default git integration creation, retrying in %s", nextGitIntegrationCheckInterval(interval, err))
 		} else {
-			return nil
+			log.G(ctx).Debugf("Retrying to create the default git integration. Error: %s", err.Error())
 		}
+
+		// a longer wait uses up the tries it spans, so the timeout still holds
+		triesLeft -= int(interval / gitIntegrationCheckInterval)
+		interval = nextGitIntegrationCheckInterval(interval, err)
+	}
+
+	if rateLimited > 0 {
+		return fmt.Errorf("timed out while waiting for git integration to be created, the git provider rate limited %d of %d attempts: %w", rateLimited, attempts, lastErr)
 	}
 
-	return fmt.Errorf("timed out while waiting for git integration to be created")
+	return fmt.Errorf("timed out while waiting for git integration to be created: %w", lastErr)
+}
+
+// nextGitIntegrationCheckInterval doubles the interval while the git provider rate limits the checks,
+// as every check makes the rate limit worse, and goes back to the default interval on any other failure
+func nextGitIntegrationCheckInterval(interval time.Duration, err error) time.Duration {
+	if !isRateLimitError(err) {
+		return gitIntegrationCheckInterval
+	}
+
+	interval *= 2
+	if interval > gitIntegrationMaxCheckInterval {
+		return gitIntegrationMaxCheckInterval
+	}
+
+	return interval
+}
+
+// isRateLimitError is true for a rate limit of the git provider, either from a direct api call,
+// or reported by the app-proxy as part of its error message
+func isRateLimitError(err error) bool {
+	var rlErr *cfgit.RateLimitError
+	if errors.As(err, &rlErr) {
+		return true
+	}
+
+	return err != nil && rateLimitMessageRegex.MatchString(err.Error())
+}
+
+// retriesForTimeout returns how many checks, one every interval, fit in the timeout. There is always at least one
+func retriesForTimeout(timeout, interval time.Duration) int {
+	retries := int(timeout / interval)
+	if retries < 1 {
+		return 1
+	}
+
+	return retries
 }
 
 func addDefaultGitIntegration(ctx context.Context, appProxyClient codefresh.AppProxyAPI, runtime string, opts *apmodel.AddGitIntegrationArgs) error {
@@ -999,22 +2173,31 @@ you can try to create it manually by running:
 func installComponents(ctx context.Context, opts *RuntimeInstallOptions, rt *runtime.Runtime) error {
 	var err error
 
-	if !store.Get().SkipIngress && rt.Spec.IngressController != string(ingressutil.IngressControllerALB) {
+	// must be configured before the overlays that may require it are pushed
+	if err = configureKustomizeBuildOptions(ctx, opts); err != nil {
+		return fmt.Errorf("failed to configure the argo-cd kustomize build options: %w", err)
+	}
+
+	if createsWorkflowsIngress(rt, opts) {
 		if err = createWorkflowsIngress(ctx, opts, rt); err != nil {
 			return fmt.Errorf("failed to patch Argo-Workflows ingress: %w", err)
 		}
 	}
 
-	if err = configureAppProxy(ctx, opts, rt); err != nil {
-		return fmt.Errorf("failed to patch App-Proxy ingress: %w", err)
+	if !isComponentSkipped(opts, "app-proxy") {
+		if err = configureAppProxy(ctx, opts, rt); err != nil {
+			return fmt.Errorf("failed to patch App-Proxy ingress: %w", err)
+		}
 	}
 
-	if err = createEventsReporter(ctx, opts.InsCloneOpts, opts); err != nil {
-		return fmt.Errorf("failed to create events-reporter: %w", err)
+	if createsReporter(rt, opts, store.Get().EventsReporterName) {
+		if err = createEventsReporter(ctx, opts.InsCloneOpts, opts); err != nil {
+			return fmt.Errorf("failed to create events-reporter: %w", err)
+		}
 	}
 
-	if err = createReporter(
-		ctx, opts.InsCloneOpts, opts, reporterCreateOptions{
+	if createsReporter(rt, opts, store.Get().WorkflowReporterName) {
+		err = createReporter(ctx, opts.InsCloneOpts, opts, reporterCreateOptions{
 			reporterName: store.Get().WorkflowReporterName,
 			gvr: []gvr{
 				{
@@ -1023,36 +2206,44 @@ func installComponents(ctx context.Context, opts *RuntimeInstallOptions, rt *run
 					version:      "v1alpha1",
 				},
 			},
-			saName:     store.Get().CodefreshSA,
-			IsInternal: true,
-		}); err != nil {
-		return fmt.Errorf("failed to create workflows-reporter: %w", err)
+			saName:             store.Get().WorkflowReporterServiceAccount,
+			createsWorkflowsSA: true,
+			IsInternal:         true,
+			triggerDataKey:     store.Get().ResourceReporterTriggerDataKey,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create workflows-reporter: %w", err)
+		}
 	}
 
-	if err = createReporter(ctx, opts.InsCloneOpts, opts, reporterCreateOptions{
-		reporterName: store.Get().RolloutReporterName,
-		gvr: []gvr{
-			{
-				resourceName: store.Get().RolloutResourceName,
-				group:        "argoproj.io",
-				version:      "v1alpha1",
-			},
-			{
-				resourceName: store.Get().ReplicaSetResourceName,
-				group:        "apps",
-				version:      "v1",
-			},
-			{
-				resourceName: store.Get().AnalysisRunResourceName,
-				group:        "argoproj.io",
-				version:      "v1alpha1",
+	if createsReporter(rt, opts, store.Get().RolloutReporterName) {
+		err = createReporter(ctx, opts.InsCloneOpts, opts, reporterCreateOptions{
+			reporterName: store.Get().RolloutReporterName,
+			gvr: []gvr{
+				{
+					resourceName: store.Get().RolloutResourceName,
+					group:        "argoproj.io",
+					version:      "v1alpha1",
+				},
+				{
+					resourceName: store.Get().ReplicaSetResourceName,
+					group:        "apps",
+					version:      "v1",
+				},
+				{
+					resourceName: store.Get().AnalysisRunResourceName,
+					group:        "argoproj.io",
+					version:      "v1alpha1",
+				},
 			},
-		},
-		saName:       store.Get().RolloutReporterServiceAccount,
-		IsInternal:   true,
-		clusterScope: true,
-	}); err != nil {
-		return fmt.Errorf("failed to create rollout-reporter: %w", err)
+			saName:         store.Get().RolloutReporterServiceAccount,
+			IsInternal:     true,
+			clusterScope:   true,
+			triggerDataKey: store.Get().ResourceReporterTriggerDataKey,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create rollout-reporter: %w", err)
+		}
 	}
 
 	return nil
@@ -1070,26 +2261,51 @@ func preInstallationChecks(ctx context.Context, opts *RuntimeInstallOptions) err
 		return err
 	}
 
-	rt, err := runtime.Download(opts.Version, opts.RuntimeName)
+	rt, err := getRuntimeDefinition(opts)
 	handleCliStep(reporter.InstallStepRunPreCheckDownloadRuntimeDefinition, "Downloading runtime definition", err, true, true)
 	if err != nil {
 		return fmt.Errorf("failed to download runtime definition: %w", err)
 	}
 
-	if rt.Spec.DefVersion.GreaterThan(store.Get().MaxDefVersion) {
-		err = fmt.Errorf("your cli version is out of date. please upgrade to the latest version before installing")
+	compatibilityReport, compatible := definitionCompatibilityReport(rt.Spec.DefVersion, store.Get().MaxDefVersion)
+	log.G(ctx).Info(compatibilityReport)
+	if !compatible {
+		err = fmt.Errorf("your cli version is out of date. please upgrade to the latest version before installing (%s)", compatibilityReport)
 	}
 	handleCliStep(reporter.InstallStepRunPreCheckEnsureCliVersion, "Checking CLI version", err, true, false)
 	if err != nil {
 		return util.DecorateErrorWithDocsLink(err, store.Get().DownloadCliLink)
 	}
 
+	err = checkIngressControllerSupport(rt, opts)
+	handleCliStep(reporter.InstallStepRunPreCheckIngressControllerSupport, "Checking ingress controller support", err, true, false)
+	if err != nil {
+		return err
+	}
+
 	err = checkRuntimeCollisions(ctx, opts.KubeFactory, opts.RuntimeName)
 	handleCliStep(reporter.InstallStepRunPreCheckRuntimeCollision, "Checking for runtime collisions", err, true, false)
 	if err != nil {
 		return fmt.Errorf("runtime collision check failed: %w", err)
 	}
 
+	cs, err := opts.KubeFactory.KubernetesClientSet()
+	if err == nil {
+		err = checkNamespaceTerminating(ctx, cs, opts.RuntimeName)
+	}
+	handleCliStep(reporter.InstallStepRunPreCheckNamespaceTerminating, "Checking the runtime namespace is not terminating", err, true, false)
+	if err != nil {
+		return err
+	}
+
+	if opts.UseExistingNamespace {
+		err = checkExistingNamespace(ctx, opts)
+		handleCliStep(reporter.InstallStepRunPreCheckExistingNamespace, "Checking the runtime namespace exists", err, true, false)
+		if err != nil {
+			return err
+		}
+	}
+
 	if !opts.FromRepo {
 		err = checkExistingRuntimes(ctx, opts.RuntimeName)
 	}
@@ -1106,9 +2322,44 @@ func preInstallationChecks(ctx context.Context, opts *RuntimeInstallOptions) err
 		return fmt.Errorf("validation of minimum cluster requirements failed: %w", err)
 	}
 
+	if !opts.SkipClusterChecks && !opts.FromRepo {
+		err = kubeutil.CheckArgoCDCRDs(ctx, opts.KubeFactory.KubernetesClientSetOrDie())
+	}
+	handleCliStep(reporter.InstallStepRunPreCheckArgoCDCRDs, "Checking existing Argo CD CRDs", err, true, false)
+	if err != nil {
+		return fmt.Errorf("argo cd crds check failed: %w", err)
+	}
+
 	return nil
 }
 
+// definitionCompatibilityReport describes how the runtime definition version relates to the max definition version
+// the cli supports, and whether the cli can install it
+func definitionCompatibilityReport(defVersion, maxDefVersion *semver.Version) (string, bool) {
+	report := fmt.Sprintf("Runtime definition version: %s, max definition version supported by the cli: %s", defVersion, maxDefVersion)
+	switch defVersion.Compare(maxDefVersion) {
+	case 1:
+		return report + " - the cli is behind the definition", false
+	case 0:
+		return report + " - the cli is up to date with the definition", true
+	default:
+		return report + " - the cli is ahead of the definition", true
+	}
+}
+
+func checkIngressControllerSupport(rt *runtime.Runtime, opts *RuntimeInstallOptions) error {
+	if store.Get().SkipIngress || opts.IngressController == nil {
+		return nil
+	}
+
+	controller := opts.IngressController.Name()
+	if rt.Spec.SupportsIngressController(controller) {
+		return nil
+	}
+
+	return fmt.Errorf("ingress controller \"%s\" is not supported by runtime version %s (supported: %s). use --version to install a runtime version that supports it, or select a different --ingress-class", controller, rt.Spec.Version, strings.Join(rt.Spec.SupportedIngressControllers, ", "))
+}
+
 func checkIscProvider(ctx context.Context, opts *apgit.CloneOptions) error {
 	iscRepo, err := getIscRepo(ctx)
 	if err != nil {
@@ -1124,7 +2375,7 @@ func checkIscProvider(ctx context.Context, opts *apgit.CloneOptions) error {
 		return fmt.Errorf("failed to check account git provider: %w", err)
 	}
 
-	insUrl, err := url.Parse(opts.URL())
+	insUrl, err := url.Parse(cfgit.HTTPSRepoURL(opts.URL()))
 	if err != nil {
 		return fmt.Errorf("failed to check account git provider: %w", err)
 	}
@@ -1180,6 +2431,76 @@ func checkRuntimeCollisions(ctx context.Context, kube kube.Factory, runtime stri
 	return fmt.Errorf("argo-cd is already installed on this cluster in namespace \"%s\", you can uninstall it by running '%s runtime uninstall %s --skip-checks --force'", subjNamespace, store.Get().BinaryName, subjNamespace)
 }
 
+// getBootstrapAppSpecifier returns where the argo-cd manifests of the repo bootstrap are taken from
+func getBootstrapAppSpecifier(rt *runtime.Runtime, opts *RuntimeInstallOptions) string {
+	if opts.BootstrapSpecifier != "" {
+		return opts.BootstrapSpecifier
+	}
+
+	if opts.FromRepo {
+		// installing argocd with manifests from the provided repo
+		return opts.InsCloneOpts.Repo + "/bootstrap/argo-cd"
+	}
+
+	return rt.Spec.FullSpecifier()
+}
+
+// checkExistingNamespace fails when --use-existing-namespace is set, but the runtime namespace was not created
+func checkExistingNamespace(ctx context.Context, opts *RuntimeInstallOptions) error {
+	exists, err := kubeutil.CheckNamespaceExists(ctx, opts.RuntimeName, opts.KubeFactory)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return fmt.Errorf("namespace \"%s\" does not exist, it must be created before installing with --use-existing-namespace", opts.RuntimeName)
+	}
+
+	return nil
+}
+
+// checkNamespaceTerminating fails when the runtime namespace is still being deleted (usually by a previous uninstall),
+// since every resource created in it would be rejected
+func checkNamespaceTerminating(ctx context.Context, cs kubernetes.Interface, namespace string) error {
+	ns, err := cs.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to get namespace \"%s\": %w", namespace, err)
+	}
+
+	if ns.Status.Phase != v1.NamespaceTerminating {
+		return nil
+	}
+
+	var details []string
+	for _, cond := range ns.Status.Conditions {
+		if cond.Status == v1.ConditionTrue && cond.Message != "" {
+			details = append(details, cond.Message)
+		}
+	}
+
+	var finalizers []string
+	finalizers = append(finalizers, ns.Finalizers...)
+	for _, f := range ns.Spec.Finalizers {
+		finalizers = append(finalizers, string(f))
+	}
+
+	msg := fmt.Sprintf("namespace \"%s\" is terminating, probably left over from a previous uninstall", namespace)
+	if len(details) > 0 {
+		msg += fmt.Sprintf(" (%s)", strings.Join(details, "; "))
+	}
+
+	msg += ". wait for the deletion to complete, or install the runtime with a different name"
+	if len(finalizers) > 0 {
+		msg += fmt.Sprintf(". if it is stuck, remove the remaining finalizers (%s) from the namespace and the resources left in it", strings.Join(finalizers, ", "))
+	}
+
+	return errors.New(msg)
+}
+
 func checkExistingRuntimes(ctx context.Context, runtime string) error {
 	_, err := cfConfig.NewClient().V2().Runtime().Get(ctx, runtime)
 	if err != nil {
@@ -1190,12 +2511,13 @@ func checkExistingRuntimes(ctx context.Context, runtime string) error {
 		return fmt.Errorf("failed to get runtime: %w", err)
 	}
 
-	return fmt.Errorf("runtime \"%s\" already exists", runtime)
+	return fmt.Errorf("runtime \"%s\" %w", runtime, errRuntimeExists)
 }
 
-func printComponentsState(ctx context.Context, runtime string) error {
+func printComponentsState(ctx context.Context, runtime string, columns []int, cs kubernetes.Interface) error {
 	components := map[string]model.Component{}
 	lock := sync.Mutex{}
+	progressing := newProgressingComponents(cs, runtime)
 
 	curComponents, err := cfConfig.NewClient().V2().Component().List(ctx, runtime)
 	if err != nil {
@@ -1227,6 +2549,8 @@ func printComponentsState(ctx context.Context, runtime string) error {
 				components[c.Metadata.Name] = c
 			}
 			lock.Unlock()
+
+			progressing.update(ctx, curComponents, time.Now())
 		}
 	}()
 
@@ -1236,7 +2560,9 @@ func printComponentsState(ctx context.Context, runtime string) error {
 		checkers[i] = func(_ context.Context) (checklist.ListItemState, checklist.ListItemInfo) {
 			lock.Lock()
 			defer lock.Unlock()
-			return getComponentChecklistState(components[name])
+			state, info := getComponentChecklistState(components[name])
+			state, info = progressing.apply(name, state, info)
+			return state, selectComponentColumns(info, columns)
 		}
 	}
 
@@ -1244,7 +2570,7 @@ func printComponentsState(ctx context.Context, runtime string) error {
 
 	cl := checklist.NewCheckList(
 		os.Stdout,
-		checklist.ListItemInfo{"COMPONENT", "HEALTH STATUS", "SYNC STATUS", "VERSION", "ERRORS"},
+		componentColumnsHeader(columns),
 		checkers,
 		&checklist.CheckListOptions{
 			Interval:     1 * time.Second,
@@ -1259,14 +2585,14 @@ func printComponentsState(ctx context.Context, runtime string) error {
 	return nil
 }
 
-func intervalCheckIsRuntimePersisted(ctx context.Context, runtimeName string) error {
+func intervalCheckIsRuntimePersisted(ctx context.Context, runtimeName string, checklistColumns []int, cs kubernetes.Interface, failFastOnDegraded bool) error {
 	maxRetries := 48 // up to 8 min
 	ticker := time.NewTicker(time.Second * 10)
 	defer ticker.Stop()
 	subCtx, cancel := context.WithCancel(ctx)
 
 	go func() {
-		if err := printComponentsState(subCtx, runtimeName); err != nil {
+		if err := printComponentsState(subCtx, runtimeName, checklistColumns, cs); err != nil {
 			log.G(ctx).WithError(err).Error("failed to print components state")
 		}
 	}()
@@ -1286,99 +2612,184 @@ func intervalCheckIsRuntimePersisted(ctx context.Context, runtimeName string) er
 			}
 
 			log.G(ctx).Debugf("retrying the call to graphql API. Error: %s", err.Error())
-		} else if runtime.InstallationStatus == model.InstallationStatusCompleted {
-			return nil
+			continue
+		}
+
+		var components []model.Component
+		if failFastOnDegraded {
+			components, err = cfConfig.NewClient().V2().Component().List(ctx, runtimeName)
+			if err != nil {
+				log.G(ctx).Debugf("failed to get the runtime components state. Error: %s", err.Error())
+			}
+		}
+
+		done, err := checkRuntimeSynced(runtime, components)
+		if err != nil || done {
+			return err
 		}
 	}
 
 	return fmt.Errorf("timed out while waiting for runtime installation to complete")
 }
 
-func RunRuntimeList(ctx context.Context) error {
-	runtimes, err := cfConfig.NewClient().V2().Runtime().List(ctx)
-	if err != nil {
-		return err
+// componentDegradedError is returned by the sync wait with --fail-fast-on-degraded, when one of the components is degraded
+type componentDegradedError struct {
+	component string
+	details   []string
+}
+
+func (e *componentDegradedError) Error() string {
+	msg := fmt.Sprintf("component \"%s\" is degraded", e.component)
+	if len(e.details) > 0 {
+		msg = fmt.Sprintf("%s: %s", msg, strings.Join(e.details, "; "))
+	}
+
+	return msg
+}
+
+// checkRuntimeSynced returns true once the runtime installation is completed. Before that,
+// it fails on the first degraded component, so the wait does not last until the timeout
+func checkRuntimeSynced(runtime *model.Runtime, components []model.Component) (bool, error) {
+	if runtime.InstallationStatus == model.InstallationStatusCompleted {
+		return true, nil
+	}
+
+	for _, c := range components {
+		if !isComponentDegraded(c) {
+			continue
+		}
+
+		_, info := getComponentChecklistState(c)
+		return false, &componentDegradedError{component: info[componentInfoName], details: componentErrorDetails(c)}
 	}
 
-	if len(runtimes) == 0 {
-		log.G(ctx).Info("No runtimes were found")
+	return false, nil
+}
+
+func addNamespaceFinalizers(ctx context.Context, opts *RuntimeInstallOptions) error {
+	if len(opts.NamespaceFinalizers) == 0 {
 		return nil
 	}
 
-	tb := ansiterm.NewTabWriter(os.Stdout, 0, 0, 4, ' ', 0)
-	_, err = fmt.Fprintln(tb, "NAME\tNAMESPACE\tCLUSTER\tVERSION\tSYNC_STATUS\tHEALTH_STATUS\tHEALTH_MESSAGE\tINSTALLATION_STATUS\tINGRESS_HOST\tINGRESS_CLASS")
+	cs, err := opts.KubeFactory.KubernetesClientSet()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to create kubernetes client: %w", err)
 	}
 
-	for _, rt := range runtimes {
-		name := rt.Metadata.Name
-		namespace := "N/A"
-		cluster := "N/A"
-		version := "N/A"
-		syncStatus := rt.SyncStatus
-		healthStatus := rt.HealthStatus
-		healthMessage := "N/A"
-		installationStatus := rt.InstallationStatus
-		ingressHost := "N/A"
-		internalIngressHost := "N/A"
-		ingressClass := "N/A"
+	if err = kubeutil.AddNamespaceFinalizers(ctx, cs, opts.RuntimeName, opts.NamespaceFinalizers); err != nil {
+		return fmt.Errorf("failed to add the namespace finalizers: %w", err)
+	}
 
-		if rt.Managed {
-			name = fmt.Sprintf("%s (hosted)", rt.Metadata.Name)
-		}
+	return nil
+}
 
-		if rt.Metadata.Namespace != nil {
-			namespace = *rt.Metadata.Namespace
-		}
+// retainExistingNamespace marks the namespace manifest of the bootstrap, so argo-cd never deletes the existing namespace,
+// neither when it is pruned nor when the runtime is uninstalled
+func retainExistingNamespace(ctx context.Context, opts *RuntimeInstallOptions) error {
+	r, repofs, err := getInstallRepo(ctx, opts)
+	if err != nil {
+		return err
+	}
 
-		if rt.Cluster != nil {
-			cluster = *rt.Cluster
-		}
+	nsPath := repofs.Join(apstore.Default.BootsrtrapDir, apstore.Default.ClusterResourcesDir, apstore.Default.ClusterContextName, opts.RuntimeName+"-ns.yaml")
+	ns := &v1.Namespace{}
+	if err = repofs.ReadYamls(nsPath, ns); err != nil {
+		return fmt.Errorf("failed to read the runtime namespace manifest: %w", err)
+	}
 
-		if rt.RuntimeVersion != nil {
-			version = *rt.RuntimeVersion
-		}
+	if ns.Annotations == nil {
+		ns.Annotations = map[string]string{}
+	}
 
-		if rt.HealthMessage != nil {
-			healthMessage = *rt.HealthMessage
-		}
+	ns.Annotations["argocd.argoproj.io/sync-options"] = "Prune=false,Delete=false"
+	if err = repofs.WriteYamls(nsPath, ns); err != nil {
+		return fmt.Errorf("failed to write the runtime namespace manifest: %w", err)
+	}
 
-		if rt.IngressHost != nil {
-			ingressHost = *rt.IngressHost
-		}
+	log.G(ctx).Infof("Pushing the retained runtime namespace \"%s\"", opts.RuntimeName)
 
-		if rt.InternalIngressHost != nil {
-			internalIngressHost = *rt.InternalIngressHost
-		}
+	return apu.PushWithMessage(ctx, r, "Retain the existing runtime namespace")
+}
 
-		if rt.IngressClass != nil {
-			ingressClass = *rt.IngressClass
-		}
+// mergeNamespaceLabels adds the --namespace-labels to the existing runtime namespace, without changing its own labels
+func mergeNamespaceLabels(ctx context.Context, opts *RuntimeInstallOptions) error {
+	cs, err := opts.KubeFactory.KubernetesClientSet()
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
 
-		_, err = fmt.Fprintf(tb, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-			name,
-			namespace,
-			cluster,
-			version,
-			syncStatus,
-			healthStatus,
-			healthMessage,
-			installationStatus,
-			ingressHost,
-			internalIngressHost,
-			ingressClass,
-		)
-		if err != nil {
-			return err
-		}
+	if err = kubeutil.MergeNamespaceLabels(ctx, cs, opts.RuntimeName, opts.NamespaceLabels); err != nil {
+		return fmt.Errorf("failed to merge the namespace labels: %w", err)
 	}
 
-	return tb.Flush()
+	return nil
 }
 
-func RunRuntimeUninstall(ctx context.Context, opts *RuntimeUninstallOptions) error {
-	defer printSummaryToUser()
+// removeNamespaceFinalizers removes the finalizers set with --namespace-finalizers, so the namespace is not left terminating.
+// it is called after the runtime resources are deleted, so the teardown hooks of the finalizers had a chance to run
+func removeNamespaceFinalizers(ctx context.Context, opts *RuntimeUninstallOptions) {
+	cs, err := opts.KubeFactory.KubernetesClientSet()
+	if err == nil {
+		err = kubeutil.RemoveNamespaceFinalizers(ctx, cs, opts.RuntimeName)
+	}
+
+	if err != nil {
+		log.G(ctx).WithError(err).Warn("Failed to remove the namespace finalizers")
+	}
+}
+
+// reportUnreadyComponents adds the components that are not healthy and synced to the summary, after the sync wait timed out
+func reportUnreadyComponents(ctx context.Context, runtimeName string) {
+	components, err := cfConfig.NewClient().V2().Component().List(ctx, runtimeName)
+	if err != nil {
+		log.G(ctx).WithError(err).Warn("Failed to get the runtime components state")
+		return
+	}
+
+	summaryArr = append(summaryArr, unreadyComponentsSummary(components)...)
+}
+
+func unreadyComponentsSummary(components []model.Component) []summaryLog {
+	logs := []summaryLog{}
+	for _, c := range components {
+		state, info := getComponentChecklistState(c)
+		if state == checklist.Ready {
+			continue
+		}
+
+		msg := fmt.Sprintf("Component \"%s\" is not ready (health: %s, sync: %s)", info[componentInfoName], info[componentInfoHealth], info[componentInfoSync])
+		if details := componentErrorDetails(c); len(details) > 0 {
+			msg = fmt.Sprintf("%s: %s", msg, strings.Join(details, "; "))
+		}
+
+		logs = append(logs, summaryLog{msg, Warning})
+	}
+
+	return logs
+}
+
+// componentErrorDetails returns the health message and the sync errors the platform reported for a component
+func componentErrorDetails(c model.Component) []string {
+	if c.Self == nil {
+		return nil
+	}
+
+	var details []string
+	if c.Self.Status != nil && c.Self.Status.HealthMessage != nil && *c.Self.Status.HealthMessage != "" {
+		details = append(details, fmt.Sprintf("health message: %s", *c.Self.Status.HealthMessage))
+	}
+
+	for _, err := range c.Self.Errors {
+		if se, ok := err.(model.SyncError); ok && se.Message != "" {
+			details = append(details, fmt.Sprintf("%s: %s", strings.ToLower(string(se.Level)), se.Message))
+		}
+	}
+
+	return details
+}
+
+func RunRuntimeUninstall(ctx context.Context, opts *RuntimeUninstallOptions) error {
+	defer printSummaryToUser()
 
 	handleCliStep(reporter.UninstallPhaseStart, "Uninstall phase started", nil, false, false)
 
@@ -1416,8 +2827,10 @@ func RunRuntimeUninstall(ctx context.Context, opts *RuntimeUninstallOptions) err
 
 	if !opts.skipAutopilotUninstall {
 		subCtx, cancel := context.WithCancel(ctx)
+		progressDone := make(chan struct{})
 		go func() {
-			if err := printApplicationsState(subCtx, opts.RuntimeName, opts.KubeFactory, opts.Managed); err != nil {
+			defer close(progressDone)
+			if err := printApplicationsState(subCtx, opts.RuntimeName, opts.KubeFactory, opts.Managed, uninstallProgressInterval(opts)); err != nil {
 				log.G(ctx).WithError(err).Debug("failed to print uninstallation progress")
 			}
 		}()
@@ -1432,8 +2845,10 @@ func RunRuntimeUninstall(ctx context.Context, opts *RuntimeUninstallOptions) err
 				Force:           opts.Force,
 				FastExit:        opts.FastExit,
 			})
+			removeNamespaceFinalizers(ctx, opts)
 		}
 		cancel() // to tell the progress to stop displaying even if it's not finished
+		<-progressDone
 		if opts.Force {
 			err = nil
 		}
@@ -1484,7 +2899,7 @@ func RunRuntimeUpgrade(ctx context.Context, opts *RuntimeUpgradeOptions) error {
 	}
 
 	log.G(ctx).Info("Cloning installation repository")
-	r, fs, err := opts.CloneOpts.GetRepo(ctx)
+	r, fs, err := apu.GetRepo(ctx, opts.CloneOpts)
 	handleCliStep(reporter.UpgradeStepGetRepo, "Getting repository", err, true, false)
 	if err != nil {
 		return err
@@ -1537,7 +2952,7 @@ func RunRuntimeUpgrade(ctx context.Context, opts *RuntimeUpgradeOptions) error {
 }
 
 func persistRuntime(ctx context.Context, cloneOpts *apgit.CloneOptions, rt *runtime.Runtime, rtConf *runtime.CommonConfig) error {
-	r, fs, err := cloneOpts.GetRepo(ctx)
+	r, fs, err := apu.GetRepo(ctx, cloneOpts)
 	if err != nil {
 		return err
 	}
@@ -1556,7 +2971,7 @@ func persistRuntime(ctx context.Context, cloneOpts *apgit.CloneOptions, rt *runt
 }
 
 func createWorkflowsIngress(ctx context.Context, opts *RuntimeInstallOptions, rt *runtime.Runtime) error {
-	r, fs, err := opts.InsCloneOpts.GetRepo(ctx)
+	r, fs, err := getInstallRepo(ctx, opts)
 	if err != nil {
 		return err
 	}
@@ -1567,6 +2982,7 @@ func createWorkflowsIngress(ctx context.Context, opts *RuntimeInstallOptions, rt
 		Namespace:        rt.Namespace,
 		IngressClassName: opts.IngressClass,
 		Host:             opts.HostName,
+		AdditionalHosts:  opts.AdditionalHostNames,
 		Annotations: map[string]string{
 			"ingress.kubernetes.io/protocol":               "https",
 			"ingress.kubernetes.io/rewrite-target":         "/$2",
@@ -1584,13 +3000,19 @@ func createWorkflowsIngress(ctx context.Context, opts *RuntimeInstallOptions, rt
 	}
 
 	if opts.ExternalIngressAnnotation != nil {
-		mergeAnnotations(ingressOptions.Annotations, opts.ExternalIngressAnnotation)
+		if err = mergeAnnotations(ingressOptions.Annotations, opts.ExternalIngressAnnotation); err != nil {
+			return fmt.Errorf("invalid --external-ingress-annotation: %w", err)
+		}
 	}
 
 	ingress := ingressutil.CreateIngress(&ingressOptions)
-	opts.IngressController.Decorate(ingress)
+	opts.IngressController.Decorate(ingress, util.IsSecureHost(opts.IngressHost))
+	setIngressCertManagerTLS(ingress, opts.CertManagerIssuer)
+	kubeutil.AddLabelsAndAnnotations(&ingress.ObjectMeta, opts.ResourceLabels, opts.ResourceAnnotations)
 
-	if err = fs.WriteYamls(fs.Join(overlaysDir, "ingress.yaml"), ingress); err != nil {
+	// e.g. the traefik middleware that replaces the rewrite annotations
+	resources := append([]interface{}{ingress}, ingressutil.IngressResources(opts.IngressController, ingress)...)
+	if err = fs.WriteYamls(fs.Join(overlaysDir, "ingress.yaml"), resources...); err != nil {
 		return err
 	}
 
@@ -1603,37 +3025,106 @@ func createWorkflowsIngress(ctx context.Context, opts *RuntimeInstallOptions, rt
 		return err
 	}
 
-	kust.Resources = append(kust.Resources, "ingress.yaml")
+	// the overlay already has the ingress, when it is recreated by "runtime ingress sync"
+	if util.StringIndexOf(kust.Resources, "ingress.yaml") == -1 {
+		kust.Resources = append(kust.Resources, "ingress.yaml")
+		kust.Patches = append(kust.Patches, kusttypes.Patch{
+			Target: &kusttypes.Selector{
+				ResId: kustid.ResId{
+					Gvk: kustid.Gvk{
+						Group:   appsv1.SchemeGroupVersion.Group,
+						Version: appsv1.SchemeGroupVersion.Version,
+						Kind:    "Deployment",
+					},
+					Name: store.Get().ArgoWFServiceName,
+				},
+			},
+			Path: "ingress-patch.json",
+		})
+	}
+
+	// e.g. traefik reaches the https argo-server only when its service is annotated with the backend scheme
+	if err = addWorkflowsServicePatch(fs, kust, overlaysDir, ingressutil.BackendAnnotations(opts.IngressController, ingress)); err != nil {
+		return err
+	}
+
+	if err = kustutil.WriteKustomization(fs, kust, overlaysDir); err != nil {
+		return err
+	}
+
+	log.G(ctx).Info("Pushing Argo Workflows ingress manifests")
+
+	return apu.PushWithMessage(ctx, r, "Created Workflows Ingress")
+}
+
+func addWorkflowsServicePatch(repofs fs.FS, kust *kusttypes.Kustomization, overlaysDir string, annotations map[string]string) error {
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	patch := &v1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        store.Get().ArgoWFServiceName,
+			Annotations: annotations,
+		},
+	}
+	if err := repofs.WriteYamls(repofs.Join(overlaysDir, "service-patch.yaml"), patch); err != nil {
+		return err
+	}
+
+	for _, p := range kust.Patches {
+		if p.Path == "service-patch.yaml" {
+			return nil
+		}
+	}
+
 	kust.Patches = append(kust.Patches, kusttypes.Patch{
 		Target: &kusttypes.Selector{
 			ResId: kustid.ResId{
 				Gvk: kustid.Gvk{
-					Group:   appsv1.SchemeGroupVersion.Group,
-					Version: appsv1.SchemeGroupVersion.Version,
-					Kind:    "Deployment",
+					Version: "v1",
+					Kind:    "Service",
 				},
 				Name: store.Get().ArgoWFServiceName,
 			},
 		},
-		Path: "ingress-patch.json",
+		Path: "service-patch.yaml",
 	})
-	if err = kustutil.WriteKustomization(fs, kust, overlaysDir); err != nil {
-		return err
-	}
 
-	log.G(ctx).Info("Pushing Argo Workflows ingress manifests")
-
-	return apu.PushWithMessage(ctx, r, "Created Workflows Ingress")
+	return nil
 }
 
-func mergeAnnotations(annotation map[string]string, newAnnotation map[string]string) {
+func mergeAnnotations(annotation map[string]string, newAnnotation map[string]string) error {
 	for key, element := range newAnnotation {
 		annotation[key] = element
 	}
+
+	return kubeutil.ValidateAnnotationsSize(annotation)
+}
+
+// validateIngressAnnotations fails early on annotations that the api-server would reject when they are applied
+func validateIngressAnnotations(opts *RuntimeInstallOptions) error {
+	if err := kubeutil.ValidateAnnotationsSize(opts.ExternalIngressAnnotation); err != nil {
+		return fmt.Errorf("invalid --external-ingress-annotation: %w", err)
+	}
+
+	if err := kubeutil.ValidateAnnotationsSize(opts.InternalIngressAnnotation); err != nil {
+		return fmt.Errorf("invalid --internal-ingress-annotation: %w", err)
+	}
+
+	if err := kubeutil.ValidateAnnotationsSize(opts.MasterIngressAnnotation); err != nil {
+		return fmt.Errorf("invalid --master-ingress-annotation: %w", err)
+	}
+
+	return nil
 }
 
 func configureAppProxy(ctx context.Context, opts *RuntimeInstallOptions, rt *runtime.Runtime) error {
-	r, fs, err := opts.InsCloneOpts.GetRepo(ctx)
+	r, fs, err := getInstallRepo(ctx, opts)
 	if err != nil {
 		return err
 	}
@@ -1652,8 +3143,8 @@ func configureAppProxy(ctx context.Context, opts *RuntimeInstallOptions, rt *run
 		"env=production",
 	}
 
-	// configure codefresh host
-	kust.ConfigMapGenerator = append(kust.ConfigMapGenerator, kusttypes.ConfigMapArgs{
+	// configure codefresh host, replacing the generator of a previous run when it is reconfigured by "runtime ingress sync"
+	appProxyCMGenerator := kusttypes.ConfigMapArgs{
 		GeneratorArgs: kusttypes.GeneratorArgs{
 			Name:     store.Get().AppProxyServiceName + "-cm",
 			Behavior: "merge",
@@ -1661,42 +3152,59 @@ func configureAppProxy(ctx context.Context, opts *RuntimeInstallOptions, rt *run
 				LiteralSources: literalResources,
 			},
 		},
-	})
+	}
+	kust.ConfigMapGenerator = setConfigMapGenerator(kust.ConfigMapGenerator, appProxyCMGenerator)
 
+	ingressHost := opts.IngressHost
 	hostName := opts.HostName
+	additionalHostNames := opts.AdditionalHostNames
 	if opts.InternalHostName != "" {
+		ingressHost = opts.InternalIngressHost
 		hostName = opts.InternalHostName
+		additionalHostNames = opts.AdditionalInternalHostNames
 	}
 
 	if !store.Get().SkipIngress {
+		serviceName, servicePort := getAppProxyService(opts)
 		ingressOptions := ingressutil.CreateIngressOptions{
 			Name:             rt.Name + store.Get().AppProxyIngressName,
 			Namespace:        rt.Namespace,
 			IngressClassName: opts.IngressClass,
 			Host:             hostName,
+			AdditionalHosts:  additionalHostNames,
 			Paths: []ingressutil.IngressPath{
 				{
 					Path:        store.Get().AppProxyIngressPath,
 					PathType:    netv1.PathTypePrefix,
-					ServiceName: store.Get().AppProxyServiceName,
-					ServicePort: store.Get().AppProxyServicePort,
+					ServiceName: serviceName,
+					ServicePort: servicePort,
 				},
 			},
 		}
 
 		if opts.InternalIngressAnnotation != nil {
 			ingressOptions.Annotations = make(map[string]string)
-			mergeAnnotations(ingressOptions.Annotations, opts.InternalIngressAnnotation)
+			if err = mergeAnnotations(ingressOptions.Annotations, opts.InternalIngressAnnotation); err != nil {
+				return fmt.Errorf("invalid --internal-ingress-annotation: %w", err)
+			}
 		}
 
 		ingress := ingressutil.CreateIngress(&ingressOptions)
-		opts.IngressController.Decorate(ingress)
+		opts.IngressController.Decorate(ingress, util.IsSecureHost(ingressHost))
+		// the insecure internal host is served over plain http, so it has no certificate
+		if !opts.InternalIngressInsecure {
+			setIngressCertManagerTLS(ingress, opts.CertManagerIssuer)
+		}
+
+		kubeutil.AddLabelsAndAnnotations(&ingress.ObjectMeta, opts.ResourceLabels, opts.ResourceAnnotations)
 
 		if err = fs.WriteYamls(fs.Join(overlaysDir, "ingress.yaml"), ingress); err != nil {
 			return err
 		}
 
-		kust.Resources = append(kust.Resources, "ingress.yaml")
+		if util.StringIndexOf(kust.Resources, "ingress.yaml") == -1 {
+			kust.Resources = append(kust.Resources, "ingress.yaml")
+		}
 	}
 
 	if err = kustutil.WriteKustomization(fs, kust, overlaysDir); err != nil {
@@ -1708,219 +3216,655 @@ func configureAppProxy(ctx context.Context, opts *RuntimeInstallOptions, rt *run
 	return apu.PushWithMessage(ctx, r, "Created App-Proxy Ingress")
 }
 
+// configureKustomizeBuildOptions sets the --kustomize-* flags as the "kustomize.buildOptions" of the runtime argo-cd,
+// which builds the overlays of the components
+func configureKustomizeBuildOptions(ctx context.Context, opts *RuntimeInstallOptions) error {
+	buildOptions := opts.KustomizeBuildOptions.Args()
+	if buildOptions == "" {
+		return nil
+	}
+
+	if opts.dryRunFS != nil {
+		// the argo-cd manifests are only written by the repo bootstrap, which is not rendered
+		log.G(ctx).Infof("The argo-cd kustomize build options will be set to: %s", buildOptions)
+		return nil
+	}
+
+	r, fs, err := getInstallRepo(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	argoCDDir := fs.Join(apstore.Default.BootsrtrapDir, apstore.Default.ArgoCDName)
+	kust, err := kustutil.ReadKustomization(fs, argoCDDir)
+	if err != nil {
+		return err
+	}
+
+	setArgoCDConfigLiteral(kust, "kustomize.buildOptions", buildOptions)
+	if err = kustutil.WriteKustomization(fs, kust, argoCDDir); err != nil {
+		return err
+	}
+
+	log.G(ctx).Infof("Pushing the argo-cd kustomize build options: %s", buildOptions)
+
+	return apu.PushWithMessage(ctx, r, "Set the argo-cd kustomize build options")
+}
+
+// setArgoCDConfigLiteral sets a key of the argocd-cm generator of the argo-cd kustomization, adding the generator when needed
+func setArgoCDConfigLiteral(kust *kusttypes.Kustomization, key, value string) {
+	literal := fmt.Sprintf("%s=%s", key, value)
+	for i, g := range kust.ConfigMapGenerator {
+		if g.Name != "argocd-cm" {
+			continue
+		}
+
+		for j, l := range g.LiteralSources {
+			if strings.HasPrefix(l, key+"=") {
+				kust.ConfigMapGenerator[i].LiteralSources[j] = literal
+				return
+			}
+		}
+
+		kust.ConfigMapGenerator[i].LiteralSources = append(kust.ConfigMapGenerator[i].LiteralSources, literal)
+		return
+	}
+
+	kust.ConfigMapGenerator = append(kust.ConfigMapGenerator, kusttypes.ConfigMapArgs{
+		GeneratorArgs: kusttypes.GeneratorArgs{
+			Name:     "argocd-cm",
+			Behavior: "merge",
+			KvPairSources: kusttypes.KvPairSources{
+				LiteralSources: []string{literal},
+			},
+		},
+	})
+}
+
+// setConfigMapGenerator replaces the generator with the same name, or appends it when there is none
+func setConfigMapGenerator(generators []kusttypes.ConfigMapArgs, generator kusttypes.ConfigMapArgs) []kusttypes.ConfigMapArgs {
+	for i, g := range generators {
+		if g.Name == generator.Name {
+			generators[i] = generator
+			return generators
+		}
+	}
+
+	return append(generators, generator)
+}
+
 func updateCodefreshCM(ctx context.Context, opts *RuntimeInstallOptions, rt *runtime.Runtime, server string) error {
 	var repofs fs.FS
 	var marshalRuntime []byte
 	var r apgit.Repository
 	var err error
 
-	r, repofs, err = opts.InsCloneOpts.GetRepo(ctx)
+	r, repofs, err = apu.GetRepo(ctx, opts.InsCloneOpts)
 	if err != nil {
 		return fmt.Errorf("failed to get repo while updating codefresh-cm: %w", err)
 	}
 
 	codefreshCM := &v1.ConfigMap{}
 
-	runtime, err := getRuntimeDataFromCodefreshCM(ctx, repofs, rt.Name, codefreshCM)
-	if err != nil {
-		return fmt.Errorf("failed to get runtime data while updating codefresh-cm: %w", err)
-	}
+	runtime, err := getRuntimeDataFromCodefreshCM(ctx, repofs, rt.Name, codefreshCM)
+	if err != nil {
+		return fmt.Errorf("failed to get runtime data while updating codefresh-cm: %w", err)
+	}
+
+	runtime.Spec.Cluster = server
+	runtime.Spec.IngressClass = opts.IngressClass
+	runtime.Spec.IngressController = opts.IngressController.Name()
+	runtime.Spec.IngressHost = opts.IngressHost
+	runtime.Spec.InternalIngressHost = opts.InternalIngressHost
+	kubeutil.AddLabelsAndAnnotations(&codefreshCM.ObjectMeta, opts.ResourceLabels, opts.ResourceAnnotations)
+
+	marshalRuntime, err = yaml.Marshal(runtime)
+	if err != nil {
+		return fmt.Errorf("failed to marshal runtime while updating codefresh-cm: %w", err)
+	}
+
+	codefreshCM.Data["runtime"] = string(marshalRuntime)
+	err = repofs.WriteYamls(repofs.Join(apstore.Default.BootsrtrapDir, rt.Name+".yaml"), codefreshCM)
+	if err != nil {
+		return fmt.Errorf("failed to write file while updating codefresh-cm: %w", err)
+	}
+
+	err = apu.PushWithMessage(ctx, r, "Updating codefresh-cm")
+	if err != nil {
+		return fmt.Errorf("failed to push to git while updating codefresh-cm: %w", err)
+	}
+
+	return nil
+}
+
+func applySecretsToCluster(ctx context.Context, opts *RuntimeInstallOptions) error {
+	runtimeTokenSecret, err := getRuntimeTokenSecret(opts.RuntimeName, opts.RuntimeToken, opts.RuntimeStoreIV, opts.ResourceLabels, opts.ResourceAnnotations)
+	if err != nil {
+		return fmt.Errorf("failed to create codefresh token secret: %w", err)
+	}
+
+	argoTokenSecret, err := getArgoCDTokenSecret(ctx, opts.kubeContext, opts.RuntimeName, opts.Insecure, opts.ResourceLabels, opts.ResourceAnnotations)
+	if err != nil {
+		return fmt.Errorf("failed to create argocd token secret: %w", err)
+	}
+
+	if err = kubeutil.Apply(ctx, opts.KubeFactory, aputil.JoinManifests(runtimeTokenSecret, argoTokenSecret), opts.ServerSideApply); err != nil {
+		return fmt.Errorf("failed to create codefresh token: %w", err)
+	}
+
+	return nil
+}
+
+func createEventsReporter(ctx context.Context, cloneOpts *apgit.CloneOptions, opts *RuntimeInstallOptions) error {
+	resPath := cloneOpts.FS.Join(apstore.Default.AppsDir, store.Get().EventsReporterName, opts.RuntimeName, "resources")
+	appURL, err := getRepoDirURL(cloneOpts, resPath)
+	if err != nil {
+		return err
+	}
+
+	appDef := &runtime.AppDef{
+		Name:       store.Get().EventsReporterName,
+		Type:       application.AppTypeDirectory,
+		URL:        appURL,
+		IsInternal: true,
+	}
+	// the app is applied to the cluster, so it is not created in a dry run
+	if opts.dryRunFS == nil {
+		if err := appDef.CreateApp(ctx, opts.KubeFactory, cloneOpts, opts.RuntimeName, store.Get().CFComponentType, "", ""); err != nil {
+			return err
+		}
+	}
+
+	r, repofs, err := getInstallRepo(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	eventsRef := getArgoEventsRef(opts)
+	if err := createEventsReporterEventSource(repofs, resPath, opts.RuntimeName, opts.Insecure, eventsRef, opts.podPlacement); err != nil {
+		return err
+	}
+
+	eventsReporterTriggers := []string{"events"}
+	if err := createSensor(repofs, store.Get().EventsReporterName, resPath, opts.RuntimeName, store.Get().EventsReporterName, eventsReporterTriggers, store.Get().EventsReporterTriggerDataKey, cfConfig.GetCurrentContext().URL+store.Get().EventReportingEndpoint, eventsRef, getTriggerRetry(opts), opts.podPlacement); err != nil {
+		return err
+	}
+
+	log.G(ctx).Info("Pushing Event Reporter manifests")
+
+	return apu.PushWithMessage(ctx, r, "Created Codefresh Event Reporter")
+}
+
+func createReporter(ctx context.Context, cloneOpts *apgit.CloneOptions, opts *RuntimeInstallOptions, reporterCreateOpts reporterCreateOptions) error {
+	resPath := cloneOpts.FS.Join(apstore.Default.AppsDir, reporterCreateOpts.reporterName, opts.RuntimeName, "resources")
+	appURL, err := getRepoDirURL(cloneOpts, resPath)
+	if err != nil {
+		return err
+	}
+
+	appDef := &runtime.AppDef{
+		Name:       reporterCreateOpts.reporterName,
+		Type:       application.AppTypeDirectory,
+		URL:        appURL,
+		IsInternal: reporterCreateOpts.IsInternal,
+	}
+	// the app is applied to the cluster, so it is not created in a dry run
+	if opts.dryRunFS == nil {
+		if err := appDef.CreateApp(ctx, opts.KubeFactory, cloneOpts, opts.RuntimeName, store.Get().CFComponentType, "", ""); err != nil {
+			return err
+		}
+	}
+
+	r, repofs, err := getInstallRepo(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := createReporterRBAC(repofs, resPath, opts.RuntimeName, reporterCreateOpts.saName, reporterCreateOpts.clusterScope, reporterCreateOpts.gvr, opts.ResourceLabels, opts.ResourceAnnotations); err != nil {
+		return err
+	}
+
+	if reporterCreateOpts.createsWorkflowsSA {
+		if err := createWorkflowsRBAC(repofs, resPath, opts.RuntimeName, opts.ResourceLabels, opts.ResourceAnnotations); err != nil {
+			return err
+		}
+	}
+
+	eventsRef := getArgoEventsRef(opts)
+	if err := createReporterEventSource(repofs, resPath, opts.RuntimeName, reporterCreateOpts, reporterCreateOpts.clusterScope, eventsRef, opts.podPlacement); err != nil {
+		return err
+	}
+
+	var triggerNames []string
+	for _, gvr := range reporterCreateOpts.gvr {
+		triggerNames = append(triggerNames, gvr.resourceName)
+	}
+
+	if err := createSensor(repofs, reporterCreateOpts.reporterName, resPath, opts.RuntimeName, reporterCreateOpts.reporterName, triggerNames, reporterCreateOpts.triggerDataKey, cfConfig.GetCurrentContext().URL+store.Get().EventReportingEndpoint, eventsRef, getTriggerRetry(opts), opts.podPlacement); err != nil {
+		return err
+	}
+
+	titleCase := cases.Title(language.AmericanEnglish)
+	log.G(ctx).Info("Pushing Codefresh ", titleCase.String(reporterCreateOpts.reporterName), " manifests")
+
+	pushMessage := "Created Codefresh" + titleCase.String(reporterCreateOpts.reporterName) + "Reporter"
+
+	if err = apu.PushWithMessage(ctx, r, pushMessage); err != nil {
+		return err
+	}
+
+	opts.reporters = append(opts.reporters, reporterCreateOpts)
+	return nil
+}
+
+// verifyReportersAccess warns about reporters whose service account is not allowed to watch their resources,
+// for example when an admission policy blocks the role that was created for it
+func verifyReportersAccess(ctx context.Context, opts *RuntimeInstallOptions) {
+	if len(opts.reporters) == 0 {
+		return
+	}
+
+	cs, err := opts.KubeFactory.KubernetesClientSet()
+	if err != nil {
+		log.G(ctx).WithError(err).Warn("Failed to verify the reporters access")
+		return
+	}
+
+	for _, msg := range reportersAccessWarnings(ctx, cs, opts.RuntimeName, opts.reporters) {
+		log.G(ctx).Warn(msg)
+	}
+}
+
+func reportersAccessWarnings(ctx context.Context, cs kubernetes.Interface, runtimeName string, reporters []reporterCreateOptions) []string {
+	var warnings []string
+	for _, r := range reporters {
+		namespace := runtimeName
+		if r.clusterScope {
+			namespace = ""
+		}
+
+		var attrs []authv1.ResourceAttributes
+		for _, gvr := range r.gvr {
+			for _, verb := range []string{"list", "watch"} {
+				attrs = append(attrs, authv1.ResourceAttributes{
+					Namespace: namespace,
+					Verb:      verb,
+					Group:     gvr.group,
+					Version:   gvr.version,
+					Resource:  gvr.resourceName,
+				})
+			}
+		}
+
+		for _, msg := range kubeutil.CheckServiceAccountAccess(ctx, cs, runtimeName, r.saName, attrs) {
+			warnings = append(warnings, fmt.Sprintf("%s reporter might not work: %s", r.reporterName, msg))
+		}
+	}
+
+	return warnings
+}
+
+var runProjectCreate = apcmd.RunProjectCreate
+
+// ensureRuntimeProject creates the runtime project and application set. With --use-existing-project, a project
+// that is already in the installation repo is reused, and only gets the labels the runtime requires
+func ensureRuntimeProject(ctx context.Context, opts *RuntimeInstallOptions, rt *runtime.Runtime) error {
+	if opts.UseExistingProject {
+		r, repofs, err := getInstallRepo(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		if repofs.ExistsOrDie(repofs.Join(apstore.Default.ProjectsDir, rt.Name+".yaml")) {
+			log.G(ctx).Infof("Using existing project \"%s\"", rt.Name)
+			if err = updateProject(repofs, rt); err != nil {
+				return fmt.Errorf("failed to update existing project: %w", err)
+			}
+
+			return apu.PushWithMessage(ctx, r, "Updated existing project labels")
+		}
+
+		log.G(ctx).Infof("Project \"%s\" does not exist, creating it", rt.Name)
+	}
+
+	return runProjectCreate(ctx, &apcmd.ProjectCreateOptions{
+		CloneOpts:   opts.InsCloneOpts,
+		ProjectName: opts.RuntimeName,
+		Labels:      getProjectAppLabels(),
+		Annotations: getProjectAppAnnotations(),
+	})
+}
+
+// getProjectAppLabels are the labels of the applications the project application set generates
+func getProjectAppLabels() map[string]string {
+	return map[string]string{
+		store.Get().LabelKeyCFType:     fmt.Sprintf("{{ labels.%s }}", util.EscapeAppsetFieldName(store.Get().LabelKeyCFType)),
+		store.Get().LabelKeyCFInternal: fmt.Sprintf("{{ labels.%s }}", util.EscapeAppsetFieldName(store.Get().LabelKeyCFInternal)),
+	}
+}
+
+func getProjectAppAnnotations() map[string]string {
+	return map[string]string{
+		store.Get().AnnotationKeySyncWave: fmt.Sprintf("{{ annotations.%s }}", util.EscapeAppsetFieldName(store.Get().AnnotationKeySyncWave)),
+	}
+}
+
+func updateProject(repofs fs.FS, rt *runtime.Runtime) error {
+	projPath := repofs.Join(apstore.Default.ProjectsDir, rt.Name+".yaml")
+	project, appset, err := getProjectInfoFromFile(repofs, projPath)
+	if err != nil {
+		return err
+	}
+
+	if project.ObjectMeta.Labels == nil {
+		project.ObjectMeta.Labels = make(map[string]string)
+	}
+
+	project.ObjectMeta.Labels[store.Get().LabelKeyCFType] = store.Get().CFRuntimeType
+
+	// an existing application set may not have been created by the runtime, so its applications may lack the labels
+	templateMeta := &appset.Spec.Template.ApplicationSetTemplateMeta
+	if templateMeta.Labels == nil {
+		templateMeta.Labels = make(map[string]string)
+	}
+
+	for k, v := range getProjectAppLabels() {
+		if _, ok := templateMeta.Labels[k]; !ok {
+			templateMeta.Labels[k] = v
+		}
+	}
+
+	if templateMeta.Annotations == nil {
+		templateMeta.Annotations = make(map[string]string)
+	}
+
+	for k, v := range getProjectAppAnnotations() {
+		if _, ok := templateMeta.Annotations[k]; !ok {
+			templateMeta.Annotations[k] = v
+		}
+	}
+
+	return repofs.WriteYamls(projPath, project, appset)
+}
+
+func getRuntimeTokenSecret(namespace string, token string, iv string, labels, annotations map[string]string) ([]byte, error) {
+	secret := &v1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      store.Get().CFTokenSecret,
+			Namespace: namespace,
+			Labels: map[string]string{
+				apstore.Default.LabelKeyAppManagedBy: apstore.Default.LabelValueManagedBy,
+			},
+		},
+		Data: map[string][]byte{
+			store.Get().CFTokenSecretKey:   []byte(token),
+			store.Get().CFStoreIVSecretKey: []byte(iv),
+		},
+	}
+	kubeutil.AddLabelsAndAnnotations(&secret.ObjectMeta, labels, annotations)
+
+	return yaml.Marshal(secret)
+}
+
+var (
+	generateArgoCDToken = cdutil.GenerateToken
+
+	argoCDTokenRetryInterval = 5 * time.Second
+	argoCDTokenTimeout       = 2 * time.Minute
+)
+
+// generateArgoCDTokenWithRetry retries the token generation until the argocd-server is reachable,
+// as it may still be starting right after the bootstrap
+func generateArgoCDTokenWithRetry(ctx context.Context, kubeContext, namespace string, insecure bool) (string, error) {
+	deadline := time.Now().Add(argoCDTokenTimeout)
+	for {
+		token, err := generateArgoCDToken(ctx, "admin", kubeContext, namespace, insecure)
+		if err == nil {
+			return token, nil
+		}
 
-	runtime.Spec.Cluster = server
-	runtime.Spec.IngressClass = opts.IngressClass
-	runtime.Spec.IngressController = opts.IngressController.Name()
-	runtime.Spec.IngressHost = opts.IngressHost
-	runtime.Spec.InternalIngressHost = opts.InternalIngressHost
+		if time.Now().Add(argoCDTokenRetryInterval).After(deadline) {
+			return "", fmt.Errorf("argocd-server in namespace \"%s\" was not reachable for %s, failed to generate an argo-cd token: %w", namespace, argoCDTokenTimeout, err)
+		}
 
-	marshalRuntime, err = yaml.Marshal(runtime)
-	if err != nil {
-		return fmt.Errorf("failed to marshal runtime while updating codefresh-cm: %w", err)
+		log.G(ctx).Debugf("Retrying to generate an argo-cd token, the argocd-server may still be starting. Error: %s", err.Error())
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(argoCDTokenRetryInterval):
+		}
 	}
+}
 
-	codefreshCM.Data["runtime"] = string(marshalRuntime)
-	err = repofs.WriteYamls(repofs.Join(apstore.Default.BootsrtrapDir, rt.Name+".yaml"), codefreshCM)
+// argoCDPartOf is the part-of label value that argo-cd selects its secrets by. It is not the argo-cd namespace,
+// and stays "argocd" whatever namespace argo-cd is installed in
+const argoCDPartOf = "argocd"
+
+func getArgoCDTokenSecret(ctx context.Context, kubeContext, namespace string, insecure bool, labels, annotations map[string]string) ([]byte, error) {
+	token, err := generateArgoCDTokenWithRetry(ctx, kubeContext, namespace, insecure)
 	if err != nil {
-		return fmt.Errorf("failed to write file while updating codefresh-cm: %w", err)
+		return nil, err
 	}
 
-	err = apu.PushWithMessage(ctx, r, "Updating codefresh-cm")
-	if err != nil {
-		return fmt.Errorf("failed to push to git while updating codefresh-cm: %w", err)
+	secret := &v1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      store.Get().ArgoCDTokenSecret,
+			Namespace: namespace,
+			Labels: map[string]string{
+				apstore.Default.LabelKeyAppPartOf: argoCDPartOf,
+			},
+		},
+		Data: map[string][]byte{
+			store.Get().ArgoCDTokenKey: []byte(token),
+		},
 	}
+	kubeutil.AddLabelsAndAnnotations(&secret.ObjectMeta, labels, annotations)
 
-	return nil
+	return yaml.Marshal(secret)
 }
 
-func applySecretsToCluster(ctx context.Context, opts *RuntimeInstallOptions) error {
-	runtimeTokenSecret, err := getRuntimeTokenSecret(opts.RuntimeName, opts.RuntimeToken, opts.RuntimeStoreIV)
-	if err != nil {
-		return fmt.Errorf("failed to create codefresh token secret: %w", err)
-	}
-
-	argoTokenSecret, err := getArgoCDTokenSecret(ctx, opts.kubeContext, opts.RuntimeName, opts.Insecure)
+// createRepoSSHCredsSecret lets argo-cd pull the ssh installation repo with the --ssh-key,
+// the bootstrap only creates the credentials of an https repo
+func createRepoSSHCredsSecret(ctx context.Context, opts *RuntimeInstallOptions) error {
+	secret, err := getRepoSSHCredsSecret(opts.RuntimeName, sshRepoBase(opts.InsCloneOpts.Repo), opts.sshPrivateKey, opts.ResourceLabels, opts.ResourceAnnotations)
 	if err != nil {
-		return fmt.Errorf("failed to create argocd token secret: %w", err)
+		return err
 	}
 
-	if err = opts.KubeFactory.Apply(ctx, aputil.JoinManifests(runtimeTokenSecret, argoTokenSecret)); err != nil {
-		return fmt.Errorf("failed to create codefresh token: %w", err)
+	if err = kubeutil.Apply(ctx, opts.KubeFactory, secret, opts.ServerSideApply); err != nil {
+		return fmt.Errorf("failed to create the repo ssh credentials: %w", err)
 	}
 
 	return nil
 }
 
-func createEventsReporter(ctx context.Context, cloneOpts *apgit.CloneOptions, opts *RuntimeInstallOptions) error {
-	resPath := cloneOpts.FS.Join(apstore.Default.AppsDir, store.Get().EventsReporterName, opts.RuntimeName, "resources")
-	u, err := url.Parse(cloneOpts.URL())
-	if err != nil {
-		return fmt.Errorf("failed to parse url: %w", err)
-	}
-	u.Path += "/" + resPath
-	q := u.Query()
-	q.Add("ref", cloneOpts.Revision())
-	u.RawQuery = q.Encode()
-
-	appDef := &runtime.AppDef{
-		Name:       store.Get().EventsReporterName,
-		Type:       application.AppTypeDirectory,
-		URL:        u.String(),
-		IsInternal: true,
-	}
-	if err := appDef.CreateApp(ctx, opts.KubeFactory, cloneOpts, opts.RuntimeName, store.Get().CFComponentType, "", ""); err != nil {
-		return err
+// configureArgoCDSSHKnownHosts adds the verified host keys of the ssh installation repo to the known hosts of argo-cd,
+// which only knows the common git providers. The cluster config map is updated so argo-cd can pull the repo right away,
+// and the argo-cd kustomization in the repo so syncing argo-cd does not revert it
+func configureArgoCDSSHKnownHosts(ctx context.Context, opts *RuntimeInstallOptions) error {
+	lines := apu.GetSSHKnownHosts()
+	if len(lines) == 0 {
+		return nil
 	}
 
-	r, repofs, err := cloneOpts.GetRepo(ctx)
+	cs, err := opts.KubeFactory.KubernetesClientSet()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to create kubernetes client: %w", err)
 	}
 
-	if err := createEventsReporterEventSource(repofs, resPath, opts.RuntimeName, opts.Insecure); err != nil {
+	r, repofs, err := getInstallRepo(ctx, opts)
+	if err != nil {
 		return err
 	}
 
-	eventsReporterTriggers := []string{"events"}
-	if err := createSensor(repofs, store.Get().EventsReporterName, resPath, opts.RuntimeName, store.Get().EventsReporterName, eventsReporterTriggers, "data"); err != nil {
+	changed, err := setArgoCDSSHKnownHosts(ctx, cs, repofs, opts.RuntimeName, lines)
+	if err != nil || !changed {
 		return err
 	}
 
-	log.G(ctx).Info("Pushing Event Reporter manifests")
+	log.G(ctx).Info("Pushing the ssh known hosts of the installation repo to argo-cd")
 
-	return apu.PushWithMessage(ctx, r, "Created Codefresh Event Reporter")
+	return apu.PushWithMessage(ctx, r, "Added the installation repo host to the argo-cd ssh known hosts")
 }
 
-func createReporter(ctx context.Context, cloneOpts *apgit.CloneOptions, opts *RuntimeInstallOptions, reporterCreateOpts reporterCreateOptions) error {
-	resPath := cloneOpts.FS.Join(apstore.Default.AppsDir, reporterCreateOpts.reporterName, opts.RuntimeName, "resources")
-	u, err := url.Parse(cloneOpts.URL())
+// setArgoCDSSHKnownHosts adds the known hosts lines to the argo-cd config map in the cluster, and writes the result
+// into the argo-cd kustomization in the repo. It returns false when argo-cd already knows all of them
+func setArgoCDSSHKnownHosts(ctx context.Context, cs kubernetes.Interface, repofs fs.FS, namespace string, lines []string) (bool, error) {
+	const knownHostsKey = "ssh_known_hosts"
+
+	cm, err := cs.CoreV1().ConfigMaps(namespace).Get(ctx, store.Get().ArgoCDSSHKnownHostsConfigMap, metav1.GetOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to parse url: %w", err)
+		return false, fmt.Errorf("failed to get the argo-cd ssh known hosts: %w", err)
 	}
-	u.Path += "/" + resPath
-	q := u.Query()
-	q.Add("ref", cloneOpts.Revision())
-	u.RawQuery = q.Encode()
 
-	appDef := &runtime.AppDef{
-		Name:       reporterCreateOpts.reporterName,
-		Type:       application.AppTypeDirectory,
-		URL:        u.String(),
-		IsInternal: reporterCreateOpts.IsInternal,
-	}
-	if err := appDef.CreateApp(ctx, opts.KubeFactory, cloneOpts, opts.RuntimeName, store.Get().CFComponentType, "", ""); err != nil {
-		return err
+	knownHosts := strings.TrimRight(cm.Data[knownHostsKey], "\n")
+	existing := strings.Split(knownHosts, "\n")
+	changed := false
+	for _, line := range lines {
+		if util.StringIndexOf(existing, line) == -1 {
+			knownHosts += "\n" + line
+			changed = true
+		}
 	}
 
-	r, repofs, err := cloneOpts.GetRepo(ctx)
-	if err != nil {
-		return err
+	if !changed {
+		return false, nil
 	}
 
-	if err := createReporterRBAC(repofs, resPath, opts.RuntimeName, reporterCreateOpts.saName, reporterCreateOpts.clusterScope); err != nil {
-		return err
+	knownHosts = strings.TrimLeft(knownHosts, "\n") + "\n"
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
 	}
 
-	if err := createReporterEventSource(repofs, resPath, opts.RuntimeName, reporterCreateOpts, reporterCreateOpts.clusterScope); err != nil {
-		return err
+	cm.Data[knownHostsKey] = knownHosts
+	if _, err = cs.CoreV1().ConfigMaps(namespace).Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return false, fmt.Errorf("failed to update the argo-cd ssh known hosts: %w", err)
 	}
 
-	var triggerNames []string
-	for _, gvr := range reporterCreateOpts.gvr {
-		triggerNames = append(triggerNames, gvr.resourceName)
+	argoCDDir := repofs.Join(apstore.Default.BootsrtrapDir, apstore.Default.ArgoCDName)
+	kust, err := kustutil.ReadKustomization(repofs, argoCDDir)
+	if err != nil {
+		return false, err
 	}
 
-	if err := createSensor(repofs, reporterCreateOpts.reporterName, resPath, opts.RuntimeName, reporterCreateOpts.reporterName, triggerNames, "data.object"); err != nil {
-		return err
+	if err = billyUtils.WriteFile(repofs, repofs.Join(argoCDDir, knownHostsKey), []byte(knownHosts), 0666); err != nil {
+		return false, fmt.Errorf("failed to write the argo-cd ssh known hosts: %w", err)
 	}
 
-	titleCase := cases.Title(language.AmericanEnglish)
-	log.G(ctx).Info("Pushing Codefresh ", titleCase.String(reporterCreateOpts.reporterName), " manifests")
+	kust.ConfigMapGenerator = setConfigMapGenerator(kust.ConfigMapGenerator, kusttypes.ConfigMapArgs{
+		GeneratorArgs: kusttypes.GeneratorArgs{
+			Name:     store.Get().ArgoCDSSHKnownHostsConfigMap,
+			Behavior: "merge",
+			KvPairSources: kusttypes.KvPairSources{
+				FileSources: []string{knownHostsKey},
+			},
+		},
+	})
 
-	pushMessage := "Created Codefresh" + titleCase.String(reporterCreateOpts.reporterName) + "Reporter"
+	return true, kustutil.WriteKustomization(repofs, kust, argoCDDir)
+}
+
+var gitSuffixRe = regexp.MustCompile(`\.git(/|$)`)
+
+// sshRepoBase returns an ssh repo url without the ".git" suffix, the path inside the repo and the ref query
+func sshRepoBase(repo string) string {
+	repo, _, _ = strings.Cut(repo, "?")
+	if loc := gitSuffixRe.FindStringIndex(repo); loc != nil {
+		repo = repo[:loc[0]]
+	}
 
-	return apu.PushWithMessage(ctx, r, pushMessage)
+	return strings.TrimSuffix(repo, "/")
 }
 
-func updateProject(repofs fs.FS, rt *runtime.Runtime) error {
-	projPath := repofs.Join(apstore.Default.ProjectsDir, rt.Name+".yaml")
-	project, appset, err := getProjectInfoFromFile(repofs, projPath)
-	if err != nil {
-		return err
+// gitlabProjectURL returns the url of a gitlab project up to its ".git" suffix. A project can be nested in any
+// number of sub-groups, so unlike an owner/repo url, the whole path up to the suffix is the project. Without the
+// suffix, the project cannot be told apart from a path inside it, so false is returned
+func gitlabProjectURL(repo string) (string, bool) {
+	repo, _, _ = strings.Cut(repo, "?")
+	if loc := gitSuffixRe.FindStringIndex(repo); loc != nil {
+		return repo[:loc[0]], true
 	}
 
-	if project.ObjectMeta.Labels == nil {
-		project.ObjectMeta.Labels = make(map[string]string)
+	return "", false
+}
+
+// getRepoDirURL returns the url of a directory in the repo, at the revision of the clone options.
+// An scp-like ssh url cannot be parsed as a url, so it is built as is
+func getRepoDirURL(cloneOpts *apgit.CloneOptions, dir string) (string, error) {
+	if cfgit.IsSSHRepoURL(cloneOpts.Repo) {
+		return sshRepoBase(cloneOpts.Repo) + ".git/" + dir + "?ref=" + url.QueryEscape(cloneOpts.Revision()), nil
 	}
 
-	project.ObjectMeta.Labels[store.Get().LabelKeyCFType] = store.Get().CFRuntimeType
+	u, err := url.Parse(cloneOpts.URL())
+	if err != nil {
+		return "", fmt.Errorf("failed to parse url: %w", err)
+	}
 
-	return repofs.WriteYamls(projPath, project, appset)
+	u.Path += "/" + dir
+	q := u.Query()
+	q.Add("ref", cloneOpts.Revision())
+	u.RawQuery = q.Encode()
+	return u.String(), nil
 }
 
-func getRuntimeTokenSecret(namespace string, token string, iv string) ([]byte, error) {
-	return yaml.Marshal(&v1.Secret{
+func getRepoSSHCredsSecret(namespace, repoURL, privateKey string, labels, annotations map[string]string) ([]byte, error) {
+	// argo-cd matches the credentials by url prefix, so they also match the git-source repos of the same owner
+	if i := strings.LastIndexAny(repoURL, ":/"); i > 0 {
+		repoURL = repoURL[:i+1]
+	}
+
+	secret := &v1.Secret{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
 			Kind:       "Secret",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      store.Get().CFTokenSecret,
+			Name:      store.Get().ArgoCDRepoSSHCredsSecret,
 			Namespace: namespace,
 			Labels: map[string]string{
-				apstore.Default.LabelKeyAppManagedBy: apstore.Default.LabelValueManagedBy,
+				apstore.Default.LabelKeyAppPartOf: argoCDPartOf,
+				"argocd.argoproj.io/secret-type":  "repo-creds",
 			},
 		},
-		Data: map[string][]byte{
-			store.Get().CFTokenSecretKey:   []byte(token),
-			store.Get().CFStoreIVSecretKey: []byte(iv),
+		StringData: map[string]string{
+			"type":          "git",
+			"url":           repoURL,
+			"sshPrivateKey": privateKey,
 		},
-	})
+	}
+	kubeutil.AddLabelsAndAnnotations(&secret.ObjectMeta, labels, annotations)
+
+	return yaml.Marshal(secret)
 }
 
-func getArgoCDTokenSecret(ctx context.Context, kubeContext, namespace string, insecure bool) ([]byte, error) {
-	token, err := cdutil.GenerateToken(ctx, "admin", kubeContext, namespace, insecure)
-	if err != nil {
-		return nil, err
-	}
+func createReporterRBAC(repofs fs.FS, path, runtimeName, saName string, clusterScope bool, gvrs []gvr, labels, annotations map[string]string) error {
+	serviceAccount, role, roleBinding := getServiceAccountRBAC(runtimeName, saName, clusterScope, getReporterRBACRules(gvrs), labels, annotations)
+	return repofs.WriteYamls(repofs.Join(path, "rbac.yaml"), serviceAccount, role, roleBinding)
+}
 
-	return yaml.Marshal(&v1.Secret{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "v1",
-			Kind:       "Secret",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      store.Get().ArgoCDTokenSecret,
-			Namespace: namespace,
-			Labels: map[string]string{
-				apstore.Default.LabelKeyAppPartOf: apstore.Default.ArgoCDNamespace,
-			},
-		},
-		Data: map[string][]byte{
-			store.Get().ArgoCDTokenKey: []byte(token),
+// createWorkflowsRBAC creates the service account the workflows run with. It is not narrowed like the reporters
+// roles, since the workflows may do anything in the runtime namespace
+func createWorkflowsRBAC(repofs fs.FS, path, runtimeName string, labels, annotations map[string]string) error {
+	rules := []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{"*"},
+			Resources: []string{"*"},
+			Verbs:     []string{"*"},
 		},
-	})
+	}
+	serviceAccount, role, roleBinding := getServiceAccountRBAC(runtimeName, store.Get().CodefreshSA, false, rules, labels, annotations)
+	return repofs.WriteYamls(repofs.Join(path, "workflows-rbac.yaml"), serviceAccount, role, roleBinding)
 }
 
-func createReporterRBAC(repofs fs.FS, path, runtimeName, saName string, clusterScope bool) error {
+func getServiceAccountRBAC(runtimeName, saName string, clusterScope bool, rules []rbacv1.PolicyRule, labels, annotations map[string]string) (*v1.ServiceAccount, *rbacv1.Role, *rbacv1.RoleBinding) {
 	serviceAccount := &v1.ServiceAccount{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "ServiceAccount",
@@ -1951,13 +3895,7 @@ func createReporterRBAC(repofs fs.FS, path, runtimeName, saName string, clusterS
 			APIVersion: "rbac.authorization.k8s.io/v1",
 		},
 		ObjectMeta: roleMeta,
-		Rules: []rbacv1.PolicyRule{
-			{
-				APIGroups: []string{"*"},
-				Resources: []string{"*"},
-				Verbs:     []string{"*"},
-			},
-		},
+		Rules:      rules,
 	}
 
 	roleBindingKind := "RoleBinding"
@@ -1973,7 +3911,7 @@ func createReporterRBAC(repofs fs.FS, path, runtimeName, saName string, clusterS
 		}
 	}
 
-	roleBinding := rbacv1.RoleBinding{
+	roleBinding := &rbacv1.RoleBinding{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       roleBindingKind,
 			APIVersion: "rbac.authorization.k8s.io/v1",
@@ -1992,10 +3930,48 @@ func createReporterRBAC(repofs fs.FS, path, runtimeName, saName string, clusterS
 		},
 	}
 
-	return repofs.WriteYamls(repofs.Join(path, "rbac.yaml"), serviceAccount, role, roleBinding)
+	for _, meta := range []*metav1.ObjectMeta{&serviceAccount.ObjectMeta, &role.ObjectMeta, &roleBinding.ObjectMeta} {
+		kubeutil.AddLabelsAndAnnotations(meta, labels, annotations)
+	}
+
+	return serviceAccount, role, roleBinding
 }
 
-func createEventsReporterEventSource(repofs fs.FS, path, namespace string, insecure bool) error {
+// getReporterRBACRules allows the reporter to only read the resources it watches, one rule per api group.
+// --reporter-wildcard-rbac restores the old rule, that allows everything
+func getReporterRBACRules(gvrs []gvr) []rbacv1.PolicyRule {
+	if store.Get().ReporterWildcardRBAC {
+		return []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{"*"},
+				Resources: []string{"*"},
+				Verbs:     []string{"*"},
+			},
+		}
+	}
+
+	var rules []rbacv1.PolicyRule
+	groupRule := map[string]int{}
+	for _, gvr := range gvrs {
+		i, ok := groupRule[gvr.group]
+		if !ok {
+			i = len(rules)
+			groupRule[gvr.group] = i
+			rules = append(rules, rbacv1.PolicyRule{
+				APIGroups: []string{gvr.group},
+				Verbs:     []string{"get", "list", "watch"},
+			})
+		}
+
+		if util.StringIndexOf(rules[i].Resources, gvr.resourceName) == -1 {
+			rules[i].Resources = append(rules[i].Resources, gvr.resourceName)
+		}
+	}
+
+	return rules
+}
+
+func createEventsReporterEventSource(repofs fs.FS, path, namespace string, insecure bool, eventsRef argoEventsRef, placement eventsutil.PodPlacement) error {
 	port := 443
 	if insecure {
 		port = 80
@@ -2005,7 +3981,9 @@ func createEventsReporterEventSource(repofs fs.FS, path, namespace string, insec
 	eventSource := eventsutil.CreateEventSource(&eventsutil.CreateEventSourceOptions{
 		Name:         store.Get().EventsReporterName,
 		Namespace:    namespace,
-		EventBusName: store.Get().EventBusName,
+		EventBusName: eventsRef.eventBusName,
+		Labels:       eventsRef.labels,
+		Placement:    placement,
 		Generic: map[string]eventsutil.CreateGenericEventSourceOptions{
 			"events": {
 				URL:             argoCDSvc,
@@ -2017,7 +3995,7 @@ func createEventsReporterEventSource(repofs fs.FS, path, namespace string, insec
 	return repofs.WriteYamls(repofs.Join(path, "event-source.yaml"), eventSource)
 }
 
-func createReporterEventSource(repofs fs.FS, path, namespace string, reporterCreateOpts reporterCreateOptions, clusterScope bool) error {
+func createReporterEventSource(repofs fs.FS, path, namespace string, reporterCreateOpts reporterCreateOptions, clusterScope bool, eventsRef argoEventsRef, placement eventsutil.PodPlacement) error {
 	var eventSource *aev1alpha1.EventSource
 	var options *eventsutil.CreateEventSourceOptions
 
@@ -2030,8 +4008,10 @@ func createReporterEventSource(repofs fs.FS, path, namespace string, reporterCre
 		Name:               reporterCreateOpts.reporterName,
 		Namespace:          namespace,
 		ServiceAccountName: reporterCreateOpts.saName,
-		EventBusName:       store.Get().EventBusName,
+		EventBusName:       eventsRef.eventBusName,
+		Labels:             eventsRef.labels,
 		Resource:           map[string]eventsutil.CreateResourceEventSourceOptions{},
+		Placement:          placement,
 	}
 
 	resourceNamespace := namespace
@@ -2054,19 +4034,108 @@ func createReporterEventSource(repofs fs.FS, path, namespace string, reporterCre
 	return repofs.WriteYamls(repofs.Join(path, "event-source.yaml"), eventSource)
 }
 
-func createSensor(repofs fs.FS, name, path, namespace, eventSourceName string, triggers []string, dataKey string) error {
+func createSensor(repofs fs.FS, name, path, namespace, eventSourceName string, triggers []string, dataKey, triggerURL string, eventsRef argoEventsRef, retry triggerRetry, placement eventsutil.PodPlacement) error {
+	if err := validateTriggerDataKey(dataKey); err != nil {
+		return fmt.Errorf("invalid trigger data key of \"%s\": %w", name, err)
+	}
+
 	sensor := eventsutil.CreateSensor(&eventsutil.CreateSensorOptions{
 		Name:            name,
 		Namespace:       namespace,
 		EventSourceName: eventSourceName,
-		EventBusName:    store.Get().EventBusName,
-		TriggerURL:      cfConfig.GetCurrentContext().URL + store.Get().EventReportingEndpoint,
+		EventBusName:    eventsRef.eventBusName,
+		Labels:          eventsRef.labels,
+		TriggerURL:      triggerURL,
 		Triggers:        triggers,
 		TriggerDestKey:  dataKey,
+		RetrySteps:      retry.steps,
+		RetryInterval:   retry.interval,
+		Placement:       placement,
 	})
 	return repofs.WriteYamls(repofs.Join(path, "sensor.yaml"), sensor)
 }
 
+// setIngressCertManagerTLS makes cert-manager issue the certificate of the ingress hosts with the --cert-manager-issuer
+func setIngressCertManagerTLS(ingress *netv1.Ingress, issuer string) {
+	if issuer == "" {
+		return
+	}
+
+	ingressutil.SetCertManagerTLS(ingress, issuer, ingress.Name+"-tls")
+}
+
+// getAppProxyService returns the app-proxy service name and port, which may be overridden for an app-proxy
+// deployed with a custom service
+func getAppProxyService(opts *RuntimeInstallOptions) (string, int32) {
+	serviceName := store.Get().AppProxyServiceName
+	if opts.AppProxyServiceName != "" {
+		serviceName = opts.AppProxyServiceName
+	}
+
+	servicePort := store.Get().AppProxyServicePort
+	if opts.AppProxyServicePort != 0 {
+		servicePort = opts.AppProxyServicePort
+	}
+
+	return serviceName, servicePort
+}
+
+var triggerDataKeyRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*$`)
+
+// validateTriggerDataKey makes sure the key is a dot separated path, that the sensor can set in the trigger payload
+func validateTriggerDataKey(dataKey string) error {
+	if dataKey == "" {
+		return fmt.Errorf("data key must not be empty")
+	}
+
+	if !triggerDataKeyRegex.MatchString(dataKey) {
+		return fmt.Errorf("data key \"%s\" must be a dot separated path of alphanumeric characters, '-' or '_' (e.g. \"data.object\")", dataKey)
+	}
+
+	return nil
+}
+
+func validateArgoEventsRef(opts *RuntimeInstallOptions) error {
+	if opts.ArgoEventsClusterWide {
+		return nil
+	}
+
+	if opts.ArgoEventsEventBus != "" {
+		return fmt.Errorf("--argo-events-eventbus requires --argo-events-cluster-wide")
+	}
+
+	if len(opts.ArgoEventsControllerLabels) > 0 {
+		return fmt.Errorf("--argo-events-controller-labels requires --argo-events-cluster-wide")
+	}
+
+	return nil
+}
+
+// getArgoEventsRef returns the runtime's own event bus, unless the reporters should reference a cluster-wide events controller
+func getArgoEventsRef(opts *RuntimeInstallOptions) argoEventsRef {
+	ref := argoEventsRef{
+		eventBusName: store.Get().EventBusName,
+	}
+	if !opts.ArgoEventsClusterWide {
+		return ref
+	}
+
+	if opts.ArgoEventsEventBus != "" {
+		ref.eventBusName = opts.ArgoEventsEventBus
+	}
+
+	ref.labels = opts.ArgoEventsControllerLabels
+	return ref
+}
+
+// getTriggerRetry returns the retry policy of the reporters sensor triggers
+func getTriggerRetry(opts *RuntimeInstallOptions) triggerRetry {
+	return triggerRetry{
+		steps:    opts.SensorRetryCount,
+		interval: opts.SensorRetryInterval,
+	}
+}
+
 func ensureGitIntegrationOpts(opts *RuntimeInstallOptions) error {
 	provider, err := parseGitProvider(string(opts.gitProvider.Type()))
 	if err != nil {
@@ -2083,9 +4152,13 @@ func ensureGitIntegrationOpts(opts *RuntimeInstallOptions) error {
 // display the user the old vs. the new configurations that will be changed upon recovery
 // and asks for permission to proceed
 func getInstallationFromRepoApproval(ctx context.Context, opts *RuntimeInstallOptions) error {
-	server, err := util.KubeCurrentServer(opts.kubeconfig)
-	if err != nil {
-		return fmt.Errorf("failed getting new cluster server: %w", err)
+	var err error
+	server := opts.ClusterServer
+	if server == "" {
+		server, err = util.KubeCurrentServer(opts.kubeconfig)
+		if err != nil {
+			return fmt.Errorf("failed getting new cluster server: %w", err)
+		}
 	}
 
 	newConfigurations := map[string]string{
@@ -2119,6 +4192,10 @@ func getInstallationFromRepoApproval(ctx context.Context, opts *RuntimeInstallOp
 			Selected: "{{ . | yellow }} ",
 		}
 
+		if err := ensureCanPrompt("approval", ""); err != nil {
+			return err
+		}
+
 		labelStr := fmt.Sprintf("%vDo you wish to proceed?%v", CYAN, COLOR_RESET)
 
 		prompt := promptui.Select{
@@ -2157,11 +4234,31 @@ func getRuntimeDataFromCodefreshCM(_ context.Context, repofs fs.FS, runtimeName
 }
 
 func postInstallationHandler(ctx context.Context, opts *RuntimeInstallOptions, err error, disableRollback *bool) {
-	if err != nil && !*disableRollback {
+	opts.postInstallationHandled = true
+	if err != nil {
+		// must be collected before the rollback removes the runtime resources
+		if bundleErr := writeSupportBundle(ctx, opts, err); bundleErr != nil {
+			log.G(ctx).WithError(bundleErr).Warn("Failed to write the support bundle")
+		}
+	}
+
+	rollback := err != nil && !*disableRollback
+	if rollback && opts.ConfirmRollback && !store.Get().Silent {
+		confirmed, promptErr := confirmRollback(err)
+		if promptErr != nil {
+			log.G(ctx).WithError(promptErr).Warn("Failed to ask for the rollback confirmation, performing installation rollback")
+		} else if !confirmed {
+			rollback = false
+			summaryArr = append(summaryArr, summaryLog{"Skipped rollback, the failed installation was kept for inspection", Info})
+			log.G(ctx).Warnf("installation failed due to error : %s, the failed installation was kept for inspection, run \"%s runtime uninstall %s --force\" to remove it", err.Error(), store.Get().BinaryName, opts.RuntimeName)
+		}
+	}
+
+	if rollback {
 		summaryArr = append(summaryArr, summaryLog{"----------Uninstalling runtime----------", Info})
 		log.G(ctx).Warnf("installation failed due to error : %s, performing installation rollback", err.Error())
 
-		err := RunRuntimeUninstall(ctx, &RuntimeUninstallOptions{
+		err := rollbackUninstall(ctx, &RuntimeUninstallOptions{
 			RuntimeName: opts.RuntimeName,
 			Timeout:     store.Get().WaitTimeout,
 			CloneOpts:   opts.InsCloneOpts,
@@ -2174,9 +4271,39 @@ func postInstallationHandler(ctx context.Context, opts *RuntimeInstallOptions, e
 		if err != nil {
 			log.G(ctx).Errorf("installation rollback failed: %s", err.Error())
 		}
+
+		if err := clearInstallState(opts.RuntimeName); err != nil {
+			log.G(ctx).WithError(err).Debug("Failed to clear the installation state")
+		}
+	}
+}
+
+var (
+	rollbackUninstall   = RunRuntimeUninstall
+	confirmRollback     = promptRollbackConfirmation
+	waitForInstallation = waitForRuntimeInstallation
+)
+
+// promptRollbackConfirmation asks whether to roll back the failed installation, or keep it for inspection
+func promptRollbackConfirmation(installErr error) (bool, error) {
+	if err := ensureCanPrompt("rollback confirmation", ""); err != nil {
+		return false, err
+	}
+
+	prompt := promptui.Select{
+		Label: fmt.Sprintf("%vInstallation failed: %s. Roll back the installation?%v", CYAN, installErr.Error(), COLOR_RESET),
+		Items: []string{"Yes, uninstall the runtime (default)", "No, keep it for inspection"},
+		Templates: &promptui.SelectTemplates{
+			Selected: "{{ . | yellow }} ",
+		},
+	}
+
+	idx, _, err := prompt.Run()
+	if err != nil {
+		return false, err
 	}
 
-	printSummaryToUser()
+	return idx == 0, nil
 }
 
 func printPreviousVsNewConfigsToUser(previousConfigurations map[string]string, newConfigurations map[string]string) {
@@ -2187,6 +4314,20 @@ func printPreviousVsNewConfigsToUser(previousConfigurations map[string]string, n
 	fmt.Printf("%vIngress host:%v       %s %v--> %s%v\n", BOLD, BOLD_RESET, previousConfigurations["IngressHost"], GREEN, newConfigurations["IngressHost"], COLOR_RESET)
 }
 
+func parseComponentVersions(componentVersions map[string]string) (map[string]*semver.Version, error) {
+	res := make(map[string]*semver.Version, len(componentVersions))
+	for name, versionStr := range componentVersions {
+		version, err := semver.NewVersion(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version \"%s\" of component \"%s\": %w", versionStr, name, err)
+		}
+
+		res[name] = version
+	}
+
+	return res, nil
+}
+
 func getVersionIfExists(versionStr string) (*semver.Version, error) {
 	if versionStr != "" {
 		log.G().Infof("vesionStr: %s", versionStr)
@@ -2200,6 +4341,18 @@ func initializeGitSourceCloneOpts(opts *RuntimeInstallOptions) {
 	opts.GsCloneOpts.Provider = opts.InsCloneOpts.Provider
 	opts.GsCloneOpts.Auth = opts.InsCloneOpts.Auth
 	opts.GsCloneOpts.Progress = opts.InsCloneOpts.Progress
+	if cfgit.IsSSHRepoURL(opts.InsCloneOpts.Repo) {
+		opts.GsCloneOpts.Repo = sshRepoBase(opts.InsCloneOpts.Repo) + "_git-source.git/resources_" + opts.RuntimeName
+		return
+	}
+
+	if opts.InsCloneOpts.Provider == string(cfgit.GITLAB) {
+		if projectURL, ok := gitlabProjectURL(opts.InsCloneOpts.Repo); ok {
+			opts.GsCloneOpts.Repo = projectURL + "_git-source.git/resources_" + opts.RuntimeName
+			return
+		}
+	}
+
 	host, orgRepo, _, _, _, suffix, _ := aputil.ParseGitUrl(opts.InsCloneOpts.Repo)
 	opts.GsCloneOpts.Repo = host + orgRepo + "_git-source" + suffix + "/resources" + "_" + opts.RuntimeName
 }
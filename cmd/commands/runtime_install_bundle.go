@@ -0,0 +1,270 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codefresh-io/cli-v2/pkg/log"
+
+	"github.com/ghodss/yaml"
+	"github.com/go-git/go-billy/v5"
+	billyUtils "github.com/go-git/go-billy/v5/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const redactedValue = "<REDACTED>"
+
+// supportBundleLogs holds a copy of the cli logs, from the moment --support-bundle was set
+var supportBundleLogs *bytes.Buffer
+
+// captureSupportBundleLogs tees the cli logs into supportBundleLogs, so they can be added to the support bundle
+func captureSupportBundleLogs() {
+	entry, err := log.GetLogrusEntry(log.G())
+	if err != nil {
+		log.G().WithError(err).Debug("Failed to capture the cli logs for the support bundle")
+		return
+	}
+
+	supportBundleLogs = &bytes.Buffer{}
+	entry.Logger.SetOutput(io.MultiWriter(entry.Logger.Out, supportBundleLogs))
+}
+
+// writeSupportBundle collects the cli logs, the generated manifests, the cluster state and the runtime definition
+// of a failed installation into a tar.gz at opts.SupportBundle. Secrets are redacted before anything is written.
+func writeSupportBundle(ctx context.Context, opts *RuntimeInstallOptions, installErr error) error {
+	if opts.SupportBundle == "" {
+		return nil
+	}
+
+	files := map[string][]byte{
+		"error.txt": []byte(installErr.Error()),
+	}
+
+	if supportBundleLogs != nil {
+		files["cli.log"] = supportBundleLogs.Bytes()
+	}
+
+	if opts.runtimeDef != nil {
+		data, err := yaml.Marshal(opts.runtimeDef)
+		if err != nil {
+			return fmt.Errorf("failed to marshal runtime definition: %w", err)
+		}
+
+		files["runtime.yaml"] = data
+	}
+
+	if opts.InsCloneOpts != nil && opts.InsCloneOpts.FS != nil {
+		if err := addManifestsToBundle(files, opts.InsCloneOpts.FS); err != nil {
+			log.G(ctx).WithError(err).Warn("Failed to add the generated manifests to the support bundle")
+		}
+	}
+
+	addClusterStateToBundle(ctx, files, opts)
+
+	secrets := []string{opts.RuntimeToken, opts.GitPassword, opts.GitSigningKeyPassphrase, opts.SSHKeyPassphrase}
+	if opts.InsCloneOpts != nil {
+		secrets = append(secrets, opts.InsCloneOpts.Auth.Password)
+	}
+
+	if opts.GitIntegrationRegistrationOpts != nil {
+		secrets = append(secrets, opts.GitIntegrationRegistrationOpts.Token)
+	}
+
+	for name, data := range files {
+		files[name] = redactValues(redactSecretManifests(data), secrets)
+	}
+
+	if err := createTarGz(opts.SupportBundle, files); err != nil {
+		return fmt.Errorf("failed to write support bundle: %w", err)
+	}
+
+	log.G(ctx).Infof("Support bundle written to \"%s\"", opts.SupportBundle)
+	return nil
+}
+
+// writeEarlySupportBundle writes the support bundle of an installation that failed before postInstallationHandler
+// ran, since the handler writes it for any later failure, before the rollback
+func writeEarlySupportBundle(ctx context.Context, opts *RuntimeInstallOptions, installErr error) {
+	if installErr == nil || opts.postInstallationHandled {
+		return
+	}
+
+	if err := writeSupportBundle(ctx, opts, installErr); err != nil {
+		log.G(ctx).WithError(err).Warn("Failed to write the support bundle")
+	}
+}
+
+func addManifestsToBundle(files map[string][]byte, repofs billy.Filesystem) error {
+	return billyUtils.Walk(repofs, "/", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		ext := filepath.Ext(path)
+		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+			return nil
+		}
+
+		data, err := billyUtils.ReadFile(repofs, path)
+		if err != nil {
+			return err
+		}
+
+		files[filepath.Join("manifests", path)] = data
+		return nil
+	})
+}
+
+func addClusterStateToBundle(ctx context.Context, files map[string][]byte, opts *RuntimeInstallOptions) {
+	var errs []string
+	if cfConfig != nil {
+		components, err := cfConfig.NewClient().V2().Component().List(ctx, opts.RuntimeName)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to list runtime components: %s", err))
+		} else if data, err := yaml.Marshal(components); err == nil {
+			files["cluster/components.yaml"] = data
+		}
+	}
+
+	if opts.KubeFactory != nil {
+		cs, err := opts.KubeFactory.KubernetesClientSet()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to create kubernetes client: %s", err))
+		} else {
+			events, err := cs.CoreV1().Events(opts.RuntimeName).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("failed to list events: %s", err))
+			} else if data, err := yaml.Marshal(events); err == nil {
+				files["cluster/events.yaml"] = data
+			}
+
+			ingresses, err := cs.NetworkingV1().Ingresses(opts.RuntimeName).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("failed to list ingresses: %s", err))
+			} else if data, err := yaml.Marshal(ingresses); err == nil {
+				files["cluster/ingresses.yaml"] = data
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		files["cluster/errors.txt"] = []byte(strings.Join(errs, "\n"))
+	}
+}
+
+// redactSecretManifests replaces the values of every Secret in a (multi document) yaml
+func redactSecretManifests(data []byte) []byte {
+	if !bytes.Contains(data, []byte("Secret")) {
+		return data
+	}
+
+	docs := strings.Split(string(data), "\n---")
+	for i, doc := range docs {
+		obj := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil || obj["kind"] != "Secret" {
+			continue
+		}
+
+		for _, field := range []string{"data", "stringData"} {
+			values, ok := obj[field].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			for k := range values {
+				values[k] = redactedValue
+			}
+		}
+
+		redacted, err := yaml.Marshal(obj)
+		if err != nil {
+			continue
+		}
+
+		docs[i] = "\n" + string(redacted)
+	}
+
+	return []byte(strings.Join(docs, "\n---"))
+}
+
+func redactValues(data []byte, secrets []string) []byte {
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+
+		data = bytes.ReplaceAll(data, []byte(secret), []byte(redactedValue))
+	}
+
+	return data
+}
+
+func createTarGz(fileName string, files map[string][]byte) error {
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		hdr := &tar.Header{
+			Name:    filepath.ToSlash(strings.TrimPrefix(name, "/")),
+			Mode:    0644,
+			Size:    int64(len(files[name])),
+			ModTime: time.Now(),
+		}
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if _, err = tw.Write(files[name]); err != nil {
+			return err
+		}
+	}
+
+	if err = tw.Close(); err != nil {
+		return err
+	}
+
+	return gw.Close()
+}
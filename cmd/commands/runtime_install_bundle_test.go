@@ -0,0 +1,123 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codefresh-io/cli-v2/pkg/runtime"
+
+	apfs "github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+	apgit "github.com/argoproj-labs/argocd-autopilot/pkg/git"
+	"github.com/go-git/go-billy/v5/memfs"
+	billyUtils "github.com/go-git/go-billy/v5/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func readTarGz(t *testing.T, fileName string) map[string]string {
+	f, err := os.Open(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{}
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		files[hdr.Name] = string(data)
+	}
+
+	return files
+}
+
+func Test_writeSupportBundle(t *testing.T) {
+	repofs := apfs.Create(memfs.New())
+	assert.NoError(t, billyUtils.WriteFile(repofs, "bootstrap/argo-cd.yaml", []byte("kind: Application\n"), 0666))
+	assert.NoError(t, billyUtils.WriteFile(repofs, "bootstrap/secrets.yaml", []byte("kind: ConfigMap\n---\nkind: Secret\ndata:\n  token: c2VjcmV0\n"), 0666))
+
+	origLogs := supportBundleLogs
+	supportBundleLogs = bytes.NewBufferString("cloning with git-token-value\n")
+	defer func() { supportBundleLogs = origLogs }()
+
+	bundle := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	opts := &RuntimeInstallOptions{
+		RuntimeName:   "runtime",
+		SupportBundle: bundle,
+		InsCloneOpts:  &apgit.CloneOptions{FS: repofs},
+		runtimeDef:    &runtime.Runtime{},
+	}
+	opts.InsCloneOpts.Auth.Password = "git-token-value"
+
+	assert.NoError(t, writeSupportBundle(context.Background(), opts, fmt.Errorf("install failed")))
+
+	files := readTarGz(t, bundle)
+	for _, name := range []string{"error.txt", "cli.log", "runtime.yaml", "manifests/bootstrap/argo-cd.yaml", "manifests/bootstrap/secrets.yaml"} {
+		assert.Contains(t, files, name)
+	}
+
+	assert.Equal(t, "install failed", files["error.txt"])
+	assert.NotContains(t, files["cli.log"], "git-token-value")
+	assert.NotContains(t, files["manifests/bootstrap/secrets.yaml"], "c2VjcmV0")
+	assert.True(t, strings.Contains(files["manifests/bootstrap/secrets.yaml"], redactedValue))
+}
+
+func Test_writeEarlySupportBundle(t *testing.T) {
+	bundle := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	opts := &RuntimeInstallOptions{RuntimeName: "runtime", SupportBundle: bundle}
+
+	// a failure before the post installation handler ran
+	writeEarlySupportBundle(context.Background(), opts, fmt.Errorf("pre-installation failed"))
+	assert.Equal(t, "pre-installation failed", readTarGz(t, bundle)["error.txt"])
+
+	// a later failure is written by the post installation handler only
+	assert.NoError(t, os.Remove(bundle))
+	disableRollback := true
+	postInstallationHandler(context.Background(), opts, fmt.Errorf("install failed"), &disableRollback)
+	assert.Equal(t, "install failed", readTarGz(t, bundle)["error.txt"])
+
+	assert.NoError(t, os.Remove(bundle))
+	writeEarlySupportBundle(context.Background(), opts, fmt.Errorf("install failed"))
+	_, err := os.Stat(bundle)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,175 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codefresh-io/cli-v2/pkg/log"
+	"github.com/codefresh-io/cli-v2/pkg/runtime"
+	"github.com/codefresh-io/cli-v2/pkg/store"
+	apu "github.com/codefresh-io/cli-v2/pkg/util/aputil"
+	kustutil "github.com/codefresh-io/cli-v2/pkg/util/kust"
+
+	"github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+	apgit "github.com/argoproj-labs/argocd-autopilot/pkg/git"
+	apstore "github.com/argoproj-labs/argocd-autopilot/pkg/store"
+	"github.com/go-git/go-billy/v5/memfs"
+	billyUtils "github.com/go-git/go-billy/v5/util"
+	kusttypes "sigs.k8s.io/kustomize/api/types"
+)
+
+// dryRunRepo is the installation repo of a --dry-run, the rendered manifests stay in memory and are never pushed
+type dryRunRepo struct{}
+
+func (dryRunRepo) Persist(_ context.Context, _ *apgit.PushOptions) (string, error) {
+	return "", nil
+}
+
+// getInstallRepo returns the installation repo, or the in-memory repo of a --dry-run
+func getInstallRepo(ctx context.Context, opts *RuntimeInstallOptions) (apgit.Repository, fs.FS, error) {
+	if opts.dryRunFS != nil {
+		return dryRunRepo{}, opts.dryRunFS, nil
+	}
+
+	return apu.GetRepo(ctx, opts.InsCloneOpts)
+}
+
+// runRuntimeInstallDryRun renders the runtime manifests that the installation would push, without creating the
+// runtime on the platform, pushing to the installation repo or applying anything to the cluster
+func runRuntimeInstallDryRun(ctx context.Context, opts *RuntimeInstallOptions) error {
+	rt, server, err := runtimeInstallPreparations(opts)
+	if err != nil {
+		return err
+	}
+
+	opts.runtimeDef = rt
+	log.G(ctx).Infof("Runtime \"%s\" components: %s", opts.RuntimeName, strings.Join(getComponents(rt, opts), ", "))
+
+	rt.Spec.Cluster = server
+	rt.Spec.IngressHost = opts.IngressHost
+	rt.Spec.IngressClass = opts.IngressClass
+	rt.Spec.InternalIngressHost = opts.InternalIngressHost
+	rt.Spec.IngressController = string(opts.IngressController.Name())
+	rt.Spec.Repo = opts.InsCloneOpts.Repo
+
+	repofs, err := renderRuntimeManifests(ctx, opts, rt)
+	if err != nil {
+		return fmt.Errorf("failed to render the runtime manifests: %w", err)
+	}
+
+	if opts.PrintResourceGraph != "" {
+		g, err := getResourceGraph(rt, opts, repofs)
+		if err != nil {
+			return fmt.Errorf("failed to build the resource graph: %w", err)
+		}
+
+		return printResourceGraph(os.Stdout, g, opts.RuntimeName, opts.PrintResourceGraph)
+	}
+
+	if opts.DryRunOutput == "" {
+		return writeDryRunManifests(repofs, os.Stdout)
+	}
+
+	if err = writeDryRunManifestsToDir(repofs, opts.DryRunOutput); err != nil {
+		return err
+	}
+
+	log.G(ctx).Infof("Wrote the runtime manifests to \"%s\"", opts.DryRunOutput)
+	return nil
+}
+
+// renderRuntimeManifests runs the manifest builders of the installation against an in-memory repo
+func renderRuntimeManifests(ctx context.Context, opts *RuntimeInstallOptions, rt *runtime.Runtime) (fs.FS, error) {
+	opts.dryRunFS = fs.Create(memfs.New())
+	repofs := opts.dryRunFS
+	if err := rt.Save(repofs, repofs.Join(apstore.Default.BootsrtrapDir, rt.Name+".yaml"), opts.CommonConfig); err != nil {
+		return nil, err
+	}
+
+	// the overlays are created by the components apps, which are not created in a dry run
+	for _, app := range []string{store.Get().WorkflowsIngressPath, "app-proxy"} {
+		overlaysDir := repofs.Join(apstore.Default.AppsDir, app, apstore.Default.OverlaysDir, rt.Name)
+		kust := &kusttypes.Kustomization{
+			TypeMeta: kusttypes.TypeMeta{
+				APIVersion: kusttypes.KustomizationVersion,
+				Kind:       kusttypes.KustomizationKind,
+			},
+		}
+		if err := kustutil.WriteKustomization(repofs, kust, overlaysDir); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := installComponents(ctx, opts, rt); err != nil {
+		return nil, err
+	}
+
+	return repofs, nil
+}
+
+// writeDryRunManifests writes all of the rendered manifests as a single yaml stream, with the repo path of each one
+func writeDryRunManifests(repofs fs.FS, w io.Writer) error {
+	first := true
+	return billyUtils.Walk(repofs, "/", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		data, err := billyUtils.ReadFile(repofs, path)
+		if err != nil {
+			return err
+		}
+
+		if !first {
+			fmt.Fprintln(w, "---")
+		}
+
+		first = false
+		fmt.Fprintf(w, "# Source: %s\n%s", strings.TrimPrefix(path, "/"), data)
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			fmt.Fprintln(w)
+		}
+
+		return nil
+	})
+}
+
+// writeDryRunManifestsToDir writes the rendered manifests to dir, in the same layout they would have in the repo
+func writeDryRunManifestsToDir(repofs fs.FS, dir string) error {
+	return billyUtils.Walk(repofs, "/", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		data, err := billyUtils.ReadFile(repofs, path)
+		if err != nil {
+			return err
+		}
+
+		fileName := filepath.Join(dir, filepath.FromSlash(path))
+		if err = os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			return fmt.Errorf("failed to create the output directory: %w", err)
+		}
+
+		return ioutil.WriteFile(fileName, data, 0644)
+	})
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codefresh-io/cli-v2/pkg/runtime"
+	"github.com/codefresh-io/cli-v2/pkg/store"
+	ingressutil "github.com/codefresh-io/cli-v2/pkg/util/ingress"
+
+	apfs "github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+	apgit "github.com/argoproj-labs/argocd-autopilot/pkg/git"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_renderRuntimeManifests(t *testing.T) {
+	withFakeCfConfig(t)
+
+	// the clone options fs is never cloned into, all of the manifests are rendered in memory
+	opts := &RuntimeInstallOptions{
+		RuntimeName:       "runtime",
+		IngressClass:      "nginx",
+		HostName:          "runtime.example.com",
+		IngressController: ingressutil.GetController("k8s.io/ingress-nginx"),
+		InsCloneOpts:      &apgit.CloneOptions{Repo: "https://github.com/owner/repo", FS: apfs.Create(memfs.New())},
+		CommonConfig:      &runtime.CommonConfig{CodefreshBaseURL: "https://g.codefresh.io"},
+	}
+	opts.InsCloneOpts.Parse()
+	rt := &runtime.Runtime{
+		ObjectMeta: metav1.ObjectMeta{Name: "runtime", Namespace: "runtime"},
+	}
+
+	repofs, err := renderRuntimeManifests(context.Background(), opts, rt)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	assert.NoError(t, writeDryRunManifestsToDir(repofs, dir))
+	for _, path := range []string{
+		"bootstrap/runtime.yaml",
+		"apps/" + store.Get().WorkflowsIngressPath + "/overlays/runtime/ingress.yaml",
+		"apps/app-proxy/overlays/runtime/ingress.yaml",
+		"apps/" + store.Get().EventsReporterName + "/runtime/resources/sensor.yaml",
+		"apps/" + store.Get().EventsReporterName + "/runtime/resources/event-source.yaml",
+	} {
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(path)))
+		assert.NoError(t, err, path)
+	}
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, writeDryRunManifests(repofs, out))
+	assert.Contains(t, out.String(), "# Source: apps/app-proxy/overlays/runtime/ingress.yaml\n")
+	assert.Contains(t, out.String(), "host: runtime.example.com")
+}
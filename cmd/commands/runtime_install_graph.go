@@ -0,0 +1,225 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/codefresh-io/cli-v2/pkg/runtime"
+	kustutil "github.com/codefresh-io/cli-v2/pkg/util/kust"
+
+	"github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+	apstore "github.com/argoproj-labs/argocd-autopilot/pkg/store"
+	"github.com/ghodss/yaml"
+	billyUtils "github.com/go-git/go-billy/v5/util"
+	kusttypes "sigs.k8s.io/kustomize/api/types"
+)
+
+type (
+	// resourceGraph is what the installation creates, from the project down to the resources of each application
+	resourceGraph struct {
+		Nodes []resourceGraphNode `json:"nodes"`
+		Edges []resourceGraphEdge `json:"edges"`
+	}
+
+	resourceGraphNode struct {
+		ID   string `json:"id"`
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	}
+
+	resourceGraphEdge struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+)
+
+const (
+	resourceGraphFormatDOT  = "dot"
+	resourceGraphFormatJSON = "json"
+)
+
+var resourceGraphFormats = []string{resourceGraphFormatDOT, resourceGraphFormatJSON}
+
+func validateResourceGraphFormat(format string) error {
+	if format != resourceGraphFormatDOT && format != resourceGraphFormatJSON {
+		return fmt.Errorf("invalid --print-resource-graph \"%s\", must be one of: %s", format, strings.Join(resourceGraphFormats, ", "))
+	}
+
+	return nil
+}
+
+// getResourceGraph builds the graph of the project, its application set, the applications of the components and reporters,
+// and the resources of each application. The component bases are built on the machine running the installation
+func getResourceGraph(rt *runtime.Runtime, opts *RuntimeInstallOptions, repofs fs.FS) (*resourceGraph, error) {
+	g := &resourceGraph{}
+	project := g.addNode("AppProject", opts.RuntimeName)
+	appSet := g.addNode("ApplicationSet", opts.RuntimeName)
+	g.addEdge(project, appSet)
+
+	for _, component := range rt.Spec.Components {
+		if isComponentSkipped(opts, component.Name) {
+			continue
+		}
+
+		// the apps of the components are only created by a real installation, so their base is built from the component url
+		manifests, err := buildComponentManifests(component.URL, &opts.KustomizeBuildOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build component \"%s\": %w", component.Name, err)
+		}
+
+		resources, err := getAppResources(repofs, repofs.Join(apstore.Default.AppsDir, component.Name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the resources of \"%s\": %w", component.Name, err)
+		}
+
+		g.addApp(appSet, opts.RuntimeName, component.Name, append(getManifestsResources(manifests), resources...))
+	}
+
+	for _, reporterName := range rt.Spec.GetReporters() {
+		if !createsReporter(rt, opts, reporterName) {
+			continue
+		}
+
+		resources, err := getAppResources(repofs, repofs.Join(apstore.Default.AppsDir, reporterName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the resources of \"%s\": %w", reporterName, err)
+		}
+
+		g.addApp(appSet, opts.RuntimeName, reporterName, resources)
+	}
+
+	return g, nil
+}
+
+var buildComponentManifests = func(url string, buildOpts *kustutil.BuildOptions) ([]byte, error) {
+	return kustutil.BuildKustomization(&kusttypes.Kustomization{
+		TypeMeta: kusttypes.TypeMeta{
+			APIVersion: kusttypes.KustomizationVersion,
+			Kind:       kusttypes.KustomizationKind,
+		},
+		Resources: []string{url},
+	}, buildOpts)
+}
+
+// addApp adds the application of the app set, with an edge to each of its resources
+func (g *resourceGraph) addApp(appSet, runtimeName, name string, resources []resourceGraphNode) {
+	app := g.addNode("Application", fmt.Sprintf("%s-%s", runtimeName, name))
+	g.addEdge(appSet, app)
+
+	sort.Slice(resources, func(i, j int) bool { return resources[i].ID < resources[j].ID })
+	added := map[string]bool{}
+	for _, res := range resources {
+		if !added[res.ID] {
+			added[res.ID] = true
+			g.addEdge(app, g.addNode(res.Kind, res.Name))
+		}
+	}
+}
+
+// getAppResources returns the kind and name of every manifest in the app directory
+func getAppResources(repofs fs.FS, appDir string) ([]resourceGraphNode, error) {
+	if !repofs.ExistsOrDie(appDir) {
+		return nil, nil
+	}
+
+	var resources []resourceGraphNode
+	err := billyUtils.Walk(repofs, appDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !(strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")) {
+			return err
+		}
+
+		data, err := billyUtils.ReadFile(repofs, path)
+		if err != nil {
+			return err
+		}
+
+		resources = append(resources, getManifestsResources(data)...)
+		return nil
+	})
+
+	return resources, err
+}
+
+// getManifestsResources returns the kind and name of every manifest in the yaml stream
+func getManifestsResources(manifests []byte) []resourceGraphNode {
+	var resources []resourceGraphNode
+	for _, doc := range strings.Split(string(manifests), "\n---") {
+		obj := &struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		}{}
+		if err := yaml.Unmarshal([]byte(doc), obj); err != nil || obj.Kind == "" || obj.Metadata.Name == "" {
+			continue
+		}
+
+		resources = append(resources, resourceGraphNode{
+			ID:   obj.Kind + "/" + obj.Metadata.Name,
+			Kind: obj.Kind,
+			Name: obj.Metadata.Name,
+		})
+	}
+
+	return resources
+}
+
+// addNode adds the node once, and returns its id
+func (g *resourceGraph) addNode(kind, name string) string {
+	id := kind + "/" + name
+	for _, n := range g.Nodes {
+		if n.ID == id {
+			return id
+		}
+	}
+
+	g.Nodes = append(g.Nodes, resourceGraphNode{ID: id, Kind: kind, Name: name})
+	return id
+}
+
+func (g *resourceGraph) addEdge(from, to string) {
+	g.Edges = append(g.Edges, resourceGraphEdge{From: from, To: to})
+}
+
+// printResourceGraph writes the graph as a graphviz digraph, or as json
+func printResourceGraph(w io.Writer, g *resourceGraph, name, format string) error {
+	if format == resourceGraphFormatJSON {
+		data, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	fmt.Fprintf(w, "digraph %q {\n", name)
+	for _, n := range g.Nodes {
+		fmt.Fprintf(w, "  %q [label=%q];\n", n.ID, n.Kind+"\n"+n.Name)
+	}
+
+	for _, e := range g.Edges {
+		fmt.Fprintf(w, "  %q -> %q;\n", e.From, e.To)
+	}
+
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/codefresh-io/cli-v2/pkg/runtime"
+	"github.com/codefresh-io/cli-v2/pkg/store"
+	ingressutil "github.com/codefresh-io/cli-v2/pkg/util/ingress"
+	kustutil "github.com/codefresh-io/cli-v2/pkg/util/kust"
+
+	apfs "github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+	apgit "github.com/argoproj-labs/argocd-autopilot/pkg/git"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_getResourceGraph(t *testing.T) {
+	withFakeCfConfig(t)
+
+	origBuild := buildComponentManifests
+	buildComponentManifests = func(url string, _ *kustutil.BuildOptions) ([]byte, error) {
+		assert.Equal(t, "github.com/codefresh-io/csdp-official/app-proxy?ref=v0.1.0", url)
+		return []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: cap-app-proxy\n"), nil
+	}
+	defer func() { buildComponentManifests = origBuild }()
+
+	opts := &RuntimeInstallOptions{
+		RuntimeName:       "runtime",
+		IngressClass:      "nginx",
+		HostName:          "runtime.example.com",
+		IngressController: ingressutil.GetController("k8s.io/ingress-nginx"),
+		InsCloneOpts:      &apgit.CloneOptions{Repo: "https://github.com/owner/repo", FS: apfs.Create(memfs.New())},
+		CommonConfig:      &runtime.CommonConfig{CodefreshBaseURL: "https://g.codefresh.io"},
+	}
+	opts.InsCloneOpts.Parse()
+	rt := &runtime.Runtime{
+		ObjectMeta: metav1.ObjectMeta{Name: "runtime", Namespace: "runtime"},
+		Spec: runtime.RuntimeSpec{
+			Components: []runtime.AppDef{{Name: "app-proxy", URL: "github.com/codefresh-io/csdp-official/app-proxy?ref=v0.1.0"}},
+			Reporters:  []string{store.Get().EventsReporterName},
+		},
+	}
+
+	repofs, err := renderRuntimeManifests(context.Background(), opts, rt)
+	assert.NoError(t, err)
+
+	g, err := getResourceGraph(rt, opts, repofs)
+	assert.NoError(t, err)
+
+	ids := map[string]bool{}
+	for _, n := range g.Nodes {
+		ids[n.ID] = true
+	}
+
+	eventsReporterApp := "Application/runtime-" + store.Get().EventsReporterName
+	for _, id := range []string{
+		"AppProject/runtime",
+		"ApplicationSet/runtime",
+		"Application/runtime-app-proxy",
+		"Deployment/cap-app-proxy",
+		eventsReporterApp,
+		"Sensor/" + store.Get().EventsReporterName,
+	} {
+		assert.True(t, ids[id], id)
+	}
+
+	assert.Contains(t, g.Edges, resourceGraphEdge{From: "AppProject/runtime", To: "ApplicationSet/runtime"})
+	assert.Contains(t, g.Edges, resourceGraphEdge{From: "Application/runtime-app-proxy", To: "Deployment/cap-app-proxy"})
+	assert.Contains(t, g.Edges, resourceGraphEdge{From: "ApplicationSet/runtime", To: eventsReporterApp})
+	assert.Contains(t, g.Edges, resourceGraphEdge{From: eventsReporterApp, To: "Sensor/" + store.Get().EventsReporterName})
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, printResourceGraph(out, g, "runtime", resourceGraphFormatDOT))
+	assert.Contains(t, out.String(), "digraph \"runtime\" {\n")
+	assert.Contains(t, out.String(), "  \"AppProject/runtime\" -> \"ApplicationSet/runtime\";\n")
+
+	out.Reset()
+	assert.NoError(t, printResourceGraph(out, g, "runtime", resourceGraphFormatJSON))
+	decoded := &resourceGraph{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), decoded))
+	assert.Equal(t, g, decoded)
+}
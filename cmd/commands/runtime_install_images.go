@@ -0,0 +1,361 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/codefresh-io/cli-v2/pkg/log"
+	"github.com/codefresh-io/cli-v2/pkg/runtime"
+	"github.com/codefresh-io/cli-v2/pkg/util"
+	apu "github.com/codefresh-io/cli-v2/pkg/util/aputil"
+	kustutil "github.com/codefresh-io/cli-v2/pkg/util/kust"
+
+	"github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+	apstore "github.com/argoproj-labs/argocd-autopilot/pkg/store"
+	"github.com/ghodss/yaml"
+	kusttypes "sigs.k8s.io/kustomize/api/types"
+)
+
+// parseImageRegistry validates the --image-registry, e.g. "my-registry.internal" or "my-registry.internal:5000/mirror"
+func parseImageRegistry(registry string) (string, error) {
+	registry = strings.TrimSuffix(registry, "/")
+	if strings.Contains(registry, "://") {
+		return "", fmt.Errorf("invalid --image-registry \"%s\", must not have a scheme (e.g. \"my-registry.internal\")", registry)
+	}
+
+	if strings.ContainsAny(registry, " @") {
+		return "", fmt.Errorf("invalid --image-registry \"%s\", must be a registry host with an optional path (e.g. \"my-registry.internal\")", registry)
+	}
+
+	return registry, nil
+}
+
+const imageRegistryPatchFile = "image-registry-patch.yaml"
+
+// workloadKinds are the kinds whose pod template may pass images to the pods it creates, in env vars or args
+var workloadKinds = []string{"Deployment", "StatefulSet", "DaemonSet"}
+
+// setComponentsImageRegistry makes the overlays of the components pull every image of the component from the --image-registry,
+// including the images the components pass in env vars and args (e.g. the argo-events sensor image and the argo-workflows
+// executor image). The reporters run in pods of the argo-events component, so their images are mirrored along with it.
+// The component bases are built on the machine running the installation, to find their images
+func setComponentsImageRegistry(ctx context.Context, opts *RuntimeInstallOptions, rt *runtime.Runtime) error {
+	if opts.ImageRegistry == "" {
+		return nil
+	}
+
+	r, repofs, err := getInstallRepo(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, component := range rt.Spec.Components {
+		if isComponentSkipped(opts, component.Name) {
+			continue
+		}
+
+		appDir := repofs.Join(apstore.Default.AppsDir, component.Name)
+		base, err := kustutil.ReadKustomization(repofs, repofs.Join(appDir, apstore.Default.BaseDir))
+		if err != nil {
+			return fmt.Errorf("failed to read the base of component \"%s\": %w", component.Name, err)
+		}
+
+		manifests, err := kustutil.BuildKustomization(base, &opts.KustomizeBuildOptions)
+		if err != nil {
+			return fmt.Errorf("failed to build component \"%s\": %w", component.Name, err)
+		}
+
+		overlaysDir := repofs.Join(appDir, apstore.Default.OverlaysDir, opts.RuntimeName)
+		if err = setImageRegistry(repofs, overlaysDir, opts.ImageRegistry, getManifestsImages(manifests)); err != nil {
+			return fmt.Errorf("failed to set the image registry of component \"%s\": %w", component.Name, err)
+		}
+
+		if err = setImageRefsRegistry(repofs, overlaysDir, getImageRefsPatches(manifests, opts.ImageRegistry)); err != nil {
+			return fmt.Errorf("failed to set the image registry of component \"%s\": %w", component.Name, err)
+		}
+	}
+
+	log.G(ctx).Infof("Pushing the components image registry \"%s\"", opts.ImageRegistry)
+
+	return apu.PushWithMessage(ctx, r, "Set the components image registry")
+}
+
+// setImageRegistry adds a kustomize images transformer to the overlay, that moves each image to the registry
+func setImageRegistry(repofs fs.FS, overlaysDir, registry string, images []string) error {
+	kust, err := kustutil.ReadKustomization(repofs, overlaysDir)
+	if err != nil {
+		return err
+	}
+
+	for _, image := range images {
+		mirrored := kusttypes.Image{
+			Name:    image,
+			NewName: mirrorImageName(registry, image),
+		}
+
+		found := false
+		for i := range kust.Images {
+			if kust.Images[i].Name == image {
+				kust.Images[i].NewName = mirrored.NewName
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			kust.Images = append(kust.Images, mirrored)
+		}
+	}
+
+	return kustutil.WriteKustomization(repofs, kust, overlaysDir)
+}
+
+// setImageRefsRegistry adds the strategic merge patches of the images passed in env vars and args to the overlay
+func setImageRefsRegistry(repofs fs.FS, overlaysDir string, patches []map[string]interface{}) error {
+	if len(patches) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, 0, len(patches))
+	for _, patch := range patches {
+		docs = append(docs, patch)
+	}
+
+	if err := repofs.WriteYamls(repofs.Join(overlaysDir, imageRegistryPatchFile), docs...); err != nil {
+		return err
+	}
+
+	kust, err := kustutil.ReadKustomization(repofs, overlaysDir)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range kust.PatchesStrategicMerge {
+		if p == imageRegistryPatchFile {
+			return nil
+		}
+	}
+
+	kust.PatchesStrategicMerge = append(kust.PatchesStrategicMerge, imageRegistryPatchFile)
+	return kustutil.WriteKustomization(repofs, kust, overlaysDir)
+}
+
+// mirrorImageName moves the image name to the registry, keeping its own registry host in the path,
+// e.g. "gcr.io/codefresh/app-proxy" becomes "my-registry.internal/gcr.io/codefresh/app-proxy".
+// Docker hub images get their full name, e.g. "redis" becomes "my-registry.internal/docker.io/library/redis"
+func mirrorImageName(registry, name string) string {
+	first, _, found := strings.Cut(name, "/")
+	switch {
+	case !found:
+		name = "docker.io/library/" + name
+	case !strings.ContainsAny(first, ".:") && first != "localhost":
+		name = "docker.io/" + name
+	}
+
+	return registry + "/" + name
+}
+
+// mirrorImage moves an image reference to the registry, keeping its tag or digest
+func mirrorImage(registry, image string) string {
+	name := imageName(image)
+	return mirrorImageName(registry, name) + strings.TrimPrefix(image, name)
+}
+
+// getImageRefsPatches returns strategic merge patches that move the images the workloads pass to their containers,
+// in env vars named *_IMAGE and in --*-image args, to the registry. The kustomize images transformer only moves
+// the images of the containers themselves
+func getImageRefsPatches(manifests []byte, registry string) []map[string]interface{} {
+	var patches []map[string]interface{}
+	for _, doc := range strings.Split(string(manifests), "\n---") {
+		obj := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			continue
+		}
+
+		kind, _ := obj["kind"].(string)
+		if util.StringIndexOf(workloadKinds, kind) == -1 {
+			continue
+		}
+
+		metadata, _ := obj["metadata"].(map[string]interface{})
+		podSpec := getNestedMap(obj, "spec", "template", "spec")
+		var patchPodSpec map[string]interface{}
+		for _, key := range []string{"initContainers", "containers"} {
+			containers, _ := podSpec[key].([]interface{})
+			var patchContainers []interface{}
+			for _, c := range containers {
+				container, _ := c.(map[string]interface{})
+				if patchContainer := getContainerImageRefsPatch(container, registry); patchContainer != nil {
+					patchContainers = append(patchContainers, patchContainer)
+				}
+			}
+
+			if len(patchContainers) > 0 {
+				if patchPodSpec == nil {
+					patchPodSpec = map[string]interface{}{}
+				}
+
+				patchPodSpec[key] = patchContainers
+			}
+		}
+
+		if patchPodSpec == nil {
+			continue
+		}
+
+		patchMetadata := map[string]interface{}{"name": metadata["name"]}
+		if namespace, ok := metadata["namespace"]; ok {
+			patchMetadata["namespace"] = namespace
+		}
+
+		patches = append(patches, map[string]interface{}{
+			"apiVersion": obj["apiVersion"],
+			"kind":       kind,
+			"metadata":   patchMetadata,
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": patchPodSpec,
+				},
+			},
+		})
+	}
+
+	return patches
+}
+
+// getContainerImageRefsPatch returns the patch of the container env vars and args that pass images, or nil when it has none.
+// The env vars are merged by name, while the args are replaced as a whole
+func getContainerImageRefsPatch(container map[string]interface{}, registry string) map[string]interface{} {
+	var patchEnv []interface{}
+	env, _ := container["env"].([]interface{})
+	for _, e := range env {
+		envVar, _ := e.(map[string]interface{})
+		name, _ := envVar["name"].(string)
+		value, _ := envVar["value"].(string)
+		if (name == "IMAGE" || strings.HasSuffix(name, "_IMAGE")) && isImageRef(value) {
+			patchEnv = append(patchEnv, map[string]interface{}{
+				"name":  name,
+				"value": mirrorImage(registry, value),
+			})
+		}
+	}
+
+	args, _ := container["args"].([]interface{})
+	patchArgs := make([]interface{}, len(args))
+	argsChanged := false
+	for i, a := range args {
+		patchArgs[i] = a
+		arg, _ := a.(string)
+		if flag, value, found := strings.Cut(arg, "="); found && isImageFlag(flag) && isImageRef(value) {
+			patchArgs[i] = flag + "=" + mirrorImage(registry, value)
+			argsChanged = true
+		} else if i > 0 && isImageRef(arg) {
+			if prev, _ := args[i-1].(string); isImageFlag(prev) {
+				patchArgs[i] = mirrorImage(registry, arg)
+				argsChanged = true
+			}
+		}
+	}
+
+	if len(patchEnv) == 0 && !argsChanged {
+		return nil
+	}
+
+	patch := map[string]interface{}{"name": container["name"]}
+	if len(patchEnv) > 0 {
+		patch["env"] = patchEnv
+	}
+
+	if argsChanged {
+		patch["args"] = patchArgs
+	}
+
+	return patch
+}
+
+func isImageFlag(flag string) bool {
+	return strings.HasPrefix(flag, "--") && (flag == "--image" || strings.HasSuffix(flag, "-image"))
+}
+
+func isImageRef(value string) bool {
+	return value != "" && !strings.HasPrefix(value, "-") && !strings.ContainsAny(value, " =$")
+}
+
+func getNestedMap(obj map[string]interface{}, fields ...string) map[string]interface{} {
+	for _, field := range fields {
+		obj, _ = obj[field].(map[string]interface{})
+	}
+
+	return obj
+}
+
+// getManifestsImages returns the sorted image names, without tags or digests, of every container in the manifests
+func getManifestsImages(manifests []byte) []string {
+	names := map[string]bool{}
+	for _, doc := range strings.Split(string(manifests), "\n---") {
+		obj := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			continue
+		}
+
+		collectContainerImages(obj, names)
+	}
+
+	images := make([]string, 0, len(names))
+	for name := range names {
+		images = append(images, name)
+	}
+
+	sort.Strings(images)
+	return images
+}
+
+func collectContainerImages(value interface{}, names map[string]bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			containers, ok := field.([]interface{})
+			if !ok || (key != "containers" && key != "initContainers") {
+				collectContainerImages(field, names)
+				continue
+			}
+
+			for _, c := range containers {
+				container, _ := c.(map[string]interface{})
+				if image, ok := container["image"].(string); ok && image != "" {
+					names[imageName(image)] = true
+				}
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectContainerImages(item, names)
+		}
+	}
+}
+
+// imageName strips the tag and digest of an image reference
+func imageName(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+
+	return image
+}
@@ -0,0 +1,229 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"testing"
+
+	kustutil "github.com/codefresh-io/cli-v2/pkg/util/kust"
+
+	apfs "github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/stretchr/testify/assert"
+	kusttypes "sigs.k8s.io/kustomize/api/types"
+)
+
+func Test_parseImageRegistry(t *testing.T) {
+	registry, err := parseImageRegistry("my-registry.internal:5000/mirror/")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-registry.internal:5000/mirror", registry)
+
+	_, err = parseImageRegistry("https://my-registry.internal")
+	assert.EqualError(t, err, "invalid --image-registry \"https://my-registry.internal\", must not have a scheme (e.g. \"my-registry.internal\")")
+}
+
+func Test_mirrorImageName(t *testing.T) {
+	tests := map[string]string{
+		"gcr.io/codefresh/app-proxy":        "my-registry.internal/gcr.io/codefresh/app-proxy",
+		"localhost:5000/codefresh/reporter": "my-registry.internal/localhost:5000/codefresh/reporter",
+		"argoproj/argocli":                  "my-registry.internal/docker.io/argoproj/argocli",
+		"redis":                             "my-registry.internal/docker.io/library/redis",
+	}
+	for image, want := range tests {
+		t.Run(image, func(t *testing.T) {
+			assert.Equal(t, want, mirrorImageName("my-registry.internal", image))
+		})
+	}
+}
+
+func Test_getManifestsImages(t *testing.T) {
+	manifests := []byte(`apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      initContainers:
+      - image: busybox:1.35
+      containers:
+      - image: gcr.io/codefresh/app-proxy:1.2.3
+      - image: quay.io/argoproj/argocli@sha256:abc
+---
+apiVersion: batch/v1
+kind: CronJob
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - image: gcr.io/codefresh/app-proxy:1.2.3
+---
+apiVersion: v1
+kind: ConfigMap
+data:
+  image: not-a-container
+`)
+
+	assert.Equal(t, []string{"busybox", "gcr.io/codefresh/app-proxy", "quay.io/argoproj/argocli"}, getManifestsImages(manifests))
+}
+
+func Test_setImageRegistry(t *testing.T) {
+	repofs := apfs.Create(memfs.New())
+	overlaysDir := "apps/app-proxy/overlays/runtime"
+	assert.NoError(t, kustutil.WriteKustomization(repofs, &kusttypes.Kustomization{
+		TypeMeta: kusttypes.TypeMeta{
+			APIVersion: kusttypes.KustomizationVersion,
+			Kind:       kusttypes.KustomizationKind,
+		},
+		Resources: []string{"../../base"},
+	}, overlaysDir))
+
+	images := []string{"gcr.io/codefresh/app-proxy"}
+	assert.NoError(t, setImageRegistry(repofs, overlaysDir, "my-registry.internal", images))
+	assert.NoError(t, setImageRegistry(repofs, overlaysDir, "my-registry.internal", images))
+
+	kust, err := kustutil.ReadKustomization(repofs, overlaysDir)
+	assert.NoError(t, err)
+	assert.Equal(t, []kusttypes.Image{{Name: "gcr.io/codefresh/app-proxy", NewName: "my-registry.internal/gcr.io/codefresh/app-proxy"}}, kust.Images)
+}
+
+func Test_getImageRefsPatches(t *testing.T) {
+	manifests := []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+spec:
+  template:
+    spec:
+      containers:
+      - name: controller-manager
+        image: quay.io/argoproj/argo-events:v1.7.0
+        env:
+        - name: ARGO_EVENTS_IMAGE
+          value: quay.io/argoproj/argo-events:v1.7.0
+        - name: NAMESPACE
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: workflow-controller
+  namespace: argo
+spec:
+  template:
+    spec:
+      containers:
+      - name: workflow-controller
+        image: quay.io/argoproj/workflow-controller:v3.3.8
+        args:
+        - --configmap
+        - workflow-controller-configmap
+        - --executor-image
+        - quay.io/argoproj/argoexec:v3.3.8
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: redis
+spec:
+  template:
+    spec:
+      containers:
+      - name: redis
+        image: redis:6
+        args:
+        - --sidecar-image=busybox@sha256:abc
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app-proxy
+spec:
+  template:
+    spec:
+      containers:
+      - name: app-proxy
+        image: gcr.io/codefresh/app-proxy:1.2.3
+        env:
+        - name: PORT
+          value: "3017"
+`)
+
+	want := []map[string]interface{}{
+		{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "controller-manager"},
+			"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{
+					"name": "controller-manager",
+					"env": []interface{}{map[string]interface{}{
+						"name":  "ARGO_EVENTS_IMAGE",
+						"value": "my-registry.internal/quay.io/argoproj/argo-events:v1.7.0",
+					}},
+				}},
+			}}},
+		},
+		{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "workflow-controller", "namespace": "argo"},
+			"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{
+					"name": "workflow-controller",
+					"args": []interface{}{"--configmap", "workflow-controller-configmap", "--executor-image", "my-registry.internal/quay.io/argoproj/argoexec:v3.3.8"},
+				}},
+			}}},
+		},
+		{
+			"apiVersion": "apps/v1",
+			"kind":       "StatefulSet",
+			"metadata":   map[string]interface{}{"name": "redis"},
+			"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{
+					"name": "redis",
+					"args": []interface{}{"--sidecar-image=my-registry.internal/docker.io/library/busybox@sha256:abc"},
+				}},
+			}}},
+		},
+	}
+	assert.Equal(t, want, getImageRefsPatches(manifests, "my-registry.internal"))
+}
+
+func Test_setImageRefsRegistry(t *testing.T) {
+	repofs := apfs.Create(memfs.New())
+	overlaysDir := "apps/argo-events/overlays/runtime"
+	assert.NoError(t, kustutil.WriteKustomization(repofs, &kusttypes.Kustomization{
+		TypeMeta: kusttypes.TypeMeta{
+			APIVersion: kusttypes.KustomizationVersion,
+			Kind:       kusttypes.KustomizationKind,
+		},
+		Resources: []string{"../../base"},
+	}, overlaysDir))
+
+	patches := []map[string]interface{}{{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": map[string]interface{}{"name": "controller-manager"}}}
+	assert.NoError(t, setImageRefsRegistry(repofs, overlaysDir, patches))
+	assert.NoError(t, setImageRefsRegistry(repofs, overlaysDir, patches))
+
+	kust, err := kustutil.ReadKustomization(repofs, overlaysDir)
+	assert.NoError(t, err)
+	assert.Equal(t, []kusttypes.PatchStrategicMerge{imageRegistryPatchFile}, kust.PatchesStrategicMerge)
+
+	exists, err := repofs.Exists(repofs.Join(overlaysDir, imageRegistryPatchFile))
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
@@ -0,0 +1,134 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/codefresh-io/cli-v2/pkg/log"
+	apu "github.com/codefresh-io/cli-v2/pkg/util/aputil"
+
+	apfs "github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+)
+
+type (
+	// installLock is committed to the installation repo for the duration of the installation,
+	// so a concurrent installation into the same repo is refused instead of racing on pushes
+	installLock struct {
+		RuntimeName string    `json:"runtimeName"`
+		Host        string    `json:"host"`
+		CreatedAt   time.Time `json:"createdAt"`
+	}
+)
+
+const installLockFileName = ".codefresh-install.lock"
+
+func acquireInstallLock(ctx context.Context, opts *RuntimeInstallOptions) error {
+	r, repofs, err := getInstallRepo(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to get installation repo: %w", err)
+	}
+
+	if err = checkInstallLock(repofs, opts.ForceUnlock); err != nil {
+		return err
+	}
+
+	if err = writeInstallLock(repofs, opts.RuntimeName); err != nil {
+		return err
+	}
+
+	log.G(ctx).Info("Locking the installation repo")
+
+	// if another installation pushed its lock first, this push is rejected
+	if err = apu.PushWithMessage(ctx, r, fmt.Sprintf("Locked installation repo for runtime %s", opts.RuntimeName)); err != nil {
+		return fmt.Errorf("failed to lock the installation repo, another installation might be in progress: %w", err)
+	}
+
+	return nil
+}
+
+func releaseInstallLock(ctx context.Context, opts *RuntimeInstallOptions) error {
+	r, repofs, err := getInstallRepo(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to get installation repo: %w", err)
+	}
+
+	lock, err := readInstallLock(repofs)
+	if err != nil {
+		return err
+	}
+
+	// never release a lock held by a different installation
+	if lock == nil || lock.RuntimeName != opts.RuntimeName {
+		return nil
+	}
+
+	if err = repofs.Remove(installLockFileName); err != nil {
+		return fmt.Errorf("failed to remove installation lock: %w", err)
+	}
+
+	log.G(ctx).Info("Releasing the installation repo lock")
+
+	return apu.PushWithMessage(ctx, r, fmt.Sprintf("Released installation repo lock of runtime %s", opts.RuntimeName))
+}
+
+// checkInstallLock fails if the installation repo is locked by another installation, unless forceUnlock is set
+func checkInstallLock(repofs apfs.FS, forceUnlock bool) error {
+	lock, err := readInstallLock(repofs)
+	if err != nil || lock == nil {
+		return err
+	}
+
+	if forceUnlock {
+		log.G().Warnf("Overriding the installation lock of runtime \"%s\" from %s", lock.RuntimeName, lock.Host)
+		return nil
+	}
+
+	return fmt.Errorf("the installation repo is locked by an installation of runtime \"%s\" started on %s at %s. if that installation is no longer running, use --force-unlock", lock.RuntimeName, lock.Host, lock.CreatedAt.Format(time.RFC3339))
+}
+
+func readInstallLock(repofs apfs.FS) (*installLock, error) {
+	if !repofs.ExistsOrDie(installLockFileName) {
+		return nil, nil
+	}
+
+	lock := &installLock{}
+	if err := repofs.ReadJson(installLockFileName, lock); err != nil {
+		return nil, fmt.Errorf("failed to read installation lock: %w", err)
+	}
+
+	return lock, nil
+}
+
+func writeInstallLock(repofs apfs.FS, runtimeName string) error {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	lock := &installLock{
+		RuntimeName: runtimeName,
+		Host:        host,
+		CreatedAt:   time.Now(),
+	}
+	if err = repofs.WriteJson(installLockFileName, lock); err != nil {
+		return fmt.Errorf("failed to write installation lock: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codefresh-io/cli-v2/pkg/log"
+
+	gg "github.com/go-git/go-git/v5"
+)
+
+const (
+	nameSuffixRandom    = "random"
+	nameSuffixTimestamp = "timestamp"
+	nameSuffixGitSHA    = "git-sha"
+
+	maxNameSuffixAttempts = 5
+)
+
+var (
+	errRuntimeExists = errors.New("already exists")
+
+	nameSuffixTypes = []string{nameSuffixRandom, nameSuffixTimestamp, nameSuffixGitSHA}
+
+	checkRuntimeNameAvailable = checkExistingRuntimes
+	generateNameSuffix        = getNameSuffix
+)
+
+func validateNameSuffix(nameSuffix string, fromRepo bool) error {
+	if nameSuffix == "" {
+		return nil
+	}
+
+	if fromRepo {
+		return fmt.Errorf("--name-suffix cannot be used with --from-repo, the recovered runtime keeps its name")
+	}
+
+	for _, t := range nameSuffixTypes {
+		if nameSuffix == t {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid --name-suffix \"%s\", must be one of: %s", nameSuffix, strings.Join(nameSuffixTypes, ", "))
+}
+
+// applyNameSuffix appends the --name-suffix to the runtime name. The suffix is regenerated as long as
+// the name collides with an existing runtime
+func applyNameSuffix(ctx context.Context, opts *RuntimeInstallOptions) error {
+	if opts.NameSuffix == "" {
+		return nil
+	}
+
+	for attempt := 0; attempt < maxNameSuffixAttempts; attempt++ {
+		suffix, err := generateNameSuffix(opts.NameSuffix, attempt)
+		if err != nil {
+			return fmt.Errorf("failed to generate the runtime name suffix: %w", err)
+		}
+
+		name := opts.RuntimeName + "-" + suffix
+		if err = validateRuntimeName(name); err != nil {
+			return fmt.Errorf("invalid runtime name \"%s\" with --name-suffix: %w", name, err)
+		}
+
+		err = checkRuntimeNameAvailable(ctx, name)
+		if err == nil {
+			log.G(ctx).Infof("Using runtime name \"%s\"", name)
+			opts.RuntimeName = name
+			return nil
+		}
+
+		if !errors.Is(err, errRuntimeExists) {
+			return err
+		}
+
+		log.G(ctx).Debugf("Runtime \"%s\" already exists, generating another suffix", name)
+	}
+
+	return fmt.Errorf("failed to find an unused runtime name with --name-suffix %s after %d attempts", opts.NameSuffix, maxNameSuffixAttempts)
+}
+
+// getNameSuffix returns a suffix of the type. The attempt is added to the suffixes that would not change
+// between attempts
+func getNameSuffix(suffixType string, attempt int) (string, error) {
+	var suffix string
+	switch suffixType {
+	case nameSuffixRandom:
+		b := make([]byte, 3)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+
+		return hex.EncodeToString(b), nil
+	case nameSuffixTimestamp:
+		suffix = time.Now().UTC().Format("20060102150405")
+	case nameSuffixGitSHA:
+		sha, err := getGitSHA()
+		if err != nil {
+			return "", err
+		}
+
+		suffix = sha
+	default:
+		return "", fmt.Errorf("unknown suffix type \"%s\"", suffixType)
+	}
+
+	if attempt > 0 {
+		suffix = fmt.Sprintf("%s-%d", suffix, attempt)
+	}
+
+	return suffix, nil
+}
+
+// getGitSHA returns the short sha of the commit that is built by the ci, or of the HEAD of the working directory
+func getGitSHA() (string, error) {
+	sha := ""
+	for _, env := range []string{"GITHUB_SHA", "CI_COMMIT_SHA", "GIT_COMMIT", "CF_REVISION"} {
+		if sha = os.Getenv(env); sha != "" {
+			break
+		}
+	}
+
+	if sha == "" {
+		r, err := gg.PlainOpenWithOptions(".", &gg.PlainOpenOptions{DetectDotGit: true})
+		if err != nil {
+			return "", fmt.Errorf("failed to open the git repository of the working directory: %w", err)
+		}
+
+		head, err := r.Head()
+		if err != nil {
+			return "", fmt.Errorf("failed to get the HEAD commit: %w", err)
+		}
+
+		sha = head.Hash().String()
+	}
+
+	sha = strings.ToLower(sha)
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+
+	return sha, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_applyNameSuffix(t *testing.T) {
+	origCheck, origGenerate := checkRuntimeNameAvailable, generateNameSuffix
+	defer func() { checkRuntimeNameAvailable, generateNameSuffix = origCheck, origGenerate }()
+
+	existing := map[string]bool{"ci-abc123": true}
+	var checked []string
+	checkRuntimeNameAvailable = func(_ context.Context, name string) error {
+		checked = append(checked, name)
+		if existing[name] {
+			return fmt.Errorf("runtime \"%s\" %w", name, errRuntimeExists)
+		}
+
+		return nil
+	}
+	generateNameSuffix = func(_ string, attempt int) (string, error) {
+		return []string{"abc123", "def456"}[attempt], nil
+	}
+
+	opts := &RuntimeInstallOptions{RuntimeName: "ci", NameSuffix: nameSuffixRandom}
+	assert.NoError(t, applyNameSuffix(context.Background(), opts))
+	assert.Equal(t, "ci-def456", opts.RuntimeName)
+	assert.Equal(t, []string{"ci-abc123", "ci-def456"}, checked)
+}
+
+func Test_applyNameSuffix_failures(t *testing.T) {
+	origCheck, origGenerate := checkRuntimeNameAvailable, generateNameSuffix
+	defer func() { checkRuntimeNameAvailable, generateNameSuffix = origCheck, origGenerate }()
+
+	generateNameSuffix = func(_ string, _ int) (string, error) { return "abc123", nil }
+
+	checkRuntimeNameAvailable = func(_ context.Context, name string) error {
+		return fmt.Errorf("runtime \"%s\" %w", name, errRuntimeExists)
+	}
+	err := applyNameSuffix(context.Background(), &RuntimeInstallOptions{RuntimeName: "ci", NameSuffix: nameSuffixRandom})
+	assert.EqualError(t, err, "failed to find an unused runtime name with --name-suffix random after 5 attempts")
+
+	checkRuntimeNameAvailable = func(_ context.Context, _ string) error {
+		return fmt.Errorf("failed to get runtime: unauthorized")
+	}
+	err = applyNameSuffix(context.Background(), &RuntimeInstallOptions{RuntimeName: "ci", NameSuffix: nameSuffixRandom})
+	assert.EqualError(t, err, "failed to get runtime: unauthorized")
+}
+
+func Test_getNameSuffix(t *testing.T) {
+	suffix, err := getNameSuffix(nameSuffixRandom, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, validateRuntimeName("ci-"+suffix))
+
+	suffix, err = getNameSuffix(nameSuffixTimestamp, 2)
+	assert.NoError(t, err)
+	assert.Regexp(t, `^\d{14}-2$`, suffix)
+
+	t.Setenv("GITHUB_SHA", "0A1B2C3D4E5F")
+	suffix, err = getNameSuffix(nameSuffixGitSHA, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "0a1b2c3", suffix)
+}
+
+func Test_validateNameSuffix(t *testing.T) {
+	assert.NoError(t, validateNameSuffix("", true))
+	assert.NoError(t, validateNameSuffix(nameSuffixGitSHA, false))
+	assert.EqualError(t, validateNameSuffix("uuid", false), "invalid --name-suffix \"uuid\", must be one of: random, timestamp, git-sha")
+	assert.Error(t, validateNameSuffix(nameSuffixRandom, true))
+}
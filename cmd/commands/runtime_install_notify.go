@@ -0,0 +1,124 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/codefresh-io/cli-v2/pkg/log"
+
+	"github.com/codefresh-io/go-sdk/pkg/codefresh/model"
+	"github.com/rkrmr33/checklist"
+)
+
+type (
+	// installNotification is the payload sent to --notify-webhook when the runtime sync completes or times out
+	installNotification struct {
+		Runtime          string   `json:"runtime"`
+		Status           string   `json:"status"`
+		DurationSeconds  int64    `json:"durationSeconds"`
+		FailedComponents []string `json:"failedComponents"`
+	}
+)
+
+const (
+	installNotificationStatusCompleted = "completed"
+	installNotificationStatusTimeout   = "timeout"
+)
+
+func validateNotifyWebhook(webhook string) error {
+	if webhook == "" {
+		return nil
+	}
+
+	u, err := url.Parse(webhook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid --notify-webhook \"%s\", must be an http(s) url", webhook)
+	}
+
+	return nil
+}
+
+// sendInstallNotification posts the installation result to --notify-webhook.
+// A failing webhook only produces a warning, since the runtime is already installed at this point
+func sendInstallNotification(ctx context.Context, opts *RuntimeInstallOptions, timeoutErr error) {
+	notification := &installNotification{
+		Runtime:          opts.RuntimeName,
+		Status:           installNotificationStatusCompleted,
+		DurationSeconds:  int64(time.Since(opts.installStart).Seconds()),
+		FailedComponents: []string{},
+	}
+	if timeoutErr != nil {
+		notification.Status = installNotificationStatusTimeout
+		components, err := cfConfig.NewClient().V2().Component().List(ctx, opts.RuntimeName)
+		if err != nil {
+			log.G(ctx).WithError(err).Warn("Failed to get the runtime components state for the notification webhook")
+		} else {
+			notification.FailedComponents = unreadyComponentNames(components)
+		}
+	}
+
+	if err := postInstallNotification(ctx, opts.NotifyWebhook, notification); err != nil {
+		log.G(ctx).WithError(err).Warn("Failed to notify the installation webhook")
+		return
+	}
+
+	log.G(ctx).Debugf("Notified \"%s\" of the installation %s", opts.NotifyWebhook, notification.Status)
+}
+
+func unreadyComponentNames(components []model.Component) []string {
+	names := []string{}
+	for _, c := range components {
+		if state, info := getComponentChecklistState(c); state != checklist.Ready {
+			names = append(names, info[componentInfoName])
+		}
+	}
+
+	return names
+}
+
+func postInstallNotification(ctx context.Context, webhook string, notification *installNotification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the notification: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status code %d", res.StatusCode)
+	}
+
+	return nil
+}
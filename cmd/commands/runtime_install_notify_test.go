@@ -0,0 +1,74 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/go-sdk/pkg/codefresh/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_sendInstallNotification(t *testing.T) {
+	var notifications []installNotification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		notification := installNotification{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&notification))
+		notifications = append(notifications, notification)
+	}))
+	defer server.Close()
+
+	opts := &RuntimeInstallOptions{
+		RuntimeName:   "runtime",
+		NotifyWebhook: server.URL,
+		installStart:  time.Now().Add(-90 * time.Second),
+	}
+	sendInstallNotification(context.Background(), opts, nil)
+
+	want := []installNotification{
+		{
+			Runtime:          "runtime",
+			Status:           installNotificationStatusCompleted,
+			DurationSeconds:  90,
+			FailedComponents: []string{},
+		},
+	}
+	assert.Equal(t, want, notifications)
+}
+
+func Test_postInstallNotification_failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := postInstallNotification(context.Background(), server.URL, &installNotification{Runtime: "runtime"})
+	assert.EqualError(t, err, "webhook responded with status code 500")
+}
+
+func Test_unreadyComponentNames(t *testing.T) {
+	components := []model.Component{
+		{Metadata: &model.ObjectMeta{Name: "runtime-argo-cd", Runtime: "runtime"}},
+	}
+
+	assert.Equal(t, []string{"argo-cd"}, unreadyComponentNames(components))
+}
@@ -0,0 +1,93 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	cfgit "github.com/codefresh-io/cli-v2/pkg/git"
+	"github.com/codefresh-io/cli-v2/pkg/log"
+)
+
+// tokenPermissionRequirement is a permission the git token needs, and the reason it is needed
+type tokenPermissionRequirement struct {
+	permission cfgit.TokenPermission
+	reason     string
+}
+
+// getRequiredTokenPermissions returns the permissions the git token needs for the selected installation flags
+func getRequiredTokenPermissions(opts *RuntimeInstallOptions) []tokenPermissionRequirement {
+	requirements := []tokenPermissionRequirement{
+		{cfgit.PermissionRepoWrite, "to push the runtime manifests to the installation repo"},
+	}
+
+	if opts.InsCloneOpts.CreateIfNotExist {
+		requirements = append(requirements, tokenPermissionRequirement{cfgit.PermissionRepoCreate, "to create the installation repo when it does not exist"})
+	}
+
+	// a recovered runtime already has its git sources
+	if !opts.FromRepo {
+		if opts.GsCloneOpts.CreateIfNotExist {
+			requirements = append(requirements, tokenPermissionRequirement{cfgit.PermissionRepoCreate, "to create the git-source repo when it does not exist"})
+		}
+
+		if opts.gitProvider != nil && opts.gitProvider.SupportsMarketplace() {
+			requirements = append(requirements, tokenPermissionRequirement{cfgit.PermissionPublicRepoRead, "to read the marketplace git-source repo"})
+		}
+	}
+
+	requirements = append(requirements, tokenPermissionRequirement{cfgit.PermissionRepoHooks, "to create the webhooks of the git-source event sources"})
+	return requirements
+}
+
+// verifyGitTokenPermissions checks every permission the installation needs at once, and reports all of the missing ones.
+// Providers that cannot tell the permissions of a token are skipped
+func verifyGitTokenPermissions(ctx context.Context, gitProvider cfgit.Provider, token string, requirements []tokenPermissionRequirement) error {
+	verifier, ok := gitProvider.(cfgit.TokenPermissionsVerifier)
+	if !ok {
+		return nil
+	}
+
+	var required []cfgit.TokenPermission
+	for _, r := range requirements {
+		required = append(required, r.permission)
+	}
+
+	missing, err := verifier.GetMissingPermissions(ctx, token, required)
+	if err != nil {
+		log.G(ctx).Debugf("Skipping the git token permissions check: %s", err.Error())
+		return nil
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	isMissing := map[cfgit.TokenPermission]bool{}
+	for _, p := range missing {
+		isMissing[p] = true
+	}
+
+	var gaps []string
+	for _, r := range requirements {
+		if isMissing[r.permission] {
+			gaps = append(gaps, fmt.Sprintf("%s (%s)", r.permission, r.reason))
+		}
+	}
+
+	return fmt.Errorf("the git token is missing the following permissions: %s", strings.Join(gaps, ", "))
+}
@@ -0,0 +1,96 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"testing"
+
+	cfgit "github.com/codefresh-io/cli-v2/pkg/git"
+
+	apgit "github.com/argoproj-labs/argocd-autopilot/pkg/git"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePermissionsProvider struct {
+	fakeProvider
+	granted map[cfgit.TokenPermission]bool
+}
+
+func (p *fakePermissionsProvider) GetMissingPermissions(_ context.Context, _ string, required []cfgit.TokenPermission) ([]cfgit.TokenPermission, error) {
+	var missing []cfgit.TokenPermission
+	for _, permission := range required {
+		if !p.granted[permission] {
+			missing = append(missing, permission)
+		}
+	}
+
+	return missing, nil
+}
+
+func Test_verifyGitTokenPermissions(t *testing.T) {
+	tests := map[string]struct {
+		createIfNotExist bool
+		fromRepo         bool
+		granted          map[cfgit.TokenPermission]bool
+		wantErr          string
+	}{
+		"should flag a missing repo create when the installation repo may be created": {
+			createIfNotExist: true,
+			fromRepo:         true,
+			granted: map[cfgit.TokenPermission]bool{
+				cfgit.PermissionRepoWrite: true,
+				cfgit.PermissionRepoHooks: true,
+			},
+			wantErr: "the git token is missing the following permissions: repo create (to create the installation repo when it does not exist)",
+		},
+		"should not require repo create when no repo is created": {
+			fromRepo: true,
+			granted: map[cfgit.TokenPermission]bool{
+				cfgit.PermissionRepoWrite: true,
+				cfgit.PermissionRepoHooks: true,
+			},
+		},
+		"should report every missing permission at once": {
+			createIfNotExist: true,
+			granted: map[cfgit.TokenPermission]bool{
+				cfgit.PermissionRepoWrite: true,
+			},
+			wantErr: "the git token is missing the following permissions: " +
+				"repo create (to create the installation repo when it does not exist), " +
+				"repo create (to create the git-source repo when it does not exist), " +
+				"public repo read (to read the marketplace git-source repo), " +
+				"repo webhooks (to create the webhooks of the git-source event sources)",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			provider := &fakePermissionsProvider{granted: tt.granted}
+			opts := &RuntimeInstallOptions{
+				FromRepo:     tt.fromRepo,
+				InsCloneOpts: &apgit.CloneOptions{CreateIfNotExist: tt.createIfNotExist},
+				GsCloneOpts:  &apgit.CloneOptions{CreateIfNotExist: true},
+				gitProvider:  provider,
+			}
+			err := verifyGitTokenPermissions(context.Background(), provider, "token", getRequiredTokenPermissions(opts))
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/codefresh-io/cli-v2/pkg/log"
+	"github.com/codefresh-io/cli-v2/pkg/runtime"
+	apu "github.com/codefresh-io/cli-v2/pkg/util/aputil"
+	eventsutil "github.com/codefresh-io/cli-v2/pkg/util/events"
+	kustutil "github.com/codefresh-io/cli-v2/pkg/util/kust"
+
+	"github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+	apstore "github.com/argoproj-labs/argocd-autopilot/pkg/store"
+	"github.com/ghodss/yaml"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const componentPlacementPatchPrefix = "placement-patch"
+
+// parsePodPlacement validates the --node-selector, --tolerations and --affinity, before anything is installed
+func parsePodPlacement(nodeSelector map[string]string, tolerations []string, affinity string) (eventsutil.PodPlacement, error) {
+	placement := eventsutil.PodPlacement{}
+	for k, v := range nodeSelector {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return placement, fmt.Errorf("invalid --node-selector key \"%s\": %s", k, strings.Join(errs, ", "))
+		}
+
+		if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+			return placement, fmt.Errorf("invalid --node-selector value \"%s\" of \"%s\": %s", v, k, strings.Join(errs, ", "))
+		}
+	}
+
+	if len(nodeSelector) > 0 {
+		placement.NodeSelector = nodeSelector
+	}
+
+	for _, value := range tolerations {
+		toleration, err := parseToleration(value)
+		if err != nil {
+			return placement, err
+		}
+
+		placement.Tolerations = append(placement.Tolerations, toleration)
+	}
+
+	if affinity != "" {
+		placement.Affinity = &v1.Affinity{}
+		if err := yaml.UnmarshalStrict([]byte(affinity), placement.Affinity, yaml.DisallowUnknownFields); err != nil {
+			return placement, fmt.Errorf("invalid --affinity: %w", err)
+		}
+	}
+
+	return placement, nil
+}
+
+// parseToleration parses a toleration as <key>[=<value>][:<effect>], like the taints of "kubectl taint".
+// Without a value, the toleration matches any value of the key
+func parseToleration(value string) (v1.Toleration, error) {
+	toleration := v1.Toleration{Operator: v1.TolerationOpExists}
+	keyValue, effect, _ := strings.Cut(value, ":")
+	key, tolerationValue, hasValue := strings.Cut(keyValue, "=")
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return toleration, fmt.Errorf("invalid --tolerations key in \"%s\": %s", value, strings.Join(errs, ", "))
+	}
+
+	toleration.Key = key
+	if hasValue {
+		if errs := validation.IsValidLabelValue(tolerationValue); len(errs) > 0 {
+			return toleration, fmt.Errorf("invalid --tolerations value in \"%s\": %s", value, strings.Join(errs, ", "))
+		}
+
+		toleration.Operator = v1.TolerationOpEqual
+		toleration.Value = tolerationValue
+	}
+
+	switch v1.TaintEffect(effect) {
+	case "", v1.TaintEffectNoSchedule, v1.TaintEffectPreferNoSchedule, v1.TaintEffectNoExecute:
+		toleration.Effect = v1.TaintEffect(effect)
+	default:
+		return toleration, fmt.Errorf("invalid --tolerations effect in \"%s\", must be one of: %s, %s, %s", value, v1.TaintEffectNoSchedule, v1.TaintEffectPreferNoSchedule, v1.TaintEffectNoExecute)
+	}
+
+	return toleration, nil
+}
+
+// setComponentsPlacement patches the workloads of the components overlays, and of the argo-cd bootstrap, with the
+// --node-selector, --tolerations and --affinity. The reporters pods are placed by their event sources and sensors
+func setComponentsPlacement(ctx context.Context, opts *RuntimeInstallOptions, rt *runtime.Runtime) error {
+	if isEmptyPodPlacement(opts.podPlacement) {
+		return nil
+	}
+
+	r, repofs, err := getInstallRepo(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, component := range rt.Spec.Components {
+		if isComponentSkipped(opts, component.Name) {
+			continue
+		}
+
+		appDir := repofs.Join(apstore.Default.AppsDir, component.Name)
+		workloads, err := getWorkloads(repofs, repofs.Join(appDir, apstore.Default.BaseDir), &opts.KustomizeBuildOptions)
+		if err != nil {
+			return fmt.Errorf("failed to build component \"%s\": %w", component.Name, err)
+		}
+
+		overlaysDir := repofs.Join(appDir, apstore.Default.OverlaysDir, opts.RuntimeName)
+		if err = setComponentPlacement(repofs, overlaysDir, workloads, opts.podPlacement); err != nil {
+			return fmt.Errorf("failed to set the placement of component \"%s\": %w", component.Name, err)
+		}
+	}
+
+	// argo-cd is installed by the repo bootstrap, and is not one of the components
+	argoCDDir := repofs.Join(apstore.Default.BootsrtrapDir, apstore.Default.ArgoCDName)
+	workloads, err := getWorkloads(repofs, argoCDDir, &opts.KustomizeBuildOptions)
+	if err != nil {
+		return fmt.Errorf("failed to build argo-cd: %w", err)
+	}
+
+	if err = setComponentPlacement(repofs, argoCDDir, workloads, opts.podPlacement); err != nil {
+		return fmt.Errorf("failed to set the placement of argo-cd: %w", err)
+	}
+
+	log.G(ctx).Info("Pushing the components placement")
+
+	return apu.PushWithMessage(ctx, r, "Set the components placement")
+}
+
+// setComponentPlacement patches the pod spec of every workload of the kustomization in dir with the placement
+func setComponentPlacement(repofs fs.FS, dir string, workloads []workload, placement eventsutil.PodPlacement) error {
+	kust, err := kustutil.ReadKustomization(repofs, dir)
+	if err != nil {
+		return err
+	}
+
+	podSpec := map[string]interface{}{}
+	if placement.NodeSelector != nil {
+		podSpec["nodeSelector"] = placement.NodeSelector
+	}
+
+	if placement.Tolerations != nil {
+		podSpec["tolerations"] = placement.Tolerations
+	}
+
+	if placement.Affinity != nil {
+		podSpec["affinity"] = placement.Affinity
+	}
+
+	for _, w := range workloads {
+		if err = writeWorkloadPatch(repofs, kust, dir, componentPlacementPatchPrefix, w, podSpec); err != nil {
+			return err
+		}
+	}
+
+	return kustutil.WriteKustomization(repofs, kust, dir)
+}
+
+func isEmptyPodPlacement(placement eventsutil.PodPlacement) bool {
+	return placement.NodeSelector == nil && placement.Tolerations == nil && placement.Affinity == nil
+}
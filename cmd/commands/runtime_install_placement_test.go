@@ -0,0 +1,216 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codefresh-io/cli-v2/pkg/runtime"
+	eventsutil "github.com/codefresh-io/cli-v2/pkg/util/events"
+	kustutil "github.com/codefresh-io/cli-v2/pkg/util/kust"
+
+	apfs "github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+	apstore "github.com/argoproj-labs/argocd-autopilot/pkg/store"
+	eventbusv1alpha1 "github.com/argoproj/argo-events/pkg/apis/eventbus/v1alpha1"
+	sensorsv1alpha1 "github.com/argoproj/argo-events/pkg/apis/sensor/v1alpha1"
+	"github.com/ghodss/yaml"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	kusttypes "sigs.k8s.io/kustomize/api/types"
+)
+
+func Test_parsePodPlacement(t *testing.T) {
+	tests := map[string]struct {
+		nodeSelector map[string]string
+		tolerations  []string
+		affinity     string
+		want         eventsutil.PodPlacement
+		wantErr      string
+	}{
+		"Should parse the node selector and tolerations": {
+			nodeSelector: map[string]string{"pool": "runtime"},
+			tolerations:  []string{"dedicated=runtime:NoSchedule", "example.com/gpu"},
+			want: eventsutil.PodPlacement{
+				NodeSelector: map[string]string{"pool": "runtime"},
+				Tolerations: []v1.Toleration{
+					{Key: "dedicated", Operator: v1.TolerationOpEqual, Value: "runtime", Effect: v1.TaintEffectNoSchedule},
+					{Key: "example.com/gpu", Operator: v1.TolerationOpExists},
+				},
+			},
+		},
+		"Should parse a yaml affinity": {
+			affinity: "nodeAffinity:\n  requiredDuringSchedulingIgnoredDuringExecution:\n    nodeSelectorTerms:\n    - matchExpressions:\n      - {key: pool, operator: In, values: [runtime]}\n",
+			want: eventsutil.PodPlacement{
+				Affinity: &v1.Affinity{
+					NodeAffinity: &v1.NodeAffinity{
+						RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+							NodeSelectorTerms: []v1.NodeSelectorTerm{{
+								MatchExpressions: []v1.NodeSelectorRequirement{{Key: "pool", Operator: v1.NodeSelectorOpIn, Values: []string{"runtime"}}},
+							}},
+						},
+					},
+				},
+			},
+		},
+		"Should fail on an invalid node selector key": {
+			nodeSelector: map[string]string{"bad key": "runtime"},
+			wantErr:      "invalid --node-selector key \"bad key\"",
+		},
+		"Should fail on an invalid toleration effect": {
+			tolerations: []string{"dedicated=runtime:NoRun"},
+			wantErr:     "invalid --tolerations effect in \"dedicated=runtime:NoRun\", must be one of: NoSchedule, PreferNoSchedule, NoExecute",
+		},
+		"Should fail on an unknown affinity field": {
+			affinity: "nodeAfinity: {}",
+			wantErr:  "invalid --affinity",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parsePodPlacement(tt.nodeSelector, tt.tolerations, tt.affinity)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_setComponentPlacement(t *testing.T) {
+	repofs := apfs.Create(memfs.New())
+	overlaysDir := "apps/argo-events/overlays/runtime"
+	assert.NoError(t, kustutil.WriteKustomization(repofs, &kusttypes.Kustomization{
+		TypeMeta: kusttypes.TypeMeta{
+			APIVersion: kusttypes.KustomizationVersion,
+			Kind:       kusttypes.KustomizationKind,
+		},
+		Resources: []string{"../../base"},
+	}, overlaysDir))
+
+	workloads := []workload{
+		{apiVersion: "apps/v1", kind: "Deployment", name: "controller-manager", podSpecPath: []string{"spec", "template", "spec"}},
+		{apiVersion: "argoproj.io/v1alpha1", kind: "EventBus", name: "codefresh-eventbus", podSpecPath: []string{"spec", "nats", "native"}},
+	}
+	placement := eventsutil.PodPlacement{
+		NodeSelector: map[string]string{"pool": "runtime"},
+		Tolerations:  []v1.Toleration{{Key: "dedicated", Operator: v1.TolerationOpExists}},
+	}
+	assert.NoError(t, setComponentPlacement(repofs, overlaysDir, workloads, placement))
+	assert.NoError(t, setComponentPlacement(repofs, overlaysDir, workloads, placement))
+
+	kust, err := kustutil.ReadKustomization(repofs, overlaysDir)
+	assert.NoError(t, err)
+	assert.Len(t, kust.Patches, 2)
+	assert.Equal(t, "placement-patch-deployment-controller-manager.yaml", kust.Patches[0].Path)
+	assert.Equal(t, "controller-manager", kust.Patches[0].Target.Name)
+	assert.Equal(t, "placement-patch-eventbus-codefresh-eventbus.yaml", kust.Patches[1].Path)
+	assert.Equal(t, "argoproj.io", kust.Patches[1].Target.Group)
+	assert.Equal(t, "EventBus", kust.Patches[1].Target.Kind)
+
+	data, err := repofs.ReadFile(repofs.Join(overlaysDir, kust.Patches[0].Path))
+	assert.NoError(t, err)
+	deployment := &appsv1.Deployment{}
+	assert.NoError(t, yaml.Unmarshal(data, deployment))
+	assert.Equal(t, placement.NodeSelector, deployment.Spec.Template.Spec.NodeSelector)
+	assert.Equal(t, placement.Tolerations, deployment.Spec.Template.Spec.Tolerations)
+	assert.Empty(t, deployment.Spec.Template.Spec.Containers)
+
+	data, err = repofs.ReadFile(repofs.Join(overlaysDir, kust.Patches[1].Path))
+	assert.NoError(t, err)
+	eventBus := &eventbusv1alpha1.EventBus{}
+	assert.NoError(t, yaml.Unmarshal(data, eventBus))
+	assert.Equal(t, placement.NodeSelector, eventBus.Spec.NATS.Native.NodeSelector)
+	assert.Equal(t, placement.Tolerations, eventBus.Spec.NATS.Native.Tolerations)
+}
+
+func Test_setComponentsPlacement(t *testing.T) {
+	repofs := apfs.Create(memfs.New())
+	argoCDDir := repofs.Join(apstore.Default.BootsrtrapDir, apstore.Default.ArgoCDName)
+	overlaysDir := repofs.Join(apstore.Default.AppsDir, "app-proxy", apstore.Default.OverlaysDir, "runtime")
+	for _, dir := range []string{argoCDDir, overlaysDir} {
+		assert.NoError(t, kustutil.WriteKustomization(repofs, &kusttypes.Kustomization{
+			TypeMeta: kusttypes.TypeMeta{
+				APIVersion: kusttypes.KustomizationVersion,
+				Kind:       kusttypes.KustomizationKind,
+			},
+		}, dir))
+	}
+
+	origGetWorkloads := getWorkloads
+	getWorkloads = func(_ apfs.FS, dir string, _ *kustutil.BuildOptions) ([]workload, error) {
+		if dir == argoCDDir {
+			return []workload{{apiVersion: "apps/v1", kind: "StatefulSet", name: "argocd-application-controller", podSpecPath: []string{"spec", "template", "spec"}}}, nil
+		}
+
+		return []workload{{apiVersion: "apps/v1", kind: "Deployment", name: "cap-app-proxy", podSpecPath: []string{"spec", "template", "spec"}}}, nil
+	}
+	defer func() { getWorkloads = origGetWorkloads }()
+
+	opts := &RuntimeInstallOptions{
+		RuntimeName:  "runtime",
+		dryRunFS:     repofs,
+		podPlacement: eventsutil.PodPlacement{NodeSelector: map[string]string{"pool": "runtime"}},
+	}
+	rt := &runtime.Runtime{
+		Spec: runtime.RuntimeSpec{
+			Components: []runtime.AppDef{{Name: "app-proxy"}},
+		},
+	}
+	assert.NoError(t, setComponentsPlacement(context.Background(), opts, rt))
+
+	tests := map[string]struct {
+		dir       string
+		wantPatch string
+	}{
+		"should place the component workloads": {
+			dir:       overlaysDir,
+			wantPatch: "placement-patch-deployment-cap-app-proxy.yaml",
+		},
+		"should place the argo-cd workloads of the bootstrap": {
+			dir:       argoCDDir,
+			wantPatch: "placement-patch-statefulset-argocd-application-controller.yaml",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			kust, err := kustutil.ReadKustomization(repofs, tt.dir)
+			assert.NoError(t, err)
+			assert.Len(t, kust.Patches, 1)
+			assert.Equal(t, tt.wantPatch, kust.Patches[0].Path)
+			assert.True(t, repofs.ExistsOrDie(repofs.Join(tt.dir, tt.wantPatch)))
+		})
+	}
+}
+
+func Test_createSensor_placement(t *testing.T) {
+	repofs := apfs.Create(memfs.New())
+	placement := eventsutil.PodPlacement{
+		NodeSelector: map[string]string{"pool": "runtime"},
+		Tolerations:  []v1.Toleration{{Key: "dedicated", Operator: v1.TolerationOpExists}},
+	}
+	opts := &RuntimeInstallOptions{}
+	assert.NoError(t, createSensor(repofs, "events-reporter", "resources", "runtime", "events-reporter", []string{"events"}, "data", "https://g.codefresh.io/2.0/api/events", getArgoEventsRef(opts), getTriggerRetry(opts), placement))
+
+	sensor := &sensorsv1alpha1.Sensor{}
+	assert.NoError(t, repofs.ReadYamls(repofs.Join("resources", "sensor.yaml"), sensor))
+	assert.Equal(t, placement.NodeSelector, sensor.Spec.Template.NodeSelector)
+	assert.Equal(t, placement.Tolerations, sensor.Spec.Template.Tolerations)
+}
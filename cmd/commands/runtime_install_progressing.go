@@ -0,0 +1,160 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/codefresh-io/cli-v2/pkg/log"
+
+	"github.com/codefresh-io/go-sdk/pkg/codefresh/model"
+	"github.com/rkrmr33/checklist"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// componentProgressingTimeout is how long a component can be progressing, before the state of its pods is checked
+const componentProgressingTimeout = 2 * time.Minute
+
+// podBackoffReasons are the container waiting reasons that keep a component progressing until the sync times out
+var podBackoffReasons = map[string]bool{
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"CrashLoopBackOff":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+type (
+	// progressingComponents tracks how long each component has been progressing, and surfaces the backoff
+	// of the pods of the ones that are progressing for too long, which otherwise look like they are still starting
+	progressingComponents struct {
+		cs        kubernetes.Interface
+		runtime   string
+		lock      sync.Mutex
+		since     map[string]time.Time
+		podErrors map[string]string
+	}
+)
+
+func newProgressingComponents(cs kubernetes.Interface, runtime string) *progressingComponents {
+	return &progressingComponents{
+		cs:        cs,
+		runtime:   runtime,
+		since:     map[string]time.Time{},
+		podErrors: map[string]string{},
+	}
+}
+
+func (p *progressingComponents) update(ctx context.Context, components []model.Component, now time.Time) {
+	if p.cs == nil {
+		return
+	}
+
+	for _, c := range components {
+		name := c.Metadata.Name
+		if !isComponentProgressing(c) {
+			p.lock.Lock()
+			delete(p.since, name)
+			delete(p.podErrors, name)
+			p.lock.Unlock()
+			continue
+		}
+
+		p.lock.Lock()
+		since, ok := p.since[name]
+		if !ok {
+			p.since[name] = now
+			since = now
+		}
+		p.lock.Unlock()
+
+		if now.Sub(since) < componentProgressingTimeout {
+			continue
+		}
+
+		namespace := p.runtime
+		if c.Metadata.Namespace != nil && *c.Metadata.Namespace != "" {
+			namespace = *c.Metadata.Namespace
+		}
+
+		podErr, err := getPodsBackoff(ctx, p.cs, namespace, name)
+		if err != nil {
+			log.G(ctx).WithError(err).Debugf("Failed to get the pods state of component \"%s\"", name)
+			continue
+		}
+
+		p.lock.Lock()
+		p.podErrors[name] = podErr
+		p.lock.Unlock()
+	}
+}
+
+// apply reports the pods backoff of a component that is stuck progressing, unless it already has a sync error
+func (p *progressingComponents) apply(name string, state checklist.ListItemState, info checklist.ListItemInfo) (checklist.ListItemState, checklist.ListItemInfo) {
+	p.lock.Lock()
+	podErr := p.podErrors[name]
+	p.lock.Unlock()
+
+	if podErr == "" || info[componentInfoErrors] != "" {
+		return state, info
+	}
+
+	info[componentInfoErrors] = podErr
+	return checklist.Error, info
+}
+
+func isComponentProgressing(c model.Component) bool {
+	return c.Self != nil && c.Self.Status != nil && c.Self.Status.HealthStatus != nil &&
+		*c.Self.Status.HealthStatus == model.HealthStatusProgressing
+}
+
+func isComponentDegraded(c model.Component) bool {
+	return c.Self != nil && c.Self.Status != nil && c.Self.Status.HealthStatus != nil &&
+		*c.Self.Status.HealthStatus == model.HealthStatusDegraded
+}
+
+// getPodsBackoff returns the first backoff of the containers of the pods of an argo-cd application
+func getPodsBackoff(ctx context.Context, cs kubernetes.Interface, namespace, appName string) (string, error) {
+	pods, err := cs.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app.kubernetes.io/instance=%s", appName),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	for _, pod := range pods.Items {
+		statuses := append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			waiting := status.State.Waiting
+			if waiting == nil || !podBackoffReasons[waiting.Reason] {
+				continue
+			}
+
+			msg := fmt.Sprintf("pod %s: %s", pod.Name, waiting.Reason)
+			if waiting.Message != "" {
+				msg = fmt.Sprintf("%s: %s", msg, waiting.Message)
+			}
+
+			return msg, nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/go-sdk/pkg/codefresh/model"
+	"github.com/rkrmr33/checklist"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_progressingComponents_podBackoff(t *testing.T) {
+	cs := fake.NewSimpleClientset(
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "argo-events-controller",
+				Namespace: "runtime",
+				Labels:    map[string]string{"app.kubernetes.io/instance": "runtime-argo-events"},
+			},
+			Status: v1.PodStatus{
+				ContainerStatuses: []v1.ContainerStatus{
+					{
+						Name: "controller",
+						State: v1.ContainerState{
+							Waiting: &v1.ContainerStateWaiting{
+								Reason:  "CrashLoopBackOff",
+								Message: "back-off 5m0s restarting failed container",
+							},
+						},
+					},
+				},
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "other-pod",
+				Namespace: "runtime",
+				Labels:    map[string]string{"app.kubernetes.io/instance": "runtime-argo-cd"},
+			},
+		},
+	)
+
+	podErr, err := getPodsBackoff(context.Background(), cs, "runtime", "runtime-argo-events")
+	assert.NoError(t, err)
+	assert.Equal(t, "pod argo-events-controller: CrashLoopBackOff: back-off 5m0s restarting failed container", podErr)
+
+	progressing := newProgressingComponents(cs, "runtime")
+	progressing.podErrors["runtime-argo-events"] = podErr
+	component := model.Component{Metadata: &model.ObjectMeta{Name: "runtime-argo-events", Runtime: "runtime"}}
+	state, info := getComponentChecklistState(component)
+	state, info = progressing.apply("runtime-argo-events", state, info)
+	assert.Equal(t, checklist.Error, state)
+	assert.Equal(t, podErr, info[componentInfoErrors])
+
+	// a component that is no longer progressing is not reported anymore
+	progressing.update(context.Background(), []model.Component{component}, time.Now())
+	state, info = getComponentChecklistState(component)
+	state, _ = progressing.apply("runtime-argo-events", state, info)
+	assert.Equal(t, checklist.Waiting, state)
+}
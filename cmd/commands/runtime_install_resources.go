@@ -0,0 +1,321 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/codefresh-io/cli-v2/pkg/log"
+	"github.com/codefresh-io/cli-v2/pkg/runtime"
+	"github.com/codefresh-io/cli-v2/pkg/util"
+	apu "github.com/codefresh-io/cli-v2/pkg/util/aputil"
+	kustutil "github.com/codefresh-io/cli-v2/pkg/util/kust"
+
+	"github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+	apstore "github.com/argoproj-labs/argocd-autopilot/pkg/store"
+	"github.com/ghodss/yaml"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	kusttypes "sigs.k8s.io/kustomize/api/types"
+	kustid "sigs.k8s.io/kustomize/kyaml/resid"
+)
+
+type (
+	// workload is a resource of a component that runs pods: a deployment, a statefulset,
+	// or an event bus (whose statefulset is created by the argo-events controller)
+	workload struct {
+		apiVersion string
+		kind       string
+		name       string
+		containers []string
+		// podSpecPath is where the pod spec fields of the workload are
+		podSpecPath []string
+	}
+)
+
+const componentResourcesPatchPrefix = "resources-patch"
+
+// parseComponentResources parses the --component-resources values, e.g. "app-proxy:cpu=200m,memory=256Mi".
+// "cpu" and "memory" set the requests, "limits.cpu" and "limits.memory" set the limits
+func parseComponentResources(values []string) (map[string]v1.ResourceRequirements, error) {
+	res := map[string]v1.ResourceRequirements{}
+	for _, value := range values {
+		name, list, found := strings.Cut(value, ":")
+		if !found || name == "" || list == "" {
+			return nil, fmt.Errorf("invalid --component-resources \"%s\", expected <component>:<resource>=<quantity>,... (e.g. \"app-proxy:cpu=200m,memory=256Mi\")", value)
+		}
+
+		requirements := res[name]
+		for _, pair := range strings.Split(list, ",") {
+			key, qty, found := strings.Cut(pair, "=")
+			if !found {
+				return nil, fmt.Errorf("invalid resource \"%s\" in --component-resources \"%s\", expected <resource>=<quantity>", pair, value)
+			}
+
+			quantity, err := resource.ParseQuantity(qty)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quantity of \"%s\" in --component-resources \"%s\": %w", key, value, err)
+			}
+
+			kind, resourceName, found := strings.Cut(key, ".")
+			if !found {
+				kind, resourceName = "requests", key
+			}
+
+			if resourceName != string(v1.ResourceCPU) && resourceName != string(v1.ResourceMemory) {
+				return nil, fmt.Errorf("unsupported resource \"%s\" in --component-resources \"%s\", must be cpu or memory", key, value)
+			}
+
+			switch kind {
+			case "requests":
+				if requirements.Requests == nil {
+					requirements.Requests = v1.ResourceList{}
+				}
+
+				requirements.Requests[v1.ResourceName(resourceName)] = quantity
+			case "limits":
+				if requirements.Limits == nil {
+					requirements.Limits = v1.ResourceList{}
+				}
+
+				requirements.Limits[v1.ResourceName(resourceName)] = quantity
+			default:
+				return nil, fmt.Errorf("unsupported resource \"%s\" in --component-resources \"%s\", must be requests or limits", key, value)
+			}
+		}
+
+		res[name] = requirements
+	}
+
+	return res, nil
+}
+
+// validateComponentResources fails on a --component-resources component that is not a component of the runtime definition
+func validateComponentResources(rt *runtime.Runtime, componentResources map[string]v1.ResourceRequirements) error {
+	var known []string
+	for _, component := range rt.Spec.Components {
+		known = append(known, component.Name)
+	}
+
+	for name := range componentResources {
+		if util.StringIndexOf(known, name) == -1 {
+			return fmt.Errorf("unknown component \"%s\" in --component-resources, the runtime components are: %s", name, strings.Join(known, ", "))
+		}
+	}
+
+	return nil
+}
+
+// setComponentsResources patches the overlays of the components with their --component-resources,
+// which override the blanket --set-default-resources. The component bases are built on the machine running the installation,
+// to find their workloads
+func setComponentsResources(ctx context.Context, opts *RuntimeInstallOptions) error {
+	if len(opts.componentResources) == 0 {
+		return nil
+	}
+
+	r, repofs, err := getInstallRepo(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(opts.componentResources))
+	for name := range opts.componentResources {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		if isComponentSkipped(opts, name) {
+			continue
+		}
+
+		appDir := repofs.Join(apstore.Default.AppsDir, name)
+		workloads, err := getWorkloads(repofs, repofs.Join(appDir, apstore.Default.BaseDir), &opts.KustomizeBuildOptions)
+		if err != nil {
+			return fmt.Errorf("failed to build component \"%s\": %w", name, err)
+		}
+
+		overlaysDir := repofs.Join(appDir, apstore.Default.OverlaysDir, opts.RuntimeName)
+		if err = setComponentResources(repofs, overlaysDir, workloads, opts.componentResources[name]); err != nil {
+			return fmt.Errorf("failed to set the resources of component \"%s\": %w", name, err)
+		}
+	}
+
+	log.G(ctx).Info("Pushing the components resources")
+
+	return apu.PushWithMessage(ctx, r, "Set the components resources")
+}
+
+// setComponentResources sets the resources of the first container of every workload of the component overlay.
+// Only the given requests and limits are set, the others are kept as they are in the base
+func setComponentResources(repofs fs.FS, overlaysDir string, workloads []workload, resources v1.ResourceRequirements) error {
+	kust, err := kustutil.ReadKustomization(repofs, overlaysDir)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range workloads {
+		if len(w.containers) == 0 {
+			continue
+		}
+
+		podSpec := map[string]interface{}{
+			"containers": []map[string]interface{}{
+				{
+					"name":      w.containers[0],
+					"resources": resources,
+				},
+			},
+		}
+		if err = writeWorkloadPatch(repofs, kust, overlaysDir, componentResourcesPatchPrefix, w, podSpec); err != nil {
+			return err
+		}
+	}
+
+	return kustutil.WriteKustomization(repofs, kust, overlaysDir)
+}
+
+// getWorkloads builds the resources of the kustomization in dir, and returns its deployments and statefulsets
+var getWorkloads = func(repofs fs.FS, dir string, buildOpts *kustutil.BuildOptions) ([]workload, error) {
+	kust, err := kustutil.ReadKustomization(repofs, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// the other fields of the kustomization may refer to files in the repo, and do not add workloads
+	manifests, err := kustutil.BuildKustomization(&kusttypes.Kustomization{
+		TypeMeta:  kust.TypeMeta,
+		Resources: kust.Resources,
+	}, buildOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return getManifestsWorkloads(manifests), nil
+}
+
+// getManifestsWorkloads returns the deployments, statefulsets and event buses of the yaml stream
+func getManifestsWorkloads(manifests []byte) []workload {
+	var workloads []workload
+	for _, doc := range strings.Split(string(manifests), "\n---") {
+		obj := &struct {
+			APIVersion string `json:"apiVersion"`
+			Kind       string `json:"kind"`
+			Metadata   struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Spec struct {
+				Template struct {
+					Spec struct {
+						Containers []struct {
+							Name string `json:"name"`
+						} `json:"containers"`
+					} `json:"spec"`
+				} `json:"template"`
+				NATS      map[string]interface{} `json:"nats"`
+				JetStream map[string]interface{} `json:"jetstream"`
+			} `json:"spec"`
+		}{}
+		if err := yaml.Unmarshal([]byte(doc), obj); err != nil || obj.Metadata.Name == "" {
+			continue
+		}
+
+		w := workload{
+			apiVersion: obj.APIVersion,
+			kind:       obj.Kind,
+			name:       obj.Metadata.Name,
+		}
+		switch obj.Kind {
+		case "Deployment", "StatefulSet":
+			w.podSpecPath = []string{"spec", "template", "spec"}
+			for _, c := range obj.Spec.Template.Spec.Containers {
+				w.containers = append(w.containers, c.Name)
+			}
+		case "EventBus":
+			if obj.Spec.JetStream != nil {
+				w.podSpecPath = []string{"spec", "jetstream"}
+			} else if obj.Spec.NATS["native"] != nil {
+				w.podSpecPath = []string{"spec", "nats", "native"}
+			} else {
+				// an exotic event bus runs no pods of the runtime
+				continue
+			}
+		default:
+			continue
+		}
+
+		workloads = append(workloads, w)
+	}
+
+	return workloads
+}
+
+// writeWorkloadPatch writes a strategic merge patch of the pod spec fields of the workload to dir, and adds it to kust
+func writeWorkloadPatch(repofs fs.FS, kust *kusttypes.Kustomization, dir, prefix string, w workload, podSpec map[string]interface{}) error {
+	fields := podSpec
+	for i := len(w.podSpecPath) - 1; i >= 0; i-- {
+		fields = map[string]interface{}{w.podSpecPath[i]: fields}
+	}
+
+	fields["apiVersion"] = w.apiVersion
+	fields["kind"] = w.kind
+	fields["metadata"] = map[string]interface{}{
+		"name": w.name,
+	}
+	patch, err := yaml.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	fileName := fmt.Sprintf("%s-%s-%s.yaml", prefix, strings.ToLower(w.kind), w.name)
+	if err = repofs.WriteFile(repofs.Join(dir, fileName), patch); err != nil {
+		return err
+	}
+
+	addWorkloadPatch(kust, w, fileName)
+	return nil
+}
+
+// addWorkloadPatch adds the patch file to the kustomization, targeting the workload, unless it was already added
+func addWorkloadPatch(kust *kusttypes.Kustomization, w workload, path string) {
+	for _, p := range kust.Patches {
+		if p.Path == path {
+			return
+		}
+	}
+
+	group, version, found := strings.Cut(w.apiVersion, "/")
+	if !found {
+		group, version = "", w.apiVersion
+	}
+
+	kust.Patches = append(kust.Patches, kusttypes.Patch{
+		Target: &kusttypes.Selector{
+			ResId: kustid.ResId{
+				Gvk: kustid.Gvk{
+					Group:   group,
+					Version: version,
+					Kind:    w.kind,
+				},
+				Name: w.name,
+			},
+		},
+		Path: path,
+	})
+}
@@ -0,0 +1,205 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/codefresh-io/cli-v2/pkg/runtime"
+	kustutil "github.com/codefresh-io/cli-v2/pkg/util/kust"
+
+	apfs "github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+	"github.com/ghodss/yaml"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	kusttypes "sigs.k8s.io/kustomize/api/types"
+)
+
+func Test_parseComponentResources(t *testing.T) {
+	tests := map[string]struct {
+		values  []string
+		want    map[string]v1.ResourceRequirements
+		wantErr string
+	}{
+		"Should parse requests and limits": {
+			values: []string{"app-proxy:cpu=200m,memory=256Mi,limits.memory=512Mi", "events:requests.cpu=1"},
+			want: map[string]v1.ResourceRequirements{
+				"app-proxy": {
+					Requests: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("200m"),
+						v1.ResourceMemory: resource.MustParse("256Mi"),
+					},
+					Limits: v1.ResourceList{
+						v1.ResourceMemory: resource.MustParse("512Mi"),
+					},
+				},
+				"events": {
+					Requests: v1.ResourceList{
+						v1.ResourceCPU: resource.MustParse("1"),
+					},
+				},
+			},
+		},
+		"Should fail without a component name": {
+			values:  []string{"cpu=200m"},
+			wantErr: "invalid --component-resources \"cpu=200m\", expected <component>:<resource>=<quantity>,... (e.g. \"app-proxy:cpu=200m,memory=256Mi\")",
+		},
+		"Should fail on an invalid quantity": {
+			values:  []string{"app-proxy:cpu=lots"},
+			wantErr: "invalid quantity of \"cpu\" in --component-resources \"app-proxy:cpu=lots\"",
+		},
+		"Should fail on an unsupported resource": {
+			values:  []string{"app-proxy:storage=1Gi"},
+			wantErr: "unsupported resource \"storage\" in --component-resources \"app-proxy:storage=1Gi\", must be cpu or memory",
+		},
+		"Should fail on an unsupported kind": {
+			values:  []string{"app-proxy:max.cpu=1"},
+			wantErr: "unsupported resource \"max.cpu\" in --component-resources \"app-proxy:max.cpu=1\", must be requests or limits",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseComponentResources(tt.values)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, len(tt.want), len(got))
+			for name, want := range tt.want {
+				assert.True(t, equalResourceLists(want.Requests, got[name].Requests), "requests of %s", name)
+				assert.True(t, equalResourceLists(want.Limits, got[name].Limits), "limits of %s", name)
+			}
+		})
+	}
+}
+
+func Test_validateComponentResources(t *testing.T) {
+	rt := &runtime.Runtime{
+		Spec: runtime.RuntimeSpec{
+			Components: []runtime.AppDef{{Name: "app-proxy"}},
+		},
+	}
+
+	assert.NoError(t, validateComponentResources(rt, map[string]v1.ResourceRequirements{"app-proxy": {}}))
+	assert.EqualError(t, validateComponentResources(rt, map[string]v1.ResourceRequirements{"events": {}}), "unknown component \"events\" in --component-resources, the runtime components are: app-proxy")
+}
+
+func Test_setComponentResources(t *testing.T) {
+	repofs := apfs.Create(memfs.New())
+	overlaysDir := "apps/argo-events/overlays/runtime"
+	assert.NoError(t, kustutil.WriteKustomization(repofs, &kusttypes.Kustomization{
+		TypeMeta: kusttypes.TypeMeta{
+			APIVersion: kusttypes.KustomizationVersion,
+			Kind:       kusttypes.KustomizationKind,
+		},
+		Resources: []string{"../../base"},
+	}, overlaysDir))
+
+	workloads := []workload{
+		{apiVersion: "apps/v1", kind: "Deployment", name: "controller-manager", containers: []string{"controller", "sidecar"}, podSpecPath: []string{"spec", "template", "spec"}},
+		{apiVersion: "apps/v1", kind: "StatefulSet", name: "eventbus", containers: []string{"main"}, podSpecPath: []string{"spec", "template", "spec"}},
+	}
+	resources := v1.ResourceRequirements{
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("200m")},
+	}
+	assert.NoError(t, setComponentResources(repofs, overlaysDir, workloads, resources))
+	assert.NoError(t, setComponentResources(repofs, overlaysDir, workloads, resources))
+
+	kust, err := kustutil.ReadKustomization(repofs, overlaysDir)
+	assert.NoError(t, err)
+	assert.Len(t, kust.Patches, 2)
+	assert.Equal(t, "resources-patch-deployment-controller-manager.yaml", kust.Patches[0].Path)
+	assert.Equal(t, "apps", kust.Patches[0].Target.Group)
+	assert.Equal(t, "Deployment", kust.Patches[0].Target.Kind)
+	assert.Equal(t, "controller-manager", kust.Patches[0].Target.Name)
+	assert.Equal(t, "resources-patch-statefulset-eventbus.yaml", kust.Patches[1].Path)
+	assert.Equal(t, "StatefulSet", kust.Patches[1].Target.Kind)
+	assert.Equal(t, "eventbus", kust.Patches[1].Target.Name)
+
+	data, err := repofs.ReadFile(repofs.Join(overlaysDir, kust.Patches[0].Path))
+	assert.NoError(t, err)
+	patch := &appsv1.Deployment{}
+	assert.NoError(t, yaml.Unmarshal(data, patch))
+	assert.Equal(t, "Deployment", patch.Kind)
+	assert.Equal(t, "controller-manager", patch.Name)
+	assert.Len(t, patch.Spec.Template.Spec.Containers, 1)
+	assert.Equal(t, "controller", patch.Spec.Template.Spec.Containers[0].Name)
+	// a strategic merge patch of the requests keeps the limits of the base
+	assert.True(t, equalResourceLists(resources.Requests, patch.Spec.Template.Spec.Containers[0].Resources.Requests))
+	assert.Nil(t, patch.Spec.Template.Spec.Containers[0].Resources.Limits)
+}
+
+func Test_getManifestsWorkloads(t *testing.T) {
+	manifests := []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: controller-manager
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+spec:
+  template:
+    spec:
+      containers:
+      - name: controller
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: eventbus
+spec:
+  template:
+    spec:
+      containers:
+      - name: main
+      - name: metrics
+---
+apiVersion: argoproj.io/v1alpha1
+kind: EventBus
+metadata:
+  name: codefresh-eventbus
+spec:
+  nats:
+    native:
+      replicas: 3
+`)
+	assert.Equal(t, []workload{
+		{apiVersion: "apps/v1", kind: "Deployment", name: "controller-manager", containers: []string{"controller"}, podSpecPath: []string{"spec", "template", "spec"}},
+		{apiVersion: "apps/v1", kind: "StatefulSet", name: "eventbus", containers: []string{"main", "metrics"}, podSpecPath: []string{"spec", "template", "spec"}},
+		{apiVersion: "argoproj.io/v1alpha1", kind: "EventBus", name: "codefresh-eventbus", podSpecPath: []string{"spec", "nats", "native"}},
+	}, getManifestsWorkloads(manifests))
+}
+
+func equalResourceLists(a, b v1.ResourceList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for name, qty := range a {
+		other, ok := b[name]
+		if !ok || qty.Cmp(other) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,118 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/codefresh-io/cli-v2/pkg/log"
+	"github.com/codefresh-io/cli-v2/pkg/reporter"
+	"github.com/codefresh-io/cli-v2/pkg/store"
+)
+
+const (
+	smokeTestModeFail = "fail"
+	smokeTestModeWarn = "warn"
+)
+
+func validateSmokeTestMode(mode string) error {
+	if mode != smokeTestModeFail && mode != smokeTestModeWarn {
+		return fmt.Errorf("invalid --smoke-test \"%s\", must be one of: %s, %s", mode, smokeTestModeFail, smokeTestModeWarn)
+	}
+
+	return nil
+}
+
+// smokeTestAppProxy requests the app-proxy version through the ingress. Component health does not
+// catch an ingress that routes the app-proxy path elsewhere, so this is the only end-to-end check
+func smokeTestAppProxy(ctx context.Context, opts *RuntimeInstallOptions) error {
+	appProxyURL := getAppProxySmokeTestURL(opts)
+	version, err := checkAppProxyVersion(ctx, appProxyURL, cfConfig.GetCurrentContext().Token, store.Get().InsecureIngressHost)
+	if err != nil {
+		err = fmt.Errorf("app-proxy smoke test failed: %w", err)
+	}
+
+	handleCliStep(reporter.InstallStepSmokeTestAppProxy, "Smoke testing the app-proxy", err, false, true)
+	if err != nil {
+		if opts.SmokeTest == smokeTestModeWarn {
+			log.G(ctx).WithError(err).Warnf("The app-proxy did not respond correctly on \"%s\", check the ingress routing", appProxyURL)
+			return nil
+		}
+
+		return err
+	}
+
+	log.G(ctx).Infof("App-proxy version %s responded on \"%s\"", version, appProxyURL)
+	return nil
+}
+
+// getAppProxySmokeTestURL is the app-proxy version url on the host the app-proxy ingress is created on
+func getAppProxySmokeTestURL(opts *RuntimeInstallOptions) string {
+	host := opts.IngressHost
+	if opts.InternalIngressHost != "" {
+		host = opts.InternalIngressHost
+	}
+
+	return strings.TrimSuffix(host, "/") + "/" + strings.Trim(store.Get().AppProxyIngressPath, "/") + store.Get().AppProxyVersionEndpoint
+}
+
+// checkAppProxyVersion returns the version the app-proxy reports. A response without a version, like the
+// page of a default backend, means the request did not reach the app-proxy
+func checkAppProxyVersion(ctx context.Context, appProxyURL, token string, insecure bool) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", appProxyURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Authorization", token)
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	res, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the response: %w", err)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return "", fmt.Errorf("\"%s\" responded with status code %d", appProxyURL, res.StatusCode)
+	}
+
+	versionInfo := &struct {
+		Version string `json:"version"`
+	}{}
+	if err = json.Unmarshal(body, versionInfo); err != nil || versionInfo.Version == "" {
+		return "", fmt.Errorf("\"%s\" did not respond with the app-proxy version, the request may be routed to a different service", appProxyURL)
+	}
+
+	return versionInfo.Version, nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codefresh-io/cli-v2/pkg/store"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newFakeIngress routes the app-proxy path to appProxy, and everything else to a default backend
+func newFakeIngress(appProxy http.Handler) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("<html>default backend - 404</html>"))
+	})
+	if appProxy != nil {
+		mux.Handle(store.Get().AppProxyIngressPath, appProxy)
+	}
+
+	return httptest.NewTLSServer(mux)
+}
+
+func Test_checkAppProxyVersion(t *testing.T) {
+	appProxyStub := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "user-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Path != "/app-proxy/api/version" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"version":"1.2.3"}`))
+	})
+	spaStub := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html>some other service</html>"))
+	})
+
+	tests := map[string]struct {
+		appProxy http.Handler
+		token    string
+		insecure bool
+		want     string
+		wantErr  string
+	}{
+		"Should return the app-proxy version": {
+			appProxy: appProxyStub,
+			token:    "user-token",
+			insecure: true,
+			want:     "1.2.3",
+		},
+		"Should fail when the app-proxy path is not routed": {
+			token:    "user-token",
+			insecure: true,
+			wantErr:  "responded with status code 404",
+		},
+		"Should fail when the app-proxy path is routed to a different service": {
+			appProxy: spaStub,
+			token:    "user-token",
+			insecure: true,
+			wantErr:  "did not respond with the app-proxy version",
+		},
+		"Should fail when the request is not authenticated": {
+			appProxy: appProxyStub,
+			token:    "other-token",
+			insecure: true,
+			wantErr:  "responded with status code 401",
+		},
+		"Should fail on an untrusted certificate when not insecure": {
+			appProxy: appProxyStub,
+			token:    "user-token",
+			wantErr:  "certificate",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ingress := newFakeIngress(tt.appProxy)
+			defer ingress.Close()
+
+			opts := &RuntimeInstallOptions{IngressHost: ingress.URL}
+			got, err := checkAppProxyVersion(context.Background(), getAppProxySmokeTestURL(opts), tt.token, tt.insecure)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_getAppProxySmokeTestURL(t *testing.T) {
+	tests := map[string]struct {
+		opts *RuntimeInstallOptions
+		want string
+	}{
+		"Should use the ingress host": {
+			opts: &RuntimeInstallOptions{IngressHost: "https://runtime.example.com"},
+			want: "https://runtime.example.com/app-proxy/api/version",
+		},
+		"Should use the internal ingress host when set": {
+			opts: &RuntimeInstallOptions{IngressHost: "https://runtime.example.com", InternalIngressHost: "http://internal.example.com/"},
+			want: "http://internal.example.com/app-proxy/api/version",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getAppProxySmokeTestURL(tt.opts))
+		})
+	}
+}
+
+func Test_validateSmokeTestMode(t *testing.T) {
+	assert.NoError(t, validateSmokeTestMode(smokeTestModeFail))
+	assert.NoError(t, validateSmokeTestMode(smokeTestModeWarn))
+	assert.EqualError(t, validateSmokeTestMode("ignore"), "invalid --smoke-test \"ignore\", must be one of: fail, warn")
+}
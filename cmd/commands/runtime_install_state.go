@@ -0,0 +1,219 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	cfgit "github.com/codefresh-io/cli-v2/pkg/git"
+	"github.com/codefresh-io/cli-v2/pkg/log"
+	apu "github.com/codefresh-io/cli-v2/pkg/util/aputil"
+
+	"github.com/spf13/cobra"
+)
+
+type (
+	installPhase string
+
+	// installState is the minimal progress persisted locally during runtime installation,
+	// so an interrupted installation can be resumed with --resume
+	installState struct {
+		RuntimeName    string       `json:"runtimeName"`
+		Phase          installPhase `json:"phase"`
+		Repo           string       `json:"repo"`
+		IngressHost    string       `json:"ingressHost"`
+		GitProvider    string       `json:"gitProvider"`
+		GitProviderAPI string       `json:"gitProviderApiUrl"`
+		UpdatedAt      time.Time    `json:"updatedAt"`
+	}
+)
+
+const (
+	// all manifests were pushed to the installation repo, only the sync wait and the git integration are left
+	installPhaseComponentsPushed installPhase = "components-pushed"
+
+	installStateDirName = ".cfinstall"
+)
+
+var installStateDir = func() (string, error) {
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	return filepath.Join(homedir, installStateDirName), nil
+}
+
+func installStateFile(runtimeName string) (string, error) {
+	dir, err := installStateDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, runtimeName+".json"), nil
+}
+
+func saveInstallState(state *installState) error {
+	fileName, err := installStateFile(state.RuntimeName)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(fileName), 0700); err != nil {
+		return fmt.Errorf("failed to create install state directory: %w", err)
+	}
+
+	state.UpdatedAt = time.Now()
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal install state: %w", err)
+	}
+
+	return ioutil.WriteFile(fileName, data, 0600)
+}
+
+// loadInstallState returns the persisted install state of the runtime, or nil if there is none
+func loadInstallState(runtimeName string) (*installState, error) {
+	fileName, err := installStateFile(runtimeName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to read install state file \"%s\": %w", fileName, err)
+	}
+
+	state := &installState{}
+	if err = json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal install state file \"%s\": %w", fileName, err)
+	}
+
+	return state, nil
+}
+
+func clearInstallState(runtimeName string) error {
+	fileName, err := installStateFile(runtimeName)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(fileName); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove install state file \"%s\": %w", fileName, err)
+	}
+
+	return nil
+}
+
+// canResumeInstall returns true if the persisted state allows skipping straight to the sync wait phase.
+// Without --repo, the installation repo of the state is resumed
+func canResumeInstall(state *installState, opts *RuntimeInstallOptions) bool {
+	if state == nil || state.Phase != installPhaseComponentsPushed {
+		return false
+	}
+
+	return state.RuntimeName == opts.RuntimeName && (opts.InsCloneOpts.Repo == "" || state.Repo == opts.InsCloneOpts.Repo)
+}
+
+// restoreInstallState sets the options that the interrupted installation already resolved
+func restoreInstallState(opts *RuntimeInstallOptions, state *installState) error {
+	var err error
+
+	opts.InsCloneOpts.Repo = state.Repo
+	opts.IngressHost = state.IngressHost
+	if opts.IngressHost != "" {
+		if err = parseHostName(opts.IngressHost, &opts.HostName); err != nil {
+			return err
+		}
+	}
+
+	opts.gitProvider, err = cfgit.GetProvider(cfgit.ProviderType(state.GitProvider), state.Repo)
+	if err != nil {
+		return err
+	}
+
+	opts.InsCloneOpts.Provider = string(opts.gitProvider.Type())
+	if err = ensureGitIntegrationOpts(opts); err != nil {
+		return err
+	}
+
+	// the api url of an on-prem provider may have been set explicitly
+	if state.GitProviderAPI != "" {
+		apiURL := state.GitProviderAPI
+		opts.GitIntegrationCreationOpts.APIURL = &apiURL
+	}
+
+	return nil
+}
+
+// preRunResumeInstall prepares the resume of an interrupted installation from its persisted state,
+// instead of running the pre installation checks again. It returns false when there is nothing to resume
+func preRunResumeInstall(cmd *cobra.Command, opts *RuntimeInstallOptions) (bool, error) {
+	ctx := cmd.Context()
+	state, err := loadInstallState(opts.RuntimeName)
+	if err != nil || !canResumeInstall(state, opts) {
+		return false, err
+	}
+
+	if err = restoreInstallState(opts, state); err != nil {
+		return false, fmt.Errorf("failed to restore the installation state: %w", err)
+	}
+
+	// the lock is released and the git integration is created with the git credentials
+	if opts.GitUsername != "" || opts.GitPassword != "" {
+		err = setGitBasicAuth(opts.InsCloneOpts, opts.GitUsername, opts.GitPassword)
+	} else {
+		err = getGitToken(cmd, opts)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err = ensureGitPAT(ctx, opts); err != nil {
+		return false, err
+	}
+
+	if opts.SSHKey != "" || cfgit.IsSSHRepoURL(opts.InsCloneOpts.Repo) {
+		if err = ensureSSHAuth(opts); err != nil {
+			return false, err
+		}
+	}
+
+	if opts.GitSigningKey != "" {
+		if err = apu.ConfigureCommitSigning(opts.InsCloneOpts, opts.GitSigningKey, opts.GitSigningKeyPassphrase); err != nil {
+			return false, err
+		}
+	}
+
+	apu.ConfigureCommitMessagePrefix(opts.CommitMessagePrefix)
+	if opts.commitAuthor != nil {
+		apu.ConfigureCommitAuthor(opts.InsCloneOpts, opts.commitAuthor)
+	}
+
+	opts.InsCloneOpts.Parse()
+	log.G(ctx).Infof("Resuming the installation of runtime \"%s\" into \"%s\", skipping the pre installation checks", opts.RuntimeName, opts.InsCloneOpts.Repo)
+
+	return true, nil
+}
@@ -0,0 +1,202 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/codefresh-io/cli-v2/pkg/git"
+
+	apfs "github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+	apgit "github.com/argoproj-labs/argocd-autopilot/pkg/git"
+	"github.com/go-git/go-billy/v5/memfs"
+)
+
+func Test_resumeInstallState(t *testing.T) {
+	dir := t.TempDir()
+	origDir := installStateDir
+	installStateDir = func() (string, error) { return dir, nil }
+	defer func() { installStateDir = origDir }()
+
+	opts := &RuntimeInstallOptions{
+		RuntimeName:  "runtime",
+		InsCloneOpts: &apgit.CloneOptions{Repo: "github.com/owner/repo"},
+	}
+
+	tests := []struct {
+		name        string
+		state       *installState
+		runtimeName string
+		want        bool
+	}{
+		{
+			name:        "should not resume when there is no state",
+			runtimeName: "runtime",
+			want:        false,
+		},
+		{
+			name: "should resume after the components were pushed",
+			state: &installState{
+				RuntimeName: "runtime",
+				Phase:       installPhaseComponentsPushed,
+				Repo:        "github.com/owner/repo",
+			},
+			runtimeName: "runtime",
+			want:        true,
+		},
+		{
+			name: "should not resume a different installation repo",
+			state: &installState{
+				RuntimeName: "runtime",
+				Phase:       installPhaseComponentsPushed,
+				Repo:        "github.com/owner/other",
+			},
+			runtimeName: "runtime",
+			want:        false,
+		},
+		{
+			name: "should not resume an unknown phase",
+			state: &installState{
+				RuntimeName: "runtime",
+				Phase:       "unknown",
+				Repo:        "github.com/owner/repo",
+			},
+			runtimeName: "runtime",
+			want:        false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := clearInstallState(tt.runtimeName); err != nil {
+				t.Fatalf("clearInstallState() error = %v", err)
+			}
+
+			if tt.state != nil {
+				if err := saveInstallState(tt.state); err != nil {
+					t.Fatalf("saveInstallState() error = %v", err)
+				}
+			}
+
+			state, err := loadInstallState(tt.runtimeName)
+			if err != nil {
+				t.Fatalf("loadInstallState() error = %v", err)
+			}
+
+			if got := canResumeInstall(state, opts); got != tt.want {
+				t.Errorf("canResumeInstall() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_canResumeInstall_withoutRepo(t *testing.T) {
+	opts := &RuntimeInstallOptions{
+		RuntimeName:  "runtime",
+		InsCloneOpts: &apgit.CloneOptions{},
+	}
+	state := &installState{
+		RuntimeName: "runtime",
+		Phase:       installPhaseComponentsPushed,
+		Repo:        "github.com/owner/repo",
+	}
+	if !canResumeInstall(state, opts) {
+		t.Errorf("canResumeInstall() = false, want the installation repo of the state to be resumed")
+	}
+}
+
+func Test_restoreInstallState(t *testing.T) {
+	opts := &RuntimeInstallOptions{
+		RuntimeName:  "runtime",
+		InsCloneOpts: &apgit.CloneOptions{},
+	}
+	state := &installState{
+		RuntimeName:    "runtime",
+		Phase:          installPhaseComponentsPushed,
+		Repo:           "https://github.com/owner/repo.git",
+		IngressHost:    "https://runtime.example.com",
+		GitProvider:    string(git.GITHUB_CLOUD),
+		GitProviderAPI: "https://api.github.example.com",
+	}
+	if err := restoreInstallState(opts, state); err != nil {
+		t.Fatal(err)
+	}
+
+	if opts.InsCloneOpts.Repo != state.Repo || opts.IngressHost != state.IngressHost || opts.HostName != "runtime.example.com" {
+		t.Errorf("restoreInstallState() repo = %s, ingress host = %s, host name = %s, want the state", opts.InsCloneOpts.Repo, opts.IngressHost, opts.HostName)
+	}
+
+	if opts.gitProvider == nil || opts.gitProvider.Type() != git.GITHUB_CLOUD || opts.InsCloneOpts.Provider != string(git.GITHUB_CLOUD) {
+		t.Errorf("restoreInstallState() git provider = %v, want %s", opts.gitProvider, git.GITHUB_CLOUD)
+	}
+
+	if opts.GitIntegrationCreationOpts.APIURL == nil || *opts.GitIntegrationCreationOpts.APIURL != state.GitProviderAPI {
+		t.Errorf("restoreInstallState() git integration api url = %v, want %s", opts.GitIntegrationCreationOpts.APIURL, state.GitProviderAPI)
+	}
+}
+
+func Test_resumeRuntimeInstall(t *testing.T) {
+	tests := map[string]struct {
+		waitErr error
+	}{
+		"should release the lock after the runtime is synced": {},
+		"should release the lock when the wait fails": {
+			waitErr: errors.New("some error"),
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			repofs := apfs.Create(memfs.New())
+			if err := writeInstallLock(repofs, "runtime"); err != nil {
+				t.Fatal(err)
+			}
+
+			waited := false
+			origWaitForInstallation := waitForInstallation
+			waitForInstallation = func(context.Context, *RuntimeInstallOptions) error {
+				waited = true
+				// the lock is held for the whole wait
+				if !repofs.ExistsOrDie(installLockFileName) {
+					t.Errorf("resumeRuntimeInstall() released the lock before the wait")
+				}
+
+				return tt.waitErr
+			}
+			defer func() { waitForInstallation = origWaitForInstallation }()
+
+			opts := &RuntimeInstallOptions{
+				RuntimeName: "runtime",
+				dryRunFS:    repofs,
+			}
+			err := resumeRuntimeInstall(context.Background(), opts, &installState{RuntimeName: "runtime", Phase: installPhaseComponentsPushed})
+			if !errors.Is(err, tt.waitErr) {
+				t.Errorf("resumeRuntimeInstall() error = %v, want %v", err, tt.waitErr)
+			}
+
+			if !waited {
+				t.Errorf("resumeRuntimeInstall() did not wait for the runtime sync")
+			}
+
+			if !opts.DisableRollback {
+				t.Errorf("resumeRuntimeInstall() rollback is enabled, want the pushed installation preserved")
+			}
+
+			if repofs.ExistsOrDie(installLockFileName) {
+				t.Errorf("resumeRuntimeInstall() did not release the lock")
+			}
+		})
+	}
+}
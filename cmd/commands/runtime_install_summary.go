@@ -0,0 +1,118 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/codefresh-io/cli-v2/pkg/log"
+)
+
+type (
+	// installSummary is written to --summary-file, for automation that runs after the installation
+	installSummary struct {
+		Runtime             string               `json:"runtime"`
+		RepoURL             string               `json:"repoURL"`
+		IngressHost         string               `json:"ingressHost"`
+		InternalIngressHost string               `json:"internalIngressHost,omitempty"`
+		Status              string               `json:"status"`
+		Error               string               `json:"error,omitempty"`
+		Steps               []installSummaryStep `json:"steps"`
+	}
+
+	installSummaryStep struct {
+		Message string `json:"message"`
+		Result  string `json:"result"`
+	}
+)
+
+const (
+	installSummaryStatusInstalled           = "installed"
+	installSummaryStatusInstalledWithIssues = "installed-with-issues"
+	installSummaryStatusFailed              = "failed"
+)
+
+// finishInstallSummary writes the --summary-file before the summary is printed, since printing it clears it.
+// The whole summary is printed when the installation fails, and only its failures and warnings when it succeeds.
+// The final outcome of the installation is printed whatever the level
+func finishInstallSummary(ctx context.Context, opts *RuntimeInstallOptions, err error) {
+	if opts.SummaryFile != "" {
+		if writeErr := writeInstallSummary(opts.SummaryFile, getInstallSummary(opts, err)); writeErr != nil {
+			log.G(ctx).WithError(writeErr).Warn("Failed to write the installation summary file")
+		} else {
+			log.G(ctx).Debugf("Wrote the installation summary to \"%s\"", opts.SummaryFile)
+		}
+	}
+
+	summaryLevel := opts.SummaryLevel
+	if summaryLevel == "" {
+		summaryLevel = summaryLevelWarnings
+		if err != nil {
+			summaryLevel = summaryLevelAll
+		}
+	}
+
+	printFilteredSummaryToUser(summaryLevel)
+	if opts.installOutcome != nil && !isShownAtSummaryLevel(opts.installOutcome.level, summaryLevel) {
+		printSummaryLog(*opts.installOutcome)
+	}
+}
+
+// getInstallSummary builds the summary from the summary log. An installation that did not fail, but had
+// failed steps (e.g. the runtime sync timed out), is installed with issues
+func getInstallSummary(opts *RuntimeInstallOptions, err error) *installSummary {
+	summary := &installSummary{
+		Runtime:             opts.RuntimeName,
+		IngressHost:         opts.IngressHost,
+		InternalIngressHost: opts.InternalIngressHost,
+		Status:              installSummaryStatusInstalled,
+		Steps:               []installSummaryStep{},
+	}
+	if opts.InsCloneOpts != nil {
+		summary.RepoURL = opts.InsCloneOpts.Repo
+	}
+
+	summaryLock.Lock()
+	defer summaryLock.Unlock()
+	for _, l := range summaryArr {
+		summary.Steps = append(summary.Steps, installSummaryStep{Message: l.message, Result: string(l.level)})
+		if l.level == Failed {
+			summary.Status = installSummaryStatusInstalledWithIssues
+		}
+	}
+
+	if err != nil {
+		summary.Status = installSummaryStatusFailed
+		summary.Error = err.Error()
+	}
+
+	return summary
+}
+
+func writeInstallSummary(path string, summary *installSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the installation summary: %w", err)
+	}
+
+	if err = os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write \"%s\": %w", path, err)
+	}
+
+	return nil
+}
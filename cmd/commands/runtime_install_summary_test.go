@@ -0,0 +1,172 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	apgit "github.com/argoproj-labs/argocd-autopilot/pkg/git"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_finishInstallSummary(t *testing.T) {
+	tests := map[string]struct {
+		logs       []summaryLog
+		err        error
+		wantStatus string
+		wantError  string
+	}{
+		"Should be installed when every step succeeded": {
+			logs: []summaryLog{
+				{"Wait for runtime sync", Success},
+				{"Runtime \"runtime\" installed successfully", Info},
+			},
+			wantStatus: installSummaryStatusInstalled,
+		},
+		"Should be installed with issues when the runtime sync timed out": {
+			logs: []summaryLog{
+				{"Wait for runtime sync", Failed},
+				{"Runtime \"runtime\" installed with some issues", Warning},
+			},
+			wantStatus: installSummaryStatusInstalledWithIssues,
+		},
+		"Should be failed when the installation failed": {
+			logs: []summaryLog{
+				{"Applying secrets to cluster", Failed},
+			},
+			err:        errors.New("failed to apply secrets to cluster"),
+			wantStatus: installSummaryStatusFailed,
+			wantError:  "failed to apply secrets to cluster",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			origSummary := summaryArr
+			defer func() { summaryArr = origSummary }()
+			summaryArr = tt.logs
+
+			path := filepath.Join(t.TempDir(), "summary.json")
+			opts := &RuntimeInstallOptions{
+				RuntimeName:  "runtime",
+				IngressHost:  "https://runtime.example.com",
+				InsCloneOpts: &apgit.CloneOptions{Repo: "https://github.com/owner/repo"},
+				SummaryFile:  path,
+			}
+			finishInstallSummary(context.Background(), opts, tt.err)
+
+			data, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			summary := &installSummary{}
+			assert.NoError(t, json.Unmarshal(data, summary))
+			assert.Equal(t, "runtime", summary.Runtime)
+			assert.Equal(t, "https://github.com/owner/repo", summary.RepoURL)
+			assert.Equal(t, "https://runtime.example.com", summary.IngressHost)
+			assert.Equal(t, tt.wantStatus, summary.Status)
+			assert.Equal(t, tt.wantError, summary.Error)
+			assert.Len(t, summary.Steps, len(tt.logs))
+			assert.Equal(t, string(tt.logs[0].level), summary.Steps[0].Result)
+
+			// the summary is printed and cleared after it is written
+			assert.Empty(t, summaryArr)
+		})
+	}
+}
+
+func Test_finishInstallSummary_summaryLevel(t *testing.T) {
+	logs := []summaryLog{
+		{"Downloading runtime definition", Success},
+		{"Wait for runtime sync", Failed},
+		{"Runtime \"runtime\" installed with some issues", Warning},
+		{"To complete the installation, configure your git provider", Info},
+	}
+	tests := map[string]struct {
+		summaryLevel string
+		err          error
+		outcome      *summaryLog
+		wantPrinted  []string
+		wantHidden   []string
+	}{
+		"Should suppress info entries of a successful install with level=warnings": {
+			summaryLevel: summaryLevelWarnings,
+			wantPrinted:  []string{"Wait for runtime sync", "installed with some issues"},
+			wantHidden:   []string{"Downloading runtime definition", "configure your git provider"},
+		},
+		"Should print only failures with level=errors": {
+			summaryLevel: summaryLevelErrors,
+			wantPrinted:  []string{"Wait for runtime sync"},
+			wantHidden:   []string{"Downloading runtime definition", "installed with some issues", "configure your git provider"},
+		},
+		"Should default to warnings on a successful install": {
+			wantPrinted: []string{"Wait for runtime sync", "installed with some issues"},
+			wantHidden:  []string{"Downloading runtime definition", "configure your git provider"},
+		},
+		"Should print the outcome of a successful install at any level": {
+			summaryLevel: summaryLevelErrors,
+			outcome:      &summaryLog{"Runtime \"runtime\" installed successfully", Info},
+			wantPrinted:  []string{"Wait for runtime sync", "Runtime \"runtime\" installed successfully"},
+			wantHidden:   []string{"Downloading runtime definition", "configure your git provider"},
+		},
+		"Should default to all on a failed install": {
+			err:         errors.New("some error"),
+			wantPrinted: []string{"Downloading runtime definition", "Wait for runtime sync", "installed with some issues", "configure your git provider"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			origSummary := summaryArr
+			defer func() { summaryArr = origSummary }()
+			summaryArr = append([]summaryLog{}, logs...)
+			if tt.outcome != nil {
+				summaryArr = append(summaryArr, *tt.outcome)
+			}
+
+			opts := &RuntimeInstallOptions{
+				RuntimeName:    "runtime",
+				SummaryLevel:   tt.summaryLevel,
+				installOutcome: tt.outcome,
+			}
+			out := captureStdout(t, func() {
+				finishInstallSummary(context.Background(), opts, tt.err)
+			})
+
+			for _, msg := range tt.wantPrinted {
+				assert.Contains(t, out, msg)
+			}
+			for _, msg := range tt.wantHidden {
+				assert.NotContains(t, out, msg)
+			}
+			assert.Empty(t, summaryArr)
+		})
+	}
+}
+
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	assert.NoError(t, err)
+	origStdout := os.Stdout
+	os.Stdout = w
+	f()
+	os.Stdout = origStdout
+	assert.NoError(t, w.Close())
+	out, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	return string(out)
+}
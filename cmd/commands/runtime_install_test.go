@@ -0,0 +1,2265 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	cfgit "github.com/codefresh-io/cli-v2/pkg/git"
+	"github.com/codefresh-io/cli-v2/pkg/log"
+	"github.com/codefresh-io/cli-v2/pkg/runtime"
+	"github.com/codefresh-io/cli-v2/pkg/store"
+	eventsutil "github.com/codefresh-io/cli-v2/pkg/util/events"
+	ingressutil "github.com/codefresh-io/cli-v2/pkg/util/ingress"
+	kubeutil "github.com/codefresh-io/cli-v2/pkg/util/kube"
+	kustutil "github.com/codefresh-io/cli-v2/pkg/util/kust"
+
+	"github.com/Masterminds/semver/v3"
+	apcmd "github.com/argoproj-labs/argocd-autopilot/cmd/commands"
+	apfs "github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+	apgit "github.com/argoproj-labs/argocd-autopilot/pkg/git"
+	apstore "github.com/argoproj-labs/argocd-autopilot/pkg/store"
+	aev1alpha1 "github.com/argoproj/argo-events/pkg/apis/eventsource/v1alpha1"
+	sensorsv1alpha1 "github.com/argoproj/argo-events/pkg/apis/sensor/v1alpha1"
+	"github.com/codefresh-io/go-sdk/pkg/codefresh/model"
+	"github.com/ghodss/yaml"
+	"github.com/go-git/go-billy/v5/memfs"
+	billyUtils "github.com/go-git/go-billy/v5/util"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	authv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	kusttypes "sigs.k8s.io/kustomize/api/types"
+)
+
+func Test_validateIngressHostPath(t *testing.T) {
+	tests := []struct {
+		name        string
+		ingressHost string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "should keep a host without a path",
+			ingressHost: "https://host.com",
+			want:        "https://host.com",
+		},
+		{
+			name:        "should remove a trailing slash",
+			ingressHost: "https://host.com:8443/",
+			want:        "https://host.com:8443",
+		},
+		{
+			name:        "should fail on a host with a path",
+			ingressHost: "https://host.com/base",
+			wantErr:     true,
+		},
+		{
+			name:        "should fail on a host without a scheme",
+			ingressHost: "host.com",
+			wantErr:     true,
+		},
+		{
+			name:        "should fail on a host with a port and without a scheme",
+			ingressHost: "host.com:8443",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateIngressHostPath(tt.ingressHost)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateIngressHostPath() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("validateIngressHostPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseHostName(t *testing.T) {
+	tests := map[string]struct {
+		ingressHost string
+		want        string
+		wantErr     bool
+	}{
+		"should parse a host name": {
+			ingressHost: "https://some.host",
+			want:        "some.host",
+		},
+		"should remove the port of a host name": {
+			ingressHost: "https://some.host:8443",
+			want:        "some.host",
+		},
+		"should leave the host name of an ipv4 address empty": {
+			ingressHost: "https://10.0.0.1",
+		},
+		"should leave the host name of an ipv6 address empty": {
+			ingressHost: "https://[::1]",
+		},
+		"should leave the host name of an ipv6 address with a port empty": {
+			ingressHost: "https://[2001:db8::1]:8443",
+		},
+		"should fail on an invalid ipv6 address": {
+			ingressHost: "https://[some.host]:8443",
+			wantErr:     true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got string
+			err := parseHostName(tt.ingressHost, &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseHostName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("parseHostName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_checkIngressControllerSupport(t *testing.T) {
+	definition := []byte(`
+spec:
+  version: 0.0.1
+  supportedIngressControllers:
+    - k8s.io/ingress-nginx
+    - ingress.k8s.aws/alb
+`)
+	rt := &runtime.Runtime{}
+	if err := yaml.Unmarshal(definition, rt); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		controller string
+		wantErr    bool
+	}{
+		{
+			name:       "should accept a supported controller",
+			controller: string(ingressutil.IngressControllerNginxCommunity),
+		},
+		{
+			name:       "should reject an unsupported controller",
+			controller: string(ingressutil.IngressControllerIstio),
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &RuntimeInstallOptions{IngressController: ingressutil.GetController(tt.controller)}
+			if err := checkIngressControllerSupport(rt, opts); (err != nil) != tt.wantErr {
+				t.Errorf("checkIngressControllerSupport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_getBootstrapAppSpecifier(t *testing.T) {
+	definition := []byte(`
+spec:
+  version: 0.0.1
+  bootstrapSpecifier: github.com/codefresh-io/cli-v2/manifests/argo-cd
+`)
+	rt := &runtime.Runtime{}
+	if err := yaml.Unmarshal(definition, rt); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts *RuntimeInstallOptions
+		want string
+	}{
+		{
+			name: "should use the runtime definition specifier",
+			opts: &RuntimeInstallOptions{},
+			want: rt.Spec.FullSpecifier(),
+		},
+		{
+			name: "should use the repo manifests when installing from repo",
+			opts: &RuntimeInstallOptions{
+				FromRepo:     true,
+				InsCloneOpts: &apgit.CloneOptions{Repo: "https://github.com/owner/repo"},
+			},
+			want: "https://github.com/owner/repo/bootstrap/argo-cd",
+		},
+		{
+			name: "should replace the computed specifier with the override",
+			opts: &RuntimeInstallOptions{BootstrapSpecifier: "https://git.internal/mirror/argo-cd?ref=v0.0.1"},
+			want: "https://git.internal/mirror/argo-cd?ref=v0.0.1",
+		},
+		{
+			name: "should prefer the override when installing from repo",
+			opts: &RuntimeInstallOptions{
+				FromRepo:           true,
+				InsCloneOpts:       &apgit.CloneOptions{Repo: "https://github.com/owner/repo"},
+				BootstrapSpecifier: "https://git.internal/mirror/argo-cd",
+			},
+			want: "https://git.internal/mirror/argo-cd",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getBootstrapAppSpecifier(rt, tt.opts); got != tt.want {
+				t.Errorf("getBootstrapAppSpecifier() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_unreadyComponentsSummary(t *testing.T) {
+	components := []model.Component{
+		{Metadata: &model.ObjectMeta{Name: "runtime-argo-cd", Runtime: "runtime"}},
+		{Metadata: &model.ObjectMeta{Name: "runtime-argo-events", Runtime: "runtime"}},
+	}
+
+	logs := unreadyComponentsSummary(components)
+	want := []string{
+		"Component \"argo-cd\" is not ready (health: N/A, sync: N/A)",
+		"Component \"argo-events\" is not ready (health: N/A, sync: N/A)",
+	}
+	if len(logs) != len(want) {
+		t.Fatalf("unreadyComponentsSummary() returned %d logs, want %d", len(logs), len(want))
+	}
+
+	for i := range want {
+		if logs[i].message != want[i] {
+			t.Errorf("unreadyComponentsSummary()[%d] = %v, want %v", i, logs[i].message, want[i])
+		}
+	}
+}
+
+func Test_unreadyComponentsSummary_healthMessage(t *testing.T) {
+	health := model.HealthStatusDegraded
+	healthMessage := "Deployment \"argo-cd-server\" exceeded its progress deadline"
+	components := []model.Component{
+		{
+			Metadata: &model.ObjectMeta{Name: "runtime-argo-cd", Runtime: "runtime"},
+			Self: &model.Application{
+				Status: &model.ArgoCDApplicationStatus{
+					SyncStatus:    model.SyncStatusSynced,
+					HealthStatus:  &health,
+					HealthMessage: &healthMessage,
+				},
+			},
+		},
+	}
+
+	logs := unreadyComponentsSummary(components)
+	if len(logs) != 1 {
+		t.Fatalf("unreadyComponentsSummary() returned %d logs, want 1", len(logs))
+	}
+
+	want := "Component \"argo-cd\" is not ready (health: Degraded, sync: Synced): health message: " + healthMessage
+	if logs[0].message != want {
+		t.Errorf("unreadyComponentsSummary()[0] = %v, want %v", logs[0].message, want)
+	}
+}
+
+func Test_checkRuntimeSynced(t *testing.T) {
+	degraded := model.HealthStatusDegraded
+	progressing := model.HealthStatusProgressing
+	healthMessage := "Deployment \"argo-cd-server\" exceeded its progress deadline"
+	components := []model.Component{
+		{
+			Metadata: &model.ObjectMeta{Name: "runtime-events", Runtime: "runtime"},
+			Self:     &model.Application{Status: &model.ArgoCDApplicationStatus{HealthStatus: &progressing}},
+		},
+		{
+			Metadata: &model.ObjectMeta{Name: "runtime-argo-cd", Runtime: "runtime"},
+			Self:     &model.Application{Status: &model.ArgoCDApplicationStatus{HealthStatus: &degraded, HealthMessage: &healthMessage}},
+		},
+	}
+
+	done, err := checkRuntimeSynced(&model.Runtime{}, components[:1])
+	if done || err != nil {
+		t.Errorf("checkRuntimeSynced() = %v, %v, want to keep waiting while the components are progressing", done, err)
+	}
+
+	done, err = checkRuntimeSynced(&model.Runtime{}, components)
+	if done {
+		t.Errorf("checkRuntimeSynced() done = true, want false")
+	}
+
+	want := "component \"argo-cd\" is degraded: health message: " + healthMessage
+	if err == nil || err.Error() != want {
+		t.Fatalf("checkRuntimeSynced() error = %v, want %v", err, want)
+	}
+
+	var degradedErr *componentDegradedError
+	if !errors.As(fmt.Errorf("failed to wait for runtime sync: %w", err), &degradedErr) {
+		t.Errorf("checkRuntimeSynced() error = %T, want *componentDegradedError", err)
+	}
+
+	done, err = checkRuntimeSynced(&model.Runtime{InstallationStatus: model.InstallationStatusCompleted}, nil)
+	if !done || err != nil {
+		t.Errorf("checkRuntimeSynced() = %v, %v, want true, nil", done, err)
+	}
+}
+
+func Test_retriesForTimeout(t *testing.T) {
+	tests := map[string]struct {
+		timeout time.Duration
+		want    int
+	}{
+		"should keep the default of a minute": {timeout: time.Minute, want: 6},
+		"should round down":                   {timeout: 95 * time.Second, want: 9},
+		"should check at least once":          {timeout: time.Second, want: 1},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := retriesForTimeout(tt.timeout, 10*time.Second); got != tt.want {
+				t.Errorf("retriesForTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_nextGitIntegrationCheckInterval(t *testing.T) {
+	tests := map[string]struct {
+		interval time.Duration
+		err      error
+		want     time.Duration
+	}{
+		"should double the interval on a rate limit message": {
+			interval: 10 * time.Second,
+			err:      errors.New("failed to create default git integration: API rate limit exceeded for user"),
+			want:     20 * time.Second,
+		},
+		"should double the interval on a provider rate limit error": {
+			interval: 20 * time.Second,
+			err:      fmt.Errorf("failed: %w", &cfgit.RateLimitError{StatusCode: http.StatusTooManyRequests}),
+			want:     40 * time.Second,
+		},
+		"should cap the interval": {
+			interval: 80 * time.Second,
+			err:      errors.New("429 Too Many Requests"),
+			want:     2 * time.Minute,
+		},
+		"should go back to the default interval on another failure": {
+			interval: 40 * time.Second,
+			err:      errors.New("connection refused"),
+			want:     10 * time.Second,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := nextGitIntegrationCheckInterval(tt.interval, tt.err); got != tt.want {
+				t.Errorf("nextGitIntegrationCheckInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_intervalCheckIsGitIntegrationCreated_rateLimit(t *testing.T) {
+	origInterval, origMaxInterval, origCreate := gitIntegrationCheckInterval, gitIntegrationMaxCheckInterval, createGitIntegrationFunc
+	defer func() {
+		gitIntegrationCheckInterval, gitIntegrationMaxCheckInterval, createGitIntegrationFunc = origInterval, origMaxInterval, origCreate
+	}()
+
+	gitIntegrationCheckInterval = time.Millisecond
+	gitIntegrationMaxCheckInterval = 4 * time.Millisecond
+	var calls []time.Time
+	createGitIntegrationFunc = func(_ context.Context, _ *RuntimeInstallOptions) error {
+		calls = append(calls, time.Now())
+		return errors.New("API rate limit exceeded")
+	}
+
+	err := intervalCheckIsGitIntegrationCreated(context.Background(), &RuntimeInstallOptions{GitIntegrationTimeout: 4 * time.Millisecond})
+	want := "timed out while waiting for git integration to be created, the git provider rate limited 3 of 3 attempts: API rate limit exceeded"
+	if err == nil || err.Error() != want {
+		t.Fatalf("intervalCheckIsGitIntegrationCreated() error = %v, want %v", err, want)
+	}
+
+	// the waits are 1ms, 2ms and 4ms, so the tries of a 4ms timeout are used up after 3 attempts
+	if len(calls) != 3 {
+		t.Fatalf("intervalCheckIsGitIntegrationCreated() attempts = %d, want 3", len(calls))
+	}
+
+	if gap := calls[2].Sub(calls[1]); gap < 4*time.Millisecond {
+		t.Errorf("intervalCheckIsGitIntegrationCreated() last retry interval = %v, want at least 4ms", gap)
+	}
+}
+
+func Test_getRuntimeTokenSecret(t *testing.T) {
+	data, err := getRuntimeTokenSecret("runtime", "token", "iv", map[string]string{"replicate": "true"}, map[string]string{"reflector/allowed": "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := &v1.Secret{}
+	if err = yaml.Unmarshal(data, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	if secret.Labels["replicate"] != "true" {
+		t.Errorf("getRuntimeTokenSecret() labels = %v, missing \"replicate\"", secret.Labels)
+	}
+
+	if len(secret.Labels) != 2 {
+		t.Errorf("getRuntimeTokenSecret() labels = %v, want the default label to be kept", secret.Labels)
+	}
+
+	if secret.Annotations["reflector/allowed"] != "true" {
+		t.Errorf("getRuntimeTokenSecret() annotations = %v, missing \"reflector/allowed\"", secret.Annotations)
+	}
+}
+
+func Test_getRepoSSHCredsSecret(t *testing.T) {
+	data, err := getRepoSSHCredsSecret("runtime", sshRepoBase("git@github.com:owner/repo.git?ref=main"), "private-key", map[string]string{"replicate": "true"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := &v1.Secret{}
+	if err = yaml.Unmarshal(data, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	if secret.Labels["argocd.argoproj.io/secret-type"] != "repo-creds" || secret.Labels["replicate"] != "true" {
+		t.Errorf("getRepoSSHCredsSecret() labels = %v, want the repo-creds and \"replicate\" labels", secret.Labels)
+	}
+
+	want := map[string]string{
+		"type":          "git",
+		"url":           "git@github.com:owner/",
+		"sshPrivateKey": "private-key",
+	}
+	if !reflect.DeepEqual(secret.StringData, want) {
+		t.Errorf("getRepoSSHCredsSecret() data = %v, want %v", secret.StringData, want)
+	}
+}
+
+func Test_sshRepoBase(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:owner/repo.git":                     "git@github.com:owner/repo",
+		"git@github.com:owner/repo.git/some/path?ref=main":  "git@github.com:owner/repo",
+		"ssh://git@my.github.com:2222/owner/repo.git?ref=v": "ssh://git@my.github.com:2222/owner/repo",
+		"git@github.com:owner/repo":                         "git@github.com:owner/repo",
+	}
+	for repo, want := range tests {
+		t.Run(repo, func(t *testing.T) {
+			if got := sshRepoBase(repo); got != want {
+				t.Errorf("sshRepoBase() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func Test_ensureSSHAuth_strictHostKeyChecking(t *testing.T) {
+	t.Setenv("SSH_KNOWN_HOSTS", filepath.Join(t.TempDir(), "missing"))
+	keyFile := filepath.Join(t.TempDir(), "id_rsa")
+	if err := os.WriteFile(keyFile, []byte("private-key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &RuntimeInstallOptions{
+		InsCloneOpts: &apgit.CloneOptions{Repo: "git@github.com:owner/repo.git"},
+		SSHKey:       keyFile,
+	}
+	err := ensureSSHAuth(opts)
+	if err == nil || !strings.Contains(err.Error(), "--ssh-known-hosts") {
+		t.Errorf("ensureSSHAuth() error = %v, want it to reject an install without known hosts", err)
+	}
+
+	opts.SSHKnownHosts = filepath.Join(t.TempDir(), "known_hosts")
+	opts.SSHInsecureIgnoreHostKey = true
+	if err = ensureSSHAuth(opts); err == nil || !strings.Contains(err.Error(), "cannot be used with") {
+		t.Errorf("ensureSSHAuth() error = %v, want it to reject --ssh-known-hosts with --ssh-insecure-ignore-host-key", err)
+	}
+}
+
+func Test_setArgoCDSSHKnownHosts(t *testing.T) {
+	repofs := apfs.Create(memfs.New())
+	argoCDDir := repofs.Join(apstore.Default.BootsrtrapDir, apstore.Default.ArgoCDName)
+	if err := kustutil.WriteKustomization(repofs, &kusttypes.Kustomization{
+		TypeMeta: kusttypes.TypeMeta{
+			APIVersion: kusttypes.KustomizationVersion,
+			Kind:       kusttypes.KustomizationKind,
+		},
+	}, argoCDDir); err != nil {
+		t.Fatal(err)
+	}
+
+	cs := fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: store.Get().ArgoCDSSHKnownHostsConfigMap, Namespace: "runtime"},
+		Data:       map[string]string{"ssh_known_hosts": "github.com ssh-ed25519 AAAA\n"},
+	})
+	lines := []string{"git.example.com ssh-ed25519 BBBB"}
+	changed, err := setArgoCDSSHKnownHosts(context.Background(), cs, repofs, "runtime", lines)
+	if err != nil || !changed {
+		t.Fatalf("setArgoCDSSHKnownHosts() = %v, %v, want true", changed, err)
+	}
+
+	want := "github.com ssh-ed25519 AAAA\ngit.example.com ssh-ed25519 BBBB\n"
+	cm, _ := cs.CoreV1().ConfigMaps("runtime").Get(context.Background(), store.Get().ArgoCDSSHKnownHostsConfigMap, metav1.GetOptions{})
+	if got := cm.Data["ssh_known_hosts"]; got != want {
+		t.Errorf("setArgoCDSSHKnownHosts() cluster known hosts = %q, want %q", got, want)
+	}
+
+	// the repo keeps the defaults of argo-cd, since the generator replaces the whole key
+	data, _ := billyUtils.ReadFile(repofs, repofs.Join(argoCDDir, "ssh_known_hosts"))
+	if string(data) != want {
+		t.Errorf("setArgoCDSSHKnownHosts() repo known hosts = %q, want %q", data, want)
+	}
+
+	kust, _ := kustutil.ReadKustomization(repofs, argoCDDir)
+	if len(kust.ConfigMapGenerator) != 1 || kust.ConfigMapGenerator[0].Name != store.Get().ArgoCDSSHKnownHostsConfigMap {
+		t.Errorf("setArgoCDSSHKnownHosts() generators = %v, want the known hosts generator", kust.ConfigMapGenerator)
+	}
+
+	// argo-cd already knows the host
+	if changed, err = setArgoCDSSHKnownHosts(context.Background(), cs, repofs, "runtime", lines); err != nil || changed {
+		t.Errorf("setArgoCDSSHKnownHosts() = %v, %v, want false", changed, err)
+	}
+}
+
+func Test_initializeGitSourceCloneOpts_ssh(t *testing.T) {
+	opts := &RuntimeInstallOptions{
+		RuntimeName:  "runtime",
+		InsCloneOpts: &apgit.CloneOptions{Repo: "git@github.com:owner/repo.git?ref=main"},
+		GsCloneOpts:  &apgit.CloneOptions{},
+	}
+	initializeGitSourceCloneOpts(opts)
+
+	want := "git@github.com:owner/repo_git-source.git/resources_runtime"
+	if opts.GsCloneOpts.Repo != want {
+		t.Errorf("initializeGitSourceCloneOpts() repo = %v, want %v", opts.GsCloneOpts.Repo, want)
+	}
+}
+
+func Test_initializeGitSourceCloneOpts_gitlabSubGroups(t *testing.T) {
+	tests := map[string]string{
+		"https://gitlab.com/group/subgroup/repo.git?ref=main":       "https://gitlab.com/group/subgroup/repo_git-source.git/resources_runtime",
+		"https://gitlab.com/group/sub1/sub2/repo.git/some/path":     "https://gitlab.com/group/sub1/sub2/repo_git-source.git/resources_runtime",
+		"https://gitlab.example.com/group/sub1/sub2/repo.git?ref=v": "https://gitlab.example.com/group/sub1/sub2/repo_git-source.git/resources_runtime",
+		// without the suffix, the url is split like the url of any other provider
+		"https://gitlab.com/owner/repo":           "https://gitlab.com/owner/repo_git-source.git/resources_runtime",
+		"https://gitlab.com/owner/repo/some/path": "https://gitlab.com/owner/repo_git-source.git/resources_runtime",
+	}
+	for repo, want := range tests {
+		t.Run(repo, func(t *testing.T) {
+			opts := &RuntimeInstallOptions{
+				RuntimeName:  "runtime",
+				InsCloneOpts: &apgit.CloneOptions{Repo: repo, Provider: string(cfgit.GITLAB)},
+				GsCloneOpts:  &apgit.CloneOptions{},
+			}
+			initializeGitSourceCloneOpts(opts)
+
+			if opts.GsCloneOpts.Repo != want {
+				t.Errorf("initializeGitSourceCloneOpts() repo = %v, want %v", opts.GsCloneOpts.Repo, want)
+			}
+		})
+	}
+}
+
+func Test_getRepoDirURL_ssh(t *testing.T) {
+	cloneOpts := &apgit.CloneOptions{Repo: "git@github.com:owner/repo.git?ref=main"}
+	cloneOpts.Parse()
+
+	got, err := getRepoDirURL(cloneOpts, "apps/events-reporter/runtime/resources")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "git@github.com:owner/repo.git/apps/events-reporter/runtime/resources?ref=main"
+	if got != want {
+		t.Errorf("getRepoDirURL() = %v, want %v", got, want)
+	}
+}
+
+func Test_generateArgoCDTokenWithRetry(t *testing.T) {
+	origGenerateArgoCDToken, origInterval, origTimeout := generateArgoCDToken, argoCDTokenRetryInterval, argoCDTokenTimeout
+	defer func() {
+		generateArgoCDToken, argoCDTokenRetryInterval, argoCDTokenTimeout = origGenerateArgoCDToken, origInterval, origTimeout
+	}()
+
+	argoCDTokenRetryInterval = 10 * time.Millisecond
+	argoCDTokenTimeout = time.Second
+
+	// a fake argocd-server, that is only reachable 50ms after the bootstrap
+	reachableAt := time.Now().Add(50 * time.Millisecond)
+	calls := 0
+	generateArgoCDToken = func(_ context.Context, _, _, _ string, _ bool) (string, error) {
+		calls++
+		if time.Now().Before(reachableAt) {
+			return "", errors.New("connection refused")
+		}
+
+		return "token", nil
+	}
+
+	token, err := generateArgoCDTokenWithRetry(context.Background(), "context", "runtime", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token != "token" || calls < 2 {
+		t.Errorf("generateArgoCDTokenWithRetry() = %v after %d calls, want token after retrying", token, calls)
+	}
+
+	argoCDTokenTimeout = 50 * time.Millisecond
+	reachableAt = time.Now().Add(time.Hour)
+	_, err = generateArgoCDTokenWithRetry(context.Background(), "context", "runtime", false)
+	if err == nil || !strings.Contains(err.Error(), "argocd-server in namespace \"runtime\" was not reachable") {
+		t.Errorf("generateArgoCDTokenWithRetry() error = %v, want the argocd-server to be unreachable", err)
+	}
+}
+
+func Test_getArgoCDTokenSecret(t *testing.T) {
+	origGenerateArgoCDToken := generateArgoCDToken
+	defer func() { generateArgoCDToken = origGenerateArgoCDToken }()
+
+	generateArgoCDToken = func(_ context.Context, _, _, _ string, _ bool) (string, error) {
+		return "token", nil
+	}
+
+	for _, namespace := range []string{"runtime", "argocd"} {
+		data, err := getArgoCDTokenSecret(context.Background(), "context", namespace, false, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		secret := &v1.Secret{}
+		if err = yaml.Unmarshal(data, secret); err != nil {
+			t.Fatal(err)
+		}
+
+		if secret.Namespace != namespace {
+			t.Errorf("getArgoCDTokenSecret() namespace = %v, want %v", secret.Namespace, namespace)
+		}
+
+		if got := secret.Labels[apstore.Default.LabelKeyAppPartOf]; got != "argocd" {
+			t.Errorf("getArgoCDTokenSecret() part-of label = %v, want argocd", got)
+		}
+
+		if string(secret.Data[store.Get().ArgoCDTokenKey]) != "token" {
+			t.Errorf("getArgoCDTokenSecret() token = %v, want token", string(secret.Data[store.Get().ArgoCDTokenKey]))
+		}
+	}
+}
+
+func Test_retainExistingNamespace(t *testing.T) {
+	repofs := apfs.Create(memfs.New())
+	nsPath := repofs.Join(apstore.Default.BootsrtrapDir, apstore.Default.ClusterResourcesDir, apstore.Default.ClusterContextName, "runtime-ns.yaml")
+	ns := &v1.Namespace{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{Name: "runtime", Annotations: map[string]string{"argocd.argoproj.io/sync-options": "Prune=false"}},
+	}
+	if err := repofs.WriteYamls(nsPath, ns); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &RuntimeInstallOptions{
+		RuntimeName: "runtime",
+		dryRunFS:    repofs,
+	}
+	if err := retainExistingNamespace(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	got := &v1.Namespace{}
+	if err := repofs.ReadYamls(nsPath, got); err != nil {
+		t.Fatal(err)
+	}
+
+	if syncOptions := got.Annotations["argocd.argoproj.io/sync-options"]; syncOptions != "Prune=false,Delete=false" {
+		t.Errorf("retainExistingNamespace() sync-options = %s, want Prune=false,Delete=false", syncOptions)
+	}
+}
+
+func Test_checkInstallLock(t *testing.T) {
+	tests := []struct {
+		name        string
+		lockedBy    string
+		forceUnlock bool
+		wantErr     bool
+	}{
+		{
+			name: "should allow an installation into an unlocked repo",
+		},
+		{
+			name:     "should refuse a concurrent installation while the lock is held",
+			lockedBy: "other-runtime",
+			wantErr:  true,
+		},
+		{
+			name:        "should allow overriding the lock with --force-unlock",
+			lockedBy:    "other-runtime",
+			forceUnlock: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repofs := apfs.Create(memfs.New())
+			if tt.lockedBy != "" {
+				if err := writeInstallLock(repofs, tt.lockedBy); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := checkInstallLock(repofs, tt.forceUnlock); (err != nil) != tt.wantErr {
+				t.Errorf("checkInstallLock() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_acquireInstallLock(t *testing.T) {
+	repofs := apfs.Create(memfs.New())
+	opts := &RuntimeInstallOptions{
+		RuntimeName: "runtime",
+		dryRunFS:    repofs,
+	}
+	if err := acquireInstallLock(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := readInstallLock(repofs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lock == nil || lock.RuntimeName != "runtime" {
+		t.Fatalf("acquireInstallLock() lock = %v, want a lock of runtime \"runtime\"", lock)
+	}
+
+	other := &RuntimeInstallOptions{
+		RuntimeName: "other-runtime",
+		dryRunFS:    repofs,
+	}
+	if err = acquireInstallLock(context.Background(), other); err == nil {
+		t.Error("acquireInstallLock() should refuse to lock a repo that is locked by another installation")
+	}
+}
+
+func Test_releaseInstallLock(t *testing.T) {
+	tests := map[string]struct {
+		lockedBy string
+		wantLock bool
+	}{
+		"should release the lock of the installation": {
+			lockedBy: "runtime",
+		},
+		"should keep the lock of another installation": {
+			lockedBy: "other-runtime",
+			wantLock: true,
+		},
+		"should do nothing when the repo is not locked": {},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			repofs := apfs.Create(memfs.New())
+			if tt.lockedBy != "" {
+				if err := writeInstallLock(repofs, tt.lockedBy); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			opts := &RuntimeInstallOptions{
+				RuntimeName: "runtime",
+				dryRunFS:    repofs,
+			}
+			if err := releaseInstallLock(context.Background(), opts); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := repofs.ExistsOrDie(installLockFileName); got != tt.wantLock {
+				t.Errorf("releaseInstallLock() lock exists = %v, want %v", got, tt.wantLock)
+			}
+		})
+	}
+}
+
+func Test_getHelmValues(t *testing.T) {
+	opts := &RuntimeInstallOptions{
+		RuntimeName:       "runtime",
+		IngressHost:       "https://host.com",
+		HostName:          "host.com",
+		IngressClass:      "nginx",
+		IngressController: ingressutil.GetController(string(ingressutil.IngressControllerNginxCommunity)),
+		InsCloneOpts:      &apgit.CloneOptions{Repo: "https://github.com/owner/repo?ref=develop"},
+		gitProvider:       &fakeProvider{},
+	}
+	opts.InsCloneOpts.Parse()
+
+	data, err := yaml.Marshal(getHelmValues(opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]interface{}{}
+	if err = yaml.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"runtime": map[string]interface{}{
+			"name": "runtime",
+			"ingress": map[string]interface{}{
+				"enabled":    true,
+				"className":  "nginx",
+				"controller": "k8s.io/ingress-nginx",
+				"host":       "https://host.com",
+				"hostName":   "host.com",
+			},
+			"repo": map[string]interface{}{
+				"url":      opts.InsCloneOpts.URL(),
+				"revision": "develop",
+			},
+			"gitProvider": map[string]interface{}{
+				"type":   "github",
+				"apiUrl": "https://api.github.com",
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getHelmValues() = %v, want %v", got, want)
+	}
+}
+
+func Test_validateIngressHostsCertificates(t *testing.T) {
+	// self signed certificate
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	origSilent := store.Get().Silent
+	store.Get().Silent = true
+	defer func() { store.Get().Silent = origSilent }()
+
+	tests := []struct {
+		name         string
+		ingressHosts []string
+		wantErrHost  string
+	}{
+		{
+			name:         "should accept all valid hosts",
+			ingressHosts: []string{"http://host-a.com", "http://host-b.com"},
+		},
+		{
+			name:         "should validate every host and not only the primary one",
+			ingressHosts: []string{"http://host-a.com", server.URL},
+			wantErrHost:  server.URL,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateIngressHostsCertificates(context.Background(), tt.ingressHosts)
+			if tt.wantErrHost == "" {
+				if err != nil {
+					t.Errorf("validateIngressHostsCertificates() error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErrHost) {
+				t.Errorf("validateIngressHostsCertificates() error = %v, want error for host %s", err, tt.wantErrHost)
+			}
+		})
+	}
+}
+
+func Test_validateInternalIngressHostCertificate(t *testing.T) {
+	// self signed certificate
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	origSilent := store.Get().Silent
+	store.Get().Silent = true
+	defer func() { store.Get().Silent = origSilent }()
+
+	opts := &RuntimeInstallOptions{InternalIngressHost: server.URL}
+	if err := validateInternalIngressHostCertificate(context.Background(), opts); err == nil {
+		t.Errorf("validateInternalIngressHostCertificate() error = nil, want the self signed certificate to fail")
+	}
+
+	opts.InternalIngressInsecure = true
+	if err := validateInternalIngressHostCertificate(context.Background(), opts); err != nil {
+		t.Errorf("validateInternalIngressHostCertificate() error = %v, want the validation to be skipped", err)
+	}
+}
+
+func Test_mergeAnnotations(t *testing.T) {
+	tests := []struct {
+		name          string
+		newAnnotation map[string]string
+		wantErr       bool
+	}{
+		{
+			name:          "should merge small annotations",
+			newAnnotation: map[string]string{"nginx.ingress.kubernetes.io/proxy-body-size": "10m"},
+		},
+		{
+			name:          "should reject an annotation value over the size limit",
+			newAnnotation: map[string]string{"config": strings.Repeat("a", kubeutil.TotalAnnotationSizeLimitB)},
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			annotations := map[string]string{"nginx.org/mergeable-ingress-type": "master"}
+			if err := mergeAnnotations(annotations, tt.newAnnotation); (err != nil) != tt.wantErr {
+				t.Errorf("mergeAnnotations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			opts := &RuntimeInstallOptions{ExternalIngressAnnotation: tt.newAnnotation}
+			if err := validateIngressAnnotations(opts); (err != nil) != tt.wantErr {
+				t.Errorf("validateIngressAnnotations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_parseComponentVersions(t *testing.T) {
+	got, err := parseComponentVersions(map[string]string{"events": "0.0.101"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got["events"].String() != "0.0.101" {
+		t.Errorf("parseComponentVersions() = %v, want events=0.0.101", got)
+	}
+
+	if _, err = parseComponentVersions(map[string]string{"events": "latest"}); err == nil {
+		t.Error("parseComponentVersions() should fail on an invalid version")
+	}
+}
+
+func Test_reportersAccessWarnings(t *testing.T) {
+	cs := fake.NewSimpleClientset()
+	// a fake authorizer that denies access to rollouts only
+	cs.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, kruntime.Object, error) {
+		sar := action.(k8stesting.CreateAction).GetObject().(*authv1.SubjectAccessReview)
+		sar.Status.Allowed = sar.Spec.ResourceAttributes.Resource != "rollouts"
+		return true, sar, nil
+	})
+
+	reporters := []reporterCreateOptions{
+		{
+			reporterName: "workflow-reporter",
+			saName:       "codefresh-sa",
+			gvr:          []gvr{{resourceName: "workflows", group: "argoproj.io", version: "v1alpha1"}},
+		},
+		{
+			reporterName: "rollout-reporter",
+			saName:       "rollout-reporter-sa",
+			gvr: []gvr{
+				{resourceName: "rollouts", group: "argoproj.io", version: "v1alpha1"},
+				{resourceName: "replicasets", group: "apps", version: "v1"},
+			},
+		},
+	}
+
+	warnings := reportersAccessWarnings(context.Background(), cs, "runtime", reporters)
+	want := []string{
+		"rollout-reporter reporter might not work: service account rollout-reporter-sa is not allowed to list argoproj.io/rollouts on namespace runtime",
+		"rollout-reporter reporter might not work: service account rollout-reporter-sa is not allowed to watch argoproj.io/rollouts on namespace runtime",
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("reportersAccessWarnings() = %v, want %v", warnings, want)
+	}
+}
+
+func Test_getMasterIngress(t *testing.T) {
+	origName := store.Get().MasterIngressName
+	store.Get().MasterIngressName = "-nginx-master"
+	defer func() { store.Get().MasterIngressName = origName }()
+
+	opts := &RuntimeInstallOptions{
+		RuntimeName:               "runtime",
+		HostName:                  "host.com",
+		IngressClass:              "nginx",
+		ExternalIngressAnnotation: map[string]string{"external": "true"},
+		MasterIngressAnnotation:   map[string]string{"nginx.org/server-snippets": "gzip on;"},
+	}
+
+	ingress, err := getMasterIngress(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ingress.Name != "runtime-nginx-master" {
+		t.Errorf("getMasterIngress() name = %v, want runtime-nginx-master", ingress.Name)
+	}
+
+	wantAnnotations := map[string]string{
+		"nginx.org/mergeable-ingress-type": "master",
+		"external":                         "true",
+		"nginx.org/server-snippets":        "gzip on;",
+	}
+	if !reflect.DeepEqual(ingress.Annotations, wantAnnotations) {
+		t.Errorf("getMasterIngress() annotations = %v, want %v", ingress.Annotations, wantAnnotations)
+	}
+}
+
+func Test_createReporterEventSource(t *testing.T) {
+	tests := map[string]struct {
+		opts         *RuntimeInstallOptions
+		wantEventBus string
+		wantLabels   map[string]string
+	}{
+		"should use the runtime event bus when namespaced to the runtime": {
+			opts:         &RuntimeInstallOptions{},
+			wantEventBus: store.Get().EventBusName,
+			wantLabels: map[string]string{
+				apstore.Default.LabelKeyAppManagedBy: store.Get().BinaryName,
+			},
+		},
+		"should reference the cluster-wide events controller": {
+			opts: &RuntimeInstallOptions{
+				ArgoEventsClusterWide:      true,
+				ArgoEventsEventBus:         "shared-eventbus",
+				ArgoEventsControllerLabels: map[string]string{"controller-instanceid": "shared"},
+			},
+			wantEventBus: "shared-eventbus",
+			wantLabels: map[string]string{
+				apstore.Default.LabelKeyAppManagedBy: store.Get().BinaryName,
+				"controller-instanceid":              "shared",
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			repofs := apfs.Create(memfs.New())
+			reporterCreateOpts := reporterCreateOptions{
+				reporterName: "workflow-reporter",
+				gvr:          []gvr{{resourceName: "workflows", group: "argoproj.io", version: "v1alpha1"}},
+				saName:       "workflow-reporter-sa",
+			}
+			if err := createReporterEventSource(repofs, "resources", "runtime", reporterCreateOpts, false, getArgoEventsRef(tt.opts), eventsutil.PodPlacement{}); err != nil {
+				t.Fatal(err)
+			}
+
+			eventSource := &aev1alpha1.EventSource{}
+			if err := repofs.ReadYamls(repofs.Join("resources", "event-source.yaml"), eventSource); err != nil {
+				t.Fatal(err)
+			}
+
+			if eventSource.Spec.EventBusName != tt.wantEventBus {
+				t.Errorf("createReporterEventSource() eventBusName = %v, want %v", eventSource.Spec.EventBusName, tt.wantEventBus)
+			}
+
+			if !reflect.DeepEqual(eventSource.Labels, tt.wantLabels) {
+				t.Errorf("createReporterEventSource() labels = %v, want %v", eventSource.Labels, tt.wantLabels)
+			}
+
+			if eventSource.Spec.Resource["workflows"].Namespace != "runtime" {
+				t.Errorf("createReporterEventSource() resource namespace = %v, want runtime", eventSource.Spec.Resource["workflows"].Namespace)
+			}
+		})
+	}
+}
+
+func Test_validateArgoEventsRef(t *testing.T) {
+	opts := &RuntimeInstallOptions{ArgoEventsEventBus: "shared-eventbus"}
+	if err := validateArgoEventsRef(opts); err == nil {
+		t.Error("validateArgoEventsRef() expected an error without --argo-events-cluster-wide")
+	}
+
+	opts.ArgoEventsClusterWide = true
+	if err := validateArgoEventsRef(opts); err != nil {
+		t.Errorf("validateArgoEventsRef() error = %v", err)
+	}
+}
+
+func Test_ensureRepoFromTemplate(t *testing.T) {
+	tests := map[string]struct {
+		createIfNotExist bool
+		private          bool
+		wantTemplate     string
+	}{
+		"should create a private repo from the template": {
+			createIfNotExist: true,
+			private:          true,
+			wantTemplate:     "org/template",
+		},
+		"should create a public repo from the template": {
+			createIfNotExist: true,
+			wantTemplate:     "org/template",
+		},
+		"should ignore the template when the repo must already exist": {
+			private: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cloneOpts := &apgit.CloneOptions{Repo: "https://github.com/owner/repo", CreateIfNotExist: tt.createIfNotExist}
+			provider := &fakeTemplatingProvider{}
+			if err := ensureRepoFromTemplate(context.Background(), provider, cloneOpts, "org/template", tt.private); err != nil {
+				t.Fatal(err)
+			}
+
+			if provider.template != tt.wantTemplate {
+				t.Errorf("ensureRepoFromTemplate() template = %v, want %v", provider.template, tt.wantTemplate)
+			}
+
+			if tt.wantTemplate != "" && provider.private != tt.private {
+				t.Errorf("ensureRepoFromTemplate() private = %v, want %v", provider.private, tt.private)
+			}
+
+			// providers without templates fall back to the plain create
+			if err := ensureRepoFromTemplate(context.Background(), &fakeProvider{}, cloneOpts, "org/template", tt.private); err != nil {
+				t.Errorf("ensureRepoFromTemplate() error = %v", err)
+			}
+		})
+	}
+}
+
+func Test_checkNamespaceTerminating(t *testing.T) {
+	tests := map[string]struct {
+		ns      *v1.Namespace
+		wantErr string
+	}{
+		"should pass when the namespace does not exist": {},
+		"should pass when the namespace is active": {
+			ns: &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "runtime"},
+				Status:     v1.NamespaceStatus{Phase: v1.NamespaceActive},
+			},
+		},
+		"should fail with guidance when the namespace is terminating": {
+			ns: &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "runtime"},
+				Spec:       v1.NamespaceSpec{Finalizers: []v1.FinalizerName{v1.FinalizerKubernetes}},
+				Status: v1.NamespaceStatus{
+					Phase: v1.NamespaceTerminating,
+					Conditions: []v1.NamespaceCondition{
+						{
+							Type:    v1.NamespaceFinalizersRemaining,
+							Status:  v1.ConditionTrue,
+							Message: "Some content in the namespace has finalizers remaining: resources-finalizer.argocd.argoproj.io in 3 resource instances",
+						},
+					},
+				},
+			},
+			wantErr: "namespace \"runtime\" is terminating, probably left over from a previous uninstall (Some content in the namespace has finalizers remaining: resources-finalizer.argocd.argoproj.io in 3 resource instances). wait for the deletion to complete, or install the runtime with a different name. if it is stuck, remove the remaining finalizers (kubernetes) from the namespace and the resources left in it",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cs := fake.NewSimpleClientset()
+			if tt.ns != nil {
+				cs = fake.NewSimpleClientset(tt.ns)
+			}
+
+			err := checkNamespaceTerminating(context.Background(), cs, "runtime")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkNamespaceTerminating() error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("checkNamespaceTerminating() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_createSensor(t *testing.T) {
+	tests := map[string]struct {
+		dataKey string
+		wantErr bool
+	}{
+		"should set a custom data key as the trigger destination": {
+			dataKey: "data.resource",
+		},
+		"should fail on an empty data key": {
+			wantErr: true,
+		},
+		"should fail on an invalid data key": {
+			dataKey: "data..object",
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			repofs := apfs.Create(memfs.New())
+			err := createSensor(repofs, "workflow-reporter", "resources", "runtime", "workflow-reporter", []string{"workflows"}, tt.dataKey, "https://g.codefresh.io/2.0/api/events", getArgoEventsRef(&RuntimeInstallOptions{}), getTriggerRetry(&RuntimeInstallOptions{}), eventsutil.PodPlacement{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("createSensor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			sensor := &sensorsv1alpha1.Sensor{}
+			if err := repofs.ReadYamls(repofs.Join("resources", "sensor.yaml"), sensor); err != nil {
+				t.Fatal(err)
+			}
+
+			if dest := sensor.Spec.Triggers[0].Template.HTTP.Payload[0].Dest; dest != tt.dataKey {
+				t.Errorf("createSensor() trigger dest = %v, want %v", dest, tt.dataKey)
+			}
+		})
+	}
+}
+
+func Test_createSensor_retry(t *testing.T) {
+	tests := map[string]struct {
+		opts         *RuntimeInstallOptions
+		wantSteps    int32
+		wantDuration string
+	}{
+		"should set the default retry policy": {
+			opts:         &RuntimeInstallOptions{},
+			wantSteps:    3,
+			wantDuration: "3s",
+		},
+		"should set the configured retry policy": {
+			opts: &RuntimeInstallOptions{
+				SensorRetryCount:    5,
+				SensorRetryInterval: 10 * time.Second,
+			},
+			wantSteps:    5,
+			wantDuration: "10s",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			repofs := apfs.Create(memfs.New())
+			if err := createSensor(repofs, "events-reporter", "resources", "runtime", "events-reporter", []string{"events"}, "data", "https://g.codefresh.io/2.0/api/events", getArgoEventsRef(tt.opts), getTriggerRetry(tt.opts), eventsutil.PodPlacement{}); err != nil {
+				t.Fatal(err)
+			}
+
+			sensor := &sensorsv1alpha1.Sensor{}
+			if err := repofs.ReadYamls(repofs.Join("resources", "sensor.yaml"), sensor); err != nil {
+				t.Fatal(err)
+			}
+
+			retry := sensor.Spec.Triggers[0].RetryStrategy
+			if retry == nil || retry.Duration == nil {
+				t.Fatalf("createSensor() retry strategy = %v, want steps %d and duration %s", retry, tt.wantSteps, tt.wantDuration)
+			}
+
+			if retry.Steps != tt.wantSteps {
+				t.Errorf("createSensor() retry steps = %v, want %v", retry.Steps, tt.wantSteps)
+			}
+
+			if retry.Duration.StrVal != tt.wantDuration {
+				t.Errorf("createSensor() retry duration = %v, want %v", retry.Duration.StrVal, tt.wantDuration)
+			}
+		})
+	}
+}
+
+func Test_configureAppProxy_service(t *testing.T) {
+	withFakeCfConfig(t)
+
+	tests := map[string]struct {
+		serviceName     string
+		servicePort     int32
+		wantServiceName string
+		wantServicePort int32
+	}{
+		"should route to the default app-proxy service": {
+			wantServiceName: "cap-app-proxy",
+			wantServicePort: 3017,
+		},
+		"should route to the custom app-proxy service": {
+			serviceName:     "custom-app-proxy",
+			servicePort:     8080,
+			wantServiceName: "custom-app-proxy",
+			wantServicePort: 8080,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			repofs := apfs.Create(memfs.New())
+			overlaysDir := repofs.Join(apstore.Default.AppsDir, "app-proxy", apstore.Default.OverlaysDir, "runtime")
+			if err := kustutil.WriteKustomization(repofs, &kusttypes.Kustomization{}, overlaysDir); err != nil {
+				t.Fatal(err)
+			}
+
+			opts := &RuntimeInstallOptions{
+				RuntimeName:         "runtime",
+				HostName:            "runtime.example.com",
+				IngressController:   ingressutil.GetController("k8s.io/ingress-nginx"),
+				AppProxyServiceName: tt.serviceName,
+				AppProxyServicePort: tt.servicePort,
+				dryRunFS:            repofs,
+			}
+			rt := &runtime.Runtime{}
+			rt.Name = "runtime"
+			rt.Namespace = "runtime"
+			if err := configureAppProxy(context.Background(), opts, rt); err != nil {
+				t.Fatal(err)
+			}
+
+			ingress := &netv1.Ingress{}
+			if err := repofs.ReadYamls(repofs.Join(overlaysDir, "ingress.yaml"), ingress); err != nil {
+				t.Fatal(err)
+			}
+
+			backend := ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service
+			if backend.Name != tt.wantServiceName || backend.Port.Number != tt.wantServicePort {
+				t.Errorf("configureAppProxy() ingress backend = %s:%d, want %s:%d", backend.Name, backend.Port.Number, tt.wantServiceName, tt.wantServicePort)
+			}
+		})
+	}
+}
+
+func Test_ensureIngressHost_internalHosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	opts := &RuntimeInstallOptions{
+		IngressHost:         server.URL,
+		InternalIngressHost: "https://internal-a.example.com, https://internal-b.example.com:8443/",
+		SkipClusterChecks:   true,
+	}
+	if err := ensureIngressHost(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if opts.InternalIngressHost != "https://internal-a.example.com" || opts.InternalHostName != "internal-a.example.com" {
+		t.Errorf("ensureIngressHost() primary internal host = %v (%v), want https://internal-a.example.com", opts.InternalIngressHost, opts.InternalHostName)
+	}
+
+	if !reflect.DeepEqual(opts.AdditionalInternalIngressHosts, []string{"https://internal-b.example.com:8443"}) {
+		t.Errorf("ensureIngressHost() additional internal hosts = %v", opts.AdditionalInternalIngressHosts)
+	}
+
+	if !reflect.DeepEqual(opts.AdditionalInternalHostNames, []string{"internal-b.example.com"}) {
+		t.Errorf("ensureIngressHost() additional internal host names = %v", opts.AdditionalInternalHostNames)
+	}
+
+	opts.InternalIngressHost = "https://internal-a.example.com,https://internal-b.example.com/path"
+	if err := ensureIngressHost(context.Background(), opts); err == nil {
+		t.Error("ensureIngressHost() should fail on an internal host with a path")
+	}
+}
+
+func Test_ensureIngressHost_internalIngressInsecure(t *testing.T) {
+	tests := map[string]struct {
+		internalIngressHost string
+		want                string
+		wantErr             bool
+	}{
+		"should use the http scheme for an https host": {
+			internalIngressHost: "https://internal.example.com:8443",
+			want:                "http://internal.example.com:8443",
+		},
+		"should keep an http host": {
+			internalIngressHost: "http://internal.example.com",
+			want:                "http://internal.example.com",
+		},
+		"should fail on a host without a scheme": {
+			internalIngressHost: "internal.example.com",
+			wantErr:             true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := &RuntimeInstallOptions{
+				IngressHost:             "https://runtime.example.com",
+				InternalIngressHost:     tt.internalIngressHost,
+				InternalIngressInsecure: true,
+				SkipClusterChecks:       true,
+			}
+			err := ensureIngressHost(context.Background(), opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ensureIngressHost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && opts.InternalIngressHost != tt.want {
+				t.Errorf("ensureIngressHost() internal ingress host = %v, want %v", opts.InternalIngressHost, tt.want)
+			}
+		})
+	}
+}
+
+func Test_configureAppProxy_internalHosts(t *testing.T) {
+	withFakeCfConfig(t)
+
+	repofs := apfs.Create(memfs.New())
+	overlaysDir := repofs.Join(apstore.Default.AppsDir, "app-proxy", apstore.Default.OverlaysDir, "runtime")
+	if err := kustutil.WriteKustomization(repofs, &kusttypes.Kustomization{}, overlaysDir); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &RuntimeInstallOptions{
+		RuntimeName:                 "runtime",
+		HostName:                    "runtime.example.com",
+		AdditionalHostNames:         []string{"alt.example.com"},
+		InternalHostName:            "internal-a.example.com",
+		AdditionalInternalHostNames: []string{"internal-b.example.com", "internal-c.example.com"},
+		IngressController:           ingressutil.GetController("k8s.io/ingress-nginx"),
+		dryRunFS:                    repofs,
+	}
+	rt := &runtime.Runtime{}
+	rt.Name = "runtime"
+	rt.Namespace = "runtime"
+	if err := configureAppProxy(context.Background(), opts, rt); err != nil {
+		t.Fatal(err)
+	}
+
+	ingress := &netv1.Ingress{}
+	if err := repofs.ReadYamls(repofs.Join(overlaysDir, "ingress.yaml"), ingress); err != nil {
+		t.Fatal(err)
+	}
+
+	var hosts []string
+	for _, rule := range ingress.Spec.Rules {
+		hosts = append(hosts, rule.Host)
+	}
+
+	want := []string{"internal-a.example.com", "internal-b.example.com", "internal-c.example.com"}
+	if !reflect.DeepEqual(hosts, want) {
+		t.Errorf("configureAppProxy() ingress hosts = %v, want %v", hosts, want)
+	}
+}
+
+func Test_definitionCompatibilityReport(t *testing.T) {
+	tests := map[string]struct {
+		defVersion     string
+		wantRelation   string
+		wantCompatible bool
+	}{
+		"should report the cli is behind a newer definition": {
+			defVersion:   "1.1.0",
+			wantRelation: "the cli is behind the definition",
+		},
+		"should report the cli is up to date with the same definition": {
+			defVersion:     "1.0.1",
+			wantRelation:   "the cli is up to date with the definition",
+			wantCompatible: true,
+		},
+		"should report the cli is ahead of an older definition": {
+			defVersion:     "1.0.0",
+			wantRelation:   "the cli is ahead of the definition",
+			wantCompatible: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			report, compatible := definitionCompatibilityReport(semver.MustParse(tt.defVersion), semver.MustParse("1.0.1"))
+			if compatible != tt.wantCompatible {
+				t.Errorf("definitionCompatibilityReport() compatible = %v, want %v", compatible, tt.wantCompatible)
+			}
+
+			want := "Runtime definition version: " + tt.defVersion + ", max definition version supported by the cli: 1.0.1 - " + tt.wantRelation
+			if report != want {
+				t.Errorf("definitionCompatibilityReport() = %v, want %v", report, want)
+			}
+		})
+	}
+}
+
+func Test_createGitSources_demoResources(t *testing.T) {
+	var created []*GitSourceCreateOptions
+	origRunGitSourceCreate := runGitSourceCreate
+	runGitSourceCreate = func(_ context.Context, opts *GitSourceCreateOptions) error {
+		created = append(created, opts)
+		return nil
+	}
+	defer func() { runGitSourceCreate = origRunGitSourceCreate }()
+	origVerifyMarketplaceGitSource := verifyMarketplaceGitSource
+	verifyMarketplaceGitSource = func(_ context.Context) {}
+	defer func() { verifyMarketplaceGitSource = origVerifyMarketplaceGitSource }()
+
+	opts := &RuntimeInstallOptions{
+		RuntimeName:          "runtime",
+		InsCloneOpts:         &apgit.CloneOptions{Repo: "https://github.com/owner/repo"},
+		GsCloneOpts:          &apgit.CloneOptions{Repo: "https://github.com/owner/repo_git-source"},
+		InstallDemoResources: true,
+		DemoResourcesRepo:    "https://github.com/org/starters",
+		DemoResourcesPath:    "pipelines",
+		gitProvider:          &fakeProvider{},
+	}
+	if err := createGitSources(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(created) != 2 {
+		t.Fatalf("createGitSources() created %d git-sources, want 2", len(created))
+	}
+
+	gsOpts := created[0]
+	if gsOpts.DemoResourcesCloneOpts == nil || gsOpts.DemoResourcesCloneOpts.Repo != "https://github.com/org/starters" {
+		t.Errorf("createGitSources() demo resources clone options = %v, want the --demo-resources-repo", gsOpts.DemoResourcesCloneOpts)
+	}
+
+	if gsOpts.DemoResourcesPath != "pipelines" {
+		t.Errorf("createGitSources() demo resources path = %v, want pipelines", gsOpts.DemoResourcesPath)
+	}
+
+	if created[1].DemoResourcesCloneOpts != nil {
+		t.Errorf("createGitSources() marketplace git-source got the demo resources")
+	}
+}
+
+func Test_warnOnEmptyGitSource(t *testing.T) {
+	tests := map[string]struct {
+		files    []string
+		wantWarn bool
+	}{
+		"Should not warn when the include pattern matches": {
+			files:    []string{"workflows/a/versions/0.0.1/workflowTemplate.yaml", "README.md"},
+			wantWarn: false,
+		},
+		"Should warn when the include pattern matches nothing": {
+			files:    []string{"templates/a/versions/0.0.1/workflowTemplate.yaml", "README.md"},
+			wantWarn: true,
+		},
+		"Should warn when all matches are excluded": {
+			files:    []string{"workflows/a/images/v1/icon.yaml"},
+			wantWarn: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			repofs := apfs.Create(memfs.New())
+			for _, path := range tt.files {
+				if err := billyUtils.WriteFile(repofs, path, []byte("kind: WorkflowTemplate"), 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			origLogger := log.L
+			defer func() { log.L = origLogger }()
+			logger, hook := logrustest.NewNullLogger()
+			ctx := log.WithLogger(context.Background(), log.FromLogrus(logrus.NewEntry(logger), nil))
+			warnOnEmptyGitSource(ctx, repofs, "marketplace-git-source", marketplaceGitSourceInclude, marketplaceGitSourceExclude)
+
+			warned := false
+			for _, entry := range hook.AllEntries() {
+				if entry.Level == logrus.WarnLevel && strings.Contains(entry.Message, "did not match any resource") {
+					warned = true
+				}
+			}
+
+			if warned != tt.wantWarn {
+				t.Errorf("warnOnEmptyGitSource() warned = %v, want %v", warned, tt.wantWarn)
+			}
+		})
+	}
+}
+
+func Test_copyDir(t *testing.T) {
+	src := memfs.New()
+	for _, path := range []string{"pipelines/a/sensor.yaml", "pipelines/b.yaml", "README.md"} {
+		if err := billyUtils.WriteFile(src, path, []byte(path), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	srcDir, err := src.Chroot("pipelines")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := memfs.New()
+	copied, err := copyDir(srcDir, dst, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if copied != 2 {
+		t.Errorf("copyDir() copied %d files, want 2", copied)
+	}
+
+	data, err := billyUtils.ReadFile(dst, "a/sensor.yaml")
+	if err != nil || string(data) != "pipelines/a/sensor.yaml" {
+		t.Errorf("copyDir() a/sensor.yaml = %s, %v", data, err)
+	}
+}
+
+func Test_resolveIngressClass(t *testing.T) {
+	newIngressClass := func(name, controller string) *netv1.IngressClass {
+		return &netv1.IngressClass{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       netv1.IngressClassSpec{Controller: controller},
+		}
+	}
+	cs := fake.NewSimpleClientset(
+		newIngressClass("nginx-internal", string(ingressutil.IngressControllerNginxCommunity)),
+		newIngressClass("nginx-prod", string(ingressutil.IngressControllerNginxCommunity)),
+		newIngressClass("traefik", string(ingressutil.IngressControllerTraefik)),
+		newIngressClass("unsupported-prod", "example.com/unsupported"),
+	)
+	ingressClassNames, _, err := getSupportedIngressClasses(context.Background(), cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("INGRESS_ENV", "prod")
+	tests := map[string]struct {
+		ingressClass string
+		want         string
+		wantErr      string
+	}{
+		"should keep a plain class name": {
+			ingressClass: "traefik",
+			want:         "traefik",
+		},
+		"should resolve an environment variable": {
+			ingressClass: "nginx-${INGRESS_ENV}",
+			want:         "nginx-prod",
+		},
+		"should resolve a pattern to the single matching supported class": {
+			ingressClass: "*-${INGRESS_ENV}",
+			want:         "nginx-prod",
+		},
+		"should fail on a pattern that matches multiple classes": {
+			ingressClass: "nginx-*",
+			wantErr:      "matches multiple ingress classes: nginx-internal, nginx-prod",
+		},
+		"should fail on an empty environment variable": {
+			ingressClass: "${MISSING_INGRESS_CLASS}",
+			wantErr:      "resolved to an empty value",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := resolveIngressClass(tt.ingressClass, ingressClassNames)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("resolveIngressClass() error = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("resolveIngressClass() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getIngressClasses_forbiddenList(t *testing.T) {
+	cs := fake.NewSimpleClientset(&netv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx"},
+		Spec:       netv1.IngressClassSpec{Controller: string(ingressutil.IngressControllerNginxCommunity)},
+	})
+	cs.PrependReactor("list", "ingressclasses", func(action k8stesting.Action) (bool, kruntime.Object, error) {
+		return true, nil, kerrors.NewForbidden(schema.GroupResource{Group: "networking.k8s.io", Resource: "ingressclasses"}, "", errors.New("restricted user"))
+	})
+
+	ingressClassNames, ingressClassNameToController, err := getIngressClasses(context.Background(), cs, "nginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(ingressClassNames, []string{"nginx"}) {
+		t.Errorf("getIngressClasses() = %v, want [nginx]", ingressClassNames)
+	}
+
+	if _, ok := ingressClassNameToController["nginx"]; !ok {
+		t.Errorf("getIngressClasses() controllers = %v, missing \"nginx\"", ingressClassNameToController)
+	}
+
+	if _, _, err = getIngressClasses(context.Background(), cs, ""); !kerrors.IsForbidden(err) {
+		t.Errorf("getIngressClasses() error = %v, want forbidden without --ingress-class", err)
+	}
+
+	if _, _, err = getIngressClasses(context.Background(), cs, "nginx-*"); err == nil || !strings.Contains(err.Error(), "requires permission to list") {
+		t.Errorf("getIngressClasses() error = %v, want a pattern to require the list permission", err)
+	}
+}
+
+func Test_getComponents(t *testing.T) {
+	tests := map[string]struct {
+		reporters      string
+		skipComponents []string
+		want           []string
+		wantSkipErr    bool
+	}{
+		"should add all of the internal reporters by default": {
+			want: []string{"runtime-events", "runtime-events-reporter", "runtime-workflow-reporter", "runtime-rollout-reporter", store.Get().ArgoCD},
+		},
+		"should add only the reporters of the definition": {
+			reporters: "\n  reporters:\n  - events-reporter\n",
+			want:      []string{"runtime-events", "runtime-events-reporter", store.Get().ArgoCD},
+		},
+		"should not add the reporters that are not created": {
+			reporters: "\n  reporters:\n  - events-reporter\n  - custom-reporter\n",
+			want:      []string{"runtime-events", "runtime-events-reporter", store.Get().ArgoCD},
+		},
+		"should not add a skipped reporter of the definition": {
+			reporters:      "\n  reporters:\n  - events-reporter\n  - workflow-reporter\n",
+			skipComponents: []string{"workflow-reporter"},
+			want:           []string{"runtime-events", "runtime-events-reporter", store.Get().ArgoCD},
+		},
+		"should not add the skipped components and reporters": {
+			skipComponents: []string{"events", "rollout-reporter"},
+			want:           []string{"runtime-events-reporter", "runtime-workflow-reporter", store.Get().ArgoCD},
+		},
+		"should fail to skip an unknown component": {
+			skipComponents: []string{"unknown"},
+			wantSkipErr:    true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "runtime.yaml")
+			def := `apiVersion: codefresh.io/v1alpha1
+kind: Runtime
+metadata:
+  name: csdp-default
+spec:
+  version: 0.0.500
+  components:
+  - name: events
+    type: kustomize
+    url: github.com/codefresh-io/cli-v2/manifests/argo-events` + tt.reporters
+			if err := os.WriteFile(filename, []byte(def), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			rt, err := runtime.LoadDefinition(filename, "runtime")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = validateSkipComponents(rt, tt.skipComponents)
+			if (err != nil) != tt.wantSkipErr {
+				t.Fatalf("validateSkipComponents() error = %v, wantErr %v", err, tt.wantSkipErr)
+			}
+
+			if tt.wantSkipErr {
+				return
+			}
+
+			opts := &RuntimeInstallOptions{RuntimeName: "runtime", SkipComponents: tt.skipComponents}
+			if got := getComponents(rt, opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getComponents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_createsWorkflowsIngress(t *testing.T) {
+	rt := &runtime.Runtime{
+		Spec: runtime.RuntimeSpec{
+			Components: []runtime.AppDef{{Name: "workflows"}, {Name: "app-proxy"}},
+		},
+	}
+
+	if !createsWorkflowsIngress(rt, &RuntimeInstallOptions{}) {
+		t.Error("createsWorkflowsIngress() = false, want the ingress of an installed workflows component")
+	}
+
+	skipComponents := []string{store.Get().ArgoWorkflows}
+	if err := validateSkipComponents(rt, skipComponents); err != nil {
+		t.Fatalf("validateSkipComponents() error = %v, want the workflows component to be skippable", err)
+	}
+
+	if createsWorkflowsIngress(rt, &RuntimeInstallOptions{SkipComponents: skipComponents}) {
+		t.Error("createsWorkflowsIngress() = true, want no ingress for a skipped workflows component")
+	}
+
+	if !createsWorkflowsIngress(rt, &RuntimeInstallOptions{SkipComponents: []string{"app-proxy"}}) {
+		t.Error("createsWorkflowsIngress() = false, want the ingress when only another component is skipped")
+	}
+}
+
+func Test_createReporterRBAC(t *testing.T) {
+	gvrs := []gvr{
+		{resourceName: "rollouts", group: "argoproj.io", version: "v1alpha1"},
+		{resourceName: "replicasets", group: "apps", version: "v1"},
+		{resourceName: "analysisruns", group: "argoproj.io", version: "v1alpha1"},
+	}
+	tests := map[string]struct {
+		wildcard  bool
+		wantRules []rbacv1.PolicyRule
+	}{
+		"should only allow reading the watched resources": {
+			wantRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"argoproj.io"}, Resources: []string{"rollouts", "analysisruns"}, Verbs: []string{"get", "list", "watch"}},
+				{APIGroups: []string{"apps"}, Resources: []string{"replicasets"}, Verbs: []string{"get", "list", "watch"}},
+			},
+		},
+		"should fall back to the wildcard rule": {
+			wildcard: true,
+			wantRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			origWildcard := store.Get().ReporterWildcardRBAC
+			store.Get().ReporterWildcardRBAC = tt.wildcard
+			defer func() { store.Get().ReporterWildcardRBAC = origWildcard }()
+
+			repofs := apfs.Create(memfs.New())
+			if err := createReporterRBAC(repofs, "resources", "runtime", "rollout-reporter", true, gvrs, map[string]string{"policy/owner": "cli"}, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			sa, role, roleBinding := &v1.ServiceAccount{}, &rbacv1.Role{}, &rbacv1.RoleBinding{}
+			if err := repofs.ReadYamls(repofs.Join("resources", "rbac.yaml"), sa, role, roleBinding); err != nil {
+				t.Fatal(err)
+			}
+
+			if role.Kind != "ClusterRole" || roleBinding.Kind != "ClusterRoleBinding" {
+				t.Errorf("createReporterRBAC() kinds = %s, %s, want ClusterRole, ClusterRoleBinding", role.Kind, roleBinding.Kind)
+			}
+
+			if !reflect.DeepEqual(role.Rules, tt.wantRules) {
+				t.Errorf("createReporterRBAC() rules = %v, want %v", role.Rules, tt.wantRules)
+			}
+
+			for _, labels := range []map[string]string{sa.Labels, role.Labels, roleBinding.Labels} {
+				if labels["policy/owner"] != "cli" {
+					t.Errorf("createReporterRBAC() labels = %v, missing the resource labels", labels)
+				}
+			}
+		})
+	}
+}
+
+func Test_createWorkflowsRBAC(t *testing.T) {
+	repofs := apfs.Create(memfs.New())
+	if err := createWorkflowsRBAC(repofs, "resources", "runtime", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	sa, role, roleBinding := &v1.ServiceAccount{}, &rbacv1.Role{}, &rbacv1.RoleBinding{}
+	if err := repofs.ReadYamls(repofs.Join("resources", "workflows-rbac.yaml"), sa, role, roleBinding); err != nil {
+		t.Fatal(err)
+	}
+
+	if sa.Name != store.Get().CodefreshSA || role.Kind != "Role" || roleBinding.RoleRef.Name != store.Get().CodefreshSA {
+		t.Errorf("createWorkflowsRBAC() = %s %s/%s, want the namespaced role of %s", sa.Name, role.Kind, roleBinding.RoleRef.Name, store.Get().CodefreshSA)
+	}
+
+	wantRules := []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}}
+	if !reflect.DeepEqual(role.Rules, wantRules) {
+		t.Errorf("createWorkflowsRBAC() rules = %v, want %v", role.Rules, wantRules)
+	}
+}
+
+func Test_setArgoCDConfigLiteral(t *testing.T) {
+	kust := &kusttypes.Kustomization{}
+	setArgoCDConfigLiteral(kust, "kustomize.buildOptions", "--enable-helm")
+	setArgoCDConfigLiteral(kust, "timeout.reconciliation", "15s")
+	setArgoCDConfigLiteral(kust, "kustomize.buildOptions", "--load-restrictor LoadRestrictionsNone")
+
+	if len(kust.ConfigMapGenerator) != 1 {
+		t.Fatalf("setArgoCDConfigLiteral() generators = %v, want a single argocd-cm generator", kust.ConfigMapGenerator)
+	}
+
+	want := []string{"kustomize.buildOptions=--load-restrictor LoadRestrictionsNone", "timeout.reconciliation=15s"}
+	if got := kust.ConfigMapGenerator[0].LiteralSources; !reflect.DeepEqual(got, want) {
+		t.Errorf("setArgoCDConfigLiteral() literals = %v, want %v", got, want)
+	}
+}
+
+func Test_postInstallationHandler_confirmRollback(t *testing.T) {
+	origSilent := store.Get().Silent
+	store.Get().Silent = false
+	defer func() { store.Get().Silent = origSilent }()
+
+	origSummary := summaryArr
+	defer func() { summaryArr = origSummary }()
+
+	prompted := false
+	origConfirmRollback := confirmRollback
+	confirmRollback = func(error) (bool, error) {
+		prompted = true
+		return false, nil
+	}
+	defer func() { confirmRollback = origConfirmRollback }()
+
+	uninstalled := false
+	origRollbackUninstall := rollbackUninstall
+	rollbackUninstall = func(context.Context, *RuntimeUninstallOptions) error {
+		uninstalled = true
+		return nil
+	}
+	defer func() { rollbackUninstall = origRollbackUninstall }()
+
+	opts := &RuntimeInstallOptions{RuntimeName: "runtime", ConfirmRollback: true}
+	disableRollback := false
+	postInstallationHandler(context.Background(), opts, errors.New("some error"), &disableRollback)
+
+	if !prompted {
+		t.Errorf("postInstallationHandler() did not ask for the rollback confirmation")
+	}
+
+	if uninstalled {
+		t.Errorf("postInstallationHandler() uninstalled the runtime, want the failed installation preserved")
+	}
+}
+
+func Test_getClusterServer(t *testing.T) {
+	t.Setenv("KUBECONFIG", "")
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: cluster
+  cluster:
+    server: https://10.0.0.1:6443
+contexts:
+- name: context
+  context:
+    cluster: cluster
+    user: user
+current-context: context
+users:
+- name: user
+  user:
+    token: token
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		clusterServer string
+		want          string
+	}{
+		"should use the server of the kube context": {
+			want: "https://10.0.0.1:6443",
+		},
+		"should use the --cluster-server override": {
+			clusterServer: "https://cluster.example.com:6443",
+			want:          "https://cluster.example.com:6443",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := &RuntimeInstallOptions{
+				ClusterServer:     tt.clusterServer,
+				IngressController: ingressutil.GetController("k8s.io/ingress-nginx"),
+				InsCloneOpts:      &apgit.CloneOptions{Repo: "https://github.com/owner/repo"},
+				kubeContext:       "context",
+				kubeconfig:        kubeconfig,
+			}
+			got, err := getClusterServer(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("getClusterServer() = %v, want %v", got, tt.want)
+			}
+
+			args := getRuntimeInstallationArgs(opts, got, "0.0.1", nil)
+			if args.Cluster != tt.want {
+				t.Errorf("getRuntimeInstallationArgs() cluster = %v, want %v", args.Cluster, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ensureRuntimeProject_useExisting(t *testing.T) {
+	existingProject := `apiVersion: argoproj.io/v1alpha1
+kind: AppProject
+metadata:
+  name: runtime
+  namespace: argocd
+spec:
+  sourceRepos:
+  - '*'
+---
+apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: runtime
+  namespace: argocd
+spec:
+  generators: []
+  template:
+    metadata:
+      name: runtime-{{ appName }}
+      labels:
+        team: platform
+    spec:
+      project: runtime
+`
+	tests := []struct {
+		name       string
+		project    string
+		wantCreate bool
+	}{
+		{
+			name:    "should reuse the existing project",
+			project: existingProject,
+		},
+		{
+			name:       "should create the project when it does not exist",
+			wantCreate: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repofs := apfs.Create(memfs.New())
+			projPath := repofs.Join(apstore.Default.ProjectsDir, "runtime.yaml")
+			if tt.project != "" {
+				if err := billyUtils.WriteFile(repofs, projPath, []byte(tt.project), 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			created := false
+			origRunProjectCreate := runProjectCreate
+			runProjectCreate = func(_ context.Context, _ *apcmd.ProjectCreateOptions) error {
+				created = true
+				return nil
+			}
+			defer func() { runProjectCreate = origRunProjectCreate }()
+
+			opts := &RuntimeInstallOptions{
+				RuntimeName:        "runtime",
+				UseExistingProject: true,
+				dryRunFS:           repofs,
+			}
+			rt := &runtime.Runtime{}
+			rt.Name = "runtime"
+			if err := ensureRuntimeProject(context.Background(), opts, rt); err != nil {
+				t.Fatal(err)
+			}
+
+			if created != tt.wantCreate {
+				t.Fatalf("ensureRuntimeProject() created = %v, want %v", created, tt.wantCreate)
+			}
+
+			if tt.wantCreate {
+				return
+			}
+
+			project, appSet, err := getProjectInfoFromFile(repofs, projPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if project.Labels[store.Get().LabelKeyCFType] != store.Get().CFRuntimeType {
+				t.Errorf("ensureRuntimeProject() project labels = %v, want the runtime type label", project.Labels)
+			}
+
+			wantLabels := getProjectAppLabels()
+			wantLabels["team"] = "platform"
+			if !reflect.DeepEqual(appSet.Spec.Template.Labels, wantLabels) {
+				t.Errorf("ensureRuntimeProject() application set template labels = %v, want %v", appSet.Spec.Template.Labels, wantLabels)
+			}
+
+			if !reflect.DeepEqual(appSet.Spec.Template.Annotations, getProjectAppAnnotations()) {
+				t.Errorf("ensureRuntimeProject() application set template annotations = %v, want %v", appSet.Spec.Template.Annotations, getProjectAppAnnotations())
+			}
+		})
+	}
+}
+
+func Test_runtimeIngresses_certManagerIssuer(t *testing.T) {
+	withFakeCfConfig(t)
+
+	repofs := apfs.Create(memfs.New())
+	workflowsOverlay := repofs.Join(apstore.Default.AppsDir, store.Get().WorkflowsIngressPath, apstore.Default.OverlaysDir, "runtime")
+	appProxyOverlay := repofs.Join(apstore.Default.AppsDir, "app-proxy", apstore.Default.OverlaysDir, "runtime")
+	for _, dir := range []string{workflowsOverlay, appProxyOverlay} {
+		if err := kustutil.WriteKustomization(repofs, &kusttypes.Kustomization{}, dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := &RuntimeInstallOptions{
+		RuntimeName:         "runtime",
+		HostName:            "runtime.example.com",
+		AdditionalHostNames: []string{"alt.example.com"},
+		IngressController:   ingressutil.GetController("k8s.io/ingress-nginx"),
+		CertManagerIssuer:   "letsencrypt",
+		dryRunFS:            repofs,
+	}
+	rt := &runtime.Runtime{}
+	rt.Name = "runtime"
+	rt.Namespace = "runtime"
+	if err := createWorkflowsIngress(context.Background(), opts, rt); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := configureAppProxy(context.Background(), opts, rt); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range []string{workflowsOverlay, appProxyOverlay} {
+		ingress := &netv1.Ingress{}
+		if err := repofs.ReadYamls(repofs.Join(dir, "ingress.yaml"), ingress); err != nil {
+			t.Fatal(err)
+		}
+
+		if ingress.Annotations[ingressutil.CertManagerClusterIssuerAnnotation] != "letsencrypt" {
+			t.Errorf("%s annotations = %v, want the cert-manager cluster issuer", ingress.Name, ingress.Annotations)
+		}
+
+		wantTLS := []netv1.IngressTLS{{Hosts: []string{"runtime.example.com", "alt.example.com"}, SecretName: ingress.Name + "-tls"}}
+		if !reflect.DeepEqual(ingress.Spec.TLS, wantTLS) {
+			t.Errorf("%s tls = %v, want %v", ingress.Name, ingress.Spec.TLS, wantTLS)
+		}
+	}
+}
+
+func Test_createWorkflowsIngress_traefik(t *testing.T) {
+	repofs := apfs.Create(memfs.New())
+	overlaysDir := repofs.Join(apstore.Default.AppsDir, store.Get().WorkflowsIngressPath, apstore.Default.OverlaysDir, "runtime")
+	if err := kustutil.WriteKustomization(repofs, &kusttypes.Kustomization{}, overlaysDir); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &RuntimeInstallOptions{
+		RuntimeName:       "runtime",
+		IngressHost:       "http://runtime.example.com",
+		HostName:          "runtime.example.com",
+		IngressController: ingressutil.GetController(string(ingressutil.IngressControllerTraefik)),
+		dryRunFS:          repofs,
+	}
+	rt := &runtime.Runtime{}
+	rt.Name = "runtime"
+	rt.Namespace = "runtime"
+	// recreating the ingress, like "runtime ingress sync" does, must not add the patch twice
+	for i := 0; i < 2; i++ {
+		if err := createWorkflowsIngress(context.Background(), opts, rt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ingress := &netv1.Ingress{}
+	if err := repofs.ReadYamls(repofs.Join(overlaysDir, "ingress.yaml"), ingress); err != nil {
+		t.Fatal(err)
+	}
+
+	if ingress.Annotations["traefik.ingress.kubernetes.io/router.entrypoints"] != "web" || ingress.Annotations["traefik.ingress.kubernetes.io/router.tls"] != "" {
+		t.Errorf("ingress annotations = %v, want the web entrypoint of the http host", ingress.Annotations)
+	}
+
+	service := &v1.Service{}
+	if err := repofs.ReadYamls(repofs.Join(overlaysDir, "service-patch.yaml"), service); err != nil {
+		t.Fatal(err)
+	}
+
+	if service.Name != store.Get().ArgoWFServiceName || service.Annotations["traefik.ingress.kubernetes.io/service.serversscheme"] != "https" {
+		t.Errorf("service patch = %v, want the https servers scheme of %s", service.ObjectMeta, store.Get().ArgoWFServiceName)
+	}
+
+	kust, err := kustutil.ReadKustomization(repofs, overlaysDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	servicePatches := 0
+	for _, p := range kust.Patches {
+		if p.Path == "service-patch.yaml" {
+			servicePatches++
+		}
+	}
+
+	if servicePatches != 1 {
+		t.Errorf("kustomization patches = %v, want a single service patch", kust.Patches)
+	}
+}
+
+func Test_ensureIngressHost_certManagerIssuer(t *testing.T) {
+	// the certificate of the server is self-signed, and would fail the validation
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	opts := &RuntimeInstallOptions{
+		IngressHost:       server.URL,
+		CertManagerIssuer: "letsencrypt",
+	}
+	if err := ensureIngressHost(context.Background(), opts); err != nil {
+		t.Errorf("ensureIngressHost() should not validate the certificate of a host issued by cert-manager, got: %v", err)
+	}
+}
+
+func Test_ensureIngressHost_certificates(t *testing.T) {
+	// self signed certificate
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsServer.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	origSilent := store.Get().Silent
+	store.Get().Silent = true
+	defer func() { store.Get().Silent = origSilent }()
+
+	tests := []struct {
+		name    string
+		opts    *RuntimeInstallOptions
+		wantErr bool
+	}{
+		{
+			name:    "should validate the certificate of the ingress host",
+			opts:    &RuntimeInstallOptions{IngressHost: tlsServer.URL},
+			wantErr: true,
+		},
+		{
+			name:    "should validate the certificates of the additional ingress hosts",
+			opts:    &RuntimeInstallOptions{IngressHost: server.URL + "," + tlsServer.URL},
+			wantErr: true,
+		},
+		{
+			name:    "should validate the certificate of the internal ingress host",
+			opts:    &RuntimeInstallOptions{IngressHost: server.URL, InternalIngressHost: tlsServer.URL},
+			wantErr: true,
+		},
+		{
+			name: "should not validate the certificate of an insecure internal ingress host",
+			opts: &RuntimeInstallOptions{
+				IngressHost:             server.URL,
+				InternalIngressHost:     tlsServer.URL,
+				InternalIngressInsecure: true,
+			},
+		},
+		{
+			name: "should skip all of the checks with --skip-cluster-checks",
+			opts: &RuntimeInstallOptions{IngressHost: tlsServer.URL, SkipClusterChecks: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ensureIngressHost(context.Background(), tt.opts); (err != nil) != tt.wantErr {
+				t.Errorf("ensureIngressHost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
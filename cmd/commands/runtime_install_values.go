@@ -0,0 +1,120 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/codefresh-io/cli-v2/pkg/store"
+
+	"github.com/ghodss/yaml"
+)
+
+type (
+	// helmValues are the resolved installation settings, in a shape that can be used as values of a helm chart
+	helmValues struct {
+		Runtime helmRuntimeValues `json:"runtime"`
+	}
+
+	helmRuntimeValues struct {
+		Name        string                `json:"name"`
+		Version     string                `json:"version,omitempty"`
+		Ingress     helmIngressValues     `json:"ingress"`
+		Repo        helmRepoValues        `json:"repo"`
+		GitProvider helmGitProviderValues `json:"gitProvider"`
+	}
+
+	helmIngressValues struct {
+		Enabled    bool                       `json:"enabled"`
+		ClassName  string                     `json:"className,omitempty"`
+		Controller string                     `json:"controller,omitempty"`
+		Host       string                     `json:"host,omitempty"`
+		HostName   string                     `json:"hostName,omitempty"`
+		Internal   *helmInternalIngressValues `json:"internal,omitempty"`
+	}
+
+	helmInternalIngressValues struct {
+		Host     string `json:"host"`
+		HostName string `json:"hostName"`
+	}
+
+	helmRepoValues struct {
+		URL      string `json:"url"`
+		Revision string `json:"revision,omitempty"`
+		Path     string `json:"path,omitempty"`
+	}
+
+	helmGitProviderValues struct {
+		Type   string `json:"type"`
+		APIURL string `json:"apiUrl"`
+	}
+)
+
+func getHelmValues(opts *RuntimeInstallOptions) *helmValues {
+	values := &helmValues{
+		Runtime: helmRuntimeValues{
+			Name: opts.RuntimeName,
+			Ingress: helmIngressValues{
+				Enabled:   !store.Get().SkipIngress,
+				ClassName: opts.IngressClass,
+				Host:      opts.IngressHost,
+				HostName:  opts.HostName,
+			},
+			Repo: helmRepoValues{
+				URL:      opts.InsCloneOpts.URL(),
+				Revision: opts.InsCloneOpts.Revision(),
+				Path:     opts.InsCloneOpts.Path(),
+			},
+		},
+	}
+
+	if opts.Version != nil {
+		values.Runtime.Version = opts.Version.String()
+	}
+
+	if opts.IngressController != nil {
+		values.Runtime.Ingress.Controller = opts.IngressController.Name()
+	}
+
+	if opts.InternalIngressHost != "" {
+		values.Runtime.Ingress.Internal = &helmInternalIngressValues{
+			Host:     opts.InternalIngressHost,
+			HostName: opts.InternalHostName,
+		}
+	}
+
+	if opts.gitProvider != nil {
+		values.Runtime.GitProvider = helmGitProviderValues{
+			Type:   string(opts.gitProvider.Type()),
+			APIURL: opts.gitProvider.ApiUrl(),
+		}
+	}
+
+	return values
+}
+
+func exportHelmValues(fileName string, opts *RuntimeInstallOptions) error {
+	data, err := yaml.Marshal(getHelmValues(opts))
+	if err != nil {
+		return fmt.Errorf("failed to marshal helm values: %w", err)
+	}
+
+	if err = ioutil.WriteFile(fileName, data, 0644); err != nil {
+		return fmt.Errorf("failed to write helm values to \"%s\": %w", fileName, err)
+	}
+
+	return nil
+}
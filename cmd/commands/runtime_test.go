@@ -0,0 +1,253 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/cli-v2/pkg/runtime"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/codefresh-io/go-sdk/pkg/codefresh/model"
+	"github.com/rkrmr33/checklist"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_watchRuntimeList(t *testing.T) {
+	tests := []struct {
+		name    string
+		inPlace bool
+	}{
+		{
+			name:    "should redraw the list in place on every tick",
+			inPlace: true,
+		},
+		{
+			name: "should append the list on every tick when not redrawing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			calls := 0
+			listRuntimes := func(context.Context) ([]model.Runtime, error) {
+				calls++
+				if calls == 3 {
+					cancel()
+				}
+
+				return []model.Runtime{{Metadata: &model.ObjectMeta{Name: "runtime"}}}, nil
+			}
+
+			out := &bytes.Buffer{}
+			assert.NoError(t, watchRuntimeList(ctx, out, time.Millisecond, tt.inPlace, listRuntimes))
+			assert.Equal(t, 3, calls)
+			assert.Equal(t, 3, strings.Count(out.String(), "NAMESPACE"))
+			assert.Equal(t, tt.inPlace, strings.Contains(out.String(), clearScreen))
+		})
+	}
+}
+
+func Test_componentColumns(t *testing.T) {
+	namespace := "argo-events-ns"
+	component := model.Component{
+		Metadata: &model.ObjectMeta{Name: "runtime-argo-events", Runtime: "runtime", Namespace: &namespace},
+		Version:  "1.2.3",
+	}
+
+	indexes, err := parseComponentColumns([]string{"component", "namespace", "version"})
+	assert.NoError(t, err)
+	assert.Equal(t, checklist.ListItemInfo{"COMPONENT", "NAMESPACE", "VERSION"}, componentColumnsHeader(indexes))
+
+	_, info := getComponentChecklistState(component)
+	assert.Equal(t, checklist.ListItemInfo{"argo-events", "argo-events-ns", "1.2.3"}, selectComponentColumns(info, indexes))
+
+	_, err = parseComponentColumns([]string{"component", "cluster"})
+	assert.EqualError(t, err, "unknown component column \"cluster\", must be one of: component, health, sync, version, errors, namespace")
+}
+
+func Test_printRuntimes(t *testing.T) {
+	namespace := "runtime"
+	runtimes := []model.Runtime{
+		{Metadata: &model.ObjectMeta{Name: "runtime", Namespace: &namespace}, SyncStatus: model.SyncStatusSynced},
+		{Metadata: &model.ObjectMeta{Name: "hosted-runtime"}, Managed: true},
+	}
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, printRuntimes(out, runtimes, "json"))
+
+	items := []map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &items))
+	assert.Len(t, items, 2)
+	assert.Equal(t, "runtime", items[0]["name"])
+	assert.Equal(t, false, items[0]["hosted"])
+	assert.Equal(t, "runtime", items[0]["namespace"])
+	assert.Equal(t, string(model.SyncStatusSynced), items[0]["syncStatus"])
+	assert.Equal(t, "hosted-runtime", items[1]["name"])
+	assert.Equal(t, true, items[1]["hosted"])
+	assert.Contains(t, items[1], "namespace")
+	assert.Nil(t, items[1]["namespace"])
+	assert.Nil(t, items[1]["ingressHost"])
+
+	out.Reset()
+	assert.NoError(t, printRuntimes(out, runtimes, "yaml"))
+	assert.Contains(t, out.String(), "name: hosted-runtime")
+	assert.Contains(t, out.String(), "ingressHost: null")
+}
+
+func Test_printUpgradeDiff(t *testing.T) {
+	tests := map[string]struct {
+		diff *runtime.UpgradeDiff
+		want string
+	}{
+		"Should print the added, removed and changed components": {
+			diff: &runtime.UpgradeDiff{
+				FromVersion: semver.MustParse("0.0.100"),
+				ToVersion:   semver.MustParse("0.0.101"),
+				Added:       []runtime.AppDef{{Name: "rollouts", URL: "manifests/argo-rollouts?ref=v0.0.101"}},
+				Removed:     []runtime.AppDef{{Name: "legacy", URL: "manifests/legacy?ref=v0.0.100"}},
+				Changed:     []runtime.ComponentChange{{Name: "events", FromURL: "manifests/argo-events?ref=v0.0.100", ToURL: "manifests/argo-events?ref=v0.0.101"}},
+			},
+			want: `Runtime "runtime" would be upgraded from v0.0.100 to v0.0.101
+
+Components to add:
+  + rollouts (manifests/argo-rollouts?ref=v0.0.101)
+
+Components to remove:
+  - legacy (manifests/legacy?ref=v0.0.100)
+
+Components to change:
+  ~ events: manifests/argo-events?ref=v0.0.100 -> manifests/argo-events?ref=v0.0.101
+`,
+		},
+		"Should print when no component changes": {
+			diff: &runtime.UpgradeDiff{
+				FromVersion: semver.MustParse("0.0.100"),
+				ToVersion:   semver.MustParse("0.0.101"),
+			},
+			want: "Runtime \"runtime\" would be upgraded from v0.0.100 to v0.0.101\nNo component changes\n",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			printUpgradeDiff(buf, "runtime", tt.diff)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func Test_printUninstallPlan(t *testing.T) {
+	tests := map[string]struct {
+		plan *uninstallPlan
+		want string
+	}{
+		"Should print the resources of a runtime": {
+			plan: &uninstallPlan{
+				RuntimeName:     "runtime",
+				GitIntegrations: []string{"default"},
+				IscRepo:         "https://github.com/owner/isc",
+				Applications:    []string{"runtime-app-proxy", "runtime-events"},
+				RepoURL:         "https://github.com/owner/repo",
+			},
+			want: `Runtime "runtime" would be uninstalled from the cluster and the repo
+
+Git integrations to remove:
+  - default
+
+ISC entry to remove:
+  - runtime from https://github.com/owner/isc
+
+Applications to delete from namespace "runtime":
+  - runtime-app-proxy
+  - runtime-events
+
+Repo to uninstall:
+  - https://github.com/owner/repo
+
+Platform record to delete:
+  - runtime
+`,
+		},
+		"Should print the resources of a managed runtime": {
+			plan: &uninstallPlan{
+				RuntimeName: "hosted-runtime",
+				Managed:     true,
+				Errors:      []error{errors.New("failed to list git integrations: unreachable")},
+			},
+			want: `Hosted runtime "hosted-runtime" would be uninstalled, the platform removes its resources
+
+Git integrations to remove:
+  none
+
+ISC entry to remove:
+  none
+
+Platform record to delete:
+  - hosted-runtime (managed)
+
+Could not list:
+  ! failed to list git integrations: unreachable
+`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			printUninstallPlan(buf, tt.plan)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func Test_pollApplications(t *testing.T) {
+	var calls int32
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pollApplications(ctx, time.Millisecond, func(context.Context) {
+			atomic.AddInt32(&calls, 1)
+		})
+	}()
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) >= 2 }, time.Second, time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("pollApplications() did not return after the context was cancelled")
+	}
+
+	// no refresh after it returned
+	after := atomic.LoadInt32(&calls)
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, after, atomic.LoadInt32(&calls))
+}
+
+func Test_uninstallProgressInterval(t *testing.T) {
+	assert.Equal(t, defaultProgressInterval, uninstallProgressInterval(&RuntimeUninstallOptions{}))
+	assert.Equal(t, 100*time.Millisecond, uninstallProgressInterval(&RuntimeUninstallOptions{ProgressInterval: 100 * time.Millisecond}))
+}
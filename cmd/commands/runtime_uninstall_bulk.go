@@ -0,0 +1,276 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/codefresh-io/cli-v2/pkg/log"
+	"github.com/codefresh-io/cli-v2/pkg/util"
+
+	"github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+	"github.com/argoproj-labs/argocd-autopilot/pkg/kube"
+	"github.com/codefresh-io/go-sdk/pkg/codefresh/model"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// runtimeUninstallResult is the outcome of one of the runtimes of a bulk uninstall
+type runtimeUninstallResult struct {
+	RuntimeName string
+	Err         error
+	// Skipped is set when the uninstall was not started, because an earlier one failed
+	Skipped bool
+}
+
+var uninstallRuntime = runRuntimeUninstall
+
+// runtimeBulkUninstallPreRunHandler resolves the runtimes to uninstall, and returns the uninstall options of each one.
+// The repo and the cluster of every runtime are taken from the platform, so they can be uninstalled with a single git token,
+// each one on the kube context of its cluster
+func runtimeBulkUninstallPreRunHandler(cmd *cobra.Command, args []string, opts *RuntimeUninstallOptions) ([]*RuntimeUninstallOptions, error) {
+	var err error
+	ctx := cmd.Context()
+
+	if opts.All && len(args) > 0 {
+		return nil, fmt.Errorf("--all cannot be used with runtime names")
+	}
+
+	// a prompt can be skipped with --silent, so removing every runtime of the account must be asked for explicitly
+	if opts.All && !opts.Yes {
+		return nil, fmt.Errorf("--all requires --yes, to confirm the uninstall of all of the runtimes of the account")
+	}
+
+	if opts.CloneOpts.Repo != "" {
+		return nil, fmt.Errorf("--repo cannot be used when uninstalling multiple runtimes, the repo of each runtime is taken from the platform")
+	}
+
+	runtimes, err := cfConfig.NewClient().V2().Runtime().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runtimes: %w", err)
+	}
+
+	selected, err := selectRuntimesToUninstall(runtimes, args, opts.All)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.kubeContext, err = getKubeContextName(cmd.Flag("context"), cmd.Flag("kubeconfig"))
+	if err != nil {
+		return nil, err
+	}
+
+	kubeconfig := cmd.Flag("kubeconfig").Value.String()
+	// an explicit --context is used for all of the runtimes, and verified like the context of a single uninstall
+	explicitContext := cmd.Flags().Changed("context")
+	runtimesOpts := make([]*RuntimeUninstallOptions, 0, len(selected))
+	for _, rt := range selected {
+		runtimeOpts := newBulkRuntimeUninstallOptions(opts, rt)
+		if !runtimeOpts.Managed {
+			if explicitContext {
+				err = nil
+				if !opts.SkipChecks {
+					err = ensureRuntimeOnKubeContext(ctx, kubeconfig, runtimeOpts.RuntimeName, opts.kubeContext)
+				}
+			} else {
+				err = setRuntimeKubeContext(runtimeOpts, rt, kubeconfig)
+			}
+
+			if err != nil && opts.Force {
+				log.G(ctx).Warnf("Failed to verify runtime \"%s\" is installed on a kubernetes context, its installation repository will not be cleaned: %v", runtimeOpts.RuntimeName, err)
+				err = nil
+				runtimeOpts.skipAutopilotUninstall = true
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if !runtimeOpts.Managed && runtimeOpts.CloneOpts.Repo == "" {
+			return nil, fmt.Errorf("runtime \"%s\" has no installation repo in the platform, uninstall it on its own with --repo", runtimeOpts.RuntimeName)
+		}
+
+		runtimesOpts = append(runtimesOpts, runtimeOpts)
+	}
+
+	needsGitToken := false
+	for _, runtimeOpts := range runtimesOpts {
+		needsGitToken = needsGitToken || !runtimeOpts.Managed
+	}
+
+	// all of the runtimes share the git token of the flags
+	if needsGitToken {
+		if err = ensureGitToken(cmd, nil, opts.CloneOpts, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, runtimeOpts := range runtimesOpts {
+		runtimeOpts.CloneOpts.Auth = opts.CloneOpts.Auth
+		if !runtimeOpts.Managed {
+			runtimeOpts.CloneOpts.Parse()
+		}
+	}
+
+	return runtimesOpts, nil
+}
+
+// selectRuntimesToUninstall returns the platform runtimes with the requested names, in the order they were requested
+func selectRuntimesToUninstall(runtimes []model.Runtime, names []string, all bool) ([]model.Runtime, error) {
+	if all {
+		if len(runtimes) == 0 {
+			return nil, fmt.Errorf("there are no runtimes to uninstall")
+		}
+
+		return runtimes, nil
+	}
+
+	byName := make(map[string]model.Runtime, len(runtimes))
+	for _, rt := range runtimes {
+		byName[rt.Metadata.Name] = rt
+	}
+
+	selected := make([]model.Runtime, 0, len(names))
+	seen := map[string]bool{}
+	for _, name := range names {
+		rt, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("runtime \"%s\" was not found", name)
+		}
+
+		if !seen[name] {
+			seen[name] = true
+			selected = append(selected, rt)
+		}
+	}
+
+	return selected, nil
+}
+
+// setRuntimeKubeContext sets the kube context of the cluster of the runtime, and its kube factory
+func setRuntimeKubeContext(runtimeOpts *RuntimeUninstallOptions, rt model.Runtime, kubeconfig string) error {
+	if rt.Cluster == nil {
+		return fmt.Errorf("runtime \"%s\" has no cluster in the platform, uninstall it on its own with --context", runtimeOpts.RuntimeName)
+	}
+
+	contextName, err := util.KubeContextNameByServer(*rt.Cluster, kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to find the kube context of runtime \"%s\": %w", runtimeOpts.RuntimeName, err)
+	}
+
+	if contextName == runtimeOpts.kubeContext {
+		return nil
+	}
+
+	runtimeOpts.kubeContext = contextName
+	runtimeOpts.KubeFactory, err = newKubeFactory(kubeconfig, contextName)
+	return err
+}
+
+// newKubeFactory returns the kube factory of a kube context, like the one of the --kubeconfig and --context flags
+func newKubeFactory(kubeconfig, contextName string) (kube.Factory, error) {
+	flags := pflag.NewFlagSet("kube", pflag.ContinueOnError)
+	f := kube.AddFlags(flags)
+	if err := flags.Set("kubeconfig", kubeconfig); err != nil {
+		return nil, err
+	}
+
+	if err := flags.Set("context", contextName); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// newBulkRuntimeUninstallOptions copies the flags of the command for one runtime, with its own repo and clone
+func newBulkRuntimeUninstallOptions(opts *RuntimeUninstallOptions, rt model.Runtime) *RuntimeUninstallOptions {
+	runtimeOpts := *opts
+	cloneOpts := *opts.CloneOpts
+	cloneOpts.FS = fs.Create(memfs.New())
+	if rt.Repo != nil {
+		cloneOpts.Repo = *rt.Repo
+	}
+
+	runtimeOpts.RuntimeName = rt.Metadata.Name
+	runtimeOpts.Managed = rt.Managed
+	runtimeOpts.CloneOpts = &cloneOpts
+	runtimeOpts.bulk = true
+	return &runtimeOpts
+}
+
+func runRuntimeBulkUninstall(ctx context.Context, runtimesOpts []*RuntimeUninstallOptions, continueOnError bool) error {
+	results := uninstallRuntimes(ctx, runtimesOpts, continueOnError)
+
+	// the steps of the uninstalls are replaced with a line for each runtime
+	summaryLock.Lock()
+	summaryArr = []summaryLog{}
+	summaryLock.Unlock()
+	appendToSummary(bulkUninstallSummary(results)...)
+	printSummaryToUser()
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil || result.Skipped {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to uninstall %d out of %d runtimes", failed, len(results))
+	}
+
+	return nil
+}
+
+// uninstallRuntimes uninstalls the runtimes one after the other. Unless continueOnError is set,
+// a failure stops the uninstalls that were not started yet
+func uninstallRuntimes(ctx context.Context, runtimesOpts []*RuntimeUninstallOptions, continueOnError bool) []runtimeUninstallResult {
+	failed := false
+	results := make([]runtimeUninstallResult, len(runtimesOpts))
+	for i, runtimeOpts := range runtimesOpts {
+		results[i].RuntimeName = runtimeOpts.RuntimeName
+		if failed && !continueOnError {
+			results[i].Skipped = true
+			continue
+		}
+
+		results[i].Err = uninstallRuntime(ctx, runtimeOpts)
+		if results[i].Err != nil {
+			log.G(ctx).WithError(results[i].Err).Errorf("Failed to uninstall runtime \"%s\"", runtimeOpts.RuntimeName)
+			failed = true
+		}
+	}
+
+	return results
+}
+
+func bulkUninstallSummary(results []runtimeUninstallResult) []summaryLog {
+	logs := make([]summaryLog, 0, len(results))
+	for _, result := range results {
+		switch {
+		case result.Skipped:
+			logs = append(logs, summaryLog{fmt.Sprintf("Skipped uninstalling runtime \"%s\" after an earlier failure", result.RuntimeName), Info})
+		case result.Err != nil:
+			logs = append(logs, summaryLog{fmt.Sprintf("Uninstalling runtime \"%s\": %s", result.RuntimeName, result.Err.Error()), Failed})
+		default:
+			logs = append(logs, summaryLog{fmt.Sprintf("Uninstalling runtime \"%s\"", result.RuntimeName), Success})
+		}
+	}
+
+	return logs
+}
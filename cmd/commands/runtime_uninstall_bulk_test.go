@@ -0,0 +1,152 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codefresh-io/go-sdk/pkg/codefresh/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_uninstallRuntimes(t *testing.T) {
+	tests := map[string]struct {
+		continueOnError bool
+		failing         string
+		wantUninstalled []string
+		wantLevels      []summaryLogLevels
+	}{
+		"should uninstall all of the runtimes in order": {
+			wantUninstalled: []string{"runtime-a", "runtime-b", "runtime-c"},
+			wantLevels:      []summaryLogLevels{Success, Success, Success},
+		},
+		"should continue past a failure with continue-on-error": {
+			continueOnError: true,
+			failing:         "runtime-a",
+			wantUninstalled: []string{"runtime-a", "runtime-b", "runtime-c"},
+			wantLevels:      []summaryLogLevels{Failed, Success, Success},
+		},
+		"should skip the rest of the runtimes after a failure": {
+			failing:         "runtime-a",
+			wantUninstalled: []string{"runtime-a"},
+			wantLevels:      []summaryLogLevels{Failed, Info, Info},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var uninstalled []string
+			origUninstallRuntime := uninstallRuntime
+			defer func() { uninstallRuntime = origUninstallRuntime }()
+			uninstallRuntime = func(_ context.Context, opts *RuntimeUninstallOptions) error {
+				uninstalled = append(uninstalled, opts.RuntimeName)
+				if opts.RuntimeName == tt.failing {
+					return fmt.Errorf("some error")
+				}
+
+				return nil
+			}
+
+			var runtimesOpts []*RuntimeUninstallOptions
+			for _, name := range []string{"runtime-a", "runtime-b", "runtime-c"} {
+				runtimesOpts = append(runtimesOpts, &RuntimeUninstallOptions{RuntimeName: name, bulk: true})
+			}
+
+			results := uninstallRuntimes(context.Background(), runtimesOpts, tt.continueOnError)
+			assert.Equal(t, tt.wantUninstalled, uninstalled)
+
+			logs := bulkUninstallSummary(results)
+			assert.Len(t, logs, len(runtimesOpts))
+			for i, log := range logs {
+				assert.Contains(t, log.message, runtimesOpts[i].RuntimeName)
+				assert.Equal(t, tt.wantLevels[i], log.level)
+			}
+		})
+	}
+}
+
+func Test_setRuntimeKubeContext(t *testing.T) {
+	t.Setenv("KUBECONFIG", "")
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+current-context: context-a
+clusters:
+- name: cluster-a
+  cluster:
+    server: https://cluster-a.example.com
+- name: cluster-b
+  cluster:
+    server: https://cluster-b.example.com
+contexts:
+- name: context-a
+  context:
+    cluster: cluster-a
+- name: context-b
+  context:
+    cluster: cluster-b
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	server := func(s string) *string { return &s }
+	tests := map[string]struct {
+		cluster     *string
+		wantContext string
+		wantErr     bool
+	}{
+		"should keep the current context of a runtime on its cluster": {
+			cluster:     server("https://cluster-a.example.com"),
+			wantContext: "context-a",
+		},
+		"should use the context of the cluster of the runtime": {
+			cluster:     server("https://cluster-b.example.com"),
+			wantContext: "context-b",
+		},
+		"should fail on a cluster without a context": {
+			cluster: server("https://cluster-c.example.com"),
+			wantErr: true,
+		},
+		"should fail on a runtime without a cluster": {
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			runtimeOpts := &RuntimeUninstallOptions{RuntimeName: "runtime", kubeContext: "context-a"}
+			rt := model.Runtime{Cluster: tt.cluster}
+			err := setRuntimeKubeContext(runtimeOpts, rt, kubeconfig)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantContext, runtimeOpts.kubeContext)
+			if tt.wantContext != "context-a" {
+				assert.NotNil(t, runtimeOpts.KubeFactory)
+			}
+		})
+	}
+}
+
+func Test_runtimeBulkUninstallPreRunHandler_allRequiresYes(t *testing.T) {
+	cmd := NewRuntimeUninstallCommand()
+	_, err := runtimeBulkUninstallPreRunHandler(cmd, nil, &RuntimeUninstallOptions{All: true})
+	assert.EqualError(t, err, "--all requires --yes, to confirm the uninstall of all of the runtimes of the account")
+}
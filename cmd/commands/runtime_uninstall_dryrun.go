@@ -0,0 +1,167 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/codefresh-io/cli-v2/pkg/store"
+
+	"github.com/argoproj-labs/argocd-autopilot/pkg/kube"
+	argocdv1alpha1cs "github.com/argoproj/argo-cd/v2/pkg/client/clientset/versioned"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// uninstallPlan is what an uninstall would remove, the errors are of the parts that could not be listed
+type uninstallPlan struct {
+	RuntimeName     string
+	Managed         bool
+	GitIntegrations []string
+	IscRepo         string
+	Applications    []string
+	RepoURL         string
+	Errors          []error
+}
+
+func runRuntimeUninstallDryRun(ctx context.Context, opts *RuntimeUninstallOptions) {
+	printUninstallPlan(os.Stdout, getUninstallPlan(ctx, opts))
+}
+
+// getUninstallPlan lists what each step of runRuntimeUninstall would remove, without removing anything
+func getUninstallPlan(ctx context.Context, opts *RuntimeUninstallOptions) *uninstallPlan {
+	plan := &uninstallPlan{
+		RuntimeName: opts.RuntimeName,
+		Managed:     opts.Managed,
+	}
+
+	appProxyClient, err := cfConfig.NewClient().AppProxy(ctx, opts.RuntimeName, store.Get().InsecureIngressHost)
+	if err == nil {
+		integrations, listErr := appProxyClient.GitIntegrations().List(ctx)
+		for _, intg := range integrations {
+			plan.GitIntegrations = append(plan.GitIntegrations, intg.Name)
+		}
+
+		err = listErr
+	}
+
+	if err != nil {
+		plan.Errors = append(plan.Errors, fmt.Errorf("failed to list git integrations: %w", err))
+	}
+
+	// like removeRuntimeIsc, the runtime is only removed from the isc repo when it has git integrations
+	me, err := cfConfig.NewClient().V2().UsersV2().GetCurrent(ctx)
+	if err != nil {
+		plan.Errors = append(plan.Errors, fmt.Errorf("failed to get current user information: %w", err))
+	} else if me.ActiveAccount.SharedConfigRepo != nil && len(plan.GitIntegrations) > 0 {
+		plan.IscRepo = *me.ActiveAccount.SharedConfigRepo
+	}
+
+	if opts.Managed || opts.skipAutopilotUninstall {
+		return plan
+	}
+
+	plan.RepoURL = opts.CloneOpts.Repo
+	plan.Applications, err = getComponentApplicationNames(ctx, opts.KubeFactory, opts.RuntimeName)
+	if err != nil {
+		plan.Errors = append(plan.Errors, fmt.Errorf("failed to list applications: %w", err))
+	}
+
+	return plan
+}
+
+// getComponentApplicationNames returns the applications printApplicationsState tracks
+func getComponentApplicationNames(ctx context.Context, f kube.Factory, runtime string) ([]string, error) {
+	rc, err := f.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := argocdv1alpha1cs.NewForConfig(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	apps, err := cs.ArgoprojV1alpha1().Applications(runtime).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", store.Get().LabelKeyCFType, store.Get().CFComponentType),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(apps.Items))
+	for _, a := range apps.Items {
+		names = append(names, a.Name)
+	}
+
+	return names, nil
+}
+
+func printUninstallPlan(w io.Writer, plan *uninstallPlan) {
+	if plan.Managed {
+		fmt.Fprintf(w, "Hosted runtime \"%s\" would be uninstalled, the platform removes its resources\n", plan.RuntimeName)
+	} else {
+		fmt.Fprintf(w, "Runtime \"%s\" would be uninstalled from the cluster and the repo\n", plan.RuntimeName)
+	}
+
+	fmt.Fprintln(w, "\nGit integrations to remove:")
+	printPlanItems(w, plan.GitIntegrations)
+
+	fmt.Fprintln(w, "\nISC entry to remove:")
+	if plan.IscRepo != "" {
+		fmt.Fprintf(w, "  - %s from %s\n", plan.RuntimeName, plan.IscRepo)
+	} else {
+		fmt.Fprintln(w, "  none")
+	}
+
+	if !plan.Managed {
+		fmt.Fprintf(w, "\nApplications to delete from namespace \"%s\":\n", plan.RuntimeName)
+		printPlanItems(w, plan.Applications)
+
+		fmt.Fprintln(w, "\nRepo to uninstall:")
+		if plan.RepoURL != "" {
+			fmt.Fprintf(w, "  - %s\n", plan.RepoURL)
+		} else {
+			fmt.Fprintln(w, "  none, the runtime is not on the kube context")
+		}
+	}
+
+	fmt.Fprintln(w, "\nPlatform record to delete:")
+	if plan.Managed {
+		fmt.Fprintf(w, "  - %s (managed)\n", plan.RuntimeName)
+	} else {
+		fmt.Fprintf(w, "  - %s\n", plan.RuntimeName)
+	}
+
+	if len(plan.Errors) > 0 {
+		fmt.Fprintln(w, "\nCould not list:")
+		for _, err := range plan.Errors {
+			fmt.Fprintf(w, "  ! %s\n", err.Error())
+		}
+	}
+}
+
+func printPlanItems(w io.Writer, items []string) {
+	if len(items) == 0 {
+		fmt.Fprintln(w, "  none")
+		return
+	}
+
+	for _, item := range items {
+		fmt.Fprintf(w, "  - %s\n", item)
+	}
+}
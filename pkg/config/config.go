@@ -69,6 +69,8 @@ type Config struct {
 	requestTimeout  time.Duration
 	CurrentContext  string                  `mapstructure:"current-context" json:"current-context"`
 	Contexts        map[string]*AuthContext `mapstructure:"contexts" json:"contexts"`
+	// DisableTelemetry disables the analytics reporting of every command, unless --disable-telemetry is set
+	DisableTelemetry bool `mapstructure:"disable-telemetry" json:"disable-telemetry,omitempty"`
 }
 
 type AuthContext struct {
@@ -90,6 +92,8 @@ func AddFlags(f *pflag.FlagSet) *Config {
 	f.BoolVar(&conf.insecure, "insecure", false, "Disable certificate validation for TLS connections (e.g. to g.codefresh.io)")
 	f.BoolVar(&store.Get().InsecureIngressHost, "insecure-ingress-host", false, "Disable certificate validation of ingress host (default: false)")
 	f.DurationVar(&conf.requestTimeout, "request-timeout", defaultRequestTimeout, "Request timeout")
+	f.StringVar(&store.Get().Proxy, "proxy", "", "A proxy url for all outbound http(s) calls, to the platform, git providers and runtime downloads (default: $HTTPS_PROXY/$HTTP_PROXY)")
+	f.StringVar(&store.Get().NoProxy, "no-proxy", "", "Comma-separated hosts and domains that are not called through the proxy (default: $NO_PROXY)")
 	return conf
 }
 
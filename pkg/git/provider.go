@@ -16,20 +16,56 @@ package git
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
 	"strings"
+	"time"
 )
 
 type (
 	TokenType    string
 	ProviderType string
 
+	// TokenPermission is a permission of a git token, that a feature of the installation needs
+	TokenPermission string
+
 	// Provider represents a git provider
 	Provider interface {
 		Type() ProviderType
 		ApiUrl() string
 		VerifyToken(ctx context.Context, tokenType TokenType, token string) error
 		SupportsMarketplace() bool
+		GetDefaultBranch(ctx context.Context, repoURL, token string) (string, error)
+	}
+
+	// RepoTemplater is implemented by the providers that can create a repository from a template repository
+	RepoTemplater interface {
+		// CreateRepoFromTemplate creates the repository at repoURL from the template ("owner/repo"), as a private repository when private is true.
+		// It returns false when the repository already exists, in which case it is left untouched
+		CreateRepoFromTemplate(ctx context.Context, repoURL, template, token string, private bool) (bool, error)
+	}
+
+	// TokenExpirationGetter is implemented by the providers that expose when a token expires
+	TokenExpirationGetter interface {
+		// GetTokenExpiration returns the time the token expires at, or a zero time when it does not expire
+		GetTokenExpiration(ctx context.Context, token string) (time.Time, error)
+	}
+
+	// RepoWriteAccessVerifier is implemented by the providers that can check the permissions of a token on a repository
+	RepoWriteAccessVerifier interface {
+		// VerifyRepoWriteAccess fails when the token cannot push to the repository at repoURL. It fails with
+		// ErrRepoNotFound when the repository does not exist, or when the token has no access to it at all
+		VerifyRepoWriteAccess(ctx context.Context, repoURL, token string) error
+	}
+
+	// TokenPermissionsVerifier is implemented by the providers that can tell which permissions a token has
+	TokenPermissionsVerifier interface {
+		// GetMissingPermissions returns the permissions, out of required, that the token does not have
+		GetMissingPermissions(ctx context.Context, token string, required []TokenPermission) ([]TokenPermission, error)
 	}
 )
 
@@ -38,6 +74,16 @@ const (
 	PersonalToken TokenType = "personal token"
 )
 
+const (
+	PermissionRepoWrite      TokenPermission = "repo write"
+	PermissionRepoCreate     TokenPermission = "repo create"
+	PermissionRepoHooks      TokenPermission = "repo webhooks"
+	PermissionPublicRepoRead TokenPermission = "public repo read"
+)
+
+// ErrRepoNotFound is returned for a repository that either does not exist, or is hidden from the token
+var ErrRepoNotFound = errors.New("repository not found, or the token has no access to it")
+
 var (
 	providers = map[ProviderType]func(string) (Provider, error){
 		BITBUCKET_SERVER: NewBitbucketServerProvider,
@@ -47,6 +93,17 @@ var (
 	}
 )
 
+// ProviderTypes returns the supported git provider types, sorted
+func ProviderTypes() []ProviderType {
+	types := make([]ProviderType, 0, len(providers))
+	for providerType := range providers {
+		types = append(types, providerType)
+	}
+
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
 func GetProvider(providerType ProviderType, cloneURL string) (Provider, error) {
 	if providerType != "" {
 		fn := providers[providerType]
@@ -54,9 +111,11 @@ func GetProvider(providerType ProviderType, cloneURL string) (Provider, error) {
 			return nil, fmt.Errorf("invalid git provider %s", providerType)
 		}
 
-		return fn(cloneURL)
+		return fn(HTTPSRepoURL(cloneURL))
 	}
 
+	cloneURL = HTTPSRepoURL(cloneURL)
+
 	if strings.Contains(cloneURL, GITHUB_CLOUD_DOMAIN) {
 		return NewGithubCloudProvider(cloneURL)
 	}
@@ -67,3 +126,120 @@ func GetProvider(providerType ProviderType, cloneURL string) (Provider, error) {
 
 	return nil, fmt.Errorf("failed getting provider for clone url %s", cloneURL)
 }
+
+// missingPermissions returns the required permissions that none of the scopes grant.
+// A permission without scopes is granted to every token
+func missingPermissions(scopes []string, permissionScopes map[TokenPermission][]string, required []TokenPermission) []TokenPermission {
+	granted := map[string]bool{}
+	for _, scope := range scopes {
+		granted[scope] = true
+	}
+
+	var missing []TokenPermission
+	for _, permission := range required {
+		grantingScopes := permissionScopes[permission]
+		if len(grantingScopes) == 0 {
+			continue
+		}
+
+		ok := false
+		for _, scope := range grantingScopes {
+			ok = ok || granted[scope]
+		}
+
+		if !ok {
+			missing = append(missing, permission)
+		}
+	}
+
+	return missing
+}
+
+// IsSSHRepoURL returns true for an ssh repo url, either "ssh://git@host/owner/repo.git" or the scp-like "git@host:owner/repo.git"
+func IsSSHRepoURL(repoURL string) bool {
+	if strings.HasPrefix(repoURL, "ssh://") {
+		return true
+	}
+
+	if strings.Contains(repoURL, "://") {
+		return false
+	}
+
+	at := strings.Index(repoURL, "@")
+	colon := strings.Index(repoURL, ":")
+	return at > 0 && colon > at
+}
+
+// HTTPSRepoURL returns the https url of the same repository as an ssh repo url, which is what the providers apis
+// expect. Any other url is returned as is
+func HTTPSRepoURL(repoURL string) string {
+	if !IsSSHRepoURL(repoURL) {
+		return repoURL
+	}
+
+	rest := strings.TrimPrefix(repoURL, "ssh://")
+	if _, after, found := strings.Cut(rest, "@"); found {
+		rest = after
+	}
+
+	host, repoPath, found := strings.Cut(rest, ":")
+	if !found {
+		host, repoPath, _ = strings.Cut(rest, "/")
+	} else if i := strings.Index(repoPath, "/"); strings.HasPrefix(repoURL, "ssh://") && i >= 0 {
+		// "ssh://git@host:22/owner/repo.git", the ssh port means nothing to the https url
+		repoPath = repoPath[i+1:]
+	}
+
+	return "https://" + host + "/" + strings.TrimPrefix(repoPath, "/")
+}
+
+// getRepoPath returns the path of the repository in the provider (i.e "owner/repo"), without the ".git" suffix,
+// any path inside the repo or the ref query
+func getRepoPath(repoURL string) (string, error) {
+	repoURL = HTTPSRepoURL(repoURL)
+	if !strings.Contains(repoURL, "://") {
+		repoURL = "https://" + repoURL
+	}
+
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse repo url \"%s\": %w", repoURL, err)
+	}
+
+	repoPath := strings.Trim(u.Path, "/")
+	// only a ".git" path element ends the repo, a repo name may contain ".git" (e.g. "owner/repo.github.io")
+	if i := strings.Index(repoPath, ".git/"); i > 0 {
+		repoPath = repoPath[:i]
+	}
+
+	repoPath = strings.TrimSuffix(repoPath, ".git")
+
+	if repoPath == "" {
+		return "", fmt.Errorf("missing repository path in url \"%s\"", repoURL)
+	}
+
+	return repoPath, nil
+}
+
+func getJSON(ctx context.Context, fullURL string, headers map[string]string, res interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := doRequest(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to \"%s\" failed with status code %d", fullURL, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(res)
+}
@@ -16,7 +16,9 @@ package git
 
 import (
 	"context"
+	"fmt"
 	"net/url"
+	"strings"
 
 	"github.com/codefresh-io/cli-v2/pkg/log"
 )
@@ -60,3 +62,26 @@ func (bbs *bitbucketServer) VerifyToken(ctx context.Context, tokenType TokenType
 func (bbs *bitbucketServer) SupportsMarketplace() bool {
 	return false
 }
+
+func (bbs *bitbucketServer) GetDefaultBranch(ctx context.Context, repoURL, token string) (string, error) {
+	repoPath, err := getRepoPath(repoURL)
+	if err != nil {
+		return "", err
+	}
+
+	// bitbucket server clone urls look like "https://host/scm/project/repo.git"
+	parts := strings.Split(strings.TrimPrefix(repoPath, "scm/"), "/")
+	if len(parts) < 2 {
+		return "", fmt.Errorf("invalid bitbucket server repo path \"%s\"", repoPath)
+	}
+
+	res := &struct {
+		DisplayID string `json:"displayId"`
+	}{}
+	fullURL := fmt.Sprintf("%s%s/projects/%s/repos/%s/branches/default", bbs.apiURL, BITBUCKET_REST_ENDPOINT, parts[0], parts[1])
+	if err = getJSON(ctx, fullURL, map[string]string{"Authorization": "Bearer " + token}, res); err != nil {
+		return "", fmt.Errorf("failed to get default branch of \"%s\": %w", repoPath, err)
+	}
+
+	return res.DisplayID, nil
+}
@@ -15,11 +15,14 @@
 package git
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 type (
@@ -37,11 +40,21 @@ const (
 	GITHUB_ENT           ProviderType = "github-enterprise"
 )
 
+// githubTokenExpirationHeader is only set on the responses to tokens that expire
+const githubTokenExpirationHeader = "GitHub-Authentication-Token-Expiration"
+
 var requiredScopes = map[TokenType][]string{
 	RuntimeToken:  {"repo", "admin:repo_hook"},
 	PersonalToken: {"repo"},
 }
 
+// githubPermissionScopes are the classic token scopes that grant each permission, any one of them is enough
+var githubPermissionScopes = map[TokenPermission][]string{
+	PermissionRepoWrite:  {"repo", "public_repo"},
+	PermissionRepoCreate: {"repo"},
+	PermissionRepoHooks:  {"admin:repo_hook"},
+}
+
 func NewGithubCloudProvider(_ string) (Provider, error) {
 	return &github{
 		providerType: GITHUB_CLOUD,
@@ -71,25 +84,11 @@ func (g *github) ApiUrl() string {
 }
 
 func (g *github) VerifyToken(ctx context.Context, tokenType TokenType, token string) error {
-	fullURL := g.apiURL + GITHUB_REST_ENDPOINT
-	req, err := http.NewRequestWithContext(ctx, "HEAD", fullURL, nil)
+	scopes, _, err := g.tokenScopes(ctx, token)
 	if err != nil {
 		return err
 	}
 
-	req.Header.Set("Authorization", "token "+token)
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	rawScopes := resp.Header["X-Oauth-Scopes"]
-	var scopes []string
-	if len(rawScopes) > 0 {
-		scopes = strings.Split(rawScopes[0], ", ")
-	}
-
 	for _, rs := range requiredScopes[tokenType] {
 		var contained bool
 		for _, scope := range scopes {
@@ -107,6 +106,240 @@ func (g *github) VerifyToken(ctx context.Context, tokenType TokenType, token str
 	return nil
 }
 
+// GetMissingPermissions checks the scopes of a classic token. Fine-grained tokens have no scopes, so they cannot be checked
+func (g *github) GetMissingPermissions(ctx context.Context, token string, required []TokenPermission) ([]TokenPermission, error) {
+	scopes, found, err := g.tokenScopes(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, fmt.Errorf("the token has no scopes, it is not a classic token")
+	}
+
+	return missingPermissions(scopes, githubPermissionScopes, required), nil
+}
+
+// tokenScopes returns the scopes of a classic token, and whether the api returned the scopes at all
+func (g *github) tokenScopes(ctx context.Context, token string) ([]string, bool, error) {
+	fullURL := g.apiURL + GITHUB_REST_ENDPOINT
+	req, err := http.NewRequestWithContext(ctx, "HEAD", fullURL, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	req.Header.Set("Authorization", "token "+token)
+	resp, err := doRequest(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	rawScopes, found := resp.Header["X-Oauth-Scopes"]
+	var scopes []string
+	if len(rawScopes) > 0 && rawScopes[0] != "" {
+		scopes = strings.Split(rawScopes[0], ", ")
+	}
+
+	return scopes, found, nil
+}
+
+// GetTokenExpiration reads the expiration of the token from the api response headers
+func (g *github) GetTokenExpiration(ctx context.Context, token string) (time.Time, error) {
+	fullURL := g.apiURL + GITHUB_REST_ENDPOINT
+	req, err := http.NewRequestWithContext(ctx, "HEAD", fullURL, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	req.Header.Set("Authorization", "token "+token)
+	resp, err := doRequest(req)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	expiration := resp.Header.Get(githubTokenExpirationHeader)
+	if expiration == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range []string{"2006-01-02 15:04:05 MST", "2006-01-02 15:04:05 -0700"} {
+		if t, err := time.Parse(layout, expiration); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("failed to parse token expiration \"%s\"", expiration)
+}
+
 func (g *github) SupportsMarketplace() bool {
 	return true
 }
+
+func (g *github) GetDefaultBranch(ctx context.Context, repoURL, token string) (string, error) {
+	repoPath, err := getRepoPath(repoURL)
+	if err != nil {
+		return "", err
+	}
+
+	// github repos are always "owner/repo", anything after that is a path inside the repo
+	parts := strings.Split(repoPath, "/")
+	if len(parts) < 2 {
+		return "", fmt.Errorf("invalid github repo path \"%s\"", repoPath)
+	}
+
+	res := &struct {
+		DefaultBranch string `json:"default_branch"`
+	}{}
+	fullURL := fmt.Sprintf("%s/repos/%s/%s", g.restURL(), parts[0], parts[1])
+	if err = getJSON(ctx, fullURL, map[string]string{"Authorization": "token " + token}, res); err != nil {
+		return "", fmt.Errorf("failed to get default branch of \"%s\": %w", repoPath, err)
+	}
+
+	return res.DefaultBranch, nil
+}
+
+func (g *github) CreateRepoFromTemplate(ctx context.Context, repoURL, template, token string, private bool) (bool, error) {
+	repoPath, err := getRepoPath(repoURL)
+	if err != nil {
+		return false, err
+	}
+
+	parts := strings.Split(repoPath, "/")
+	if len(parts) < 2 {
+		return false, fmt.Errorf("invalid github repo path \"%s\"", repoPath)
+	}
+
+	templateParts := strings.Split(strings.Trim(template, "/"), "/")
+	if len(templateParts) != 2 || templateParts[0] == "" || templateParts[1] == "" {
+		return false, fmt.Errorf("invalid template repo \"%s\", expected \"owner/repo\"", template)
+	}
+
+	headers := map[string]string{
+		"Authorization": "token " + token,
+		"Accept":        "application/vnd.github+json",
+	}
+	exists, err := g.repoExists(ctx, parts[0], parts[1], headers)
+	if err != nil || exists {
+		return false, err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"owner":   parts[0],
+		"name":    parts[1],
+		"private": private,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	fullURL := fmt.Sprintf("%s/repos/%s/%s/generate", g.restURL(), templateParts[0], templateParts[1])
+	req, err := http.NewRequestWithContext(ctx, "POST", fullURL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := doRequest(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return false, fmt.Errorf("request to \"%s\" failed with status code %d", fullURL, resp.StatusCode)
+	}
+
+	return true, nil
+}
+
+func (g *github) VerifyRepoWriteAccess(ctx context.Context, repoURL, token string) error {
+	repoPath, err := getRepoPath(repoURL)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(repoPath, "/")
+	if len(parts) < 2 {
+		return fmt.Errorf("invalid github repo path \"%s\"", repoPath)
+	}
+
+	fullURL := fmt.Sprintf("%s/repos/%s/%s", g.restURL(), parts[0], parts[1])
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "token "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := doRequest(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		// github hides the private repos that the token cannot access
+		return fmt.Errorf("\"%s\": %w", repoPath, ErrRepoNotFound)
+	default:
+		return fmt.Errorf("request to \"%s\" failed with status code %d", fullURL, resp.StatusCode)
+	}
+
+	res := &struct {
+		Permissions *struct {
+			Push bool `json:"push"`
+		} `json:"permissions"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(res); err != nil {
+		return fmt.Errorf("failed to decode the repo of \"%s\": %w", repoPath, err)
+	}
+
+	// the permissions are only returned for authenticated requests
+	if res.Permissions != nil && !res.Permissions.Push {
+		return fmt.Errorf("the provided token does not have write access to \"%s/%s\"", parts[0], parts[1])
+	}
+
+	return nil
+}
+
+func (g *github) repoExists(ctx context.Context, owner, name string, headers map[string]string) (bool, error) {
+	fullURL := fmt.Sprintf("%s/repos/%s/%s", g.restURL(), owner, name)
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return false, err
+	}
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := doRequest(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("request to \"%s\" failed with status code %d", fullURL, resp.StatusCode)
+	}
+}
+
+func (g *github) restURL() string {
+	if g.providerType == GITHUB_ENT {
+		return g.apiURL + GITHUB_REST_ENDPOINT
+	}
+
+	return g.apiURL
+}
@@ -0,0 +1,193 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_github_CreateRepoFromTemplate(t *testing.T) {
+	tests := map[string]struct {
+		repoExists  bool
+		private     bool
+		wantCreated bool
+	}{
+		"should create a private repo from the template": {
+			private:     true,
+			wantCreated: true,
+		},
+		"should create a public repo from the template": {
+			wantCreated: true,
+		},
+		"should leave an existing repo untouched": {
+			repoExists: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var generateBody map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == "GET" && r.URL.Path == "/api/v3/repos/owner/repo":
+					if !tt.repoExists {
+						w.WriteHeader(http.StatusNotFound)
+					}
+				case r.Method == "POST" && r.URL.Path == "/api/v3/repos/org/template/generate":
+					assert.NoError(t, json.NewDecoder(r.Body).Decode(&generateBody))
+					w.WriteHeader(http.StatusCreated)
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			}))
+			defer server.Close()
+
+			g := &github{providerType: GITHUB_ENT, apiURL: server.URL}
+			created, err := g.CreateRepoFromTemplate(context.Background(), server.URL+"/owner/repo.git?ref=main", "org/template", "token", tt.private)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantCreated, created)
+			if !tt.wantCreated {
+				assert.Nil(t, generateBody)
+				return
+			}
+
+			assert.Equal(t, "owner", generateBody["owner"])
+			assert.Equal(t, "repo", generateBody["name"])
+			assert.Equal(t, tt.private, generateBody["private"])
+		})
+	}
+}
+
+func Test_github_VerifyRepoWriteAccess(t *testing.T) {
+	tests := map[string]struct {
+		status  int
+		body    string
+		wantErr string
+	}{
+		"should pass with push permissions": {
+			status: http.StatusOK,
+			body:   `{"permissions":{"admin":false,"push":true,"pull":true}}`,
+		},
+		"should fail on a repo that rejects writes": {
+			status:  http.StatusOK,
+			body:    `{"permissions":{"admin":false,"push":false,"pull":true}}`,
+			wantErr: "the provided token does not have write access to \"owner/repo_git-source\"",
+		},
+		"should fail on a repo that does not exist, or is hidden from the token": {
+			status:  http.StatusNotFound,
+			wantErr: "\"owner/repo_git-source\": repository not found, or the token has no access to it",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "GET" || r.URL.Path != "/api/v3/repos/owner/repo_git-source" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			g := &github{providerType: GITHUB_ENT, apiURL: server.URL}
+			err := g.VerifyRepoWriteAccess(context.Background(), server.URL+"/owner/repo_git-source/resources_runtime", "token")
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func Test_github_GetTokenExpiration(t *testing.T) {
+	tests := map[string]struct {
+		header string
+		want   time.Time
+	}{
+		"should parse the expiration header": {
+			header: "2022-09-15 00:00:00 UTC",
+			want:   time.Date(2022, 9, 15, 0, 0, 0, 0, time.UTC),
+		},
+		"should return a zero time for a token that does not expire": {},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "token token", r.Header.Get("Authorization"))
+				if tt.header != "" {
+					w.Header().Set(githubTokenExpirationHeader, tt.header)
+				}
+			}))
+			defer server.Close()
+
+			g := &github{providerType: GITHUB_ENT, apiURL: server.URL}
+			got, err := g.GetTokenExpiration(context.Background(), "token")
+			assert.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "got %s, want %s", got, tt.want)
+		})
+	}
+}
+
+func Test_github_GetMissingPermissions(t *testing.T) {
+	tests := map[string]struct {
+		scopes      []string
+		wantMissing []TokenPermission
+		wantErr     bool
+	}{
+		"should flag a missing repo create for a public_repo token": {
+			scopes:      []string{"public_repo, admin:repo_hook"},
+			wantMissing: []TokenPermission{PermissionRepoCreate},
+		},
+		"should grant everything to a repo token": {
+			scopes: []string{"repo, admin:repo_hook"},
+		},
+		"should fail on a token without scopes": {
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for _, scope := range tt.scopes {
+					w.Header().Add("X-Oauth-Scopes", scope)
+				}
+			}))
+			defer server.Close()
+
+			g := &github{providerType: GITHUB_ENT, apiURL: server.URL}
+			required := []TokenPermission{PermissionRepoWrite, PermissionRepoCreate, PermissionRepoHooks, PermissionPublicRepoRead}
+			missing, err := g.GetMissingPermissions(context.Background(), "token", required)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantMissing, missing)
+		})
+	}
+}
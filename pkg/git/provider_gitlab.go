@@ -16,7 +16,9 @@ package git
 
 import (
 	"context"
+	"fmt"
 	"net/url"
+	"time"
 
 	"github.com/codefresh-io/cli-v2/pkg/log"
 )
@@ -34,6 +36,13 @@ const (
 	GITLAB               ProviderType = "gitlab"
 )
 
+// gitlabPermissionScopes are the token scopes that grant each permission, any one of them is enough
+var gitlabPermissionScopes = map[TokenPermission][]string{
+	PermissionRepoWrite:  {"api", "write_repository"},
+	PermissionRepoCreate: {"api"},
+	PermissionRepoHooks:  {"api"},
+}
+
 func NewGitlabProvider(cloneURL string) (Provider, error) {
 	u, err := url.Parse(cloneURL)
 	if err != nil {
@@ -60,6 +69,59 @@ func (g *gitlab) VerifyToken(ctx context.Context, tokenType TokenType, token str
 	return nil
 }
 
+// GetTokenExpiration returns the end of the expiration day of a personal, group or project access token
+func (g *gitlab) GetTokenExpiration(ctx context.Context, token string) (time.Time, error) {
+	res := &struct {
+		ExpiresAt string `json:"expires_at"`
+	}{}
+	fullURL := fmt.Sprintf("%s%s/personal_access_tokens/self", g.apiURL, GITLAB_REST_ENDPOINT)
+	if err := getJSON(ctx, fullURL, map[string]string{"PRIVATE-TOKEN": token}, res); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get token expiration: %w", err)
+	}
+
+	if res.ExpiresAt == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse("2006-01-02", res.ExpiresAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse token expiration \"%s\": %w", res.ExpiresAt, err)
+	}
+
+	// the token is valid through the expiration day
+	return t.AddDate(0, 0, 1), nil
+}
+
+// GetMissingPermissions checks the scopes of a personal, group or project access token
+func (g *gitlab) GetMissingPermissions(ctx context.Context, token string, required []TokenPermission) ([]TokenPermission, error) {
+	res := &struct {
+		Scopes []string `json:"scopes"`
+	}{}
+	fullURL := fmt.Sprintf("%s%s/personal_access_tokens/self", g.apiURL, GITLAB_REST_ENDPOINT)
+	if err := getJSON(ctx, fullURL, map[string]string{"PRIVATE-TOKEN": token}, res); err != nil {
+		return nil, fmt.Errorf("failed to get token scopes: %w", err)
+	}
+
+	return missingPermissions(res.Scopes, gitlabPermissionScopes, required), nil
+}
+
 func (g *gitlab) SupportsMarketplace() bool {
 	return false
 }
+
+func (g *gitlab) GetDefaultBranch(ctx context.Context, repoURL, token string) (string, error) {
+	repoPath, err := getRepoPath(repoURL)
+	if err != nil {
+		return "", err
+	}
+
+	res := &struct {
+		DefaultBranch string `json:"default_branch"`
+	}{}
+	fullURL := fmt.Sprintf("%s%s/projects/%s", g.apiURL, GITLAB_REST_ENDPOINT, url.PathEscape(repoPath))
+	if err = getJSON(ctx, fullURL, map[string]string{"PRIVATE-TOKEN": token}, res); err != nil {
+		return "", fmt.Errorf("failed to get default branch of \"%s\": %w", repoPath, err)
+	}
+
+	return res.DefaultBranch, nil
+}
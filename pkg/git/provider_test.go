@@ -0,0 +1,92 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPSRepoURL(t *testing.T) {
+	tests := map[string]struct {
+		repoURL string
+		want    string
+		wantSSH bool
+	}{
+		"Should convert an scp-like url": {
+			repoURL: "git@github.com:owner/repo.git?ref=main",
+			want:    "https://github.com/owner/repo.git?ref=main",
+			wantSSH: true,
+		},
+		"Should convert an ssh url": {
+			repoURL: "ssh://git@github.com/owner/repo.git",
+			want:    "https://github.com/owner/repo.git",
+			wantSSH: true,
+		},
+		"Should drop the ssh port": {
+			repoURL: "ssh://git@gitlab.example.com:2222/owner/repo.git",
+			want:    "https://gitlab.example.com/owner/repo.git",
+			wantSSH: true,
+		},
+		"Should not change an https url": {
+			repoURL: "https://user@github.com/owner/repo.git",
+			want:    "https://user@github.com/owner/repo.git",
+		},
+		"Should not change a url without a scheme": {
+			repoURL: "github.com/owner/repo",
+			want:    "github.com/owner/repo",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.wantSSH, IsSSHRepoURL(tt.repoURL))
+			assert.Equal(t, tt.want, HTTPSRepoURL(tt.repoURL))
+		})
+	}
+}
+
+func Test_getRepoPath_ssh(t *testing.T) {
+	repoPath, err := getRepoPath("git@github.com:owner/repo.git/some/path?ref=main")
+	assert.NoError(t, err)
+	assert.Equal(t, "owner/repo", repoPath)
+}
+
+func Test_getRepoPath(t *testing.T) {
+	tests := map[string]struct {
+		repoURL string
+		want    string
+	}{
+		"should trim the .git suffix": {
+			repoURL: "https://github.com/owner/repo.git",
+			want:    "owner/repo",
+		},
+		"should keep a repo name that contains .git": {
+			repoURL: "https://github.com/owner/owner.github.io",
+			want:    "owner/owner.github.io",
+		},
+		"should trim the path inside a repo name that contains .git": {
+			repoURL: "https://github.com/owner/owner.github.io.git/some/path?ref=main",
+			want:    "owner/owner.github.io",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			repoPath, err := getRepoPath(tt.repoURL)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, repoPath)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/codefresh-io/cli-v2/pkg/log"
+
+	"golang.org/x/time/rate"
+)
+
+type (
+	// RateLimitOptions controls how the provider api calls are throttled and retried
+	RateLimitOptions struct {
+		// RequestsPerSecond limits the rate of the provider api calls, 0 means no limit
+		RequestsPerSecond float64
+		// MaxRetries is the number of times a rate limited call is retried before failing
+		MaxRetries int
+		// InitialBackoff is the wait before the first retry, when the provider does not say how long to wait
+		InitialBackoff time.Duration
+		// MaxBackoff caps the wait between retries
+		MaxBackoff time.Duration
+	}
+
+	// RateLimitError is returned when a provider api call is still rate limited after all retries
+	RateLimitError struct {
+		URL        string
+		StatusCode int
+		Limit      string
+		Remaining  string
+		Reset      string
+		RetryAfter string
+	}
+)
+
+var (
+	rateLimitOpts = RateLimitOptions{
+		MaxRetries:     3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+	}
+	limiter = rate.NewLimiter(rate.Inf, 1)
+)
+
+// SetRateLimit configures the throttling and retries of all of the provider api calls
+func SetRateLimit(opts RateLimitOptions) {
+	rateLimitOpts = opts
+	if opts.RequestsPerSecond <= 0 {
+		limiter = rate.NewLimiter(rate.Inf, 1)
+		return
+	}
+
+	limiter = rate.NewLimiter(rate.Limit(opts.RequestsPerSecond), 1)
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("request to \"%s\" was rate limited by the git provider (status code: %d, limit: %s, remaining: %s, reset: %s, retry-after: %s)",
+		e.URL, e.StatusCode, headerOrNA(e.Limit), headerOrNA(e.Remaining), headerOrNA(e.Reset), headerOrNA(e.RetryAfter))
+}
+
+// doRequest sends a provider api request, retrying with backoff while the provider rate limits it.
+// A request with a body must set GetBody (as http.NewRequest does for in-memory bodies), so it can be sent again
+func doRequest(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	backoff := rateLimitOpts.InitialBackoff
+	for try := 0; ; try++ {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		tryReq := req.Clone(ctx)
+		if try > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			tryReq.Body = body
+		}
+
+		resp, err := http.DefaultClient.Do(tryReq)
+		if err != nil {
+			return nil, err
+		}
+
+		if !isRateLimited(resp) {
+			return resp, nil
+		}
+
+		resp.Body.Close()
+		rlErr := newRateLimitError(req.URL.String(), resp)
+		if try >= rateLimitOpts.MaxRetries {
+			return nil, rlErr
+		}
+
+		wait := retryWait(resp, backoff)
+		log.G(ctx).WithField("retry", try+1).Warnf("Rate limited by the git provider, retrying in %s", wait)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+
+		backoff *= 2
+	}
+}
+
+func isRateLimited(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	// github reports an exhausted rate limit with 403
+	return resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0"
+}
+
+// retryWait prefers the wait time reported by the provider over the exponential backoff
+func retryWait(resp *http.Response, backoff time.Duration) time.Duration {
+	wait := backoff
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		wait = time.Duration(seconds) * time.Second
+	} else if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		if untilReset := time.Until(time.Unix(reset, 0)); untilReset > 0 {
+			wait = untilReset
+		}
+	}
+
+	if rateLimitOpts.MaxBackoff > 0 && wait > rateLimitOpts.MaxBackoff {
+		wait = rateLimitOpts.MaxBackoff
+	}
+
+	return wait
+}
+
+func newRateLimitError(url string, resp *http.Response) *RateLimitError {
+	return &RateLimitError{
+		URL:        url,
+		StatusCode: resp.StatusCode,
+		Limit:      resp.Header.Get("X-RateLimit-Limit"),
+		Remaining:  resp.Header.Get("X-RateLimit-Remaining"),
+		Reset:      resp.Header.Get("X-RateLimit-Reset"),
+		RetryAfter: resp.Header.Get("Retry-After"),
+	}
+}
+
+func headerOrNA(value string) string {
+	if value == "" {
+		return "N/A"
+	}
+
+	return value
+}
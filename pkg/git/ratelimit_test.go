@@ -0,0 +1,78 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_doRequest_rateLimited(t *testing.T) {
+	tests := map[string]struct {
+		rateLimitedCalls int
+		wantCalls        int
+		wantErr          bool
+	}{
+		"should back off and retry after a 429": {
+			rateLimitedCalls: 2,
+			wantCalls:        3,
+		},
+		"should fail with the rate limit headers after all retries": {
+			rateLimitedCalls: 10,
+			wantCalls:        4,
+			wantErr:          true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls <= tt.rateLimitedCalls {
+					w.Header().Set("X-RateLimit-Limit", "5000")
+					w.Header().Set("X-RateLimit-Remaining", "0")
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+
+				w.Header().Set("X-Oauth-Scopes", "repo, admin:repo_hook")
+			}))
+			defer server.Close()
+
+			origOpts := rateLimitOpts
+			SetRateLimit(RateLimitOptions{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond})
+			defer SetRateLimit(origOpts)
+
+			g := &github{providerType: GITHUB_ENT, apiURL: server.URL}
+			err := g.VerifyToken(context.Background(), RuntimeToken, "token")
+			assert.Equal(t, tt.wantCalls, calls)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+
+			var rlErr *RateLimitError
+			assert.True(t, errors.As(err, &rlErr))
+			assert.Equal(t, "5000", rlErr.Limit)
+			assert.Contains(t, err.Error(), "remaining: 0")
+		})
+	}
+}
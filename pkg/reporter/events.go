@@ -0,0 +1,233 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codefresh-io/cli-v2/pkg/log"
+)
+
+type (
+	// CliStepEvent is a single line of the NDJSON stream written to the events output
+	CliStepEvent struct {
+		Time        time.Time     `json:"time"`
+		Step        CliStep       `json:"step"`
+		Status      CliStepStatus `json:"status"`
+		Description string        `json:"description,omitempty"`
+		Error       string        `json:"error,omitempty"`
+		// DurationMs is the time since the previous step, as a step is reported when it is done
+		DurationMs int64 `json:"durationMs"`
+	}
+
+	eventsReporter struct {
+		mu           sync.Mutex
+		output       string
+		socketPath   string
+		w            io.WriteCloser
+		buffer       [][]byte
+		warned       bool
+		lastStepTime time.Time
+	}
+
+	// nopWriteCloser keeps stderr open when the reporter is closed
+	nopWriteCloser struct {
+		io.Writer
+	}
+
+	multiAnalyticsReporter struct {
+		reporters []AnalyticsReporter
+	}
+)
+
+const (
+	unixSocketPrefix = "unix://"
+	fdPrefix         = "fd://"
+	stderrOutput     = "stderr"
+
+	// maximum number of events kept in memory while the events socket is unavailable
+	maxBufferedEvents = 1000
+)
+
+// AddEventsOutput streams every reported step, as NDJSON, to the output.
+// The output can be "stderr", a file path, a file descriptor ("fd://3") or a unix socket ("unix:///path/to/socket")
+func AddEventsOutput(output string) error {
+	er, err := newEventsReporter(output)
+	if err != nil {
+		return err
+	}
+
+	ar = &multiAnalyticsReporter{reporters: []AnalyticsReporter{ar, er}}
+	return nil
+}
+
+func newEventsReporter(output string) (*eventsReporter, error) {
+	if output == stderrOutput {
+		return &eventsReporter{output: output, w: nopWriteCloser{os.Stderr}, lastStepTime: time.Now()}, nil
+	}
+
+	if strings.HasPrefix(output, unixSocketPrefix) {
+		r := &eventsReporter{output: output, socketPath: strings.TrimPrefix(output, unixSocketPrefix), lastStepTime: time.Now()}
+		if r.socketPath == "" {
+			return nil, fmt.Errorf("missing socket path in events output \"%s\"", output)
+		}
+
+		// an unavailable socket should not fail the command, events are buffered until it is reachable
+		r.dial()
+		return r, nil
+	}
+
+	if strings.HasPrefix(output, fdPrefix) {
+		fd, err := strconv.Atoi(strings.TrimPrefix(output, fdPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid file descriptor in events output \"%s\": %w", output, err)
+		}
+
+		return &eventsReporter{output: output, w: os.NewFile(uintptr(fd), output), lastStepTime: time.Now()}, nil
+	}
+
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open events output \"%s\": %w", output, err)
+	}
+
+	return &eventsReporter{output: output, w: f, lastStepTime: time.Now()}, nil
+}
+
+func (r *eventsReporter) ReportStep(data CliStepData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	event := CliStepEvent{
+		Time:        now,
+		Step:        data.Step,
+		Status:      data.Status,
+		Description: data.Description,
+		DurationMs:  now.Sub(r.lastStepTime).Milliseconds(),
+	}
+	if data.Err != nil {
+		event.Error = data.Err.Error()
+	}
+
+	r.lastStepTime = now
+	line, err := json.Marshal(event)
+	if err != nil {
+		log.G().Debugf("Failed to marshal step event: %v", err)
+		return
+	}
+
+	r.buffer = append(r.buffer, append(line, '\n'))
+	if len(r.buffer) > maxBufferedEvents {
+		r.buffer = r.buffer[len(r.buffer)-maxBufferedEvents:]
+	}
+
+	r.flush()
+}
+
+func (r *eventsReporter) Close(status CliStepStatus, err error) {
+	if status == "" {
+		status = SUCCESS
+		if err != nil {
+			status = FAILURE
+		}
+	}
+
+	r.ReportStep(CliStepData{
+		Step:        FINISH,
+		Status:      status,
+		Description: "Finished",
+		Err:         err,
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.buffer) > 0 {
+		log.G().Warnf("Failed to deliver %d step events to \"%s\"", len(r.buffer), r.output)
+	}
+
+	if r.w != nil {
+		_ = r.w.Close()
+		r.w = nil
+	}
+}
+
+// flush writes all of the buffered events, must be called while holding the lock
+func (r *eventsReporter) flush() {
+	if r.w == nil && !r.dial() {
+		return
+	}
+
+	for len(r.buffer) > 0 {
+		if _, err := r.w.Write(r.buffer[0]); err != nil {
+			r.warn(err)
+			_ = r.w.Close()
+			r.w = nil
+			return
+		}
+
+		r.buffer = r.buffer[1:]
+	}
+}
+
+func (r *eventsReporter) dial() bool {
+	if r.socketPath == "" {
+		return false
+	}
+
+	conn, err := net.Dial("unix", r.socketPath)
+	if err != nil {
+		r.warn(err)
+		return false
+	}
+
+	r.w = conn
+	r.warned = false
+	return true
+}
+
+func (r *eventsReporter) warn(err error) {
+	if r.warned {
+		return
+	}
+
+	log.G().Warnf("Events output \"%s\" is unavailable, buffering step events: %v", r.output, err)
+	r.warned = true
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func (r *multiAnalyticsReporter) ReportStep(data CliStepData) {
+	for _, reporter := range r.reporters {
+		reporter.ReportStep(data)
+	}
+}
+
+func (r *multiAnalyticsReporter) Close(status CliStepStatus, err error) {
+	for _, reporter := range r.reporters {
+		reporter.Close(status, err)
+	}
+}
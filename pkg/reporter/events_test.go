@@ -0,0 +1,133 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readEvents(t *testing.T, l net.Listener, count int) []CliStepEvent {
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	events := []CliStepEvent{}
+	scanner := bufio.NewScanner(conn)
+	for len(events) < count && scanner.Scan() {
+		event := CliStepEvent{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatal(err)
+		}
+
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func Test_eventsReporter_unixSocket(t *testing.T) {
+	tests := map[string]struct {
+		listenBeforeReport bool
+	}{
+		"should stream events to the socket": {
+			listenBeforeReport: true,
+		},
+		"should buffer events until the socket is available": {
+			listenBeforeReport: false,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			socketPath := filepath.Join(t.TempDir(), "events.sock")
+			var l net.Listener
+			var err error
+			if tt.listenBeforeReport {
+				l, err = net.Listen("unix", socketPath)
+				assert.NoError(t, err)
+				defer l.Close()
+			}
+
+			r, err := newEventsReporter("unix://" + socketPath)
+			assert.NoError(t, err)
+
+			r.ReportStep(CliStepData{Step: InstallPhaseStart, Status: SUCCESS, Description: "start"})
+			if !tt.listenBeforeReport {
+				l, err = net.Listen("unix", socketPath)
+				assert.NoError(t, err)
+				defer l.Close()
+			}
+
+			r.ReportStep(CliStepData{Step: InstallStepBootstrapRepo, Status: FAILURE, Err: errors.New("some error")})
+
+			events := readEvents(t, l, 2)
+			r.Close("", nil)
+
+			assert.Len(t, events, 2)
+			assert.Equal(t, InstallPhaseStart, events[0].Step)
+			assert.Equal(t, SUCCESS, events[0].Status)
+			assert.Equal(t, "start", events[0].Description)
+			assert.Equal(t, InstallStepBootstrapRepo, events[1].Step)
+			assert.Equal(t, FAILURE, events[1].Status)
+			assert.Equal(t, "some error", events[1].Error)
+		})
+	}
+}
+
+func Test_eventsReporter_timeline(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "events.ndjson")
+	r, err := newEventsReporter(output)
+	assert.NoError(t, err)
+
+	r.ReportStep(CliStepData{Step: InstallPhaseStart, Status: SUCCESS, Description: "start"})
+	time.Sleep(20 * time.Millisecond)
+	r.ReportStep(CliStepData{Step: InstallStepBootstrapRepo, Status: SUCCESS, Description: "bootstrap"})
+	r.Close("", nil)
+
+	data, err := os.ReadFile(output)
+	assert.NoError(t, err)
+
+	events := []CliStepEvent{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		event := CliStepEvent{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &event))
+		events = append(events, event)
+	}
+
+	assert.Len(t, events, 3)
+	assert.Equal(t, FINISH, events[2].Step)
+	assert.GreaterOrEqual(t, events[1].DurationMs, int64(20))
+}
+
+func Test_newEventsReporter_stderr(t *testing.T) {
+	r, err := newEventsReporter("stderr")
+	assert.NoError(t, err)
+	r.Close("", nil)
+
+	// closing the reporter must not close the stderr of the cli
+	_, err = os.Stderr.Stat()
+	assert.NoError(t, err)
+}
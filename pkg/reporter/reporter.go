@@ -50,6 +50,8 @@ type (
 		userName    string
 		accountId   string
 		accountName string
+		// disabled after the first failure, without affecting the other outputs of the global reporter
+		disabled bool
 	}
 
 	noopAnalyticsReporter struct{}
@@ -69,15 +71,23 @@ const (
 	InstallStepPreCheckEnsureRuntimeRepo              CliStep = "install.pre-check.step.ensure-runtime-repo"
 	InstallStepPreCheckEnsureGitToken                 CliStep = "install.pre-check.step.ensure-git-token"
 	InstallStepPreCheckEnsureGitPAT                   CliStep = "install.pre-check.step.ensure-git-personal-access-token"
+	InstallStepPreCheckEnsureGitSigningKey            CliStep = "install.pre-check.step.ensure-git-signing-key"
+	InstallStepPreCheckEnsureSSHKey                   CliStep = "install.pre-check.step.ensure-ssh-key"
+	InstallStepPreCheckEnsureGitSourceWriteAccess     CliStep = "install.pre-check.step.ensure-git-source-write-access"
+	InstallStepPreCheckEnsureGitTokenPermissions      CliStep = "install.pre-check.step.ensure-git-token-permissions"
 	InstallStepPreCheckShouldInstallDemoResources     CliStep = "install.pre-check.step.should-install-demo-resources"
 	InstallPhasePreCheckFinish                        CliStep = "install.pre-check.phase.finish"
 	InstallPhaseRunPreCheckStart                      CliStep = "install.run.pre-check.phase.start"
 	InstallStepRunPreCheckGitProvider                 CliStep = "install.run.pre-check.step.check-git-provider"
 	InstallStepRunPreCheckDownloadRuntimeDefinition   CliStep = "install.run.pre-check.step.download-runtime-definition"
 	InstallStepRunPreCheckEnsureCliVersion            CliStep = "install.run.pre-check.step.ensure-cli-version"
+	InstallStepRunPreCheckIngressControllerSupport    CliStep = "install.run.pre-check.step.ingress-controller-support"
 	InstallStepRunPreCheckRuntimeCollision            CliStep = "install.run.pre-check.step.runtime-collision"
+	InstallStepRunPreCheckNamespaceTerminating        CliStep = "install.run.pre-check.step.namespace-terminating"
+	InstallStepRunPreCheckExistingNamespace           CliStep = "install.run.pre-check.step.existing-namespace"
 	InstallStepRunPreCheckExisitingRuntimes           CliStep = "install.run.pre-check.step.existing-runtimes"
 	InstallStepRunPreCheckValidateClusterRequirements CliStep = "install.run.pre-check.step.validate-cluster-requirements"
+	InstallStepRunPreCheckArgoCDCRDs                  CliStep = "install.run.pre-check.step.argo-cd-crds"
 	InstallPhaseRunPreCheckFinish                     CliStep = "install.run.pre-check.phase.finish"
 	InstallPhaseStart                                 CliStep = "install.run.phase.start"
 	InstallStepDownloadRuntimeDefinition              CliStep = "install.run.step.download-runtime-definition"
@@ -94,6 +104,7 @@ const (
 	InstallStepCompleteRuntimeInstallation            CliStep = "install.run.step.complete-runtime-installation"
 	InstallStepCreateDefaultGitIntegration            CliStep = "install.run.step.create-default-git-integration"
 	InstallStepRegisterToDefaultGitIntegration        CliStep = "install.run.step.register-to-default-git-integration"
+	InstallStepSmokeTestAppProxy                      CliStep = "install.run.step.smoke-test-app-proxy"
 	InstallPhaseFinish                                CliStep = "install.run.phase.finish"
 
 	// Uninstall
@@ -171,6 +182,10 @@ func Init(user *codefresh.User, flow FlowType) {
 }
 
 func (r *segmentAnalyticsReporter) ReportStep(data CliStepData) {
+	if r.disabled {
+		return
+	}
+
 	properties := analytics.NewProperties().
 		Set("accountId", r.accountId).
 		Set("accountName", r.accountName).
@@ -192,13 +207,19 @@ func (r *segmentAnalyticsReporter) ReportStep(data CliStepData) {
 	})
 
 	if err != nil {
-		log.G().Debugf("Failed reporting to segment: %w", err)
-		r.Close(data.Status, err)
-		ar = &noopAnalyticsReporter{}
+		log.G().Debugf("Failed reporting to segment: %v", err)
+		r.disabled = true
+		if err := r.client.Close(); err != nil {
+			log.G().Debugf("Failed to close segment client: %v", err)
+		}
 	}
 }
 
 func (r *segmentAnalyticsReporter) Close(status CliStepStatus, err error) {
+	if r.disabled {
+		return
+	}
+
 	if status == "" {
 		status = SUCCESS
 		if err != nil {
@@ -214,6 +235,10 @@ func (r *segmentAnalyticsReporter) Close(status CliStepStatus, err error) {
 		Description: "Finished",
 		Err:         err,
 	})
+	if r.disabled {
+		// the client was closed by the failed report
+		return
+	}
 
 	if err := r.client.Close(); err != nil {
 		log.G().Debugf("Failed to close segment client: %w", err)
@@ -0,0 +1,72 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/segmentio/analytics-go.v3"
+)
+
+type (
+	failingSegmentClient struct {
+		enqueued int
+		closed   int
+	}
+
+	recordingReporter struct {
+		steps  []CliStep
+		closed bool
+	}
+)
+
+func (c *failingSegmentClient) Enqueue(analytics.Message) error {
+	c.enqueued++
+	return errors.New("some error")
+}
+
+func (c *failingSegmentClient) Close() error {
+	c.closed++
+	return nil
+}
+
+func (r *recordingReporter) ReportStep(data CliStepData) {
+	r.steps = append(r.steps, data.Step)
+}
+
+func (r *recordingReporter) Close(_ CliStepStatus, _ error) {
+	r.closed = true
+}
+
+func Test_segmentAnalyticsReporter_failure(t *testing.T) {
+	origAr := ar
+	defer func() { ar = origAr }()
+
+	client := &failingSegmentClient{}
+	events := &recordingReporter{}
+	ar = &multiAnalyticsReporter{reporters: []AnalyticsReporter{&segmentAnalyticsReporter{client: client}, events}}
+
+	G().ReportStep(CliStepData{Step: InstallPhaseStart, Status: SUCCESS})
+	G().ReportStep(CliStepData{Step: InstallStepCreateProject, Status: SUCCESS})
+	G().Close("", nil)
+
+	// only the segment output is disabled by its failure
+	assert.Equal(t, 1, client.enqueued)
+	assert.Equal(t, 1, client.closed)
+	assert.Equal(t, []CliStep{InstallPhaseStart, InstallStepCreateProject}, events.steps)
+	assert.True(t, events.closed)
+}
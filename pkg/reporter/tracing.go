@@ -0,0 +1,141 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/codefresh-io/cli-v2/pkg/log"
+	"github.com/codefresh-io/cli-v2/pkg/store"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingReporter exports the flow as an OpenTelemetry trace, with a child span for every reported step.
+// A step is reported when it is done, so its span starts where the previous step ended
+type tracingReporter struct {
+	mu           sync.Mutex
+	provider     *sdktrace.TracerProvider
+	tracer       trace.Tracer
+	ctx          context.Context
+	root         trace.Span
+	lastStepTime time.Time
+}
+
+const tracerName = "github.com/codefresh-io/cli-v2"
+
+// tracesExportTimeout bounds how long the cli waits for the collector when it exits
+var tracesExportTimeout = 10 * time.Second
+
+// AddTracesOutput exports the flow as an OpenTelemetry trace, with a span for every step, to an OTLP/HTTP collector.
+// The endpoint is the collector url, e.g. "http://localhost:4318"
+func AddTracesOutput(endpoint string, flow FlowType) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid otel endpoint \"%s\", expected a url such as \"http://localhost:4318\"", endpoint)
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	if u.Path != "" && u.Path != "/" {
+		opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create the otel exporter: %w", err)
+	}
+
+	tr := newTracingReporter(sdktrace.NewBatchSpanProcessor(exporter), flow)
+	ar = &multiAnalyticsReporter{reporters: []AnalyticsReporter{ar, tr}}
+	return nil
+}
+
+func newTracingReporter(sp sdktrace.SpanProcessor, flow FlowType) *tracingReporter {
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(sp),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", store.Get().BinaryName))),
+	)
+	tracer := provider.Tracer(tracerName)
+	ctx, root := tracer.Start(context.Background(), string(flow), trace.WithAttributes(attribute.String("flow", string(flow))))
+
+	return &tracingReporter{
+		provider:     provider,
+		tracer:       tracer,
+		ctx:          ctx,
+		root:         root,
+		lastStepTime: time.Now(),
+	}
+}
+
+func (r *tracingReporter) ReportStep(data CliStepData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	_, span := r.tracer.Start(r.ctx, string(data.Step),
+		trace.WithTimestamp(r.lastStepTime),
+		trace.WithAttributes(
+			attribute.String("step", string(data.Step)),
+			attribute.String("status", string(data.Status)),
+			attribute.String("description", data.Description),
+		),
+	)
+	setSpanError(span, data.Err)
+	span.End(trace.WithTimestamp(now))
+	r.lastStepTime = now
+}
+
+func (r *tracingReporter) Close(status CliStepStatus, err error) {
+	if status == "" {
+		status = SUCCESS
+		if err != nil {
+			status = FAILURE
+		}
+	}
+
+	r.mu.Lock()
+	r.root.SetAttributes(attribute.String("status", string(status)))
+	setSpanError(r.root, err)
+	r.root.End()
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), tracesExportTimeout)
+	defer cancel()
+	if err := r.provider.Shutdown(ctx); err != nil {
+		log.G().Warnf("Failed to export the traces: %v", err)
+	}
+}
+
+func setSpanError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,52 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func Test_tracingReporter(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	r := newTracingReporter(sdktrace.NewSimpleSpanProcessor(exporter), InstallFlow)
+
+	r.ReportStep(CliStepData{Step: InstallPhaseStart, Status: SUCCESS, Description: "Runtime installation phase started"})
+	r.ReportStep(CliStepData{Step: InstallStepCreateProject, Status: FAILURE, Description: "Creating Project", Err: errors.New("some error")})
+	r.Close("", errors.New("some error"))
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 3)
+
+	root := spans[2]
+	assert.Equal(t, string(InstallFlow), root.Name)
+	assert.Equal(t, codes.Error, root.Status.Code)
+
+	for i, step := range []CliStep{InstallPhaseStart, InstallStepCreateProject} {
+		assert.Equal(t, string(step), spans[i].Name)
+		assert.Equal(t, root.SpanContext.TraceID(), spans[i].SpanContext.TraceID())
+		assert.Equal(t, root.SpanContext.SpanID(), spans[i].Parent.SpanID())
+	}
+
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	assert.Equal(t, "some error", spans[1].Status.Description)
+	assert.False(t, spans[1].StartTime.Before(spans[0].EndTime))
+}
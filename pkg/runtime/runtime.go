@@ -27,6 +27,7 @@ import (
 	"github.com/codefresh-io/cli-v2/pkg/log"
 	"github.com/codefresh-io/cli-v2/pkg/store"
 	"github.com/codefresh-io/cli-v2/pkg/util"
+	kubeutil "github.com/codefresh-io/cli-v2/pkg/util/kube"
 	kustutil "github.com/codefresh-io/cli-v2/pkg/util/kust"
 
 	"github.com/Masterminds/semver/v3"
@@ -62,11 +63,23 @@ type (
 		IngressController   string          `json:"ingressController"`
 		Repo                string          `json:"repo"`
 
+		// SupportedIngressControllers is the list of ingress controllers this definition can be installed with.
+		// An empty list means there is no restriction
+		SupportedIngressControllers []string `json:"supportedIngressControllers,omitempty"`
+
+		// Reporters is the list of the reporters the cli creates for this definition.
+		// An empty list means all of the internal reporters
+		Reporters []string `json:"reporters,omitempty"`
+
 		devMode bool
 	}
 
 	CommonConfig struct {
 		CodefreshBaseURL string `json:"baseUrl"`
+
+		// additional labels and annotations for the codefresh-cm
+		ResourceLabels      map[string]string `json:"-"`
+		ResourceAnnotations map[string]string `json:"-"`
 	}
 
 	AppDef struct {
@@ -77,6 +90,22 @@ type (
 		Wait       bool   `json:"wait"`
 		IsInternal bool   `json:"isInternal"`
 	}
+
+	// UpgradeDiff is what an upgrade would change in the runtime, without applying it
+	UpgradeDiff struct {
+		FromVersion *semver.Version
+		ToVersion   *semver.Version
+		Added       []AppDef
+		Removed     []AppDef
+		Changed     []ComponentChange
+	}
+
+	// ComponentChange is a component whose url (and so its version) changes in an upgrade
+	ComponentChange struct {
+		Name    string
+		FromURL string
+		ToURL   string
+	}
 )
 
 func Download(version *semver.Version, name string) (*Runtime, error) {
@@ -111,12 +140,33 @@ func Download(version *semver.Version, name string) (*Runtime, error) {
 		devMode = true
 	}
 
+	return parseDefinition(body, name, devMode)
+}
+
+// LoadDefinition reads a runtime definition from a local file, in the same format as the released definition,
+// so the runtime can be installed without downloading the definition
+func LoadDefinition(filename, name string) (*Runtime, error) {
+	body, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read runtime definition from \"%s\": %w", filename, err)
+	}
+
+	return parseDefinition(body, name, false)
+}
+
+func parseDefinition(body []byte, name string, devMode bool) (*Runtime, error) {
 	runtime := &Runtime{}
-	err = yaml.Unmarshal(body, runtime)
+	err := yaml.Unmarshal(body, runtime)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal runtime definition data: %w", err)
 	}
 
+	for _, reporter := range runtime.Spec.Reporters {
+		if util.StringIndexOf(store.Get().CFInternalReporters, reporter) == -1 {
+			return nil, fmt.Errorf("unknown reporter \"%s\" in runtime definition, must be one of: %s", reporter, strings.Join(store.Get().CFInternalReporters, ", "))
+		}
+	}
+
 	runtime.Name = name
 	runtime.Namespace = name
 	runtime.Spec.devMode = devMode
@@ -184,6 +234,7 @@ func (r *Runtime) Save(fs fs.FS, filename string, config *CommonConfig) error {
 			"base-url": config.CodefreshBaseURL,
 		},
 	}
+	kubeutil.AddLabelsAndAnnotations(&cm.ObjectMeta, config.ResourceLabels, config.ResourceAnnotations)
 
 	return fs.WriteYamls(filename, cm)
 }
@@ -201,6 +252,35 @@ func (r *Runtime) Upgrade(fs fs.FS, newRt *Runtime, config *CommonConfig) ([]App
 	return newComponents, nil
 }
 
+// UpgradeDiff returns the components that upgrading to newRt would add, remove and change
+func (r *Runtime) UpgradeDiff(newRt *Runtime) *UpgradeDiff {
+	diff := &UpgradeDiff{
+		FromVersion: r.Spec.Version,
+		ToVersion:   newRt.Spec.Version,
+	}
+
+	for _, newComponent := range newRt.Spec.Components {
+		curComponent := r.Spec.component(newComponent.Name)
+		if curComponent == nil {
+			diff.Added = append(diff.Added, newComponent)
+		} else if curComponent.URL != newComponent.URL {
+			diff.Changed = append(diff.Changed, ComponentChange{
+				Name:    newComponent.Name,
+				FromURL: curComponent.URL,
+				ToURL:   newComponent.URL,
+			})
+		}
+	}
+
+	for _, curComponent := range r.Spec.Components {
+		if newRt.Spec.component(curComponent.Name) == nil {
+			diff.Removed = append(diff.Removed, curComponent)
+		}
+	}
+
+	return diff
+}
+
 func (r *RuntimeSpec) upgrade(fs fs.FS, newRt *RuntimeSpec) ([]AppDef, error) {
 	log.G().Infof("Upgrading bootstrap specifier")
 	argocdDir := fs.Join(apstore.Default.BootsrtrapDir, apstore.Default.ArgoCDName)
@@ -261,6 +341,55 @@ func (r *RuntimeSpec) FullSpecifier() string {
 	return buildFullURL(url, r.Version, r.devMode)
 }
 
+func (r *RuntimeSpec) SupportsIngressController(name string) bool {
+	if len(r.SupportedIngressControllers) == 0 {
+		return true
+	}
+
+	for _, supported := range r.SupportedIngressControllers {
+		if supported == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetReporters returns the names of the reporters that are created for this definition
+func (r *RuntimeSpec) GetReporters() []string {
+	if len(r.Reporters) == 0 {
+		return store.Get().CFInternalReporters
+	}
+
+	return r.Reporters
+}
+
+func (r *RuntimeSpec) HasReporter(name string) bool {
+	return util.StringIndexOf(r.GetReporters(), name) != -1
+}
+
+// SetComponentVersion pins a single component to a version other than the runtime version
+func (r *RuntimeSpec) SetComponentVersion(name string, version *semver.Version) error {
+	for i := range r.Components {
+		if r.Components[i].Name != name {
+			continue
+		}
+
+		urlObj, err := url.Parse(r.Components[i].URL)
+		if err != nil {
+			return fmt.Errorf("failed to parse the url of component \"%s\": %w", name, err)
+		}
+
+		v := urlObj.Query()
+		v.Set("ref", "v"+version.String())
+		urlObj.RawQuery = v.Encode()
+		r.Components[i].URL = urlObj.String()
+		return nil
+	}
+
+	return fmt.Errorf("component \"%s\" does not exist in runtime version %s", name, r.Version)
+}
+
 func (r *RuntimeSpec) fullURL(url string) string {
 	return buildFullURL(url, r.Version, r.devMode)
 }
@@ -0,0 +1,152 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codefresh-io/cli-v2/pkg/store"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/argoproj-labs/argocd-autopilot/pkg/fs"
+	apstore "github.com/argoproj-labs/argocd-autopilot/pkg/store"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestRuntime_Save(t *testing.T) {
+	tests := map[string]struct {
+		config          *CommonConfig
+		wantLabels      map[string]string
+		wantAnnotations map[string]string
+	}{
+		"should save the codefresh-cm with the default labels": {
+			config: &CommonConfig{CodefreshBaseURL: "https://g.codefresh.io"},
+			wantLabels: map[string]string{
+				apstore.Default.LabelKeyAppManagedBy: store.Get().Codefresh,
+				store.Get().LabelKeyCFType:           store.Get().CFRuntimeDefType,
+			},
+		},
+		"should add the custom labels and annotations": {
+			config: &CommonConfig{
+				CodefreshBaseURL:    "https://g.codefresh.io",
+				ResourceLabels:      map[string]string{"replicate": "true"},
+				ResourceAnnotations: map[string]string{"reflector/allowed": "true"},
+			},
+			wantLabels: map[string]string{
+				apstore.Default.LabelKeyAppManagedBy: store.Get().Codefresh,
+				store.Get().LabelKeyCFType:           store.Get().CFRuntimeDefType,
+				"replicate":                          "true",
+			},
+			wantAnnotations: map[string]string{"reflector/allowed": "true"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			repofs := fs.Create(memfs.New())
+			rt := &Runtime{}
+			rt.Name = "runtime"
+			rt.Namespace = "runtime"
+
+			assert.NoError(t, rt.Save(repofs, "codefresh-cm.yaml", tt.config))
+
+			cm := &v1.ConfigMap{}
+			assert.NoError(t, repofs.ReadYamls("codefresh-cm.yaml", cm))
+			assert.Equal(t, tt.wantLabels, cm.Labels)
+			assert.Equal(t, tt.wantAnnotations, cm.Annotations)
+		})
+	}
+}
+
+func TestRuntimeSpec_SetComponentVersion(t *testing.T) {
+	version := semver.MustParse("0.0.100")
+	spec := &RuntimeSpec{
+		Version: version,
+		Components: []AppDef{
+			{Name: "events", URL: buildFullURL("github.com/codefresh-io/cli-v2/manifests/argo-events", version, false)},
+			{Name: "workflows", URL: buildFullURL("github.com/codefresh-io/cli-v2/manifests/argo-workflows", version, false)},
+		},
+	}
+
+	assert.NoError(t, spec.SetComponentVersion("events", semver.MustParse("0.0.101")))
+	assert.Equal(t, "github.com/codefresh-io/cli-v2/manifests/argo-events?ref=v0.0.101", spec.Components[0].URL)
+	assert.Equal(t, "github.com/codefresh-io/cli-v2/manifests/argo-workflows?ref=v0.0.100", spec.Components[1].URL)
+
+	assert.Error(t, spec.SetComponentVersion("missing", semver.MustParse("0.0.101")))
+}
+
+func TestRuntime_UpgradeDiff(t *testing.T) {
+	curVersion := semver.MustParse("0.0.100")
+	newVersion := semver.MustParse("0.0.101")
+	curRt := &Runtime{Spec: RuntimeSpec{
+		Version: curVersion,
+		Components: []AppDef{
+			{Name: "events", URL: buildFullURL("github.com/codefresh-io/cli-v2/manifests/argo-events", curVersion, false)},
+			{Name: "workflows", URL: buildFullURL("github.com/codefresh-io/cli-v2/manifests/argo-workflows", curVersion, false)},
+			{Name: "legacy", URL: buildFullURL("github.com/codefresh-io/cli-v2/manifests/legacy", curVersion, false)},
+		},
+	}}
+	newRt := &Runtime{Spec: RuntimeSpec{
+		Version: newVersion,
+		Components: []AppDef{
+			{Name: "events", URL: buildFullURL("github.com/codefresh-io/cli-v2/manifests/argo-events", newVersion, false)},
+			{Name: "workflows", URL: buildFullURL("github.com/codefresh-io/cli-v2/manifests/argo-workflows?ref=v0.0.100", newVersion, false)},
+			{Name: "rollouts", URL: buildFullURL("github.com/codefresh-io/cli-v2/manifests/argo-rollouts", newVersion, false)},
+		},
+	}}
+
+	diff := curRt.UpgradeDiff(newRt)
+	assert.Equal(t, curVersion, diff.FromVersion)
+	assert.Equal(t, newVersion, diff.ToVersion)
+	assert.Equal(t, []AppDef{newRt.Spec.Components[2]}, diff.Added)
+	assert.Equal(t, []AppDef{curRt.Spec.Components[2]}, diff.Removed)
+	assert.Equal(t, []ComponentChange{{
+		Name:    "events",
+		FromURL: "github.com/codefresh-io/cli-v2/manifests/argo-events?ref=v0.0.100",
+		ToURL:   "github.com/codefresh-io/cli-v2/manifests/argo-events?ref=v0.0.101",
+	}}, diff.Changed)
+}
+
+func TestLoadDefinition(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "runtime.yaml")
+	def := `apiVersion: codefresh.io/v1alpha1
+kind: Runtime
+metadata:
+  name: csdp-default
+spec:
+  defVersion: 1.0.1
+  version: 0.0.500
+  bootstrapSpecifier: github.com/codefresh-io/cli-v2/manifests/argo-cd
+  components:
+  - name: events
+    type: kustomize
+    url: github.com/codefresh-io/cli-v2/manifests/argo-events
+`
+	assert.NoError(t, os.WriteFile(filename, []byte(def), 0644))
+
+	rt, err := LoadDefinition(filename, "runtime")
+	assert.NoError(t, err)
+	assert.Equal(t, "runtime", rt.Name)
+	assert.Equal(t, "runtime", rt.Namespace)
+	assert.Equal(t, "1.0.1", rt.Spec.DefVersion.String())
+	assert.Equal(t, "0.0.500", rt.Spec.Version.String())
+	assert.Equal(t, "github.com/codefresh-io/cli-v2/manifests/argo-events?ref=v0.0.500", rt.Spec.Components[0].URL)
+
+	_, err = LoadDefinition(filepath.Join(t.TempDir(), "missing.yaml"), "runtime")
+	assert.Error(t, err)
+}
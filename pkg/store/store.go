@@ -48,6 +48,8 @@ type Store struct {
 	ArgoCDServerName                    string
 	ArgoCDTokenKey                      string
 	ArgoCDTokenSecret                   string
+	ArgoCDRepoSSHCredsSecret            string
+	ArgoCDSSHKnownHostsConfigMap        string
 	ArgoWFServiceName                   string
 	ArgoWFServicePort                   int32
 	BinaryName                          string
@@ -68,7 +70,9 @@ type Store struct {
 	DefaultAPI                          string
 	EventBusName                        string
 	EventReportingEndpoint              string
+	AppProxyVersionEndpoint             string
 	EventsReporterName                  string
+	EventsReporterTriggerDataKey        string
 	GitSourceName                       string
 	WorkflowsIngressName                string
 	WorkflowsIngressPath                string
@@ -80,6 +84,7 @@ type Store struct {
 	LabelKeyCFType                      string
 	LabelKeyCFInternal                  string
 	AnnotationKeySyncWave               string
+	AnnotationKeyNamespaceFinalizers    string
 	MarketplaceGitSourceName            string
 	MarketplaceRepo                     string
 	MaxDefVersion                       *semver.Version
@@ -113,11 +118,16 @@ type Store struct {
 	GithubAccessTokenSecretKey          string
 	GithubEventTypeHeader               string
 	ArgoCD                              string
+	ArgoWorkflows                       string
 	Silent                              bool
 	InsecureIngressHost                 bool
+	Proxy                               string
+	NoProxy                             string
 	BypassIngressClassCheck             bool
 	SkipIngress                         bool
+	ReporterWildcardRBAC                bool
 	SetDefaultResources                 bool
+	IngressHealthPath                   string
 	MinimumMemorySizeRequired           string
 	MinimumCpuRequired                  string
 	MinimumLocalDiskSizeRequired        string
@@ -129,6 +139,8 @@ type Store struct {
 	RolloutReporterName                 string
 	RolloutResourceName                 string
 	RolloutReporterServiceAccount       string
+	WorkflowReporterServiceAccount      string
+	ResourceReporterTriggerDataKey      string
 	SegmentWriteKey                     string
 	DefaultNamespace                    string
 	NetworkTesterName                   string
@@ -136,6 +148,9 @@ type Store struct {
 	NetworkTesterImage                  string
 	MinKubeVersion                      string
 	MaxKubeVersion                      string
+	ArgoCDCRDsGroup                     string
+	ArgoCDCRDsVersion                   string
+	ArgoCDCRDs                          []string
 	MasterIngressName                   string
 	InClusterPath                       string
 	SccName                             string
@@ -159,6 +174,8 @@ func init() {
 	s.ArgoCDServerName = "argocd-server"
 	s.ArgoCDTokenKey = "token"
 	s.ArgoCDTokenSecret = "argocd-token"
+	s.ArgoCDRepoSSHCredsSecret = "codefresh-repo-ssh-creds"
+	s.ArgoCDSSHKnownHostsConfigMap = "argocd-ssh-known-hosts-cm"
 	s.ArgoWFServiceName = "argo-server"
 	s.ArgoWFServicePort = 2746
 	s.BinaryName = binaryName
@@ -179,7 +196,9 @@ func init() {
 	s.DefaultAPI = "https://g.codefresh.io"
 	s.EventBusName = "codefresh-eventbus"
 	s.EventReportingEndpoint = "/2.0/api/events"
+	s.AppProxyVersionEndpoint = "/api/version"
 	s.EventsReporterName = "events-reporter"
+	s.EventsReporterTriggerDataKey = "data"
 	s.WorkflowsIngressName = "-workflows-ingress"
 	s.WorkflowsIngressPath = "workflows"
 	s.AppProxyIngressName = "-cap-app-proxy"
@@ -190,6 +209,7 @@ func init() {
 	s.LabelKeyCFType = "codefresh.io/entity"
 	s.LabelKeyCFInternal = "codefresh.io/internal"
 	s.AnnotationKeySyncWave = "argocd.argoproj.io/sync-wave"
+	s.AnnotationKeyNamespaceFinalizers = "codefresh.io/namespace-finalizers"
 	s.MaxDefVersion = semver.MustParse(maxDefVersion)
 	s.RuntimeDefURL = RuntimeDefURL
 	s.MarketplaceGitSourceName = "marketplace-git-source"
@@ -222,6 +242,7 @@ func init() {
 	s.GithubAccessTokenSecretKey = "git_token"
 	s.GithubEventTypeHeader = "X-GitHub-Event"
 	s.ArgoCD = "argo-cd"
+	s.ArgoWorkflows = "workflows"
 	s.RolloutResourceName = "rollouts"
 	s.ReplicaSetResourceName = "replicasets"
 	s.AnalysisRunResourceName = "analysisruns"
@@ -230,6 +251,8 @@ func init() {
 	s.WorkflowResourceName = "workflows"
 	s.RolloutReporterName = "rollout-reporter"
 	s.RolloutReporterServiceAccount = "rollout-reporter-sa"
+	s.WorkflowReporterServiceAccount = "workflow-reporter-sa"
+	s.ResourceReporterTriggerDataKey = "data.object"
 	s.SegmentWriteKey = segmentWriteKey
 	s.RequirementsLink = "https://codefresh.io/csdp-docs/docs/runtime/requirements/"
 	s.DownloadCliLink = "https://codefresh.io/csdp-docs/docs/clients/csdp-cli/"
@@ -239,6 +262,9 @@ func init() {
 	s.NetworkTesterImage = "quay.io/codefresh/cf-venona-network-tester:latest"
 	s.MinKubeVersion = "v1.18.0"
 	s.MaxKubeVersion = "v1.25.0"
+	s.ArgoCDCRDsGroup = "argoproj.io"
+	s.ArgoCDCRDsVersion = "v1alpha1"
+	s.ArgoCDCRDs = []string{"applications", "applicationsets"}
 	s.MasterIngressName = "-master"
 	s.InClusterPath = "/bootstrap/cluster-resources/in-cluster"
 	s.SccName = "cf-scc"
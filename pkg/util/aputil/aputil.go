@@ -16,32 +16,34 @@ package aputil
 
 import (
 	"context"
-	"errors"
 	"io"
-	"time"
 
-	"github.com/codefresh-io/cli-v2/pkg/log"
 	"github.com/codefresh-io/cli-v2/pkg/util"
 
+	"github.com/argoproj-labs/argocd-autopilot/pkg/fs"
 	"github.com/argoproj-labs/argocd-autopilot/pkg/git"
 	aplog "github.com/argoproj-labs/argocd-autopilot/pkg/log"
 	"github.com/go-git/go-billy/v5/memfs"
-	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
-const (
-	pushRetries = 3
-)
+type (
+	CloneFlagsOptions struct {
+		Prefix           string
+		Optional         bool
+		CreateIfNotExist bool
+		CloneForWrite    bool
+		Progress         io.Writer
+	}
 
-type CloneFlagsOptions struct {
-	Prefix           string
-	Optional         bool
-	CreateIfNotExist bool
-	CloneForWrite    bool
-	Progress         io.Writer
-}
+	// repository is an autopilot repository whose commits are prefixed, and whose pushed commits may be rewritten
+	repository struct {
+		git.Repository
+		cloneOpts *git.CloneOptions
+	}
+)
 
 func AddCloneFlags(cmd *cobra.Command, o *CloneFlagsOptions) *git.CloneOptions {
 	opts := git.AddFlags(cmd, &git.AddFlagsOptions{
@@ -60,34 +62,57 @@ func AddCloneFlags(cmd *cobra.Command, o *CloneFlagsOptions) *git.CloneOptions {
 	return opts
 }
 
-func PushWithMessage(ctx context.Context, r git.Repository, msg string, progress ...io.Writer) error {
-	var (
-		err  error
-		prog io.Writer
-	)
+// GetRepo clones the repo of cloneOpts, like cloneOpts.GetRepo. The commits persisted through the returned
+// repository are pushed the way the repo requires (see ConfigureCommitSigning and ConfigureCommitAuthor)
+func GetRepo(ctx context.Context, cloneOpts *git.CloneOptions) (git.Repository, fs.FS, error) {
+	r, repofs, err := cloneOpts.GetRepo(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	if len(progress) > 0 {
-		prog = progress[0]
+	return &repository{
+		Repository: r,
+		cloneOpts:  cloneOpts,
+	}, repofs, nil
+}
+
+// Persist commits once and pushes, like the autopilot repository does, with the message prefix of the installation.
+// The pushed commit may be rewritten on the way (see commitTransport), in which case its hash is returned,
+// while the local branch keeps the original commit
+func (r *repository) Persist(ctx context.Context, opts *git.PushOptions) (string, error) {
+	if opts == nil {
+		return "", git.ErrNilOpts
+	}
+
+	pushOpts := *opts
+	pushOpts.CommitMsg = commitMessage(opts.CommitMsg)
+	revision, err := r.Repository.Persist(ctx, &pushOpts)
+	if err != nil {
+		return "", err
 	}
 
-	for try := 0; try < pushRetries; try++ {
-		_, err = r.Persist(ctx, &git.PushOptions{
-			AddGlobPattern: ".",
-			CommitMsg:      msg,
-			Progress:       prog,
-		})
-		if err == nil || !errors.Is(err, transport.ErrRepositoryNotFound) {
-			break
+	if c, ok := committers[r.cloneOpts]; ok {
+		if pushed, ok := c.pushed[plumbing.NewHash(revision)]; ok {
+			return pushed.String(), nil
 		}
+	}
 
-		log.G(ctx).WithFields(log.Fields{
-			"retry": try,
-			"err":   err.Error(),
-		}).Warn("Failed to push to repository, trying again in 3 seconds...")
+	return revision, nil
+}
 
-		time.Sleep(time.Second * 3)
+// PushWithMessage commits all of the changes in r with msg, and pushes them
+func PushWithMessage(ctx context.Context, r git.Repository, msg string, progress ...io.Writer) error {
+	var prog io.Writer
+	if len(progress) > 0 {
+		prog = progress[0]
 	}
 
+	// the push is retried by Persist, retrying here would commit again
+	_, err := r.Persist(ctx, &git.PushOptions{
+		AddGlobPattern: ".",
+		CommitMsg:      msg,
+		Progress:       prog,
+	})
 	return err
 }
 
@@ -0,0 +1,184 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aputil
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/argoproj-labs/argocd-autopilot/pkg/git"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+type (
+	// CommitAuthor is the identity every commit pushed to a repo is authored by
+	CommitAuthor struct {
+		Name  string
+		Email string
+	}
+
+	// committer is how the commits pushed to a repo are made, since autopilot can neither sign them nor set their author
+	committer struct {
+		signer commitSigner
+		author *CommitAuthor
+		// pushed are the rewritten commits that were pushed, by the hash of the original commit
+		pushed map[plumbing.Hash]plumbing.Hash
+	}
+)
+
+var (
+	// committers are set for the repo of each clone options by ConfigureCommitSigning and ConfigureCommitAuthor
+	committers = map[*git.CloneOptions]*committer{}
+
+	// commitMessagePrefix is set by ConfigureCommitMessagePrefix
+	commitMessagePrefix string
+)
+
+// ParseCommitAuthor parses an author in the form "Name <email>"
+func ParseCommitAuthor(author string) (*CommitAuthor, error) {
+	address, err := mail.ParseAddress(author)
+	if err != nil || address.Name == "" {
+		return nil, fmt.Errorf("invalid commit author \"%s\", must be in the form \"Name <email>\"", author)
+	}
+
+	return &CommitAuthor{
+		Name:  address.Name,
+		Email: address.Address,
+	}, nil
+}
+
+// ConfigureCommitAuthor makes every commit pushed to the repo of cloneOpts authored by author, instead of the git config user
+func ConfigureCommitAuthor(cloneOpts *git.CloneOptions, author *CommitAuthor) {
+	getCommitter(cloneOpts).author = author
+}
+
+// ConfigureCommitMessagePrefix prepends prefix (e.g. a ticket reference) to the message of every pushed commit
+func ConfigureCommitMessagePrefix(prefix string) {
+	commitMessagePrefix = strings.TrimSpace(prefix)
+	if commitMessagePrefix != "" {
+		installCommitTransport()
+	}
+}
+
+func commitMessage(msg string) string {
+	if commitMessagePrefix == "" || strings.HasPrefix(msg, commitMessagePrefix+" ") {
+		return msg
+	}
+
+	return commitMessagePrefix + " " + msg
+}
+
+func getCommitter(cloneOpts *git.CloneOptions) *committer {
+	c, ok := committers[cloneOpts]
+	if !ok {
+		c = &committer{}
+		committers[cloneOpts] = c
+		installCommitTransport()
+	}
+
+	return c
+}
+
+// needsRewrite is true when the commit was not made the way the repo requires, e.g. by autopilot
+func (c *committer) needsRewrite(commit *object.Commit) bool {
+	if c.signer != nil && commit.PGPSignature == "" {
+		return true
+	}
+
+	if c.author != nil && (commit.Author.Name != c.author.Name || commit.Author.Email != c.author.Email) {
+		return true
+	}
+
+	return commitMessage(commit.Message) != commit.Message
+}
+
+// rewriteCommit rewrites the commit h and its parents in s that need it, and returns the hash of the rewritten commit.
+// Commits that are not in s (already on the remote) are kept as they are
+func (c *committer) rewriteCommit(s storer.EncodedObjectStorer, h plumbing.Hash, rewritten map[plumbing.Hash]plumbing.Hash) (plumbing.Hash, error) {
+	if newHash, ok := rewritten[h]; ok {
+		return newHash, nil
+	}
+
+	commit, err := object.GetCommit(s, h)
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return h, nil
+	}
+
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to read commit %s: %w", h, err)
+	}
+
+	parentsChanged := false
+	parents := make([]plumbing.Hash, len(commit.ParentHashes))
+	for i, parent := range commit.ParentHashes {
+		if parents[i], err = c.rewriteCommit(s, parent, rewritten); err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		parentsChanged = parentsChanged || parents[i] != parent
+	}
+
+	newHash := h
+	if parentsChanged || c.needsRewrite(commit) {
+		if newHash, err = c.storeCommit(s, commit, parents); err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to rewrite commit %s: %w", h, err)
+		}
+	}
+
+	rewritten[h] = newHash
+	return newHash, nil
+}
+
+// storeCommit stores a copy of commit with the given parents, the configured author and message prefix, signed if a key is configured
+func (c *committer) storeCommit(s storer.EncodedObjectStorer, commit *object.Commit, parents []plumbing.Hash) (plumbing.Hash, error) {
+	newCommit := &object.Commit{
+		Author:       commit.Author,
+		Committer:    commit.Committer,
+		Message:      commitMessage(commit.Message),
+		TreeHash:     commit.TreeHash,
+		ParentHashes: parents,
+	}
+	if c.author != nil {
+		newCommit.Author.Name, newCommit.Author.Email = c.author.Name, c.author.Email
+		newCommit.Committer.Name, newCommit.Committer.Email = c.author.Name, c.author.Email
+	}
+
+	if c.signer != nil {
+		encoded := &plumbing.MemoryObject{}
+		if err := newCommit.Encode(encoded); err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		reader, err := encoded.Reader()
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		if newCommit.PGPSignature, err = c.signer.sign(reader); err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to sign: %w", err)
+		}
+	}
+
+	obj := s.NewEncodedObject()
+	if err := newCommit.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return s.SetEncodedObject(obj)
+}
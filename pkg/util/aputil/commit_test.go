@@ -0,0 +1,159 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aputil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/argoproj-labs/argocd-autopilot/pkg/git"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingRepo struct {
+	git.Repository
+	commitMsgs []string
+	err        error
+}
+
+func (r *recordingRepo) Persist(_ context.Context, opts *git.PushOptions) (string, error) {
+	r.commitMsgs = append(r.commitMsgs, opts.CommitMsg)
+	return plumbing.ZeroHash.String(), r.err
+}
+
+func storeTestCommit(t *testing.T, s *memory.Storage, msg string, parents []plumbing.Hash) plumbing.Hash {
+	sig := object.Signature{Name: "config user", Email: "user@example.com", When: time.Now()}
+	commit := &object.Commit{
+		Author:       sig,
+		Committer:    sig,
+		Message:      msg,
+		TreeHash:     plumbing.ZeroHash,
+		ParentHashes: parents,
+	}
+	obj := s.NewEncodedObject()
+	assert.NoError(t, commit.Encode(obj))
+	h, err := s.SetEncodedObject(obj)
+	assert.NoError(t, err)
+	return h
+}
+
+func TestParseCommitAuthor(t *testing.T) {
+	author, err := ParseCommitAuthor("Service Account <svc@example.com>")
+	assert.NoError(t, err)
+	assert.Equal(t, &CommitAuthor{Name: "Service Account", Email: "svc@example.com"}, author)
+
+	_, err = ParseCommitAuthor("svc@example.com")
+	assert.EqualError(t, err, "invalid commit author \"svc@example.com\", must be in the form \"Name <email>\"")
+
+	_, err = ParseCommitAuthor("Service Account")
+	assert.Error(t, err)
+}
+
+func TestPushWithMessage_messagePrefix(t *testing.T) {
+	ConfigureCommitMessagePrefix("TICKET-123 ")
+	defer ConfigureCommitMessagePrefix("")
+
+	inner := &recordingRepo{}
+	r := &repository{Repository: inner, cloneOpts: &git.CloneOptions{}}
+	assert.NoError(t, PushWithMessage(context.Background(), r, "Persisted runtime data"))
+	assert.Equal(t, []string{"TICKET-123 Persisted runtime data"}, inner.commitMsgs)
+}
+
+func TestPushWithMessage_commitsOnce(t *testing.T) {
+	r := &recordingRepo{err: transport.ErrRepositoryNotFound}
+	err := PushWithMessage(context.Background(), r, "Persisted runtime data")
+	assert.ErrorIs(t, err, transport.ErrRepositoryNotFound)
+	assert.Equal(t, []string{"Persisted runtime data"}, r.commitMsgs)
+}
+
+func Test_committer_rewriteCommit(t *testing.T) {
+	ConfigureCommitMessagePrefix("TICKET-123")
+	defer ConfigureCommitMessagePrefix("")
+
+	c := &committer{author: &CommitAuthor{Name: "Service Account", Email: "svc@example.com"}}
+	s := memory.NewStorage()
+	parent := storeTestCommit(t, s, "Autopilot Bootstrap", nil)
+	h := storeTestCommit(t, s, "TICKET-123 Created App-Proxy Ingress", []plumbing.Hash{parent})
+	remoteParent := plumbing.NewHash("0123456789012345678901234567890123456789")
+	other := storeTestCommit(t, s, "Persisted runtime data", []plumbing.Hash{remoteParent})
+
+	rewritten := map[plumbing.Hash]plumbing.Hash{}
+	newHash, err := c.rewriteCommit(s, h, rewritten)
+	assert.NoError(t, err)
+
+	commit, err := object.GetCommit(s, newHash)
+	assert.NoError(t, err)
+	assert.Equal(t, "TICKET-123 Created App-Proxy Ingress", commit.Message)
+	assert.Equal(t, "Service Account", commit.Author.Name)
+	assert.Equal(t, "svc@example.com", commit.Committer.Email)
+	assert.Equal(t, []plumbing.Hash{rewritten[parent]}, commit.ParentHashes)
+
+	parentCommit, err := object.GetCommit(s, rewritten[parent])
+	assert.NoError(t, err)
+	assert.Equal(t, "TICKET-123 Autopilot Bootstrap", parentCommit.Message)
+
+	// parents that are not in the pushed objects are already on the remote
+	newHash, err = c.rewriteCommit(s, other, rewritten)
+	assert.NoError(t, err)
+	commit, err = object.GetCommit(s, newHash)
+	assert.NoError(t, err)
+	assert.Equal(t, []plumbing.Hash{remoteParent}, commit.ParentHashes)
+
+	// commits that are already made the way the repo requires are kept
+	keptHash, err := c.rewriteCommit(s, newHash, rewritten)
+	assert.NoError(t, err)
+	assert.Equal(t, newHash, keptHash)
+}
+
+func Test_getPushCommitter(t *testing.T) {
+	cloneOpts := &git.CloneOptions{Repo: "https://github.com/owner/repo.git"}
+	cloneOpts.Parse()
+	c := getCommitter(cloneOpts)
+	defer delete(committers, cloneOpts)
+
+	tests := map[string]struct {
+		url    string
+		prefix string
+		want   *committer
+	}{
+		"should return the committer of the same repo": {
+			url:  "https://GitHub.com/owner/repo",
+			want: c,
+		},
+		"should return nil for another repo": {
+			url: "https://github.com/owner/other.git",
+		},
+		"should only prefix the commits of another repo": {
+			url:    "https://github.com/owner/other.git",
+			prefix: "TICKET-123",
+			want:   &committer{},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ConfigureCommitMessagePrefix(tt.prefix)
+			defer ConfigureCommitMessagePrefix("")
+
+			ep, err := transport.NewEndpoint(tt.url)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, getPushCommitter(ep))
+		})
+	}
+}
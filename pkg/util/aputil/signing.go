@@ -0,0 +1,214 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aputil
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/argoproj-labs/argocd-autopilot/pkg/git"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// sshSigNamespace is the namespace git signs and verifies commits in
+	sshSigNamespace = "git"
+	sshSigMagic     = "SSHSIG"
+)
+
+type (
+	// commitSigner returns the armored signature of an encoded commit
+	commitSigner interface {
+		sign(message io.Reader) (string, error)
+	}
+
+	gpgSigner struct {
+		key *openpgp.Entity
+	}
+
+	// sshSigner signs in the format of "ssh-keygen -Y sign", which git verifies with gpg.format=ssh
+	sshSigner struct {
+		signer ssh.Signer
+	}
+)
+
+// ConfigureCommitSigning makes every commit pushed to the repo of cloneOpts signed with the private key in keyFile,
+// either an armored gpg key or an ssh key. The key is verified to be usable for signing before it is set.
+func ConfigureCommitSigning(cloneOpts *git.CloneOptions, keyFile, passphrase string) error {
+	signer, err := readSigningKey(keyFile, passphrase)
+	if err != nil {
+		return err
+	}
+
+	getCommitter(cloneOpts).signer = signer
+	return nil
+}
+
+// readSigningKey reads and decrypts a gpg or ssh private key, and makes sure it can sign
+func readSigningKey(keyFile, passphrase string) (commitSigner, error) {
+	data, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	var signer commitSigner
+	if bytes.Contains(data, []byte("PGP PRIVATE KEY")) {
+		signer, err = readGPGSigningKey(keyFile, data, passphrase)
+	} else {
+		signer, err = readSSHSigningKey(data, passphrase)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = signer.sign(strings.NewReader("")); err != nil {
+		return nil, fmt.Errorf("signing key is not usable: %w", err)
+	}
+
+	return signer, nil
+}
+
+func readGPGSigningKey(keyFile string, data []byte, passphrase string) (commitSigner, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+
+	if len(entities) == 0 || entities[0].PrivateKey == nil {
+		return nil, fmt.Errorf("signing key file \"%s\" does not contain a private key", keyFile)
+	}
+
+	key := entities[0]
+	if err = decryptKey(key, passphrase); err != nil {
+		return nil, err
+	}
+
+	return &gpgSigner{key}, nil
+}
+
+func readSSHSigningKey(data []byte, passphrase string) (commitSigner, error) {
+	var (
+		signer ssh.Signer
+		err    error
+	)
+
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(data)
+	}
+
+	if err != nil {
+		var missingPassphrase *ssh.PassphraseMissingError
+		if errors.As(err, &missingPassphrase) {
+			return nil, fmt.Errorf("signing key is encrypted, a passphrase is required")
+		}
+
+		return nil, fmt.Errorf("failed to parse signing key, must be an armored gpg private key or an ssh private key: %w", err)
+	}
+
+	return &sshSigner{signer}, nil
+}
+
+func decryptKey(key *openpgp.Entity, passphrase string) error {
+	if key.PrivateKey.Encrypted {
+		if passphrase == "" {
+			return fmt.Errorf("signing key is encrypted, a passphrase is required")
+		}
+
+		if err := key.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+			return fmt.Errorf("failed to decrypt signing key: %w", err)
+		}
+	}
+
+	for _, sub := range key.Subkeys {
+		if sub.PrivateKey != nil && sub.PrivateKey.Encrypted {
+			if err := sub.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+				return fmt.Errorf("failed to decrypt signing subkey: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *gpgSigner) sign(message io.Reader) (string, error) {
+	var b bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&b, s.key, message, nil); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// sign implements the ssh signature protocol (openssh PROTOCOL.sshsig) with a sha512 message hash
+func (s *sshSigner) sign(message io.Reader) (string, error) {
+	h := sha512.New()
+	if _, err := io.Copy(h, message); err != nil {
+		return "", err
+	}
+
+	signedData := append([]byte(sshSigMagic), ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{sshSigNamespace, "", "sha512", h.Sum(nil)})...)
+
+	var (
+		sig *ssh.Signature
+		err error
+	)
+
+	// git rejects ssh-rsa (sha1) signatures
+	if as, ok := s.signer.(ssh.AlgorithmSigner); ok && s.signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		sig, err = as.SignWithAlgorithm(rand.Reader, signedData, ssh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = s.signer.Sign(rand.Reader, signedData)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	blob := append([]byte(sshSigMagic), ssh.Marshal(struct {
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}{1, s.signer.PublicKey().Marshal(), sshSigNamespace, "", "sha512", ssh.Marshal(sig)})...)
+
+	encoded := base64.StdEncoding.EncodeToString(blob)
+	var b strings.Builder
+	b.WriteString("-----BEGIN SSH SIGNATURE-----\n")
+	for len(encoded) > 70 {
+		b.WriteString(encoded[:70] + "\n")
+		encoded = encoded[70:]
+	}
+
+	b.WriteString(encoded + "\n-----END SSH SIGNATURE-----\n")
+	return b.String(), nil
+}
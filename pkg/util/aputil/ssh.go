@@ -0,0 +1,137 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aputil
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SSHHostKeyOptions is how the host key of the git server is verified
+type SSHHostKeyOptions struct {
+	// KnownHostsFile is the known_hosts file the host key is verified against. When empty, the default
+	// files are used ($SSH_KNOWN_HOSTS, ~/.ssh/known_hosts and /etc/ssh/ssh_known_hosts)
+	KnownHostsFile string
+
+	// InsecureIgnoreHostKey accepts any host key, and is only meant for development
+	InsecureIgnoreHostKey bool
+}
+
+// sshKeyTransport wraps the ssh transport, so the sessions of one repo authenticate with a private key whatever
+// auth they are given. autopilot only passes basic auth, which the ssh transport cannot use
+type sshKeyTransport struct {
+	transport.Transport
+	repoURL string
+	auth    transport.AuthMethod
+}
+
+var (
+	sshKnownHostsLock sync.Mutex
+	// the verified host keys of the ssh remotes, in known_hosts format
+	sshKnownHosts = map[string]bool{}
+)
+
+// ConfigureSSHAuth makes the ssh clones and pushes of the repo at repoURL authenticate with the private key in keyFile,
+// any other ssh remote keeps the auth it is given. The host key is verified by hostKeyCallback, see GetSSHHostKeyCallback,
+// and the verified host keys are returned by GetSSHKnownHosts
+func ConfigureSSHAuth(repoURL, keyFile, passphrase string, hostKeyCallback ssh.HostKeyCallback) error {
+	auth, err := gitssh.NewPublicKeysFromFile("git", keyFile, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to read ssh key: %w", err)
+	}
+
+	auth.HostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if err := hostKeyCallback(hostname, remote, key); err != nil {
+			return err
+		}
+
+		sshKnownHostsLock.Lock()
+		defer sshKnownHostsLock.Unlock()
+		sshKnownHosts[knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key)] = true
+		return nil
+	}
+
+	// wraps the installed transport, which may already rewrite the pushed commits, instead of replacing it
+	t := client.Protocols["ssh"]
+	if keyTransport, ok := t.(*sshKeyTransport); ok {
+		t = keyTransport.Transport
+	} else if t == nil {
+		t = gitssh.DefaultClient
+	}
+
+	client.InstallProtocol("ssh", &sshKeyTransport{
+		Transport: t,
+		repoURL:   repoURL,
+		auth:      auth,
+	})
+	return nil
+}
+
+// GetSSHKnownHosts returns the host keys verified by the ssh auth of ConfigureSSHAuth, as known_hosts lines
+func GetSSHKnownHosts() []string {
+	sshKnownHostsLock.Lock()
+	defer sshKnownHostsLock.Unlock()
+	lines := make([]string, 0, len(sshKnownHosts))
+	for line := range sshKnownHosts {
+		lines = append(lines, line)
+	}
+
+	sort.Strings(lines)
+	return lines
+}
+
+// GetSSHHostKeyCallback returns a strict host key check, unless the host key is explicitly ignored.
+// Without any known hosts to check against it fails, instead of trusting the first key it sees
+func GetSSHHostKeyCallback(o SSHHostKeyOptions) (ssh.HostKeyCallback, error) {
+	if o.InsecureIgnoreHostKey {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	var files []string
+	if o.KnownHostsFile != "" {
+		files = append(files, o.KnownHostsFile)
+	}
+
+	callback, err := gitssh.NewKnownHostsCallback(files...)
+	if err != nil {
+		return nil, fmt.Errorf("no known hosts to verify the ssh host key against: %w", err)
+	}
+
+	return callback, nil
+}
+
+func (t *sshKeyTransport) NewUploadPackSession(ep *transport.Endpoint, auth transport.AuthMethod) (transport.UploadPackSession, error) {
+	return t.Transport.NewUploadPackSession(ep, t.getAuth(ep, auth))
+}
+
+func (t *sshKeyTransport) NewReceivePackSession(ep *transport.Endpoint, auth transport.AuthMethod) (transport.ReceivePackSession, error) {
+	return t.Transport.NewReceivePackSession(ep, t.getAuth(ep, auth))
+}
+
+func (t *sshKeyTransport) getAuth(ep *transport.Endpoint, auth transport.AuthMethod) transport.AuthMethod {
+	if isSameRepo(ep, t.repoURL) {
+		return t.auth
+	}
+
+	return auth
+}
@@ -0,0 +1,185 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aputil
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// fakeTransport records the auth of the last session
+type fakeTransport struct {
+	auth transport.AuthMethod
+}
+
+func (t *fakeTransport) NewUploadPackSession(_ *transport.Endpoint, auth transport.AuthMethod) (transport.UploadPackSession, error) {
+	t.auth = auth
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (t *fakeTransport) NewReceivePackSession(_ *transport.Endpoint, auth transport.AuthMethod) (transport.ReceivePackSession, error) {
+	t.auth = auth
+	return nil, fmt.Errorf("not implemented")
+}
+
+func newHostKey(t *testing.T) ssh.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	key, err := ssh.NewPublicKey(pub)
+	assert.NoError(t, err)
+	return key
+}
+
+func TestConfigureSSHAuth(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "id_rsa")
+	assert.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	}), 0600))
+
+	origTransport := client.Protocols["ssh"]
+	defer client.InstallProtocol("ssh", origTransport)
+	inner := &fakeTransport{}
+	client.InstallProtocol("ssh", inner)
+	assert.NoError(t, ConfigureSSHAuth("git@github.com:owner/repo", keyFile, "", ssh.InsecureIgnoreHostKey()))
+
+	tr, ok := client.Protocols["ssh"].(*sshKeyTransport)
+	assert.True(t, ok)
+	assert.Equal(t, gitssh.PublicKeysName, tr.auth.Name())
+	// the installed transport is wrapped, and not replaced
+	assert.Equal(t, inner, tr.Transport)
+
+	// configuring it again does not wrap it twice
+	assert.NoError(t, ConfigureSSHAuth("git@github.com:owner/repo", keyFile, "", ssh.InsecureIgnoreHostKey()))
+	assert.Equal(t, inner, client.Protocols["ssh"].(*sshKeyTransport).Transport)
+
+	basicAuth := &githttp.BasicAuth{Username: "username", Password: "token"}
+	tests := map[string]struct {
+		repoURL  string
+		wantAuth string
+	}{
+		"Should authenticate the repo with the ssh key": {
+			repoURL:  "ssh://git@github.com/owner/repo.git",
+			wantAuth: gitssh.PublicKeysName,
+		},
+		"Should keep the auth of any other repo": {
+			repoURL:  "ssh://git@github.com/owner/git-source.git",
+			wantAuth: basicAuth.Name(),
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ep, err := transport.NewEndpoint(tt.repoURL)
+			assert.NoError(t, err)
+
+			_, _ = client.Protocols["ssh"].NewUploadPackSession(ep, basicAuth)
+			assert.Equal(t, tt.wantAuth, inner.auth.Name())
+
+			_, _ = client.Protocols["ssh"].NewReceivePackSession(ep, basicAuth)
+			assert.Equal(t, tt.wantAuth, inner.auth.Name())
+		})
+	}
+}
+
+func TestGetSSHKnownHosts(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "id_rsa")
+	assert.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	}), 0600))
+
+	origTransport := client.Protocols["ssh"]
+	defer client.InstallProtocol("ssh", origTransport)
+	knownKey := newHostKey(t)
+	unknownKey := newHostKey(t)
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+	assert.NoError(t, ConfigureSSHAuth("git@git.example.com:owner/repo", keyFile, "", func(_ string, _ net.Addr, key ssh.PublicKey) error {
+		if !bytes.Equal(key.Marshal(), knownKey.Marshal()) {
+			return fmt.Errorf("unknown host key")
+		}
+
+		return nil
+	}))
+
+	callback := client.Protocols["ssh"].(*sshKeyTransport).auth.(*gitssh.PublicKeys).HostKeyCallback
+	assert.NoError(t, callback("git.example.com:22", remote, knownKey))
+	assert.Error(t, callback("other.example.com:22", remote, unknownKey))
+
+	// only the verified host keys are returned
+	assert.Contains(t, GetSSHKnownHosts(), knownhosts.Line([]string{"git.example.com"}, knownKey))
+	assert.NotContains(t, GetSSHKnownHosts(), knownhosts.Line([]string{"other.example.com"}, unknownKey))
+}
+
+func TestConfigureSSHAuth_missingKey(t *testing.T) {
+	err := ConfigureSSHAuth("git@github.com:owner/repo", filepath.Join(t.TempDir(), "id_rsa"), "", ssh.InsecureIgnoreHostKey())
+	assert.ErrorContains(t, err, "failed to read ssh key")
+}
+
+func TestGetSSHHostKeyCallback(t *testing.T) {
+	knownKey := newHostKey(t)
+	unknownKey := newHostKey(t)
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	assert.NoError(t, ioutil.WriteFile(knownHostsFile, []byte(knownhosts.Line([]string{"known.example.com"}, knownKey)+"\n"), 0600))
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+
+	t.Run("Should accept a known host in strict mode", func(t *testing.T) {
+		callback, err := GetSSHHostKeyCallback(SSHHostKeyOptions{KnownHostsFile: knownHostsFile})
+		assert.NoError(t, err)
+		assert.NoError(t, callback("known.example.com:22", remote, knownKey))
+	})
+
+	t.Run("Should reject an unknown host in strict mode", func(t *testing.T) {
+		callback, err := GetSSHHostKeyCallback(SSHHostKeyOptions{KnownHostsFile: knownHostsFile})
+		assert.NoError(t, err)
+		assert.Error(t, callback("unknown.example.com:22", remote, unknownKey))
+		assert.Error(t, callback("known.example.com:22", remote, unknownKey))
+	})
+
+	t.Run("Should accept an unknown host in insecure mode", func(t *testing.T) {
+		callback, err := GetSSHHostKeyCallback(SSHHostKeyOptions{InsecureIgnoreHostKey: true})
+		assert.NoError(t, err)
+		assert.NoError(t, callback("unknown.example.com:22", remote, unknownKey))
+	})
+
+	t.Run("Should fail in strict mode without known hosts", func(t *testing.T) {
+		t.Setenv("SSH_KNOWN_HOSTS", filepath.Join(t.TempDir(), "missing"))
+		_, err := GetSSHHostKeyCallback(SSHHostKeyOptions{})
+		assert.ErrorContains(t, err, "no known hosts to verify the ssh host key against")
+
+		_, err = GetSSHHostKeyCallback(SSHHostKeyOptions{KnownHostsFile: filepath.Join(t.TempDir(), "missing")})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,182 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aputil
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+type (
+	// commitTransport rewrites the pushed commits that were not made the way their repo requires.
+	// autopilot commits and pushes through repositories of its own (e.g. on repo bootstrap), which cannot be wrapped
+	commitTransport struct {
+		transport.Transport
+	}
+
+	commitReceivePackSession struct {
+		transport.ReceivePackSession
+		committer *committer
+	}
+)
+
+var installCommitTransportOnce sync.Once
+
+// installCommitTransport wraps the transport of every protocol, so the pushes through it are rewritten
+func installCommitTransport() {
+	installCommitTransportOnce.Do(func() {
+		for scheme, t := range client.Protocols {
+			client.InstallProtocol(scheme, &commitTransport{Transport: t})
+		}
+	})
+}
+
+// getPushCommitter returns the committer of the repo at ep, or nil when its commits are pushed as they are
+func getPushCommitter(ep *transport.Endpoint) *committer {
+	for cloneOpts, c := range committers {
+		if isSameRepo(ep, cloneOpts.URL()) {
+			return c
+		}
+	}
+
+	if commitMessagePrefix != "" {
+		return &committer{}
+	}
+
+	return nil
+}
+
+func isSameRepo(ep *transport.Endpoint, repoURL string) bool {
+	repoEp, err := transport.NewEndpoint(repoURL)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(ep.Host, repoEp.Host) && repoPath(ep) == repoPath(repoEp)
+}
+
+func repoPath(ep *transport.Endpoint) string {
+	return strings.TrimSuffix(strings.Trim(ep.Path, "/"), ".git")
+}
+
+func (t *commitTransport) NewReceivePackSession(ep *transport.Endpoint, auth transport.AuthMethod) (transport.ReceivePackSession, error) {
+	sess, err := t.Transport.NewReceivePackSession(ep, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	c := getPushCommitter(ep)
+	if c == nil {
+		return sess, nil
+	}
+
+	return &commitReceivePackSession{
+		ReceivePackSession: sess,
+		committer:          c,
+	}, nil
+}
+
+func (s *commitReceivePackSession) ReceivePack(ctx context.Context, req *packp.ReferenceUpdateRequest) (*packp.ReportStatus, error) {
+	if req.Packfile == nil {
+		return s.ReceivePackSession.ReceivePack(ctx, req)
+	}
+
+	original := make([]plumbing.Hash, len(req.Commands))
+	for i, cmd := range req.Commands {
+		original[i] = cmd.New
+	}
+
+	if err := s.committer.rewritePush(req); err != nil {
+		return nil, fmt.Errorf("failed to rewrite the pushed commits: %w", err)
+	}
+
+	rs, err := s.ReceivePackSession.ReceivePack(ctx, req)
+
+	// go-git updates the remote references of the local repo from req, and the rewritten commits are not in it
+	for i, cmd := range req.Commands {
+		cmd.New = original[i]
+	}
+
+	return rs, err
+}
+
+// rewritePush rewrites the pushed commits, and replaces the packfile and the updated references of req with the rewritten ones
+func (c *committer) rewritePush(req *packp.ReferenceUpdateRequest) error {
+	data, err := io.ReadAll(req.Packfile)
+	_ = req.Packfile.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read packfile: %w", err)
+	}
+
+	req.Packfile = io.NopCloser(bytes.NewReader(data))
+	s := memory.NewStorage()
+	if err = packfile.UpdateObjectStorage(s, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to parse packfile: %w", err)
+	}
+
+	if c.pushed == nil {
+		c.pushed = map[plumbing.Hash]plumbing.Hash{}
+	}
+
+	changed := false
+	rewritten := map[plumbing.Hash]plumbing.Hash{}
+	for _, cmd := range req.Commands {
+		if cmd.New.IsZero() {
+			continue
+		}
+
+		newHash, err := c.rewriteCommit(s, cmd.New, rewritten)
+		if err != nil {
+			return err
+		}
+
+		if newHash != cmd.New {
+			changed = true
+			c.pushed[cmd.New] = newHash
+			cmd.New = newHash
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	hashes := []plumbing.Hash{}
+	for h := range s.ObjectStorage.Objects {
+		// the original commits are not needed anymore
+		if newHash, ok := rewritten[h]; !ok || newHash == h {
+			hashes = append(hashes, h)
+		}
+	}
+
+	pack := &bytes.Buffer{}
+	if _, err = packfile.NewEncoder(pack, s, false).Encode(hashes, 0); err != nil {
+		return fmt.Errorf("failed to write packfile: %w", err)
+	}
+
+	req.Packfile = io.NopCloser(pack)
+	return nil
+}
@@ -15,6 +15,8 @@
 package util
 
 import (
+	"time"
+
 	"github.com/codefresh-io/cli-v2/pkg/store"
 
 	apstore "github.com/argoproj-labs/argocd-autopilot/pkg/store"
@@ -40,8 +42,17 @@ type (
 		Namespace          string
 		ServiceAccountName string
 		EventBusName       string
+		Labels             map[string]string
 		Resource           map[string]CreateResourceEventSourceOptions
 		Generic            map[string]CreateGenericEventSourceOptions
+		Placement          PodPlacement
+	}
+
+	// PodPlacement is where the pods of an event source or a sensor are scheduled
+	PodPlacement struct {
+		NodeSelector map[string]string
+		Tolerations  []v1.Toleration
+		Affinity     *v1.Affinity
 	}
 
 	CreateResourceEventSourceOptions struct {
@@ -70,9 +81,14 @@ type (
 		EventSourceName string
 		EventName       string
 		EventBusName    string
+		Labels          map[string]string
 		TriggerURL      string
 		Triggers        []string
 		TriggerDestKey  string
+		// RetrySteps and RetryInterval are the retry policy of the triggers, the defaults are used when they are not set
+		RetrySteps    int32
+		RetryInterval time.Duration
+		Placement     PodPlacement
 	}
 
 	createTriggerOptions struct {
@@ -80,9 +96,16 @@ type (
 		URL            string
 		DependencyName string
 		DataDestKey    string
+		RetrySteps     int32
+		RetryInterval  time.Duration
 	}
 )
 
+const (
+	DefaultTriggerRetrySteps    = 3
+	DefaultTriggerRetryInterval = 3 * time.Second
+)
+
 func CreateEventDependency(opts *CreateEventDependencyOptions) *sensorsv1alpha1.EventDependency {
 	return &sensorsv1alpha1.EventDependency{
 		Name:            opts.Name,
@@ -119,6 +142,10 @@ func CreateEventSource(opts *CreateEventSourceOptions) *eventsourcev1alpha1.Even
 		tpl.ServiceAccountName = opts.ServiceAccountName
 	}
 
+	tpl.NodeSelector = opts.Placement.NodeSelector
+	tpl.Tolerations = opts.Placement.Tolerations
+	tpl.Affinity = opts.Placement.Affinity
+
 	return &eventsourcev1alpha1.EventSource{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       eventsourcereg.Kind,
@@ -127,9 +154,7 @@ func CreateEventSource(opts *CreateEventSourceOptions) *eventsourcev1alpha1.Even
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      opts.Name,
 			Namespace: opts.Namespace,
-			Labels: map[string]string{
-				apstore.Default.LabelKeyAppManagedBy: store.Get().BinaryName,
-			},
+			Labels:    createLabels(opts.Labels),
 		},
 		Spec: eventsourcev1alpha1.EventSourceSpec{
 			Template:     tpl,
@@ -200,10 +225,17 @@ func CreateSensor(opts *CreateSensorOptions) *sensorsv1alpha1.Sensor {
 			URL:            opts.TriggerURL,
 			DependencyName: trigger,
 			DataDestKey:    opts.TriggerDestKey,
+			RetrySteps:     opts.RetrySteps,
+			RetryInterval:  opts.RetryInterval,
 		})
 	}
 
-	tpl := &sensorsv1alpha1.Template{Container: &v1.Container{}}
+	tpl := &sensorsv1alpha1.Template{
+		Container:    &v1.Container{},
+		NodeSelector: opts.Placement.NodeSelector,
+		Tolerations:  opts.Placement.Tolerations,
+		Affinity:     opts.Placement.Affinity,
+	}
 
 	if store.Get().SetDefaultResources {
 		SetDefaultResourceRequirements(tpl.Container)
@@ -217,9 +249,7 @@ func CreateSensor(opts *CreateSensorOptions) *sensorsv1alpha1.Sensor {
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      opts.Name,
 			Namespace: opts.Namespace,
-			Labels: map[string]string{
-				apstore.Default.LabelKeyAppManagedBy: store.Get().BinaryName,
-			},
+			Labels:    createLabels(opts.Labels),
 		},
 		Spec: sensorsv1alpha1.SensorSpec{
 			Template:     tpl,
@@ -230,7 +260,31 @@ func CreateSensor(opts *CreateSensorOptions) *sensorsv1alpha1.Sensor {
 	}
 }
 
+// createLabels adds the managed-by label to the extra labels of an event source or a sensor
+func createLabels(extra map[string]string) map[string]string {
+	labels := map[string]string{
+		apstore.Default.LabelKeyAppManagedBy: store.Get().BinaryName,
+	}
+	for k, v := range extra {
+		labels[k] = v
+	}
+
+	return labels
+}
+
 func createTrigger(opts *createTriggerOptions) *sensorsv1alpha1.Trigger {
+	steps := opts.RetrySteps
+	if steps == 0 {
+		steps = DefaultTriggerRetrySteps
+	}
+
+	interval := opts.RetryInterval
+	if interval == 0 {
+		interval = DefaultTriggerRetryInterval
+	}
+
+	duration := apicommon.FromString(interval.String())
+
 	return &sensorsv1alpha1.Trigger{
 		Template: &sensorsv1alpha1.TriggerTemplate{
 			Conditions: opts.Conditions,
@@ -266,10 +320,9 @@ func createTrigger(opts *createTriggerOptions) *sensorsv1alpha1.Trigger {
 			},
 		},
 		RetryStrategy: &apicommon.Backoff{
-			Steps: 3,
-			Duration: &apicommon.Int64OrString{
-				StrVal: "3s",
-			},
+			Steps: steps,
+			// a string duration must have the string type, or it is marshaled as the zero int
+			Duration: &duration,
 		},
 	}
 }
@@ -15,6 +15,8 @@
 package util
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 
 	netv1 "k8s.io/api/networking/v1"
@@ -24,7 +26,8 @@ import (
 type (
 	IngressController interface {
 		Name() string
-		Decorate(ingress *netv1.Ingress)
+		// Decorate adds the annotations the controller needs to the ingress. tls is false when the ingress host is served over plain http
+		Decorate(ingress *netv1.Ingress, tls bool)
 	}
 
 	baseController struct {
@@ -39,6 +42,46 @@ type (
 		baseController
 	}
 
+	ingressControllerTraefik struct {
+		baseController
+	}
+
+	// IngressResourcesProvider is implemented by the controllers that need more resources than the ingress itself
+	IngressResourcesProvider interface {
+		IngressResources(ingress *netv1.Ingress) []interface{}
+	}
+
+	// TraefikMiddleware is the traefik Middleware custom resource, limited to the stripPrefix middleware
+	TraefikMiddleware struct {
+		metav1.TypeMeta   `json:",inline"`
+		metav1.ObjectMeta `json:"metadata,omitempty"`
+		Spec              TraefikMiddlewareSpec `json:"spec"`
+	}
+
+	TraefikMiddlewareSpec struct {
+		StripPrefix *TraefikStripPrefix `json:"stripPrefix,omitempty"`
+	}
+
+	TraefikStripPrefix struct {
+		Prefixes []string `json:"prefixes"`
+	}
+
+	// TraefikServersTransport is the traefik ServersTransport custom resource, limited to the tls verification of the backend
+	TraefikServersTransport struct {
+		metav1.TypeMeta   `json:",inline"`
+		metav1.ObjectMeta `json:"metadata,omitempty"`
+		Spec              TraefikServersTransportSpec `json:"spec"`
+	}
+
+	TraefikServersTransportSpec struct {
+		InsecureSkipVerify bool `json:"insecureSkipVerify"`
+	}
+
+	// BackendAnnotationsProvider is implemented by the controllers that need annotations on the backend services of the ingress
+	BackendAnnotationsProvider interface {
+		BackendAnnotations(ingress *netv1.Ingress) map[string]string
+	}
+
 	IngressPath struct {
 		Path        string
 		PathType    netv1.PathType
@@ -52,6 +95,7 @@ type (
 		IngressClassName string
 		Annotations      map[string]string
 		Host             string
+		AdditionalHosts  []string
 		Paths            []IngressPath
 	}
 
@@ -68,13 +112,32 @@ const (
 	IngressControllerNginxCodefresh  ingressControllerType = "k8s.io/ingress-nginx-codefresh"
 )
 
+const (
+	traefikAPIVersion                 = "traefik.io/v1alpha1"
+	traefikEntrypointsAnnotation      = "traefik.ingress.kubernetes.io/router.entrypoints"
+	traefikTLSAnnotation              = "traefik.ingress.kubernetes.io/router.tls"
+	traefikMiddlewaresAnnotation      = "traefik.ingress.kubernetes.io/router.middlewares"
+	traefikServersSchemeAnnotation    = "traefik.ingress.kubernetes.io/service.serversscheme"
+	traefikServersTransportAnnotation = "traefik.ingress.kubernetes.io/service.serverstransport"
+	nginxRewriteTargetAnnotation      = "nginx.ingress.kubernetes.io/rewrite-target"
+	nginxBackendProtocolAnnotation    = "nginx.ingress.kubernetes.io/backend-protocol"
+	traefikStripPrefixSuffix          = "-strip-prefix"
+	traefikServersTransportNameSuffix = "-servers-transport"
+
+	// CertManagerClusterIssuerAnnotation makes cert-manager issue the certificate of the ingress tls hosts
+	CertManagerClusterIssuerAnnotation = "cert-manager.io/cluster-issuer"
+)
+
+// rewritePathRe matches the "/prefix(/|$)(.*)" paths, that are rewritten to "/$2" by nginx
+var rewritePathRe = regexp.MustCompile(`^(/.+)\(/\|\$\)\(\.\*\)$`)
+
 var SupportedControllers = []ingressControllerType{IngressControllerNginxCommunity, IngressControllerNginxEnterprise, IngressControllerIstio, IngressControllerTraefik, IngressControllerAmbassador, IngressControllerALB, IngressControllerNginxCodefresh}
 
 func (c baseController) Name() string {
 	return c.name
 }
 
-func (c baseController) Decorate(ingress *netv1.Ingress) {}
+func (c baseController) Decorate(ingress *netv1.Ingress, tls bool) {}
 
 func GetController(name string) IngressController {
 	b := baseController{name}
@@ -83,12 +146,14 @@ func GetController(name string) IngressController {
 		return ingressControllerALB{b}
 	case string(IngressControllerNginxEnterprise):
 		return ingressControllerNginxEnterprise{b}
+	case string(IngressControllerTraefik):
+		return ingressControllerTraefik{b}
 	default:
 		return b
 	}
 }
 
-func (ingressControllerALB) Decorate(ingress *netv1.Ingress) {
+func (ingressControllerALB) Decorate(ingress *netv1.Ingress, tls bool) {
 	if ingress.Annotations == nil {
 		ingress.Annotations = make(map[string]string)
 	}
@@ -97,13 +162,159 @@ func (ingressControllerALB) Decorate(ingress *netv1.Ingress) {
 	ingress.Annotations["alb.ingress.kubernetes.io/target-type"] = "ip"
 }
 
-func (ingressControllerNginxEnterprise) Decorate(ingress *netv1.Ingress) {
+func (ingressControllerNginxEnterprise) Decorate(ingress *netv1.Ingress, tls bool) {
 	if ingress.Annotations == nil {
 		ingress.Annotations = make(map[string]string)
 	}
 	ingress.Annotations["nginx.org/mergeable-ingress-type"] = "minion"
 }
 
+// Decorate routes the ingress through the websecure entrypoint, or the web entrypoint of a plain http host.
+// The "/$2" rewrite of nginx is replaced with a stripPrefix middleware, which is returned by IngressResources
+func (ingressControllerTraefik) Decorate(ingress *netv1.Ingress, tls bool) {
+	if ingress.Annotations == nil {
+		ingress.Annotations = make(map[string]string)
+	}
+
+	if tls {
+		ingress.Annotations[traefikEntrypointsAnnotation] = "websecure"
+		ingress.Annotations[traefikTLSAnnotation] = "true"
+	} else {
+		ingress.Annotations[traefikEntrypointsAnnotation] = "web"
+	}
+
+	if ingress.Annotations[nginxRewriteTargetAnnotation] != "/$2" {
+		return
+	}
+
+	rewritten := false
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for i := range rule.HTTP.Paths {
+			match := rewritePathRe.FindStringSubmatch(rule.HTTP.Paths[i].Path)
+			if match == nil {
+				continue
+			}
+
+			pathType := netv1.PathTypePrefix
+			rule.HTTP.Paths[i].Path = match[1]
+			rule.HTTP.Paths[i].PathType = &pathType
+			rewritten = true
+		}
+	}
+
+	if rewritten {
+		ingress.Annotations[traefikMiddlewaresAnnotation] = fmt.Sprintf("%s-%s%s@kubernetescrd", ingress.Namespace, ingress.Name, traefikStripPrefixSuffix)
+	}
+}
+
+// IngressResources returns the stripPrefix middleware of an ingress that was decorated with one,
+// and the servers transport of an https backend
+func (ingressControllerTraefik) IngressResources(ingress *netv1.Ingress) []interface{} {
+	var resources []interface{}
+	if ingress.Annotations[traefikMiddlewaresAnnotation] != "" {
+		resources = append(resources, traefikStripPrefixMiddleware(ingress))
+	}
+
+	if isHTTPSBackend(ingress) {
+		// the backends serve self-signed certificates (e.g. argo-server)
+		resources = append(resources, &TraefikServersTransport{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: traefikAPIVersion,
+				Kind:       "ServersTransport",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ingress.Name + traefikServersTransportNameSuffix,
+				Namespace: ingress.Namespace,
+			},
+			Spec: TraefikServersTransportSpec{
+				InsecureSkipVerify: true,
+			},
+		})
+	}
+
+	return resources
+}
+
+// BackendAnnotations makes traefik reach an https backend over https, through the servers transport of the ingress
+func (ingressControllerTraefik) BackendAnnotations(ingress *netv1.Ingress) map[string]string {
+	if !isHTTPSBackend(ingress) {
+		return nil
+	}
+
+	return map[string]string{
+		traefikServersSchemeAnnotation:    "https",
+		traefikServersTransportAnnotation: fmt.Sprintf("%s-%s%s@kubernetescrd", ingress.Namespace, ingress.Name, traefikServersTransportNameSuffix),
+	}
+}
+
+// isHTTPSBackend is true for an ingress whose backends are reached over https by nginx
+func isHTTPSBackend(ingress *netv1.Ingress) bool {
+	return strings.EqualFold(ingress.Annotations[nginxBackendProtocolAnnotation], "https")
+}
+
+func traefikStripPrefixMiddleware(ingress *netv1.Ingress) *TraefikMiddleware {
+	var prefixes []string
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for _, p := range rule.HTTP.Paths {
+			if !containsString(prefixes, p.Path) {
+				prefixes = append(prefixes, p.Path)
+			}
+		}
+	}
+
+	return &TraefikMiddleware{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: traefikAPIVersion,
+			Kind:       "Middleware",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ingress.Name + traefikStripPrefixSuffix,
+			Namespace: ingress.Namespace,
+		},
+		Spec: TraefikMiddlewareSpec{
+			StripPrefix: &TraefikStripPrefix{
+				Prefixes: prefixes,
+			},
+		},
+	}
+}
+
+// IngressResources returns the resources the controller needs for the ingress, besides the ingress itself
+func IngressResources(c IngressController, ingress *netv1.Ingress) []interface{} {
+	if provider, ok := c.(IngressResourcesProvider); ok {
+		return provider.IngressResources(ingress)
+	}
+
+	return nil
+}
+
+// BackendAnnotations returns the annotations the controller needs on the backend services of the ingress
+func BackendAnnotations(c IngressController, ingress *netv1.Ingress) map[string]string {
+	if provider, ok := c.(BackendAnnotationsProvider); ok {
+		return provider.BackendAnnotations(ingress)
+	}
+
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
 func createHTTPIngressPaths(paths []IngressPath) []netv1.HTTPIngressPath {
 	httpIngressPaths := make([]netv1.HTTPIngressPath, 0, len(paths))
 	for _, p := range paths {
@@ -136,21 +347,30 @@ func CreateIngress(opts *CreateIngressOptions) *netv1.Ingress {
 			Namespace: opts.Namespace,
 			Name:      opts.Name,
 		},
-		Spec: netv1.IngressSpec{
-			Rules: []netv1.IngressRule{
-				{
-					Host: opts.Host,
-				},
-			},
-		},
 	}
 
-	if len(opts.Paths) > 0 {
-		ingress.Spec.Rules[0].IngressRuleValue = netv1.IngressRuleValue{
-			HTTP: &netv1.HTTPIngressRuleValue{
-				Paths: createHTTPIngressPaths(opts.Paths),
-			},
+	// the same paths are served on every host. an ip ingress host has no host name, so its rule omits the host on
+	// purpose, to match the requests to any host. several ip hosts share that one rule
+	added := map[string]bool{}
+	for _, host := range append([]string{opts.Host}, opts.AdditionalHosts...) {
+		if added[host] {
+			continue
+		}
+
+		added[host] = true
+		rule := netv1.IngressRule{
+			Host: host,
+		}
+
+		if len(opts.Paths) > 0 {
+			rule.IngressRuleValue = netv1.IngressRuleValue{
+				HTTP: &netv1.HTTPIngressRuleValue{
+					Paths: createHTTPIngressPaths(opts.Paths),
+				},
+			}
 		}
+
+		ingress.Spec.Rules = append(ingress.Spec.Rules, rule)
 	}
 
 	if opts.IngressClassName != "" {
@@ -163,3 +383,28 @@ func CreateIngress(opts *CreateIngressOptions) *netv1.Ingress {
 
 	return ingress
 }
+
+// SetCertManagerTLS adds the cluster issuer annotation and a tls section with all of the ingress hosts,
+// so cert-manager issues their certificate into secretName
+func SetCertManagerTLS(ingress *netv1.Ingress, clusterIssuer, secretName string) {
+	if ingress.Annotations == nil {
+		ingress.Annotations = make(map[string]string)
+	}
+
+	ingress.Annotations[CertManagerClusterIssuerAnnotation] = clusterIssuer
+
+	var hosts []string
+	for _, rule := range ingress.Spec.Rules {
+		// the rule of an ip ingress host has no host to issue a certificate for
+		if rule.Host != "" {
+			hosts = append(hosts, rule.Host)
+		}
+	}
+
+	ingress.Spec.TLS = []netv1.IngressTLS{
+		{
+			Hosts:      hosts,
+			SecretName: secretName,
+		},
+	}
+}
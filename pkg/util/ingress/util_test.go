@@ -0,0 +1,182 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	netv1 "k8s.io/api/networking/v1"
+)
+
+func TestCreateIngress(t *testing.T) {
+	tests := map[string]struct {
+		host            string
+		additionalHosts []string
+		wantHosts       []string
+	}{
+		"should create a single rule": {
+			host:      "host.com",
+			wantHosts: []string{"host.com"},
+		},
+		"should create a rule for every host": {
+			host:            "host.com",
+			additionalHosts: []string{"host-b.com", "host-c.com"},
+			wantHosts:       []string{"host.com", "host-b.com", "host-c.com"},
+		},
+		"should omit the host of an ip ingress host": {
+			wantHosts: []string{""},
+		},
+		"should add a single rule without a host for several ip ingress hosts": {
+			additionalHosts: []string{"", "host-b.com", ""},
+			wantHosts:       []string{"", "host-b.com"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ingress := CreateIngress(&CreateIngressOptions{
+				Name:            "ingress",
+				Host:            tt.host,
+				AdditionalHosts: tt.additionalHosts,
+				Paths: []IngressPath{
+					{
+						Path:        "/app-proxy",
+						PathType:    netv1.PathTypePrefix,
+						ServiceName: "cap-app-proxy",
+						ServicePort: 3017,
+					},
+				},
+			})
+
+			assert.Len(t, ingress.Spec.Rules, len(tt.wantHosts))
+			for i, rule := range ingress.Spec.Rules {
+				assert.Equal(t, tt.wantHosts[i], rule.Host)
+				assert.Len(t, rule.HTTP.Paths, 1)
+				assert.Equal(t, "/app-proxy", rule.HTTP.Paths[0].Path)
+			}
+		})
+	}
+}
+
+func Test_ingressControllerTraefik(t *testing.T) {
+	ingress := CreateIngress(&CreateIngressOptions{
+		Name:      "runtime-workflows-ingress",
+		Namespace: "runtime",
+		Host:      "host.com",
+		Annotations: map[string]string{
+			"nginx.ingress.kubernetes.io/rewrite-target":   "/$2",
+			"nginx.ingress.kubernetes.io/backend-protocol": "https",
+		},
+		Paths: []IngressPath{
+			{
+				Path:        "/workflows(/|$)(.*)",
+				PathType:    netv1.PathTypeImplementationSpecific,
+				ServiceName: "argo-server",
+				ServicePort: 2746,
+			},
+		},
+	})
+
+	controller := GetController(string(IngressControllerTraefik))
+	assert.Equal(t, string(IngressControllerTraefik), controller.Name())
+	controller.Decorate(ingress, true)
+
+	assert.Equal(t, "websecure", ingress.Annotations["traefik.ingress.kubernetes.io/router.entrypoints"])
+	assert.Equal(t, "runtime-runtime-workflows-ingress-strip-prefix@kubernetescrd", ingress.Annotations["traefik.ingress.kubernetes.io/router.middlewares"])
+	path := ingress.Spec.Rules[0].HTTP.Paths[0]
+	assert.Equal(t, "/workflows", path.Path)
+	assert.Equal(t, netv1.PathTypePrefix, *path.PathType)
+
+	resources := IngressResources(controller, ingress)
+	assert.Len(t, resources, 2)
+	middleware := resources[0].(*TraefikMiddleware)
+	assert.Equal(t, "traefik.io/v1alpha1", middleware.APIVersion)
+	assert.Equal(t, "runtime-workflows-ingress-strip-prefix", middleware.Name)
+	assert.Equal(t, "runtime", middleware.Namespace)
+	assert.Equal(t, []string{"/workflows"}, middleware.Spec.StripPrefix.Prefixes)
+	transport := resources[1].(*TraefikServersTransport)
+	assert.Equal(t, "ServersTransport", transport.Kind)
+	assert.Equal(t, "runtime-workflows-ingress-servers-transport", transport.Name)
+	assert.True(t, transport.Spec.InsecureSkipVerify)
+
+	assert.Equal(t, map[string]string{
+		"traefik.ingress.kubernetes.io/service.serversscheme":    "https",
+		"traefik.ingress.kubernetes.io/service.serverstransport": "runtime-runtime-workflows-ingress-servers-transport@kubernetescrd",
+	}, BackendAnnotations(controller, ingress))
+	assert.Empty(t, BackendAnnotations(GetController(string(IngressControllerNginxCommunity)), ingress))
+}
+
+func Test_ingressControllerTraefik_noRewrite(t *testing.T) {
+	ingress := CreateIngress(&CreateIngressOptions{
+		Name: "runtime-cap-app-proxy",
+		Host: "host.com",
+		Paths: []IngressPath{
+			{
+				Path:        "/app-proxy",
+				PathType:    netv1.PathTypePrefix,
+				ServiceName: "cap-app-proxy",
+				ServicePort: 3017,
+			},
+		},
+	})
+
+	controller := GetController(string(IngressControllerTraefik))
+	controller.Decorate(ingress, true)
+
+	assert.Equal(t, "true", ingress.Annotations["traefik.ingress.kubernetes.io/router.tls"])
+	assert.Empty(t, ingress.Annotations["traefik.ingress.kubernetes.io/router.middlewares"])
+	assert.Empty(t, IngressResources(controller, ingress))
+	assert.Empty(t, BackendAnnotations(controller, ingress))
+	assert.Empty(t, IngressResources(GetController(string(IngressControllerNginxCommunity)), ingress))
+}
+
+func Test_ingressControllerTraefik_insecureHost(t *testing.T) {
+	ingress := CreateIngress(&CreateIngressOptions{
+		Name: "runtime-cap-app-proxy",
+		Host: "host.com",
+		Paths: []IngressPath{
+			{
+				Path:        "/app-proxy",
+				PathType:    netv1.PathTypePrefix,
+				ServiceName: "cap-app-proxy",
+				ServicePort: 3017,
+			},
+		},
+	})
+
+	GetController(string(IngressControllerTraefik)).Decorate(ingress, false)
+
+	assert.Equal(t, "web", ingress.Annotations["traefik.ingress.kubernetes.io/router.entrypoints"])
+	assert.Empty(t, ingress.Annotations["traefik.ingress.kubernetes.io/router.tls"])
+}
+
+func TestSetCertManagerTLS(t *testing.T) {
+	ingress := CreateIngress(&CreateIngressOptions{
+		Name:            "runtime-cap-app-proxy",
+		Namespace:       "runtime",
+		Host:            "host.com",
+		AdditionalHosts: []string{"alt.host.com", ""},
+	})
+
+	SetCertManagerTLS(ingress, "letsencrypt", "runtime-cap-app-proxy-tls")
+
+	assert.Equal(t, "letsencrypt", ingress.Annotations[CertManagerClusterIssuerAnnotation])
+	assert.Equal(t, []netv1.IngressTLS{
+		{
+			Hosts:      []string{"host.com", "alt.host.com"},
+			SecretName: "runtime-cap-app-proxy-tls",
+		},
+	}, ingress.Spec.TLS)
+}
@@ -24,16 +24,24 @@ import (
 
 	"github.com/codefresh-io/cli-v2/pkg/log"
 	"github.com/codefresh-io/cli-v2/pkg/store"
+	"github.com/codefresh-io/cli-v2/pkg/util"
 
 	"github.com/argoproj-labs/argocd-autopilot/pkg/kube"
 	authv1 "k8s.io/api/authorization/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
 )
 
 type (
@@ -61,6 +69,9 @@ type (
 	}
 )
 
+// TotalAnnotationSizeLimitB is the api-server limit on the total size of the annotations of a resource
+const TotalAnnotationSizeLimitB = 256 * (1 << 10) // 256 kB
+
 func EnsureClusterRequirements(ctx context.Context, kubeFactory kube.Factory, namespace string, contextUrl string) error {
 	requirementsValidationErrorMessage := "cluster does not meet minimum requirements"
 	var specificErrorMessages []string
@@ -410,6 +421,38 @@ func testRBAC(ctx context.Context, client kubernetes.Interface, specs []*authv1.
 	return res
 }
 
+// CheckServiceAccountAccess returns a message for every resource attribute the service account is not allowed.
+// It uses SubjectAccessReviews, since a SelfSubjectAccessReview can only review the current user
+func CheckServiceAccountAccess(ctx context.Context, client kubernetes.Interface, namespace, saName string, attrs []authv1.ResourceAttributes) []string {
+	res := []string{}
+	user := fmt.Sprintf("system:serviceaccount:%s:%s", namespace, saName)
+	for i := range attrs {
+		sar := &authv1.SubjectAccessReview{
+			Spec: authv1.SubjectAccessReviewSpec{
+				User:               user,
+				Groups:             []string{"system:serviceaccounts", "system:serviceaccounts:" + namespace},
+				ResourceAttributes: &attrs[i],
+			},
+		}
+		resp, err := client.AuthorizationV1().SubjectAccessReviews().Create(ctx, sar, metav1.CreateOptions{})
+		if err != nil {
+			res = append(res, err.Error())
+			continue
+		}
+
+		if !resp.Status.Allowed {
+			msg := fmt.Sprintf("service account %s is not allowed to %s %s/%s", saName, attrs[i].Verb, attrs[i].Group, attrs[i].Resource)
+			if attrs[i].Namespace != "" {
+				msg += fmt.Sprintf(" on namespace %s", attrs[i].Namespace)
+			}
+
+			res = append(res, msg)
+		}
+	}
+
+	return res
+}
+
 func testNode(n v1.Node, req validationRequest) []string {
 	result := []string{}
 
@@ -533,3 +576,238 @@ func CheckNamespaceExists(ctx context.Context, namespace string, kubeFactory kub
 
 	return true, nil
 }
+
+// MergeNamespaceLabels adds the labels to an existing namespace, keeping the labels it already has
+func MergeNamespaceLabels(ctx context.Context, client kubernetes.Interface, namespace string, labels map[string]string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	ns, err := client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+
+	AddLabelsAndAnnotations(&ns.ObjectMeta, labels, nil)
+	if _, err = client.CoreV1().Namespaces().Update(ctx, ns, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update namespace %s: %w", namespace, err)
+	}
+
+	return nil
+}
+
+// AddNamespaceFinalizers adds the finalizers to the namespace, and records them in an annotation,
+// so RemoveNamespaceFinalizers can remove only them on uninstall
+func AddNamespaceFinalizers(ctx context.Context, client kubernetes.Interface, namespace string, finalizers []string) error {
+	if len(finalizers) == 0 {
+		return nil
+	}
+
+	ns, err := client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+
+	for _, finalizer := range finalizers {
+		if util.StringIndexOf(ns.Finalizers, finalizer) == -1 {
+			ns.Finalizers = append(ns.Finalizers, finalizer)
+		}
+	}
+
+	AddLabelsAndAnnotations(&ns.ObjectMeta, nil, map[string]string{
+		store.Get().AnnotationKeyNamespaceFinalizers: strings.Join(finalizers, ","),
+	})
+	if _, err = client.CoreV1().Namespaces().Update(ctx, ns, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update namespace %s: %w", namespace, err)
+	}
+
+	return nil
+}
+
+// RemoveNamespaceFinalizers removes the finalizers added by AddNamespaceFinalizers, so the namespace
+// is not left terminating after the runtime is uninstalled
+func RemoveNamespaceFinalizers(ctx context.Context, client kubernetes.Interface, namespace string) error {
+	ns, err := client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+
+	added, ok := ns.Annotations[store.Get().AnnotationKeyNamespaceFinalizers]
+	if !ok {
+		return nil
+	}
+
+	addedFinalizers := strings.Split(added, ",")
+	finalizers := make([]string, 0, len(ns.Finalizers))
+	for _, finalizer := range ns.Finalizers {
+		if util.StringIndexOf(addedFinalizers, finalizer) == -1 {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+
+	ns.Finalizers = finalizers
+	delete(ns.Annotations, store.Get().AnnotationKeyNamespaceFinalizers)
+	if _, err = client.CoreV1().Namespaces().Update(ctx, ns, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update namespace %s: %w", namespace, err)
+	}
+
+	return nil
+}
+
+// ValidateAnnotationsSize fails if the total size of the annotations is over the api-server limit,
+// which is otherwise only reported by the api-server when the resource is applied
+func ValidateAnnotationsSize(annotations map[string]string) error {
+	totalSize := 0
+	for k, v := range annotations {
+		totalSize += len(k) + len(v)
+	}
+
+	if totalSize > TotalAnnotationSizeLimitB {
+		return fmt.Errorf("annotations total size is %d bytes, which is over the limit of %d bytes", totalSize, TotalAnnotationSizeLimitB)
+	}
+
+	return nil
+}
+
+// AddLabelsAndAnnotations adds the labels and annotations to the object metadata, initializing its maps when needed.
+// It does not override existing keys, so the user metadata does not clobber the codefresh-managed labels and annotations
+func AddLabelsAndAnnotations(meta *metav1.ObjectMeta, labels, annotations map[string]string) {
+	if len(labels) > 0 && meta.Labels == nil {
+		meta.Labels = make(map[string]string, len(labels))
+	}
+
+	for k, v := range labels {
+		if _, exists := meta.Labels[k]; !exists {
+			meta.Labels[k] = v
+		}
+	}
+
+	if len(annotations) > 0 && meta.Annotations == nil {
+		meta.Annotations = make(map[string]string, len(annotations))
+	}
+
+	for k, v := range annotations {
+		if _, exists := meta.Annotations[k]; !exists {
+			meta.Annotations[k] = v
+		}
+	}
+}
+
+// CheckArgoCDCRDs fails if Argo CD CRDs already exist in the cluster, without serving the version the runtime expects
+func CheckArgoCDCRDs(ctx context.Context, client kubernetes.Interface) error {
+	groups, err := client.Discovery().ServerGroups()
+	if err != nil {
+		return fmt.Errorf("failed to get the cluster's api groups: %w", err)
+	}
+
+	var versions []string
+	for _, g := range groups.Groups {
+		if g.Name != store.Get().ArgoCDCRDsGroup {
+			continue
+		}
+
+		for _, v := range g.Versions {
+			versions = append(versions, v.Version)
+		}
+	}
+
+	// crd name -> the versions it is served in
+	served := map[string][]string{}
+	for _, v := range versions {
+		gv := fmt.Sprintf("%s/%s", store.Get().ArgoCDCRDsGroup, v)
+		resources, err := client.Discovery().ServerResourcesForGroupVersion(gv)
+		if err != nil {
+			return fmt.Errorf("failed to get the resources of \"%s\": %w", gv, err)
+		}
+
+		for _, r := range resources.APIResources {
+			served[r.Name] = append(served[r.Name], v)
+		}
+	}
+
+	for _, crd := range store.Get().ArgoCDCRDs {
+		crdVersions, ok := served[crd]
+		if !ok {
+			continue
+		}
+
+		crdName := fmt.Sprintf("%s.%s", crd, store.Get().ArgoCDCRDsGroup)
+		if util.StringIndexOf(crdVersions, store.Get().ArgoCDCRDsVersion) == -1 {
+			return fmt.Errorf("crd \"%s\" is installed with version(s) %s, but the runtime requires %s. remove or upgrade the existing Argo CD installation before installing the runtime", crdName, strings.Join(crdVersions, ", "), store.Get().ArgoCDCRDsVersion)
+		}
+
+		log.G(ctx).Warnf("Found an existing crd \"%s\", it will be updated by the runtime installation", crdName)
+	}
+
+	return nil
+}
+
+// Apply applies the manifests with the client-side apply of the factory, or with server-side apply when serverSide is set
+func Apply(ctx context.Context, f kube.Factory, manifests []byte, serverSide bool) error {
+	if !serverSide {
+		return f.Apply(ctx, manifests)
+	}
+
+	rc, err := f.ToRESTConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get rest config: %w", err)
+	}
+
+	client, err := dynamic.NewForConfig(rc)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	cs, err := f.KubernetesClientSet()
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(cs.Discovery()))
+	return ServerSideApply(ctx, client, mapper, manifests, store.Get().BinaryName)
+}
+
+// ServerSideApply applies the (multi document) manifests with server-side apply, as fieldManager.
+// Unlike client-side apply, fields owned by other managers are not overwritten, but reported as conflicts
+func ServerSideApply(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, manifests []byte, fieldManager string) error {
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifests), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return fmt.Errorf("failed to decode manifests: %w", err)
+		}
+
+		if len(obj.Object) == 0 {
+			continue
+		}
+
+		gvk := obj.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return fmt.Errorf("failed to get the resource of \"%s\": %w", gvk.String(), err)
+		}
+
+		data, err := obj.MarshalJSON()
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s \"%s\": %w", gvk.Kind, obj.GetName(), err)
+		}
+
+		var ri dynamic.ResourceInterface = client.Resource(mapping.Resource)
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			ri = client.Resource(mapping.Resource).Namespace(obj.GetNamespace())
+		}
+
+		_, err = ri.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: fieldManager})
+		if err != nil {
+			return fmt.Errorf("failed to apply %s \"%s\": %w", gvk.Kind, obj.GetName(), err)
+		}
+	}
+}
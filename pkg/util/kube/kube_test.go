@@ -0,0 +1,166 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codefresh-io/cli-v2/pkg/store"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCheckArgoCDCRDs(t *testing.T) {
+	tests := map[string]struct {
+		resources []*metav1.APIResourceList
+		wantErr   string
+	}{
+		"should pass when there are no argo cd crds": {
+			resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "v1",
+					APIResources: []metav1.APIResource{{Name: "pods"}},
+				},
+			},
+		},
+		"should pass when the crds have a compatible version": {
+			resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "argoproj.io/v1alpha1",
+					APIResources: []metav1.APIResource{{Name: "applications"}, {Name: "applicationsets"}},
+				},
+			},
+		},
+		"should fail when the crds have an incompatible version": {
+			resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "argoproj.io/v1beta1",
+					APIResources: []metav1.APIResource{{Name: "applications"}},
+				},
+			},
+			wantErr: "crd \"applications.argoproj.io\" is installed with version(s) v1beta1, but the runtime requires v1alpha1",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			client.Discovery().(*fakediscovery.FakeDiscovery).Resources = tt.resources
+
+			err := CheckArgoCDCRDs(context.Background(), client)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestServerSideApply(t *testing.T) {
+	client := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme())
+	var patches []k8stesting.PatchActionImpl
+	client.PrependReactor("patch", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patches = append(patches, action.(k8stesting.PatchActionImpl))
+		return true, &unstructured.Unstructured{}, nil
+	})
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, meta.RESTScopeNamespace)
+
+	manifests := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: codefresh-token
+  namespace: runtime
+stringData:
+  token: abc
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: argocd-token
+  namespace: runtime
+`)
+	assert.NoError(t, ServerSideApply(context.Background(), client, mapper, manifests, "cf"))
+	assert.Len(t, patches, 2)
+	for i, name := range []string{"codefresh-token", "argocd-token"} {
+		assert.Equal(t, types.ApplyPatchType, patches[i].GetPatchType())
+		assert.Equal(t, name, patches[i].GetName())
+		assert.Equal(t, "runtime", patches[i].GetNamespace())
+	}
+
+	assert.Contains(t, string(patches[0].GetPatch()), `"stringData":{"token":"abc"}`)
+}
+
+func TestNamespaceFinalizers(t *testing.T) {
+	client := fake.NewSimpleClientset(&v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "runtime", Finalizers: []string{"other.io/keep"}},
+	})
+	ctx := context.Background()
+
+	assert.NoError(t, AddNamespaceFinalizers(ctx, client, "runtime", []string{"example.com/cleanup"}))
+	ns, err := client.CoreV1().Namespaces().Get(ctx, "runtime", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"other.io/keep", "example.com/cleanup"}, ns.Finalizers)
+
+	assert.NoError(t, RemoveNamespaceFinalizers(ctx, client, "runtime"))
+	ns, err = client.CoreV1().Namespaces().Get(ctx, "runtime", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"other.io/keep"}, ns.Finalizers)
+	assert.NotContains(t, ns.Annotations, store.Get().AnnotationKeyNamespaceFinalizers)
+
+	assert.NoError(t, RemoveNamespaceFinalizers(ctx, client, "missing"))
+}
+
+func TestMergeNamespaceLabels(t *testing.T) {
+	client := fake.NewSimpleClientset(&v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "runtime", Labels: map[string]string{"team": "platform"}},
+	})
+	ctx := context.Background()
+
+	assert.NoError(t, MergeNamespaceLabels(ctx, client, "runtime", map[string]string{"team": "runtime", "env": "prod"}))
+	ns, err := client.CoreV1().Namespaces().Get(ctx, "runtime", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "platform", "env": "prod"}, ns.Labels)
+
+	assert.Error(t, MergeNamespaceLabels(ctx, client, "missing", map[string]string{"env": "prod"}))
+}
+
+func TestAddLabelsAndAnnotations(t *testing.T) {
+	meta := &metav1.ObjectMeta{
+		Labels: map[string]string{"app.kubernetes.io/managed-by": "codefresh"},
+	}
+
+	AddLabelsAndAnnotations(meta,
+		map[string]string{"app.kubernetes.io/managed-by": "someone-else", "policy/owner": "cli"},
+		map[string]string{"policy/team": "platform"},
+	)
+
+	assert.Equal(t, map[string]string{"app.kubernetes.io/managed-by": "codefresh", "policy/owner": "cli"}, meta.Labels)
+	assert.Equal(t, map[string]string{"policy/team": "platform"}, meta.Annotations)
+}
@@ -19,6 +19,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/argoproj-labs/argocd-autopilot/pkg/fs"
 	"github.com/ghodss/yaml"
@@ -29,6 +30,55 @@ import (
 
 var KUSTOMOZATION_FILE_NAME = "kustomization.yaml"
 
+// BuildOptions are the kustomize build flags some overlays require, e.g. to inflate helm charts
+type BuildOptions struct {
+	EnableHelm     bool
+	LoadRestrictor string
+}
+
+// Validate fails on a load restrictor that kustomize does not support
+func (o *BuildOptions) Validate() error {
+	switch o.LoadRestrictor {
+	case "", kusttypes.LoadRestrictionsRootOnly.String(), kusttypes.LoadRestrictionsNone.String():
+		return nil
+	}
+
+	return fmt.Errorf("invalid kustomize load restrictor \"%s\", must be one of: %s, %s", o.LoadRestrictor, kusttypes.LoadRestrictionsRootOnly, kusttypes.LoadRestrictionsNone)
+}
+
+// Args returns the options as "kustomize build" flags, as argo-cd expects them in "kustomize.buildOptions"
+func (o *BuildOptions) Args() string {
+	var args []string
+	if o.EnableHelm {
+		args = append(args, "--enable-helm")
+	}
+
+	if o.LoadRestrictor != "" {
+		args = append(args, "--load-restrictor", o.LoadRestrictor)
+	}
+
+	return strings.Join(args, " ")
+}
+
+func (o *BuildOptions) krustyOptions() *krusty.Options {
+	opts := krusty.MakeDefaultOptions()
+	opts.DoLegacyResourceSort = true
+	if o == nil {
+		return opts
+	}
+
+	if o.EnableHelm {
+		opts.PluginConfig.HelmConfig.Enabled = true
+		opts.PluginConfig.HelmConfig.Command = "helm"
+	}
+
+	if o.LoadRestrictor == kusttypes.LoadRestrictionsNone.String() {
+		opts.LoadRestrictions = kusttypes.LoadRestrictionsNone
+	}
+
+	return opts
+}
+
 func ReadKustomization(fs fs.FS, directory string) (*kusttypes.Kustomization, error) {
 	fileName := fs.Join(directory, KUSTOMOZATION_FILE_NAME)
 	kust := &kusttypes.Kustomization{}
@@ -65,7 +115,7 @@ func WriteKustomization(fs fs.FS, kust *kusttypes.Kustomization, directory strin
 	return fs.WriteYamls(fileName, kust)
 }
 
-func BuildKustomization(k *kusttypes.Kustomization) ([]byte, error) {
+func BuildKustomization(k *kusttypes.Kustomization, buildOpts *BuildOptions) ([]byte, error) {
 	td, err := ioutil.TempDir(".", "csdp-add-cluster")
 	if err != nil {
 		return nil, err
@@ -82,9 +132,7 @@ func BuildKustomization(k *kusttypes.Kustomization) ([]byte, error) {
 		return nil, err
 	}
 
-	opts := krusty.MakeDefaultOptions()
-	opts.DoLegacyResourceSort = true
-	kust := krusty.MakeKustomizer(opts)
+	kust := krusty.MakeKustomizer(buildOpts.krustyOptions())
 	fs := filesys.MakeFsOnDisk()
 	res, err := kust.Run(fs, td)
 	if err != nil {
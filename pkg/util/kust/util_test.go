@@ -0,0 +1,84 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	kusttypes "sigs.k8s.io/kustomize/api/types"
+)
+
+func TestBuildKustomization_buildOptions(t *testing.T) {
+	// a resource outside of the kustomization root, which only the LoadRestrictionsNone restrictor allows
+	resource := filepath.Join(t.TempDir(), "cm.yaml")
+	cm := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: shared\n"
+	if err := os.WriteFile(resource, []byte(cm), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	k := &kusttypes.Kustomization{
+		TypeMeta:  kusttypes.TypeMeta{APIVersion: kusttypes.KustomizationVersion, Kind: kusttypes.KustomizationKind},
+		Resources: []string{resource},
+	}
+
+	if _, err := BuildKustomization(k, nil); err == nil {
+		t.Errorf("BuildKustomization() error = nil, want the default restrictor to refuse the resource")
+	}
+
+	manifests, err := BuildKustomization(k, &BuildOptions{LoadRestrictor: kusttypes.LoadRestrictionsNone.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(manifests), "name: shared") {
+		t.Errorf("BuildKustomization() = %s, want the shared config map", manifests)
+	}
+}
+
+func TestBuildOptions_Args(t *testing.T) {
+	tests := map[string]struct {
+		opts    BuildOptions
+		want    string
+		wantErr bool
+	}{
+		"should have no args by default": {},
+		"should add all of the options": {
+			opts: BuildOptions{EnableHelm: true, LoadRestrictor: "LoadRestrictionsNone"},
+			want: "--enable-helm --load-restrictor LoadRestrictionsNone",
+		},
+		"should fail on an unknown restrictor": {
+			opts:    BuildOptions{LoadRestrictor: "none"},
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := tt.opts.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got := tt.opts.Args(); got != tt.want {
+				t.Errorf("Args() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -21,25 +21,55 @@ import (
 	"github.com/codefresh-io/cli-v2/pkg/log"
 	"github.com/codefresh-io/cli-v2/pkg/store"
 	apu "github.com/codefresh-io/cli-v2/pkg/util/aputil"
-	kubeutil "github.com/codefresh-io/cli-v2/pkg/util/kube"
 
 	"github.com/argoproj-labs/argocd-autopilot/pkg/git"
 	"github.com/argoproj-labs/argocd-autopilot/pkg/kube"
 	apstore "github.com/argoproj-labs/argocd-autopilot/pkg/store"
 	ocsecurityv1 "github.com/openshift/api/security/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
 )
 
-type OpenshiftOptions struct {
-	KubeFactory  kube.Factory
-	RuntimeName  string
-	InsCloneOpts *git.CloneOptions
-}
+type (
+	// Mode controls whether the openshift preparation runs
+	Mode string
+
+	OpenshiftOptions struct {
+		KubeFactory  kube.Factory
+		RuntimeName  string
+		InsCloneOpts *git.CloneOptions
+		Mode         Mode
+	}
+)
 
-const openshiftNs = "openshift"
+const (
+	// ModeAuto runs the preparation only when the cluster is detected as openshift
+	ModeAuto Mode = "auto"
+	// ModeTrue always runs the preparation
+	ModeTrue Mode = "true"
+	// ModeFalse never runs the preparation
+	ModeFalse Mode = "false"
+
+	openshiftRouteGroup = "route.openshift.io"
+)
+
+// ValidateMode fails on anything other than auto, true or false
+func ValidateMode(mode string) error {
+	switch Mode(mode) {
+	case ModeAuto, ModeTrue, ModeFalse:
+		return nil
+	default:
+		return fmt.Errorf("invalid openshift mode \"%s\", must be one of: %s, %s, %s", mode, ModeAuto, ModeTrue, ModeFalse)
+	}
+}
 
 func PrepareOpenshiftCluster(ctx context.Context, opts *OpenshiftOptions) error {
-	isOpenshift, err := isOpenshiftCluster(ctx, opts.KubeFactory)
+	cs, err := opts.KubeFactory.KubernetesClientSet()
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	isOpenshift, err := shouldPrepareCluster(ctx, opts.Mode, cs.Discovery())
 	if err != nil {
 		return err
 	}
@@ -56,21 +86,46 @@ func PrepareOpenshiftCluster(ctx context.Context, opts *OpenshiftOptions) error
 	return nil
 }
 
-func isOpenshiftCluster(ctx context.Context, kubeFactory kube.Factory) (bool, error) {
-	exists, err := kubeutil.CheckNamespaceExists(ctx, openshiftNs, kubeFactory)
+func shouldPrepareCluster(ctx context.Context, mode Mode, client discovery.DiscoveryInterface) (bool, error) {
+	switch mode {
+	case ModeTrue:
+		log.G(ctx).Info("Preparing the cluster for Openshift (--openshift=true)")
+		return true, nil
+	case ModeFalse:
+		log.G(ctx).Debug("Skipping the Openshift preparation (--openshift=false)")
+		return false, nil
+	}
+
+	isOpenshift, err := IsOpenshiftCluster(client)
 	if err != nil {
-		return false, err
+		return false, fmt.Errorf("failed to detect an openshift cluster, you can set --openshift=true|false explicitly: %w", err)
 	}
-	if !exists {
-		return false, nil
+
+	if isOpenshift {
+		log.G(ctx).Info("Running on an Openshift cluster")
+	}
+
+	return isOpenshift, nil
+}
+
+// IsOpenshiftCluster checks whether the cluster serves the openshift routes api
+func IsOpenshiftCluster(client discovery.DiscoveryInterface) (bool, error) {
+	groups, err := client.ServerGroups()
+	if err != nil {
+		return false, fmt.Errorf("failed to get the cluster's api groups: %w", err)
+	}
+
+	for _, g := range groups.Groups {
+		if g.Name == openshiftRouteGroup {
+			return true, nil
+		}
 	}
 
-	log.G().Info("Running on an Openshift cluster")
-	return true, nil
+	return false, nil
 }
 
 func createScc(ctx context.Context, opts *OpenshiftOptions) error {
-	r, fs, err := opts.InsCloneOpts.GetRepo(ctx)
+	r, fs, err := apu.GetRepo(ctx, opts.InsCloneOpts)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,71 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openshift
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_shouldPrepareCluster(t *testing.T) {
+	openshiftResources := []*metav1.APIResourceList{
+		{
+			GroupVersion: "route.openshift.io/v1",
+			APIResources: []metav1.APIResource{{Name: "routes"}},
+		},
+	}
+	tests := map[string]struct {
+		mode      Mode
+		resources []*metav1.APIResourceList
+		want      bool
+	}{
+		"auto should prepare an openshift cluster": {
+			mode:      ModeAuto,
+			resources: openshiftResources,
+			want:      true,
+		},
+		"auto should skip a non openshift cluster": {
+			mode: ModeAuto,
+		},
+		"true should prepare a cluster that is not detected as openshift": {
+			mode: ModeTrue,
+			want: true,
+		},
+		"false should skip an openshift cluster": {
+			mode:      ModeFalse,
+			resources: openshiftResources,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			client.Discovery().(*fakediscovery.FakeDiscovery).Resources = tt.resources
+
+			got, err := shouldPrepareCluster(context.Background(), tt.mode, client.Discovery())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateMode(t *testing.T) {
+	assert.NoError(t, ValidateMode("auto"))
+	assert.Error(t, ValidateMode("yes"))
+}
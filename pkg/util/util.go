@@ -18,6 +18,8 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
@@ -34,6 +36,7 @@ import (
 	"github.com/briandowns/spinner"
 	"github.com/codefresh-io/go-sdk/pkg/codefresh"
 	"github.com/pkg/browser"
+	"golang.org/x/net/http/httpproxy"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
@@ -230,6 +233,41 @@ func KubeCurrentContextName(kubeconfig string) string {
 	return conf.CurrentContext
 }
 
+// WriteImpersonatingKubeconfig writes a copy of the kubeconfig to a temp file, in which the user of the context
+// (the current one, when empty) impersonates the given identity. the caller is responsible for removing the file
+func WriteImpersonatingKubeconfig(kubeconfig, contextName, as string, asGroups []string) (string, error) {
+	conf := kubeConfig(kubeconfig)
+	if contextName == "" {
+		contextName = conf.CurrentContext
+	}
+
+	context := conf.Contexts[contextName]
+	if context == nil {
+		return "", fmt.Errorf("kubeconfig file missing context \"%s\"", contextName)
+	}
+
+	authInfo := conf.AuthInfos[context.AuthInfo]
+	if authInfo == nil {
+		return "", fmt.Errorf("kubeconfig file missing user \"%s\"", context.AuthInfo)
+	}
+
+	authInfo.Impersonate = as
+	authInfo.ImpersonateGroups = asGroups
+
+	f, err := ioutil.TempFile("", "kubeconfig-impersonate-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create impersonating kubeconfig: %w", err)
+	}
+	f.Close()
+
+	if err = clientcmd.WriteToFile(*conf, f.Name()); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write impersonating kubeconfig: %w", err)
+	}
+
+	return f.Name(), nil
+}
+
 func CurrentAccount(user *codefresh.User) (string, error) {
 	for i := range user.Accounts {
 		if user.Accounts[i].Name == user.ActiveAccountName {
@@ -316,6 +354,11 @@ func IsIP(s string) bool {
 	return ipRegexp.MatchString(s)
 }
 
+// IsSecureHost is false for a host url with the plain http scheme
+func IsSecureHost(host string) bool {
+	return !strings.HasPrefix(strings.ToLower(host), "http://")
+}
+
 func StringIndexOf(slice []string, val string) int {
 	for i, item := range slice {
 		if item == val {
@@ -329,3 +372,38 @@ func StringIndexOf(slice []string, val string) int {
 func GenerateIngressEventSourcePath(runtimeName string) string {
 	return fmt.Sprintf("%s/%s/%s", store.Get().WebhooksRootPath, runtimeName, store.Get().GithubExampleEventSourceObjectName)
 }
+
+// SetProxy routes the calls of the default http transport, which the platform client, the git clones and the
+// runtime downloads all use, through proxyURL. Empty values fall back to $HTTPS_PROXY/$HTTP_PROXY and $NO_PROXY
+func SetProxy(proxyURL, noProxy string) error {
+	if proxyURL == "" && noProxy == "" {
+		return nil
+	}
+
+	cfg := httpproxy.FromEnvironment()
+	if proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid --proxy \"%s\", expected a url such as \"http://proxy.corp:3128\"", proxyURL)
+		}
+
+		cfg.HTTPProxy = proxyURL
+		cfg.HTTPSProxy = proxyURL
+	}
+
+	if noProxy != "" {
+		cfg.NoProxy = noProxy
+	}
+
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("failed to set the proxy: unexpected default http transport")
+	}
+
+	proxyFunc := cfg.ProxyFunc()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2022 The Codefresh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestWriteImpersonatingKubeconfig(t *testing.T) {
+	var gotUser string
+	var gotGroups []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser = r.Header.Get("Impersonate-User")
+		gotGroups = r.Header.Values("Impersonate-Group")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"major":"1","minor":"23","gitVersion":"v1.23.3"}`))
+	}))
+	defer server.Close()
+
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	conf := clientcmdapi.NewConfig()
+	conf.Clusters["cluster"] = &clientcmdapi.Cluster{Server: server.URL}
+	conf.AuthInfos["admin"] = &clientcmdapi.AuthInfo{Token: "token"}
+	conf.Contexts["context"] = &clientcmdapi.Context{Cluster: "cluster", AuthInfo: "admin"}
+	conf.AuthInfos["other"] = &clientcmdapi.AuthInfo{Token: "other-token"}
+	conf.Contexts["other"] = &clientcmdapi.Context{Cluster: "cluster", AuthInfo: "other"}
+	conf.CurrentContext = "other"
+	assert.NoError(t, clientcmd.WriteToFile(*conf, kubeconfig))
+
+	_, err := WriteImpersonatingKubeconfig(kubeconfig, "missing", "system:serviceaccount:runtime:installer", nil)
+	assert.EqualError(t, err, "kubeconfig file missing context \"missing\"")
+
+	impersonatingKubeconfig, err := WriteImpersonatingKubeconfig(kubeconfig, "context", "system:serviceaccount:runtime:installer", []string{"installers"})
+	assert.NoError(t, err)
+	defer os.Remove(impersonatingKubeconfig)
+
+	// only the user of the selected context impersonates
+	written, err := clientcmd.LoadFromFile(impersonatingKubeconfig)
+	assert.NoError(t, err)
+	assert.Empty(t, written.AuthInfos["other"].Impersonate)
+	written.CurrentContext = "context"
+	assert.NoError(t, clientcmd.WriteToFile(*written, impersonatingKubeconfig))
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", impersonatingKubeconfig)
+	assert.NoError(t, err)
+	assert.Equal(t, "system:serviceaccount:runtime:installer", restConfig.Impersonate.UserName)
+	assert.Equal(t, []string{"installers"}, restConfig.Impersonate.Groups)
+
+	cs, err := kubernetes.NewForConfig(restConfig)
+	assert.NoError(t, err)
+
+	_, err = cs.Discovery().ServerVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, "system:serviceaccount:runtime:installer", gotUser)
+	assert.Equal(t, []string{"installers"}, gotGroups)
+}
+
+func TestSetProxy(t *testing.T) {
+	transport := http.DefaultTransport.(*http.Transport)
+	origProxy := transport.Proxy
+	defer func() { transport.Proxy = origProxy }()
+
+	assert.EqualError(t, SetProxy("proxy.corp", ""), "invalid --proxy \"proxy.corp\", expected a url such as \"http://proxy.corp:3128\"")
+	assert.NoError(t, SetProxy("http://proxy.corp:3128", "g.codefresh.io,.internal"))
+
+	tests := map[string]string{
+		"https://github.com/owner/repo":          "http://proxy.corp:3128",
+		"https://g.codefresh.io/2.0/api":         "",
+		"https://git.internal/owner/repo":        "",
+		"https://github.com/codefresh-io/cli-v2": "http://proxy.corp:3128",
+	}
+	for reqURL, want := range tests {
+		req, err := http.NewRequest("GET", reqURL, nil)
+		assert.NoError(t, err)
+
+		got, err := transport.Proxy(req)
+		assert.NoError(t, err)
+		if want == "" {
+			assert.Nil(t, got, reqURL)
+		} else {
+			assert.Equal(t, want, got.String(), reqURL)
+		}
+	}
+}